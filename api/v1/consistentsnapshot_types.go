@@ -0,0 +1,191 @@
+/*
+Copyright 2021 Red Hat OpenShift Container Storage.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+)
+
+// ConsistentSnapshotSpec defines the PVCs of the namespace to snapshot
+// together
+type ConsistentSnapshotSpec struct {
+	// Selector selects the PVCs of the namespace to snapshot
+	Selector metav1.LabelSelector `json:"selector"`
+
+	// VolumeSnapshotClassName is the class of the VolumeSnapshots. Defaults
+	// to the OCS VolumeSnapshotClass of the driver of each PVC.
+	// +optional
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+
+	// PreHook is run before the snapshots are taken, e.g. to quiesce the
+	// application
+	// +optional
+	PreHook *SnapshotHook `json:"preHook,omitempty"`
+
+	// PostHook is run once the snapshots are taken, e.g. to resume the
+	// application. It also runs when the PreHook or the snapshots failed.
+	// +optional
+	PostHook *SnapshotHook `json:"postHook,omitempty"`
+}
+
+// SnapshotHook defines a Job run in the namespace of the snapshotted PVCs
+type SnapshotHook struct {
+	// Image of the hook container
+	Image string `json:"image"`
+
+	// Command run by the hook container
+	// +kubebuilder:validation:MinItems=1
+	Command []string `json:"command"`
+
+	// ServiceAccountName is the ServiceAccount the hook runs as
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// Timeout after which the hook is failed. Defaults to 5 minutes.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// These constants represent the overall Phase of a ConsistentSnapshot
+const (
+	// ConsistentSnapshotPhasePending is used until the PreHook is started
+	ConsistentSnapshotPhasePending = "Pending"
+	// ConsistentSnapshotPhaseQuiescing is used while the PreHook runs
+	ConsistentSnapshotPhaseQuiescing = "Quiescing"
+	// ConsistentSnapshotPhaseSnapshotting is used until all the snapshots
+	// are taken
+	ConsistentSnapshotPhaseSnapshotting = "Snapshotting"
+	// ConsistentSnapshotPhaseResuming is used while the PostHook runs
+	ConsistentSnapshotPhaseResuming = "Resuming"
+	// ConsistentSnapshotPhaseWaitingForReady is used until all the
+	// snapshots are ready to be restored
+	ConsistentSnapshotPhaseWaitingForReady = "WaitingForReady"
+	// ConsistentSnapshotPhaseReady is used once all the snapshots are ready
+	// to be restored
+	ConsistentSnapshotPhaseReady = "Ready"
+	// ConsistentSnapshotPhaseFailed is used when a hook or a snapshot failed
+	ConsistentSnapshotPhaseFailed = "Failed"
+)
+
+// ConsistentSnapshotVolume is the snapshot of a single PVC of the group
+type ConsistentSnapshotVolume struct {
+	// PVCName is the name of the snapshotted PVC
+	PVCName string `json:"pvcName"`
+
+	// VolumeSnapshotName is the name of the VolumeSnapshot of the PVC
+	VolumeSnapshotName string `json:"volumeSnapshotName"`
+
+	// VolumeSnapshotClassName is the class of the VolumeSnapshot
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName"`
+
+	// StorageClassName is the StorageClass of the snapshotted PVC, which
+	// the PVC is restored with
+	StorageClassName string `json:"storageClassName"`
+
+	// AccessModes are the access modes of the snapshotted PVC
+	// +optional
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+
+	// VolumeMode is the volume mode of the snapshotted PVC
+	// +optional
+	VolumeMode *corev1.PersistentVolumeMode `json:"volumeMode,omitempty"`
+
+	// Size is the storage requested by the snapshotted PVC
+	Size resource.Quantity `json:"size"`
+
+	// RestoreSize is the minimum size of a volume restored from the snapshot
+	// +optional
+	RestoreSize *resource.Quantity `json:"restoreSize,omitempty"`
+
+	// CreationTime is the time the snapshot was taken by the storage
+	// +optional
+	CreationTime *metav1.Time `json:"creationTime,omitempty"`
+
+	// ReadyToUse is set once the snapshot can be restored
+	// +optional
+	ReadyToUse bool `json:"readyToUse,omitempty"`
+
+	// Error reported by the snapshotter for the VolumeSnapshot
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// ConsistentSnapshotStatus defines the observed state of ConsistentSnapshot
+type ConsistentSnapshotStatus struct {
+	// Phase describes the Phase of ConsistentSnapshot
+	// This is used by OLM UI to provide status information
+	// to the user
+	Phase string `json:"phase,omitempty"`
+
+	// Conditions describes the state of the ConsistentSnapshot resource.
+	// +optional
+	Conditions []conditionsv1.Condition `json:"conditions,omitempty"`
+
+	// Volumes are the snapshots of the PVCs of the group
+	// +optional
+	Volumes []ConsistentSnapshotVolume `json:"volumes,omitempty"`
+
+	// HookJobName is the name of the Job of the running hook
+	// +optional
+	HookJobName string `json:"hookJobName,omitempty"`
+
+	// Failed is set once a hook or a snapshot failed, while the PostHook
+	// still runs
+	// +optional
+	Failed bool `json:"failed,omitempty"`
+
+	// SnapshotTime is the time the VolumeSnapshots were created
+	// +optional
+	SnapshotTime *metav1.Time `json:"snapshotTime,omitempty"`
+
+	// CompletionTime is the time the group became ready or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=.metadata.creationTimestamp
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=.status.phase,description="Current Phase"
+// +kubebuilder:printcolumn:name="Snapshot Time",type=string,JSONPath=.status.snapshotTime
+// +kubebuilder:printcolumn:name="Created At",type=string,JSONPath=.metadata.creationTimestamp
+
+// ConsistentSnapshot is the Schema for the consistentsnapshots API
+type ConsistentSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsistentSnapshotSpec   `json:"spec,omitempty"`
+	Status ConsistentSnapshotStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ConsistentSnapshotList contains a list of ConsistentSnapshot
+type ConsistentSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsistentSnapshot `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsistentSnapshot{}, &ConsistentSnapshotList{})
+}
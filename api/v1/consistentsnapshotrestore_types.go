@@ -0,0 +1,123 @@
+/*
+Copyright 2021 Red Hat OpenShift Container Storage.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+)
+
+// ConsistentSnapshotRestoreSpec defines the ConsistentSnapshot of the
+// namespace to restore and where to restore it
+type ConsistentSnapshotRestoreSpec struct {
+	// ConsistentSnapshotName is the name of the ready ConsistentSnapshot to
+	// restore
+	ConsistentSnapshotName string `json:"consistentSnapshotName"`
+
+	// TargetNamespace is the namespace the PVCs are restored into, under
+	// their original names. Only the users allowed to create PVCs in it may
+	// set it. Defaults to the namespace of the ConsistentSnapshotRestore, in
+	// which the PVCs are restored as <PVC name>-restore-<restore name>.
+	// +optional
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+}
+
+// These constants represent the overall Phase of a ConsistentSnapshotRestore
+const (
+	// ConsistentSnapshotRestorePhaseRestoring is used until all the PVCs
+	// are bound
+	ConsistentSnapshotRestorePhaseRestoring = "Restoring"
+	// ConsistentSnapshotRestorePhaseCompleted is used once all the PVCs are
+	// bound
+	ConsistentSnapshotRestorePhaseCompleted = "Completed"
+	// ConsistentSnapshotRestorePhaseFailed is used when the PVCs can not be
+	// restored
+	ConsistentSnapshotRestorePhaseFailed = "Failed"
+)
+
+// RestoredVolume is a PVC restored from a snapshot of the group
+type RestoredVolume struct {
+	// PVCName is the name of the restored PVC in the target namespace
+	PVCName string `json:"pvcName"`
+
+	// VolumeSnapshotName is the name of the VolumeSnapshot the PVC is
+	// restored from, in the target namespace
+	VolumeSnapshotName string `json:"volumeSnapshotName"`
+
+	// VolumeSnapshotContentName is the name of the VolumeSnapshotContent
+	// binding the VolumeSnapshot of another target namespace to the
+	// snapshot
+	// +optional
+	VolumeSnapshotContentName string `json:"volumeSnapshotContentName,omitempty"`
+
+	// Bound is set once the restored PVC is bound
+	// +optional
+	Bound bool `json:"bound,omitempty"`
+}
+
+// ConsistentSnapshotRestoreStatus defines the observed state of
+// ConsistentSnapshotRestore
+type ConsistentSnapshotRestoreStatus struct {
+	// Phase describes the Phase of ConsistentSnapshotRestore
+	// This is used by OLM UI to provide status information
+	// to the user
+	Phase string `json:"phase,omitempty"`
+
+	// Conditions describes the state of the ConsistentSnapshotRestore
+	// resource.
+	// +optional
+	Conditions []conditionsv1.Condition `json:"conditions,omitempty"`
+
+	// Volumes are the restored PVCs
+	// +optional
+	Volumes []RestoredVolume `json:"volumes,omitempty"`
+
+	// CompletionTime is the time all the PVCs were bound, or the restore
+	// failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=.metadata.creationTimestamp
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=.status.phase,description="Current Phase"
+// +kubebuilder:printcolumn:name="Target Namespace",type=string,JSONPath=.spec.targetNamespace
+// +kubebuilder:printcolumn:name="Created At",type=string,JSONPath=.metadata.creationTimestamp
+
+// ConsistentSnapshotRestore is the Schema for the consistentsnapshotrestores API
+type ConsistentSnapshotRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsistentSnapshotRestoreSpec   `json:"spec,omitempty"`
+	Status ConsistentSnapshotRestoreStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ConsistentSnapshotRestoreList contains a list of ConsistentSnapshotRestore
+type ConsistentSnapshotRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsistentSnapshotRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsistentSnapshotRestore{}, &ConsistentSnapshotRestoreList{})
+}
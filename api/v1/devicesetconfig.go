@@ -16,12 +16,47 @@ limitations under the License.
 
 package v1
 
+import "strconv"
+
+// The keys of the Rook StorageClassDeviceSet config map
+const (
+	OsdsPerDeviceKey   = "osdsPerDevice"
+	EncryptedDeviceKey = "encryptedDevice"
+	MetadataDeviceKey  = "metadataDevice"
+	DatabaseSizeMBKey  = "databaseSizeMB"
+	WalSizeMBKey       = "walSizeMB"
+	JournalSizeMBKey   = "journalSizeMB"
+)
+
+// OsdConfigKeys are the keys which may be set in StorageDeviceSetConfig.OsdConfig
+var OsdConfigKeys = []string{
+	DatabaseSizeMBKey,
+	WalSizeMBKey,
+	JournalSizeMBKey,
+}
+
 // ToMap converts a StorageDeviceSetConfig object to a map[string]string that
-// can be set in a Rook StorageClassDeviceSet object
-// This functions just returns `nil` right now as the StorageDeviceSetConfig
-// struct itself is empty. It will be updated to perform actual conversion and
-// return a proper map when the StorageDeviceSetConfig struct is updated.
-// TODO: Do actual conversion to map when StorageDeviceSetConfig has defined members
+// can be set in a Rook StorageClassDeviceSet object. It returns `nil` if none
+// of the Rook config options are set.
 func (c *StorageDeviceSetConfig) ToMap() map[string]string {
-	return nil
+	config := map[string]string{}
+
+	for k, v := range c.OsdConfig {
+		config[k] = v
+	}
+	// The typed fields take precedence over the free-form map
+	if c.OsdsPerDevice > 0 {
+		config[OsdsPerDeviceKey] = strconv.Itoa(c.OsdsPerDevice)
+	}
+	if c.EncryptedDevice != nil {
+		config[EncryptedDeviceKey] = strconv.FormatBool(*c.EncryptedDevice)
+	}
+	if c.MetadataDevice != "" {
+		config[MetadataDeviceKey] = c.MetadataDevice
+	}
+
+	if len(config) == 0 {
+		return nil
+	}
+	return config
 }
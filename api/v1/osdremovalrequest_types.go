@@ -0,0 +1,165 @@
+/*
+Copyright 2021 Red Hat OpenShift Container Storage.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+)
+
+// OSDRemovalRequestSpec defines the OSDs to remove from the StorageCluster
+// of the namespace
+type OSDRemovalRequestSpec struct {
+	// OSDIDs are the IDs of the OSDs to remove
+	// +kubebuilder:validation:MinItems=1
+	OSDIDs []int `json:"osdIDs"`
+
+	// Force removes the OSDs even if Ceph does not report them as safe to
+	// destroy, which may result in data loss
+	// +optional
+	Force bool `json:"force,omitempty"`
+
+	// DeletePVCs deletes the PVCs of the device sets backing the removed OSDs
+	// +optional
+	DeletePVCs bool `json:"deletePVCs,omitempty"`
+
+	// DeletePVs also deletes the PVs bound to the deleted PVCs, for the PVs
+	// which are retained once released. It requires DeletePVCs.
+	// +optional
+	DeletePVs bool `json:"deletePVs,omitempty"`
+}
+
+// These constants represent the overall Phase of an OSDRemovalRequest
+const (
+	// OSDRemovalRequestPhasePending is used while the request waits for
+	// another request of the namespace to complete
+	OSDRemovalRequestPhasePending = "Pending"
+	// OSDRemovalRequestPhaseRunning is used while the OSDs are being removed
+	OSDRemovalRequestPhaseRunning = "Running"
+	// OSDRemovalRequestPhaseCompleted is used once all the OSDs are removed
+	OSDRemovalRequestPhaseCompleted = "Completed"
+	// OSDRemovalRequestPhaseFailed is used when some of the OSDs could not
+	// be removed
+	OSDRemovalRequestPhaseFailed = "Failed"
+)
+
+// OSDRemovalPhase is the state of the removal of a single OSD
+type OSDRemovalPhase string
+
+const (
+	// OSDRemovalPending means the OSD is waiting to be removed
+	OSDRemovalPending OSDRemovalPhase = "Pending"
+	// OSDRemovalRemoved means the OSD was removed from the Ceph cluster
+	OSDRemovalRemoved OSDRemovalPhase = "Removed"
+	// OSDRemovalUnsafe means Ceph reported the OSD as not safe to destroy
+	OSDRemovalUnsafe OSDRemovalPhase = "Unsafe"
+	// OSDRemovalNotFound means the OSD does not exist in the Ceph cluster
+	OSDRemovalNotFound OSDRemovalPhase = "NotFound"
+	// OSDRemovalFailed means the OSD could not be removed
+	OSDRemovalFailed OSDRemovalPhase = "Failed"
+)
+
+// OSDRemovalStatus is the state of the removal of a single OSD
+type OSDRemovalStatus struct {
+	// ID is the ID of the OSD
+	ID int `json:"id"`
+
+	// Phase is the state of the removal of the OSD
+	Phase OSDRemovalPhase `json:"phase"`
+
+	// Message gives the details reported by Ceph for the OSD
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// PVCName is the name of the device set PVC backing the OSD
+	// +optional
+	PVCName string `json:"pvcName,omitempty"`
+
+	// PVCDeleted is set once the PVC of the OSD was deleted
+	// +optional
+	PVCDeleted bool `json:"pvcDeleted,omitempty"`
+
+	// PVName is the name of the PV bound to the PVC of the OSD
+	// +optional
+	PVName string `json:"pvName,omitempty"`
+
+	// PVDeleted is set once the PV of the OSD was deleted
+	// +optional
+	PVDeleted bool `json:"pvDeleted,omitempty"`
+}
+
+// OSDRemovalRequestStatus defines the observed state of OSDRemovalRequest
+type OSDRemovalRequestStatus struct {
+	// Phase describes the Phase of OSDRemovalRequest
+	// This is used by OLM UI to provide status information
+	// to the user
+	Phase string `json:"phase,omitempty"`
+
+	// Conditions describes the state of the OSDRemovalRequest resource.
+	// +optional
+	Conditions []conditionsv1.Condition `json:"conditions,omitempty"`
+
+	// StorageCluster is the name of the StorageCluster the OSDs belong to
+	// +optional
+	StorageCluster string `json:"storageCluster,omitempty"`
+
+	// JobName is the name of the Job removing the OSDs
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// OSDs reports the state of the removal of each OSD
+	// +optional
+	OSDs []OSDRemovalStatus `json:"osds,omitempty"`
+
+	// StartTime is the time the removal Job was launched
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time the request completed or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=.metadata.creationTimestamp
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=.status.phase,description="Current Phase"
+// +kubebuilder:printcolumn:name="OSDs",type=string,JSONPath=.spec.osdIDs,description="OSDs to remove"
+// +kubebuilder:printcolumn:name="Created At",type=string,JSONPath=.metadata.creationTimestamp
+
+// OSDRemovalRequest is the Schema for the osdremovalrequests API
+type OSDRemovalRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OSDRemovalRequestSpec   `json:"spec,omitempty"`
+	Status OSDRemovalRequestStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// OSDRemovalRequestList contains a list of OSDRemovalRequest
+type OSDRemovalRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OSDRemovalRequest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OSDRemovalRequest{}, &OSDRemovalRequestList{})
+}
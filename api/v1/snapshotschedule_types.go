@@ -0,0 +1,123 @@
+/*
+Copyright 2021 Red Hat OpenShift Container Storage.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+)
+
+// SnapshotScheduleSpec defines when the PVCs of the namespace are
+// snapshotted and how long their snapshots are kept
+type SnapshotScheduleSpec struct {
+	// Schedule is a cron expression in UTC, e.g. "0 2 * * *" or "@daily"
+	Schedule string `json:"schedule"`
+
+	// Selector selects the PVCs of the namespace to snapshot
+	Selector metav1.LabelSelector `json:"selector"`
+
+	// VolumeSnapshotClassName is the class of the VolumeSnapshots. Defaults
+	// to the OCS VolumeSnapshotClass of the driver of each PVC.
+	// +optional
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+
+	// Retention defines which snapshots are pruned
+	// +optional
+	Retention SnapshotRetention `json:"retention,omitempty"`
+
+	// Suspend stops taking new snapshots. The existing snapshots are still
+	// pruned.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// SnapshotRetention defines how many snapshots of each PVC are kept, and for
+// how long. Seven snapshots of each PVC are kept if neither is set.
+type SnapshotRetention struct {
+	// MaxCount is the number of snapshots of each PVC which are kept
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxCount *int32 `json:"maxCount,omitempty"`
+
+	// MaxAge is how long a snapshot is kept
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// SnapshotScheduleStatus defines the observed state of SnapshotSchedule
+type SnapshotScheduleStatus struct {
+	// Conditions describes the state of the SnapshotSchedule resource.
+	// +optional
+	Conditions []conditionsv1.Condition `json:"conditions,omitempty"`
+
+	// LastScheduleTime is the time the latest snapshots were taken
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// NextScheduleTime is the time the next snapshots are taken
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+
+	// LastSuccessTime is the schedule time of the latest snapshots which all
+	// became ready
+	// +optional
+	LastSuccessTime *metav1.Time `json:"lastSuccessTime,omitempty"`
+
+	// LastFailureTime is the schedule time of the latest snapshots which
+	// failed
+	// +optional
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+
+	// LastFailureMessage describes the latest failure
+	// +optional
+	LastFailureMessage string `json:"lastFailureMessage,omitempty"`
+
+	// Snapshots is the number of retained snapshots
+	// +optional
+	Snapshots int `json:"snapshots,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=.metadata.creationTimestamp
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=.spec.schedule
+// +kubebuilder:printcolumn:name="Last Success",type=date,JSONPath=.status.lastSuccessTime
+// +kubebuilder:printcolumn:name="Snapshots",type=integer,JSONPath=.status.snapshots
+// +kubebuilder:printcolumn:name="Created At",type=string,JSONPath=.metadata.creationTimestamp
+
+// SnapshotSchedule is the Schema for the snapshotschedules API
+type SnapshotSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SnapshotScheduleSpec   `json:"spec,omitempty"`
+	Status SnapshotScheduleStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SnapshotScheduleList contains a list of SnapshotSchedule
+type SnapshotScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SnapshotSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SnapshotSchedule{}, &SnapshotScheduleList{})
+}
@@ -21,6 +21,8 @@ import (
 	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
 	rook "github.com/rook/rook/pkg/apis/rook.io/v1"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -37,6 +39,22 @@ type StorageClusterSpec struct {
 	HostNetwork bool `json:"hostNetwork,omitempty"`
 	// Placement is optional and used to specify placements of OCS components explicitly
 	Placement rook.PlacementSpec `json:"placement,omitempty"`
+	// Mon configures the Ceph monitors
+	// +optional
+	Mon MonSpec `json:"mon,omitempty"`
+	// Mgr configures the Ceph managers
+	// +optional
+	Mgr MgrSpec `json:"mgr,omitempty"`
+	// HealthCheck tunes the health checks and liveness probes of the Ceph
+	// daemons. Unset fields keep the defaults of the platform.
+	// +optional
+	HealthCheck *HealthCheckSpec `json:"healthCheck,omitempty"`
+	// ResourceProfile selects the preset resource requirements of the mon,
+	// mgr, mds, rgw, osd and noobaa daemons. Defaults to balanced. Entries of
+	// spec.resources override the preset of their daemon.
+	// +kubebuilder:validation:Enum=lean;balanced;performance
+	// +optional
+	ResourceProfile string `json:"resourceProfile,omitempty"`
 	// Resources follows the conventions of and is mapped to CephCluster.Spec.Resources
 	Resources          map[string]corev1.ResourceRequirements `json:"resources,omitempty"`
 	Encryption         EncryptionSpec                         `json:"encryption,omitempty"`
@@ -67,8 +85,108 @@ type StorageClusterSpec struct {
 	// ArbiterSpec specifies the storage cluster options related to arbiter.
 	// If Arbiter is enabled, ArbiterLocation in the NodeTopologies must be specified.
 	Arbiter ArbiterSpec `json:"arbiter,omitempty"`
+	// CephConfig holds Ceph configuration options, keyed by config section
+	// (e.g. "global" or "osd") and then by option name. They are merged over
+	// the default options in the rook-config-override ConfigMap.
+	// +optional
+	CephConfig map[string]CephConfigSection `json:"cephConfig,omitempty"`
+	// Capacity controls how the operator manages the capacity of the Ceph
+	// cluster
+	// +optional
+	Capacity *CapacitySpec `json:"capacity,omitempty"`
+	// NFS configures the NFS server exporting the CephFilesystem
+	// +optional
+	NFS *NFSSpec `json:"nfs,omitempty"`
+	// Mirroring configures the RBD mirroring of the CephBlockPools to peer
+	// clusters
+	// +optional
+	Mirroring *MirroringSpec `json:"mirroring,omitempty"`
 }
 
+// NFSSpec defines the NFS server of the StorageCluster
+type NFSSpec struct {
+	// Enable creates a CephNFS backed by the CephFilesystem, together with
+	// an NFS StorageClass and a Service exposing the NFS server. Disabling
+	// it deletes them once no PersistentVolume of the NFS StorageClass
+	// remains. The StorageClass is only created once the
+	// <operator namespace>.nfs.csi.ceph.com CSI driver is installed, as Rook
+	// does not deploy it.
+	// +optional
+	Enable bool `json:"enable,omitempty"`
+	// +optional
+	ReconcileStrategy string `json:"reconcileStrategy,omitempty"`
+	// DisableStorageClass disables the creation of the NFS StorageClass
+	// +optional
+	DisableStorageClass bool `json:"disableStorageClass,omitempty"`
+	// ServiceType is the type of the Service of the NFS server. Defaults to
+	// ClusterIP, use NodePort or LoadBalancer to expose the NFS server to
+	// clients outside of the cluster.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +optional
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+}
+
+// MirroringSpec defines the RBD mirroring of the StorageCluster
+type MirroringSpec struct {
+	// Enabled enables image mirroring on the replicated CephBlockPools,
+	// deploys a CephRBDMirror and creates the VolumeReplicationClasses. The
+	// pools stop mirroring once it is disabled, the CephRBDMirror and the
+	// VolumeReplicationClasses are left in place.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// +optional
+	ReconcileStrategy string `json:"reconcileStrategy,omitempty"`
+	// PeerSecretNames are the names of the Secrets, in the namespace of the
+	// StorageCluster, holding the bootstrap peer tokens of the remote
+	// clusters. Each Secret must have the "token" and "pool" keys, the peer
+	// is added to the pool named by the latter. The pools can also be
+	// peered by hand by importing the bootstrap peer token of the remote
+	// pool. The mirrored pools without any peer are reported by a Degraded
+	// condition.
+	// +optional
+	PeerSecretNames []string `json:"peerSecretNames,omitempty"`
+	// SchedulingIntervals are the intervals at which the mirrored images are
+	// snapshotted, e.g. "5m", "1h" or "1d". A VolumeReplicationClass is
+	// created for each of them. Defaults to "5m".
+	// +optional
+	SchedulingIntervals []string `json:"schedulingIntervals,omitempty"`
+}
+
+// CapacitySpec controls how the operator manages the capacity of the Ceph
+// cluster
+type CapacitySpec struct {
+	// FullRatioOverride temporarily raises the full ratio of the Ceph
+	// cluster, so that a cluster which reached it can accept writes again
+	// while capacity is added or data is deleted
+	// +optional
+	FullRatioOverride *FullRatioOverrideSpec `json:"fullRatioOverride,omitempty"`
+	// EnforceFeasibility holds back creating or expanding the CephCluster
+	// while the storage nodes or the StorageClasses of the StorageDeviceSets
+	// cannot host all of their OSDs. Defaults to true. When set to false, the
+	// StorageDeviceSets are applied anyway and their OSDs which do not fit
+	// stay Pending, which is only reported by the CapacityInsufficient
+	// condition.
+	// +optional
+	EnforceFeasibility *bool `json:"enforceFeasibility,omitempty"`
+}
+
+// FullRatioOverrideSpec defines a temporary full ratio of the Ceph cluster
+type FullRatioOverrideSpec struct {
+	// Ratio is the full ratio applied while the override is active, e.g.
+	// "0.87". It must be above the configured full ratio and may not exceed
+	// 0.9.
+	// +kubebuilder:validation:Pattern=`^0?\.[0-9]+$`
+	Ratio string `json:"ratio"`
+
+	// Duration is how long the override stays active. The default full
+	// ratio is restored once it expires, or earlier once the usage of the
+	// cluster drops below the backfillfull ratio.
+	Duration metav1.Duration `json:"duration"`
+}
+
+// CephConfigSection maps Ceph config option names to their values
+type CephConfigSection map[string]string
+
 // KeyManagementServiceSpec provides a way to enable KMS
 type KeyManagementServiceSpec struct {
 	// +optional
@@ -102,6 +220,105 @@ type ManageCephBlockPools struct {
 	ReconcileStrategy    string `json:"reconcileStrategy,omitempty"`
 	DisableStorageClass  bool   `json:"disableStorageClass,omitempty"`
 	DisableSnapshotClass bool   `json:"disableSnapshotClass,omitempty"`
+	// ErasureCoded, if set, creates an erasure-coded pool for the data of
+	// RBD images, whose metadata stays in the default pool. The
+	// StorageCluster must have at least dataChunks+codingChunks failure
+	// domains.
+	// +optional
+	ErasureCoded *ErasureCodedSpec `json:"erasureCoded,omitempty"`
+	// AdditionalPools is a list of CephBlockPools to be created alongside
+	// the default one. Each pool gets its own StorageClass and
+	// VolumeSnapshotClass.
+	// +optional
+	AdditionalPools []AdditionalBlockPool `json:"additionalPools,omitempty"`
+	// TopologyConstrainedPools, if set, creates a CephBlockPool restricted
+	// to each failure domain of the StorageCluster, along with a
+	// StorageClass whose volumes are pinned to that failure domain. The
+	// pools and StorageClasses of removed failure domains are deleted. With
+	// the host failure domain, the OSDs must not be portable.
+	// +optional
+	TopologyConstrainedPools bool `json:"topologyConstrainedPools,omitempty"`
+	// StorageClassOverrides customizes the RBD StorageClasses, those of the
+	// additional pools included. Only the default class is marked as the
+	// default StorageClass of the cluster.
+	// +optional
+	StorageClassOverrides *StorageClassOverrides `json:"storageClassOverrides,omitempty"`
+}
+
+// StorageClassOverrides defines the settings of a StorageClass created by
+// the operator which differ from its defaults. Changing a setting that
+// Kubernetes does not allow to update recreates the StorageClass.
+type StorageClassOverrides struct {
+	// FSType is the filesystem created on RBD volumes. Defaults to ext4.
+	// +kubebuilder:validation:Enum=ext4;xfs
+	// +optional
+	FSType string `json:"fsType,omitempty"`
+	// ImageFeatures are the RBD image features enabled in addition to
+	// layering. object-map requires exclusive-lock, fast-diff requires
+	// object-map.
+	// +optional
+	ImageFeatures []string `json:"imageFeatures,omitempty"`
+	// ReclaimPolicy of the volumes. Defaults to Delete.
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// +optional
+	ReclaimPolicy *corev1.PersistentVolumeReclaimPolicy `json:"reclaimPolicy,omitempty"`
+	// VolumeBindingMode of the volumes. Defaults to Immediate.
+	// +kubebuilder:validation:Enum=Immediate;WaitForFirstConsumer
+	// +optional
+	VolumeBindingMode *storagev1.VolumeBindingMode `json:"volumeBindingMode,omitempty"`
+	// MountOptions used to mount the volumes
+	// +optional
+	MountOptions []string `json:"mountOptions,omitempty"`
+	// Default sets the annotation marking the StorageClass as the default
+	// StorageClass of the cluster to true or false. Removing it removes the
+	// annotation it set, an annotation set by hand is left alone.
+	// +optional
+	Default *bool `json:"default,omitempty"`
+}
+
+// AdditionalBlockPool defines a CephBlockPool to be created in addition to
+// the default one
+type AdditionalBlockPool struct {
+	// Name is used to derive the names of the CephBlockPool, StorageClass
+	// and VolumeSnapshotClass created for this pool
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// Replica is the number of copies kept of each object in a replicated
+	// pool. Defaults to the replica size of the default pool.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replica uint `json:"replica,omitempty"`
+	// DeviceClass restricts the pool to OSDs of the given device class
+	// +kubebuilder:validation:Enum=ssd;hdd;nvme;""
+	// +optional
+	DeviceClass string `json:"deviceClass,omitempty"`
+	// CompressionMode is the inline compression mode of the pool
+	// +kubebuilder:validation:Enum=none;passive;aggressive;force;""
+	// +optional
+	CompressionMode string `json:"compressionMode,omitempty"`
+	// FailureDomain of the pool. Defaults to the failure domain of the
+	// StorageCluster.
+	// +optional
+	FailureDomain string `json:"failureDomain,omitempty"`
+	// ErasureCoded makes this an erasure-coded pool. Images on it keep
+	// their metadata in the default pool.
+	// +optional
+	ErasureCoded *ErasureCodedSpec `json:"erasureCoded,omitempty"`
+	// +optional
+	DisableStorageClass bool `json:"disableStorageClass,omitempty"`
+	// +optional
+	DisableSnapshotClass bool `json:"disableSnapshotClass,omitempty"`
+}
+
+// ErasureCodedSpec defines the erasure coding profile of a pool
+type ErasureCodedSpec struct {
+	// DataChunks is the number of chunks each object is split into
+	// +kubebuilder:validation:Minimum=2
+	DataChunks uint `json:"dataChunks"`
+	// CodingChunks is the number of coding chunks computed for each
+	// object, and thus the number of failures the pool can survive
+	// +kubebuilder:validation:Minimum=1
+	CodingChunks uint `json:"codingChunks"`
 }
 
 // ManageCephFilesystems defines how to reconcile CephFilesystems
@@ -109,6 +326,74 @@ type ManageCephFilesystems struct {
 	ReconcileStrategy    string `json:"reconcileStrategy,omitempty"`
 	DisableStorageClass  bool   `json:"disableStorageClass,omitempty"`
 	DisableSnapshotClass bool   `json:"disableSnapshotClass,omitempty"`
+	// ErasureCoded, if set, adds an erasure-coded data pool to the
+	// filesystem, which is used by the volumes of the StorageClass. The
+	// StorageCluster must have at least dataChunks+codingChunks failure
+	// domains.
+	// +optional
+	ErasureCoded *ErasureCodedSpec `json:"erasureCoded,omitempty"`
+	// AdditionalFilesystems is a list of CephFilesystems to be created
+	// alongside the default one. Each filesystem gets its own
+	// StorageClass and VolumeSnapshotClass.
+	// +optional
+	AdditionalFilesystems []AdditionalFilesystem `json:"additionalFilesystems,omitempty"`
+	// StorageClassOverrides customizes the CephFS StorageClasses, those of
+	// the additional filesystems included. FSType and ImageFeatures do not
+	// apply to CephFS. Only the default class is marked as the default
+	// StorageClass of the cluster.
+	// +optional
+	StorageClassOverrides *StorageClassOverrides `json:"storageClassOverrides,omitempty"`
+}
+
+// AdditionalFilesystem defines a CephFilesystem to be created in addition
+// to the default one
+type AdditionalFilesystem struct {
+	// Name is used to derive the names of the CephFilesystem, StorageClass
+	// and VolumeSnapshotClass created for this filesystem
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// ActiveMetadataServers is the number of active MDS daemons of the
+	// filesystem. Each of them gets a standby. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ActiveMetadataServers int32 `json:"activeMetadataServers,omitempty"`
+	// Resources of the MDS daemons. Defaults to the "mds" entry of
+	// spec.resources.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// Placement of the MDS daemons. Defaults to the "mds" entry of
+	// spec.placement.
+	// +optional
+	Placement *rook.Placement `json:"placement,omitempty"`
+	// DataPools of the filesystem. The StorageClass places volumes in the
+	// first one, which can not be erasure-coded. Defaults to a single
+	// replicated pool.
+	// +optional
+	DataPools []FilesystemDataPool `json:"dataPools,omitempty"`
+	// +optional
+	DisableStorageClass bool `json:"disableStorageClass,omitempty"`
+	// +optional
+	DisableSnapshotClass bool `json:"disableSnapshotClass,omitempty"`
+}
+
+// FilesystemDataPool defines a data pool of an additional CephFilesystem
+type FilesystemDataPool struct {
+	// Replica is the number of copies kept of each object in a replicated
+	// pool. Defaults to the replica size of the default data pool.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replica uint `json:"replica,omitempty"`
+	// DeviceClass restricts the pool to OSDs of the given device class
+	// +kubebuilder:validation:Enum=ssd;hdd;nvme;""
+	// +optional
+	DeviceClass string `json:"deviceClass,omitempty"`
+	// CompressionMode is the inline compression mode of the pool
+	// +kubebuilder:validation:Enum=none;passive;aggressive;force;""
+	// +optional
+	CompressionMode string `json:"compressionMode,omitempty"`
+	// ErasureCoded makes this an erasure-coded pool
+	// +optional
+	ErasureCoded *ErasureCodedSpec `json:"erasureCoded,omitempty"`
 }
 
 // ManageCephObjectStores defines how to reconcile CephObjectStores
@@ -116,6 +401,12 @@ type ManageCephObjectStores struct {
 	ReconcileStrategy   string `json:"reconcileStrategy,omitempty"`
 	DisableStorageClass bool   `json:"disableStorageClass,omitempty"`
 	GatewayInstances    int32  `json:"gatewayInstances,omitempty"`
+	// ErasureCoded, if set, stores object data in an erasure-coded pool
+	// instead of a replicated one. It only takes effect when the object
+	// store is created. The StorageCluster must have at least
+	// dataChunks+codingChunks failure domains.
+	// +optional
+	ErasureCoded *ErasureCodedSpec `json:"erasureCoded,omitempty"`
 }
 
 // ManageCephObjectStoreUsers defines how to reconcile CephObjectStoreUsers
@@ -183,6 +474,11 @@ type StorageDeviceSet struct {
 	// +optional
 	Portable bool `json:"portable,omitempty"`
 
+	// AutoExpand lets the operator increase Count when the usage of the
+	// Ceph cluster exceeds a threshold
+	// +optional
+	AutoExpand *AutoExpandPolicy `json:"autoExpand,omitempty"`
+
 	Name                string                        `json:"name"`
 	Resources           corev1.ResourceRequirements   `json:"resources,omitempty"`
 	PreparePlacement    rook.Placement                `json:"preparePlacement,omitempty"`
@@ -193,8 +489,37 @@ type StorageDeviceSet struct {
 	WalPVCTemplate      *corev1.PersistentVolumeClaim `json:"walPVCTemplate,omitempty"`
 }
 
+// AutoExpandPolicy defines when and how far the operator increases the Count
+// of a StorageDeviceSet
+type AutoExpandPolicy struct {
+	// Enable turns the automatic expansion of the StorageDeviceSet on
+	// +optional
+	Enable bool `json:"enable,omitempty"`
+
+	// UsageThreshold is the share of the raw capacity of the Ceph cluster in
+	// use above which the StorageDeviceSet is expanded, e.g. "0.7"
+	// +kubebuilder:validation:Pattern=`^0?\.[0-9]+$`
+	UsageThreshold string `json:"usageThreshold"`
+
+	// Step is the amount Count is increased by on each expansion. With the
+	// default Replica, Count is split over 3 StorageClassDeviceSets and Step
+	// must be a multiple of 3.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Step int `json:"step,omitempty"`
+
+	// MaxCount is the highest Count the StorageDeviceSet is expanded to
+	// +kubebuilder:validation:Minimum=1
+	MaxCount int `json:"maxCount"`
+
+	// Cooldown is the minimum time between two expansions of the
+	// StorageDeviceSet, giving Ceph time to rebalance the data. Defaults
+	// to 1 hour.
+	// +optional
+	Cooldown *metav1.Duration `json:"cooldown,omitempty"`
+}
+
 // StorageDeviceSetConfig defines Ceph OSD specific config options for the StorageDeviceSet
-// TODO: Fill in the members when the actual configurable options are defined in rook-ceph
 type StorageDeviceSetConfig struct {
 	// TuneSlowDeviceClass tunes the OSD when running on a slow Device Class
 	// +optional
@@ -203,6 +528,27 @@ type StorageDeviceSetConfig struct {
 	// TuneFastDeviceClass tunes the OSD when running on a fast Device Class
 	// +optional
 	TuneFastDeviceClass bool `json:"tuneFastDeviceClass,omitempty"`
+
+	// OsdsPerDevice is the number of OSDs to create on each device
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	OsdsPerDevice int `json:"osdsPerDevice,omitempty"`
+
+	// EncryptedDevice overrides spec.encryption.enable for the OSDs of
+	// this StorageDeviceSet
+	// +optional
+	EncryptedDevice *bool `json:"encryptedDevice,omitempty"`
+
+	// MetadataDevice is the name of the device to use for the OSD metadata
+	// (block.db). It can not be combined with a metadataPVCTemplate.
+	// +optional
+	MetadataDevice string `json:"metadataDevice,omitempty"`
+
+	// OsdConfig holds additional Ceph OSD config options that are passed
+	// through to Rook. Only the keys understood by Rook are accepted:
+	// "databaseSizeMB", "walSizeMB" and "journalSizeMB".
+	// +optional
+	OsdConfig map[string]string `json:"osdConfig,omitempty"`
 }
 
 // MultiCloudGatewaySpec defines specific multi-cloud gateway configuration options
@@ -234,6 +580,86 @@ type EncryptionSpec struct {
 	KeyManagementService KeyManagementServiceSpec `json:"kms,omitempty"`
 }
 
+// MonSpec defines the settings of the Ceph monitors
+type MonSpec struct {
+	// Count is the number of mons. Defaults to 3, or 5 in arbiter mode.
+	// +kubebuilder:validation:Enum=3;5
+	// +optional
+	Count int `json:"count,omitempty"`
+	// AllowMultiplePerNode allows several mons to run on the same node. It
+	// is only meant for test clusters, as the loss of a single node may then
+	// break the mon quorum.
+	// +optional
+	AllowMultiplePerNode bool `json:"allowMultiplePerNode,omitempty"`
+}
+
+// MgrSpec defines the settings of the Ceph managers
+type MgrSpec struct {
+	// Count is the number of mgrs. With two mgrs, the standby one takes over
+	// when the active one fails. Defaults to 2, or 1 when the mons are
+	// allowed to run on the same node, as on single node test clusters.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=2
+	// +optional
+	Count int `json:"count,omitempty"`
+	// Modules lists the mgr modules to enable or disable. The pg_autoscaler
+	// and balancer modules are enabled unless they are listed here.
+	// +optional
+	Modules []MgrModuleSpec `json:"modules,omitempty"`
+}
+
+// MgrModuleSpec defines the state of a Ceph mgr module
+type MgrModuleSpec struct {
+	// Name is the name of the mgr module
+	Name string `json:"name"`
+	// Enabled determines whether the module is enabled
+	Enabled bool `json:"enabled"`
+}
+
+// HealthCheckSpec defines the health checks of the Ceph daemons
+type HealthCheckSpec struct {
+	// Status configures the check of the Ceph cluster health
+	// +optional
+	Status *DaemonHealthCheckSpec `json:"status,omitempty"`
+	// Mon configures the check of the mons. Its timeout is the time after
+	// which a mon out of quorum is failed over.
+	// +optional
+	Mon *DaemonHealthCheckSpec `json:"mon,omitempty"`
+	// OSD configures the check of the OSDs
+	// +optional
+	OSD *DaemonHealthCheckSpec `json:"osd,omitempty"`
+	// LivenessProbe overrides the liveness probe of the mon, mgr and osd
+	// daemons
+	// +optional
+	LivenessProbe map[rook.KeyType]*rook.ProbeSpec `json:"livenessProbe,omitempty"`
+}
+
+// DaemonHealthCheckSpec defines a health check of the Ceph daemons
+type DaemonHealthCheckSpec struct {
+	// Disabled disables the check
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+	// Interval is the time between two checks
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Timeout is the time after which a failing daemon is acted upon
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// MonStatus reports the state of the Ceph monitors
+type MonStatus struct {
+	// Count is the desired number of mons
+	Count int `json:"count,omitempty"`
+	// Mons lists the IDs of the mons of the Ceph cluster, as recorded by Rook
+	// +optional
+	Mons []string `json:"mons,omitempty"`
+	// QuorumMons lists the IDs of the mons in quorum, as reported by Ceph.
+	// It is empty until Ceph reports its health.
+	// +optional
+	QuorumMons []string `json:"quorumMons,omitempty"`
+}
+
 // StorageClusterStatus defines the observed state of StorageCluster
 type StorageClusterStatus struct {
 	// Phase describes the Phase of StorageCluster
@@ -276,6 +702,256 @@ type StorageClusterStatus struct {
 
 	// Images holds the image reconcile status for all images reconciled by the operator
 	Images ImagesStatus `json:"images,omitempty"`
+
+	// CephConfigHash holds the checksum of the effective Ceph configuration
+	// written to the rook-config-override ConfigMap.
+	// +optional
+	CephConfigHash string `json:"cephConfigHash,omitempty"`
+
+	// Components holds the reconcile status of each of the components
+	// managed by the StorageCluster, keyed by component name (e.g.
+	// cephCluster, noobaa or blockPools).
+	// +optional
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+
+	// Plan describes the changes the operator would make to the managed
+	// resources. It is only set while the StorageCluster has the
+	// ocs.openshift.io/dry-run annotation set to "true".
+	// +optional
+	Plan *StorageClusterPlan `json:"plan,omitempty"`
+
+	// OSDRemovals records the latest OSDRemovalRequests completed for the
+	// StorageCluster, the most recent last
+	// +optional
+	OSDRemovals []OSDRemovalRecord `json:"osdRemovals,omitempty"`
+
+	// FullRatio reports the full ratio applied to the Ceph cluster and the
+	// state of spec.capacity.fullRatioOverride
+	// +optional
+	FullRatio *FullRatioStatus `json:"fullRatio,omitempty"`
+
+	// DeviceSetCapacity reports the capacity of the StorageDeviceSets with
+	// an autoExpand policy
+	// +optional
+	DeviceSetCapacity []DeviceSetCapacityStatus `json:"deviceSetCapacity,omitempty"`
+
+	// Mon reports the desired number of mons and the mons which are running
+	// +optional
+	Mon *MonStatus `json:"mon,omitempty"`
+
+	// ResourceProfile is the resource profile applied to the daemons
+	// +optional
+	ResourceProfile string `json:"resourceProfile,omitempty"`
+
+	// EffectiveResources reports the resource requirements applied to each
+	// daemon, from spec.resourceProfile and spec.resources. The "osd" entry
+	// applies to the StorageDeviceSets which do not set their own resources,
+	// the "osd-<name>" entries report the resources applied to the OSDs of
+	// each StorageDeviceSet.
+	// +optional
+	EffectiveResources map[string]corev1.ResourceRequirements `json:"effectiveResources,omitempty"`
+
+	// Mirroring reports the mirroring health of the mirrored CephBlockPools
+	// +optional
+	Mirroring *MirroringStatus `json:"mirroring,omitempty"`
+}
+
+// MirroringStatus reports the RBD mirroring of the StorageCluster
+type MirroringStatus struct {
+	// Pools reports the mirroring health of each mirrored CephBlockPool
+	// +optional
+	Pools []PoolMirroringStatus `json:"pools,omitempty"`
+}
+
+// PoolMirroringStatus reports the mirroring health of a CephBlockPool, as
+// last checked by Rook
+type PoolMirroringStatus struct {
+	// Name is the name of the CephBlockPool
+	Name string `json:"name"`
+
+	// Health is the overall mirroring health of the pool: OK, WARNING,
+	// ERROR or UNKNOWN
+	// +optional
+	Health string `json:"health,omitempty"`
+
+	// DaemonHealth is the health of the rbd-mirror daemons
+	// +optional
+	DaemonHealth string `json:"daemonHealth,omitempty"`
+
+	// ImageHealth is the health of the mirrored images
+	// +optional
+	ImageHealth string `json:"imageHealth,omitempty"`
+
+	// BootstrapPeerSecretName is the Secret holding the bootstrap peer token
+	// of the pool, to be imported by the peer clusters
+	// +optional
+	BootstrapPeerSecretName string `json:"bootstrapPeerSecretName,omitempty"`
+
+	// PeerSites are the site names of the peer clusters the pool is
+	// mirrored to
+	// +optional
+	PeerSites []string `json:"peerSites,omitempty"`
+
+	// LastChecked is the time Rook last checked the mirroring health
+	// +optional
+	LastChecked string `json:"lastChecked,omitempty"`
+}
+
+// DeviceSetCapacityStatus reports the capacity of a StorageDeviceSet which is
+// expanded automatically
+type DeviceSetCapacityStatus struct {
+	// Name is the name of the StorageDeviceSet
+	Name string `json:"name"`
+
+	// Count is the current Count of the StorageDeviceSet
+	Count int `json:"count"`
+
+	// Capacity is the raw capacity of the StorageDeviceSet at its current
+	// Count
+	Capacity resource.Quantity `json:"capacity"`
+
+	// PendingCapacity is the raw capacity added by the last expansion which
+	// the Ceph cluster is still rolling out
+	// +optional
+	PendingCapacity *resource.Quantity `json:"pendingCapacity,omitempty"`
+
+	// MaxCapacity is the raw capacity of the StorageDeviceSet once expanded
+	// to its MaxCount
+	MaxCapacity resource.Quantity `json:"maxCapacity"`
+
+	// LastExpansionTime is the time the StorageDeviceSet was last expanded
+	// automatically
+	// +optional
+	LastExpansionTime *metav1.Time `json:"lastExpansionTime,omitempty"`
+}
+
+// FullRatioStatus reports the full ratio applied to the Ceph cluster
+type FullRatioStatus struct {
+	// AppliedRatio is the full ratio last applied to the Ceph cluster
+	// +optional
+	AppliedRatio string `json:"appliedRatio,omitempty"`
+
+	// Override is the override this status refers to. A new override
+	// starts whenever spec.capacity.fullRatioOverride differs from it.
+	// +optional
+	Override *FullRatioOverrideSpec `json:"override,omitempty"`
+
+	// StartTime is the time the override started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// ExpirationTime is the time the override expires
+	// +optional
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+
+	// RevertReason is set once the override was reverted before it was
+	// removed from the spec, e.g. Expired or UsageDropped
+	// +optional
+	RevertReason string `json:"revertReason,omitempty"`
+}
+
+// OSDRemovalRecord records the outcome of an OSDRemovalRequest
+type OSDRemovalRecord struct {
+	// RequestName is the name of the OSDRemovalRequest
+	RequestName string `json:"requestName"`
+
+	// Phase is the final phase of the OSDRemovalRequest
+	Phase string `json:"phase"`
+
+	// Forced is set if the OSDs were removed without checking that they
+	// were safe to destroy
+	// +optional
+	Forced bool `json:"forced,omitempty"`
+
+	// RemovedOSDs are the IDs of the OSDs which were removed
+	// +optional
+	RemovedOSDs []int `json:"removedOSDs,omitempty"`
+
+	// FailedOSDs are the IDs of the OSDs which were not removed
+	// +optional
+	FailedOSDs []int `json:"failedOSDs,omitempty"`
+
+	// CompletionTime is the time the OSDRemovalRequest completed
+	CompletionTime metav1.Time `json:"completionTime"`
+}
+
+// PlannedAction is the action the operator would take on a managed resource
+type PlannedAction string
+
+const (
+	// PlannedActionCreate means the resource does not exist and would be created
+	PlannedActionCreate PlannedAction = "Create"
+	// PlannedActionUpdate means the resource would be updated in place
+	PlannedActionUpdate PlannedAction = "Update"
+	// PlannedActionRecreate means the resource would be deleted and created
+	// again, as the changed fields are immutable
+	PlannedActionRecreate PlannedAction = "Recreate"
+)
+
+// StorageClusterPlan describes the changes the operator would make to the
+// managed resources when reconciling the StorageCluster
+type StorageClusterPlan struct {
+	// ObservedGeneration is the generation of the StorageCluster the plan
+	// was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ClusterExpansion is true if the CephCluster would be expanded, i.e.
+	// the StorageCluster would enter the "Expanding Capacity" phase
+	// +optional
+	ClusterExpansion bool `json:"clusterExpansion,omitempty"`
+
+	// AddedDeviceSets lists the StorageClassDeviceSets which would be added
+	// to the CephCluster
+	// +optional
+	AddedDeviceSets []string `json:"addedDeviceSets,omitempty"`
+
+	// Changes lists the managed resources which would be created or changed.
+	// Resources which are already in the desired state are not listed.
+	// +optional
+	Changes []PlannedChange `json:"changes,omitempty"`
+
+	// Errors lists the errors hit while generating the desired resources
+	// +optional
+	Errors []string `json:"errors,omitempty"`
+}
+
+// PlannedChange describes the change the operator would make to a single
+// managed resource
+type PlannedChange struct {
+	Kind      string        `json:"kind"`
+	Name      string        `json:"name"`
+	Namespace string        `json:"namespace,omitempty"`
+	Action    PlannedAction `json:"action"`
+	// Fields lists the paths of the fields which would be changed
+	// +optional
+	Fields []string `json:"fields,omitempty"`
+}
+
+// ComponentStatus describes the reconcile status of a single component of
+// the StorageCluster
+type ComponentStatus struct {
+	// Phase describes the Phase of the component
+	Phase string `json:"phase,omitempty"`
+
+	// Conditions holds the negative conditions (!Available, Degraded,
+	// Progressing, !Upgradeable) reported by the component
+	// +optional
+	Conditions []conditionsv1.Condition `json:"conditions,omitempty"`
+
+	// LastError is the error hit while last reconciling the component. It
+	// is cleared by the next successful reconcile.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// ObservedGeneration is the generation of the StorageCluster that the
+	// component was last reconciled against
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastSuccessTime is the last time the component was reconciled
+	// without errors
+	// +optional
+	LastSuccessTime *metav1.Time `json:"lastSuccessTime,omitempty"`
 }
 
 // ImagesStatus maps every component image name it's reconciliation status information
@@ -324,6 +1000,14 @@ const (
 	// ConditionExternalClusterConnecting type indicates that rook is still trying for
 	// an external connection
 	ConditionExternalClusterConnecting conditionsv1.ConditionType = "ExternalClusterConnecting"
+
+	// ConditionFullRatioOverridden is True while the full ratio of the Ceph
+	// cluster is raised by spec.capacity.fullRatioOverride
+	ConditionFullRatioOverridden conditionsv1.ConditionType = "FullRatioOverridden"
+
+	// ConditionCapacityInsufficient is True when the storage nodes or the
+	// StorageClasses of the StorageDeviceSets cannot host all of their OSDs
+	ConditionCapacityInsufficient conditionsv1.ConditionType = "CapacityInsufficient"
 )
 
 // List of constants to show different different reconciliation messages and statuses.
@@ -25,10 +25,63 @@ import (
 	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
 	rook_iov1 "github.com/rook/rook/pkg/apis/rook.io/v1"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdditionalBlockPool) DeepCopyInto(out *AdditionalBlockPool) {
+	*out = *in
+	if in.ErasureCoded != nil {
+		in, out := &in.ErasureCoded, &out.ErasureCoded
+		*out = new(ErasureCodedSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdditionalBlockPool.
+func (in *AdditionalBlockPool) DeepCopy() *AdditionalBlockPool {
+	if in == nil {
+		return nil
+	}
+	out := new(AdditionalBlockPool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdditionalFilesystem) DeepCopyInto(out *AdditionalFilesystem) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(rook_iov1.Placement)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DataPools != nil {
+		in, out := &in.DataPools, &out.DataPools
+		*out = make([]FilesystemDataPool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdditionalFilesystem.
+func (in *AdditionalFilesystem) DeepCopy() *AdditionalFilesystem {
+	if in == nil {
+		return nil
+	}
+	out := new(AdditionalFilesystem)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ArbiterSpec) DeepCopyInto(out *ArbiterSpec) {
 	*out = *in
@@ -49,6 +102,72 @@ func (in *ArbiterSpec) DeepCopy() *ArbiterSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoExpandPolicy) DeepCopyInto(out *AutoExpandPolicy) {
+	*out = *in
+	if in.Cooldown != nil {
+		in, out := &in.Cooldown, &out.Cooldown
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoExpandPolicy.
+func (in *AutoExpandPolicy) DeepCopy() *AutoExpandPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoExpandPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CapacitySpec) DeepCopyInto(out *CapacitySpec) {
+	*out = *in
+	if in.FullRatioOverride != nil {
+		in, out := &in.FullRatioOverride, &out.FullRatioOverride
+		*out = new(FullRatioOverrideSpec)
+		**out = **in
+	}
+	if in.EnforceFeasibility != nil {
+		in, out := &in.EnforceFeasibility, &out.EnforceFeasibility
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CapacitySpec.
+func (in *CapacitySpec) DeepCopy() *CapacitySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CapacitySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in CephConfigSection) DeepCopyInto(out *CephConfigSection) {
+	{
+		in := &in
+		*out = make(CephConfigSection, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephConfigSection.
+func (in CephConfigSection) DeepCopy() CephConfigSection {
+	if in == nil {
+		return nil
+	}
+	out := new(CephConfigSection)
+	in.DeepCopyInto(out)
+	return *out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComponentImageStatus) DeepCopyInto(out *ComponentImageStatus) {
 	*out = *in
@@ -65,285 +184,1285 @@ func (in *ComponentImageStatus) DeepCopy() *ComponentImageStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EncryptionSpec) DeepCopyInto(out *EncryptionSpec) {
+func (in *ComponentStatus) DeepCopyInto(out *ComponentStatus) {
 	*out = *in
-	out.KeyManagementService = in.KeyManagementService
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]conditionsv1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSuccessTime != nil {
+		in, out := &in.LastSuccessTime, &out.LastSuccessTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EncryptionSpec.
-func (in *EncryptionSpec) DeepCopy() *EncryptionSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentStatus.
+func (in *ComponentStatus) DeepCopy() *ComponentStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(EncryptionSpec)
+	out := new(ComponentStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ExternalStorageClusterSpec) DeepCopyInto(out *ExternalStorageClusterSpec) {
+func (in *ConsistentSnapshot) DeepCopyInto(out *ConsistentSnapshot) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalStorageClusterSpec.
-func (in *ExternalStorageClusterSpec) DeepCopy() *ExternalStorageClusterSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsistentSnapshot.
+func (in *ConsistentSnapshot) DeepCopy() *ConsistentSnapshot {
 	if in == nil {
 		return nil
 	}
-	out := new(ExternalStorageClusterSpec)
+	out := new(ConsistentSnapshot)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsistentSnapshot) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ImagesStatus) DeepCopyInto(out *ImagesStatus) {
+func (in *ConsistentSnapshotList) DeepCopyInto(out *ConsistentSnapshotList) {
 	*out = *in
-	if in.Ceph != nil {
-		in, out := &in.Ceph, &out.Ceph
-		*out = new(ComponentImageStatus)
-		**out = **in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ConsistentSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	if in.NooBaaCore != nil {
-		in, out := &in.NooBaaCore, &out.NooBaaCore
-		*out = new(ComponentImageStatus)
-		**out = **in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsistentSnapshotList.
+func (in *ConsistentSnapshotList) DeepCopy() *ConsistentSnapshotList {
+	if in == nil {
+		return nil
 	}
-	if in.NooBaaDB != nil {
-		in, out := &in.NooBaaDB, &out.NooBaaDB
-		*out = new(ComponentImageStatus)
-		**out = **in
+	out := new(ConsistentSnapshotList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsistentSnapshotList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsistentSnapshotRestore) DeepCopyInto(out *ConsistentSnapshotRestore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsistentSnapshotRestore.
+func (in *ConsistentSnapshotRestore) DeepCopy() *ConsistentSnapshotRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsistentSnapshotRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsistentSnapshotRestore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsistentSnapshotRestoreList) DeepCopyInto(out *ConsistentSnapshotRestoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ConsistentSnapshotRestore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsistentSnapshotRestoreList.
+func (in *ConsistentSnapshotRestoreList) DeepCopy() *ConsistentSnapshotRestoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsistentSnapshotRestoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsistentSnapshotRestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsistentSnapshotRestoreSpec) DeepCopyInto(out *ConsistentSnapshotRestoreSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsistentSnapshotRestoreSpec.
+func (in *ConsistentSnapshotRestoreSpec) DeepCopy() *ConsistentSnapshotRestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsistentSnapshotRestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsistentSnapshotRestoreStatus) DeepCopyInto(out *ConsistentSnapshotRestoreStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]conditionsv1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]RestoredVolume, len(*in))
+		copy(*out, *in)
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsistentSnapshotRestoreStatus.
+func (in *ConsistentSnapshotRestoreStatus) DeepCopy() *ConsistentSnapshotRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsistentSnapshotRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsistentSnapshotSpec) DeepCopyInto(out *ConsistentSnapshotSpec) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+	if in.PreHook != nil {
+		in, out := &in.PreHook, &out.PreHook
+		*out = new(SnapshotHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PostHook != nil {
+		in, out := &in.PostHook, &out.PostHook
+		*out = new(SnapshotHook)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsistentSnapshotSpec.
+func (in *ConsistentSnapshotSpec) DeepCopy() *ConsistentSnapshotSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsistentSnapshotSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsistentSnapshotStatus) DeepCopyInto(out *ConsistentSnapshotStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]conditionsv1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]ConsistentSnapshotVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SnapshotTime != nil {
+		in, out := &in.SnapshotTime, &out.SnapshotTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsistentSnapshotStatus.
+func (in *ConsistentSnapshotStatus) DeepCopy() *ConsistentSnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsistentSnapshotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsistentSnapshotVolume) DeepCopyInto(out *ConsistentSnapshotVolume) {
+	*out = *in
+	if in.AccessModes != nil {
+		in, out := &in.AccessModes, &out.AccessModes
+		*out = make([]corev1.PersistentVolumeAccessMode, len(*in))
+		copy(*out, *in)
+	}
+	if in.VolumeMode != nil {
+		in, out := &in.VolumeMode, &out.VolumeMode
+		*out = new(corev1.PersistentVolumeMode)
+		**out = **in
+	}
+	out.Size = in.Size.DeepCopy()
+	if in.RestoreSize != nil {
+		in, out := &in.RestoreSize, &out.RestoreSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.CreationTime != nil {
+		in, out := &in.CreationTime, &out.CreationTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsistentSnapshotVolume.
+func (in *ConsistentSnapshotVolume) DeepCopy() *ConsistentSnapshotVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsistentSnapshotVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DaemonHealthCheckSpec) DeepCopyInto(out *DaemonHealthCheckSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DaemonHealthCheckSpec.
+func (in *DaemonHealthCheckSpec) DeepCopy() *DaemonHealthCheckSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DaemonHealthCheckSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeviceSetCapacityStatus) DeepCopyInto(out *DeviceSetCapacityStatus) {
+	*out = *in
+	out.Capacity = in.Capacity.DeepCopy()
+	if in.PendingCapacity != nil {
+		in, out := &in.PendingCapacity, &out.PendingCapacity
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	out.MaxCapacity = in.MaxCapacity.DeepCopy()
+	if in.LastExpansionTime != nil {
+		in, out := &in.LastExpansionTime, &out.LastExpansionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceSetCapacityStatus.
+func (in *DeviceSetCapacityStatus) DeepCopy() *DeviceSetCapacityStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DeviceSetCapacityStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EncryptionSpec) DeepCopyInto(out *EncryptionSpec) {
+	*out = *in
+	out.KeyManagementService = in.KeyManagementService
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EncryptionSpec.
+func (in *EncryptionSpec) DeepCopy() *EncryptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EncryptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ErasureCodedSpec) DeepCopyInto(out *ErasureCodedSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ErasureCodedSpec.
+func (in *ErasureCodedSpec) DeepCopy() *ErasureCodedSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ErasureCodedSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalStorageClusterSpec) DeepCopyInto(out *ExternalStorageClusterSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalStorageClusterSpec.
+func (in *ExternalStorageClusterSpec) DeepCopy() *ExternalStorageClusterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalStorageClusterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FilesystemDataPool) DeepCopyInto(out *FilesystemDataPool) {
+	*out = *in
+	if in.ErasureCoded != nil {
+		in, out := &in.ErasureCoded, &out.ErasureCoded
+		*out = new(ErasureCodedSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FilesystemDataPool.
+func (in *FilesystemDataPool) DeepCopy() *FilesystemDataPool {
+	if in == nil {
+		return nil
+	}
+	out := new(FilesystemDataPool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FullRatioOverrideSpec) DeepCopyInto(out *FullRatioOverrideSpec) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FullRatioOverrideSpec.
+func (in *FullRatioOverrideSpec) DeepCopy() *FullRatioOverrideSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FullRatioOverrideSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FullRatioStatus) DeepCopyInto(out *FullRatioStatus) {
+	*out = *in
+	if in.Override != nil {
+		in, out := &in.Override, &out.Override
+		*out = new(FullRatioOverrideSpec)
+		**out = **in
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ExpirationTime != nil {
+		in, out := &in.ExpirationTime, &out.ExpirationTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FullRatioStatus.
+func (in *FullRatioStatus) DeepCopy() *FullRatioStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FullRatioStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthCheckSpec) DeepCopyInto(out *HealthCheckSpec) {
+	*out = *in
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(DaemonHealthCheckSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Mon != nil {
+		in, out := &in.Mon, &out.Mon
+		*out = new(DaemonHealthCheckSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OSD != nil {
+		in, out := &in.OSD, &out.OSD
+		*out = new(DaemonHealthCheckSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = make(map[rook_iov1.KeyType]*rook_iov1.ProbeSpec, len(*in))
+		for key, val := range *in {
+			var outVal *rook_iov1.ProbeSpec
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(rook_iov1.ProbeSpec)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheckSpec.
+func (in *HealthCheckSpec) DeepCopy() *HealthCheckSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthCheckSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImagesStatus) DeepCopyInto(out *ImagesStatus) {
+	*out = *in
+	if in.Ceph != nil {
+		in, out := &in.Ceph, &out.Ceph
+		*out = new(ComponentImageStatus)
+		**out = **in
+	}
+	if in.NooBaaCore != nil {
+		in, out := &in.NooBaaCore, &out.NooBaaCore
+		*out = new(ComponentImageStatus)
+		**out = **in
+	}
+	if in.NooBaaDB != nil {
+		in, out := &in.NooBaaDB, &out.NooBaaDB
+		*out = new(ComponentImageStatus)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagesStatus.
+func (in *ImagesStatus) DeepCopy() *ImagesStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ImagesStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyManagementServiceSpec) DeepCopyInto(out *KeyManagementServiceSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyManagementServiceSpec.
+func (in *KeyManagementServiceSpec) DeepCopy() *KeyManagementServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyManagementServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManageCephBlockPools) DeepCopyInto(out *ManageCephBlockPools) {
+	*out = *in
+	if in.ErasureCoded != nil {
+		in, out := &in.ErasureCoded, &out.ErasureCoded
+		*out = new(ErasureCodedSpec)
+		**out = **in
+	}
+	if in.AdditionalPools != nil {
+		in, out := &in.AdditionalPools, &out.AdditionalPools
+		*out = make([]AdditionalBlockPool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StorageClassOverrides != nil {
+		in, out := &in.StorageClassOverrides, &out.StorageClassOverrides
+		*out = new(StorageClassOverrides)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageCephBlockPools.
+func (in *ManageCephBlockPools) DeepCopy() *ManageCephBlockPools {
+	if in == nil {
+		return nil
+	}
+	out := new(ManageCephBlockPools)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManageCephConfig) DeepCopyInto(out *ManageCephConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageCephConfig.
+func (in *ManageCephConfig) DeepCopy() *ManageCephConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ManageCephConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManageCephDashboard) DeepCopyInto(out *ManageCephDashboard) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageCephDashboard.
+func (in *ManageCephDashboard) DeepCopy() *ManageCephDashboard {
+	if in == nil {
+		return nil
+	}
+	out := new(ManageCephDashboard)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManageCephFilesystems) DeepCopyInto(out *ManageCephFilesystems) {
+	*out = *in
+	if in.ErasureCoded != nil {
+		in, out := &in.ErasureCoded, &out.ErasureCoded
+		*out = new(ErasureCodedSpec)
+		**out = **in
+	}
+	if in.AdditionalFilesystems != nil {
+		in, out := &in.AdditionalFilesystems, &out.AdditionalFilesystems
+		*out = make([]AdditionalFilesystem, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StorageClassOverrides != nil {
+		in, out := &in.StorageClassOverrides, &out.StorageClassOverrides
+		*out = new(StorageClassOverrides)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageCephFilesystems.
+func (in *ManageCephFilesystems) DeepCopy() *ManageCephFilesystems {
+	if in == nil {
+		return nil
+	}
+	out := new(ManageCephFilesystems)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManageCephObjectStoreUsers) DeepCopyInto(out *ManageCephObjectStoreUsers) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageCephObjectStoreUsers.
+func (in *ManageCephObjectStoreUsers) DeepCopy() *ManageCephObjectStoreUsers {
+	if in == nil {
+		return nil
+	}
+	out := new(ManageCephObjectStoreUsers)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManageCephObjectStores) DeepCopyInto(out *ManageCephObjectStores) {
+	*out = *in
+	if in.ErasureCoded != nil {
+		in, out := &in.ErasureCoded, &out.ErasureCoded
+		*out = new(ErasureCodedSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageCephObjectStores.
+func (in *ManageCephObjectStores) DeepCopy() *ManageCephObjectStores {
+	if in == nil {
+		return nil
+	}
+	out := new(ManageCephObjectStores)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedResourcesSpec) DeepCopyInto(out *ManagedResourcesSpec) {
+	*out = *in
+	out.CephConfig = in.CephConfig
+	out.CephDashboard = in.CephDashboard
+	in.CephBlockPools.DeepCopyInto(&out.CephBlockPools)
+	in.CephFilesystems.DeepCopyInto(&out.CephFilesystems)
+	in.CephObjectStores.DeepCopyInto(&out.CephObjectStores)
+	out.CephObjectStoreUsers = in.CephObjectStoreUsers
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedResourcesSpec.
+func (in *ManagedResourcesSpec) DeepCopy() *ManagedResourcesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedResourcesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MgrModuleSpec) DeepCopyInto(out *MgrModuleSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MgrModuleSpec.
+func (in *MgrModuleSpec) DeepCopy() *MgrModuleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MgrModuleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MgrSpec) DeepCopyInto(out *MgrSpec) {
+	*out = *in
+	if in.Modules != nil {
+		in, out := &in.Modules, &out.Modules
+		*out = make([]MgrModuleSpec, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MgrSpec.
+func (in *MgrSpec) DeepCopy() *MgrSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MgrSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MirroringSpec) DeepCopyInto(out *MirroringSpec) {
+	*out = *in
+	if in.PeerSecretNames != nil {
+		in, out := &in.PeerSecretNames, &out.PeerSecretNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SchedulingIntervals != nil {
+		in, out := &in.SchedulingIntervals, &out.SchedulingIntervals
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MirroringSpec.
+func (in *MirroringSpec) DeepCopy() *MirroringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MirroringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MirroringStatus) DeepCopyInto(out *MirroringStatus) {
+	*out = *in
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]PoolMirroringStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MirroringStatus.
+func (in *MirroringStatus) DeepCopy() *MirroringStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MirroringStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonSpec) DeepCopyInto(out *MonSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonSpec.
+func (in *MonSpec) DeepCopy() *MonSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonStatus) DeepCopyInto(out *MonStatus) {
+	*out = *in
+	if in.Mons != nil {
+		in, out := &in.Mons, &out.Mons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.QuorumMons != nil {
+		in, out := &in.QuorumMons, &out.QuorumMons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonStatus.
+func (in *MonStatus) DeepCopy() *MonStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MonStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitoringSpec) DeepCopyInto(out *MonitoringSpec) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringSpec.
+func (in *MonitoringSpec) DeepCopy() *MonitoringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitoringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MultiCloudGatewaySpec) DeepCopyInto(out *MultiCloudGatewaySpec) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = new(v1alpha1.EndpointsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultiCloudGatewaySpec.
+func (in *MultiCloudGatewaySpec) DeepCopy() *MultiCloudGatewaySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MultiCloudGatewaySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NFSSpec) DeepCopyInto(out *NFSSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NFSSpec.
+func (in *NFSSpec) DeepCopy() *NFSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NFSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeTopologyMap) DeepCopyInto(out *NodeTopologyMap) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]TopologyLabelValues, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make(TopologyLabelValues, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeTopologyMap.
+func (in *NodeTopologyMap) DeepCopy() *NodeTopologyMap {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeTopologyMap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCSInitialization) DeepCopyInto(out *OCSInitialization) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCSInitialization.
+func (in *OCSInitialization) DeepCopy() *OCSInitialization {
+	if in == nil {
+		return nil
+	}
+	out := new(OCSInitialization)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OCSInitialization) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCSInitializationList) DeepCopyInto(out *OCSInitializationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OCSInitialization, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCSInitializationList.
+func (in *OCSInitializationList) DeepCopy() *OCSInitializationList {
+	if in == nil {
+		return nil
+	}
+	out := new(OCSInitializationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OCSInitializationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCSInitializationSpec) DeepCopyInto(out *OCSInitializationSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCSInitializationSpec.
+func (in *OCSInitializationSpec) DeepCopy() *OCSInitializationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OCSInitializationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCSInitializationStatus) DeepCopyInto(out *OCSInitializationStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]conditionsv1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RelatedObjects != nil {
+		in, out := &in.RelatedObjects, &out.RelatedObjects
+		*out = make([]corev1.ObjectReference, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagesStatus.
-func (in *ImagesStatus) DeepCopy() *ImagesStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCSInitializationStatus.
+func (in *OCSInitializationStatus) DeepCopy() *OCSInitializationStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ImagesStatus)
+	out := new(OCSInitializationStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeyManagementServiceSpec) DeepCopyInto(out *KeyManagementServiceSpec) {
+func (in *OSDRemovalRecord) DeepCopyInto(out *OSDRemovalRecord) {
 	*out = *in
+	if in.RemovedOSDs != nil {
+		in, out := &in.RemovedOSDs, &out.RemovedOSDs
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailedOSDs != nil {
+		in, out := &in.FailedOSDs, &out.FailedOSDs
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	in.CompletionTime.DeepCopyInto(&out.CompletionTime)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyManagementServiceSpec.
-func (in *KeyManagementServiceSpec) DeepCopy() *KeyManagementServiceSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSDRemovalRecord.
+func (in *OSDRemovalRecord) DeepCopy() *OSDRemovalRecord {
 	if in == nil {
 		return nil
 	}
-	out := new(KeyManagementServiceSpec)
+	out := new(OSDRemovalRecord)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManageCephBlockPools) DeepCopyInto(out *ManageCephBlockPools) {
+func (in *OSDRemovalRequest) DeepCopyInto(out *OSDRemovalRequest) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageCephBlockPools.
-func (in *ManageCephBlockPools) DeepCopy() *ManageCephBlockPools {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSDRemovalRequest.
+func (in *OSDRemovalRequest) DeepCopy() *OSDRemovalRequest {
 	if in == nil {
 		return nil
 	}
-	out := new(ManageCephBlockPools)
+	out := new(OSDRemovalRequest)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OSDRemovalRequest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManageCephConfig) DeepCopyInto(out *ManageCephConfig) {
+func (in *OSDRemovalRequestList) DeepCopyInto(out *OSDRemovalRequestList) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OSDRemovalRequest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageCephConfig.
-func (in *ManageCephConfig) DeepCopy() *ManageCephConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSDRemovalRequestList.
+func (in *OSDRemovalRequestList) DeepCopy() *OSDRemovalRequestList {
 	if in == nil {
 		return nil
 	}
-	out := new(ManageCephConfig)
+	out := new(OSDRemovalRequestList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OSDRemovalRequestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManageCephDashboard) DeepCopyInto(out *ManageCephDashboard) {
+func (in *OSDRemovalRequestSpec) DeepCopyInto(out *OSDRemovalRequestSpec) {
 	*out = *in
+	if in.OSDIDs != nil {
+		in, out := &in.OSDIDs, &out.OSDIDs
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageCephDashboard.
-func (in *ManageCephDashboard) DeepCopy() *ManageCephDashboard {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSDRemovalRequestSpec.
+func (in *OSDRemovalRequestSpec) DeepCopy() *OSDRemovalRequestSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ManageCephDashboard)
+	out := new(OSDRemovalRequestSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManageCephFilesystems) DeepCopyInto(out *ManageCephFilesystems) {
+func (in *OSDRemovalRequestStatus) DeepCopyInto(out *OSDRemovalRequestStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]conditionsv1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.OSDs != nil {
+		in, out := &in.OSDs, &out.OSDs
+		*out = make([]OSDRemovalStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageCephFilesystems.
-func (in *ManageCephFilesystems) DeepCopy() *ManageCephFilesystems {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSDRemovalRequestStatus.
+func (in *OSDRemovalRequestStatus) DeepCopy() *OSDRemovalRequestStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ManageCephFilesystems)
+	out := new(OSDRemovalRequestStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManageCephObjectStoreUsers) DeepCopyInto(out *ManageCephObjectStoreUsers) {
+func (in *OSDRemovalStatus) DeepCopyInto(out *OSDRemovalStatus) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageCephObjectStoreUsers.
-func (in *ManageCephObjectStoreUsers) DeepCopy() *ManageCephObjectStoreUsers {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSDRemovalStatus.
+func (in *OSDRemovalStatus) DeepCopy() *OSDRemovalStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ManageCephObjectStoreUsers)
+	out := new(OSDRemovalStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManageCephObjectStores) DeepCopyInto(out *ManageCephObjectStores) {
+func (in *PlannedChange) DeepCopyInto(out *PlannedChange) {
 	*out = *in
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManageCephObjectStores.
-func (in *ManageCephObjectStores) DeepCopy() *ManageCephObjectStores {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlannedChange.
+func (in *PlannedChange) DeepCopy() *PlannedChange {
 	if in == nil {
 		return nil
 	}
-	out := new(ManageCephObjectStores)
+	out := new(PlannedChange)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManagedResourcesSpec) DeepCopyInto(out *ManagedResourcesSpec) {
+func (in *PoolMirroringStatus) DeepCopyInto(out *PoolMirroringStatus) {
 	*out = *in
-	out.CephConfig = in.CephConfig
-	out.CephDashboard = in.CephDashboard
-	out.CephBlockPools = in.CephBlockPools
-	out.CephFilesystems = in.CephFilesystems
-	out.CephObjectStores = in.CephObjectStores
-	out.CephObjectStoreUsers = in.CephObjectStoreUsers
+	if in.PeerSites != nil {
+		in, out := &in.PeerSites, &out.PeerSites
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedResourcesSpec.
-func (in *ManagedResourcesSpec) DeepCopy() *ManagedResourcesSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolMirroringStatus.
+func (in *PoolMirroringStatus) DeepCopy() *PoolMirroringStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ManagedResourcesSpec)
+	out := new(PoolMirroringStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MonitoringSpec) DeepCopyInto(out *MonitoringSpec) {
+func (in *RestoredVolume) DeepCopyInto(out *RestoredVolume) {
 	*out = *in
-	if in.Labels != nil {
-		in, out := &in.Labels, &out.Labels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringSpec.
-func (in *MonitoringSpec) DeepCopy() *MonitoringSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoredVolume.
+func (in *RestoredVolume) DeepCopy() *RestoredVolume {
 	if in == nil {
 		return nil
 	}
-	out := new(MonitoringSpec)
+	out := new(RestoredVolume)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MultiCloudGatewaySpec) DeepCopyInto(out *MultiCloudGatewaySpec) {
+func (in *SnapshotHook) DeepCopyInto(out *SnapshotHook) {
 	*out = *in
-	if in.Endpoints != nil {
-		in, out := &in.Endpoints, &out.Endpoints
-		*out = new(v1alpha1.EndpointsSpec)
-		(*in).DeepCopyInto(*out)
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultiCloudGatewaySpec.
-func (in *MultiCloudGatewaySpec) DeepCopy() *MultiCloudGatewaySpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotHook.
+func (in *SnapshotHook) DeepCopy() *SnapshotHook {
 	if in == nil {
 		return nil
 	}
-	out := new(MultiCloudGatewaySpec)
+	out := new(SnapshotHook)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NodeTopologyMap) DeepCopyInto(out *NodeTopologyMap) {
+func (in *SnapshotRetention) DeepCopyInto(out *SnapshotRetention) {
 	*out = *in
-	if in.Labels != nil {
-		in, out := &in.Labels, &out.Labels
-		*out = make(map[string]TopologyLabelValues, len(*in))
-		for key, val := range *in {
-			var outVal []string
-			if val == nil {
-				(*out)[key] = nil
-			} else {
-				in, out := &val, &outVal
-				*out = make(TopologyLabelValues, len(*in))
-				copy(*out, *in)
-			}
-			(*out)[key] = outVal
-		}
+	if in.MaxCount != nil {
+		in, out := &in.MaxCount, &out.MaxCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(metav1.Duration)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeTopologyMap.
-func (in *NodeTopologyMap) DeepCopy() *NodeTopologyMap {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotRetention.
+func (in *SnapshotRetention) DeepCopy() *SnapshotRetention {
 	if in == nil {
 		return nil
 	}
-	out := new(NodeTopologyMap)
+	out := new(SnapshotRetention)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OCSInitialization) DeepCopyInto(out *OCSInitialization) {
+func (in *SnapshotSchedule) DeepCopyInto(out *SnapshotSchedule) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCSInitialization.
-func (in *OCSInitialization) DeepCopy() *OCSInitialization {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotSchedule.
+func (in *SnapshotSchedule) DeepCopy() *SnapshotSchedule {
 	if in == nil {
 		return nil
 	}
-	out := new(OCSInitialization)
+	out := new(SnapshotSchedule)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *OCSInitialization) DeepCopyObject() runtime.Object {
+func (in *SnapshotSchedule) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -351,31 +1470,31 @@ func (in *OCSInitialization) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OCSInitializationList) DeepCopyInto(out *OCSInitializationList) {
+func (in *SnapshotScheduleList) DeepCopyInto(out *SnapshotScheduleList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]OCSInitialization, len(*in))
+		*out = make([]SnapshotSchedule, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCSInitializationList.
-func (in *OCSInitializationList) DeepCopy() *OCSInitializationList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotScheduleList.
+func (in *SnapshotScheduleList) DeepCopy() *SnapshotScheduleList {
 	if in == nil {
 		return nil
 	}
-	out := new(OCSInitializationList)
+	out := new(SnapshotScheduleList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *OCSInitializationList) DeepCopyObject() runtime.Object {
+func (in *SnapshotScheduleList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -383,22 +1502,24 @@ func (in *OCSInitializationList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OCSInitializationSpec) DeepCopyInto(out *OCSInitializationSpec) {
+func (in *SnapshotScheduleSpec) DeepCopyInto(out *SnapshotScheduleSpec) {
 	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+	in.Retention.DeepCopyInto(&out.Retention)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCSInitializationSpec.
-func (in *OCSInitializationSpec) DeepCopy() *OCSInitializationSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotScheduleSpec.
+func (in *SnapshotScheduleSpec) DeepCopy() *SnapshotScheduleSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(OCSInitializationSpec)
+	out := new(SnapshotScheduleSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OCSInitializationStatus) DeepCopyInto(out *OCSInitializationStatus) {
+func (in *SnapshotScheduleStatus) DeepCopyInto(out *SnapshotScheduleStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
@@ -407,19 +1528,70 @@ func (in *OCSInitializationStatus) DeepCopyInto(out *OCSInitializationStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.RelatedObjects != nil {
-		in, out := &in.RelatedObjects, &out.RelatedObjects
-		*out = make([]corev1.ObjectReference, len(*in))
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextScheduleTime != nil {
+		in, out := &in.NextScheduleTime, &out.NextScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessTime != nil {
+		in, out := &in.LastSuccessTime, &out.LastSuccessTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastFailureTime != nil {
+		in, out := &in.LastFailureTime, &out.LastFailureTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotScheduleStatus.
+func (in *SnapshotScheduleStatus) DeepCopy() *SnapshotScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageClassOverrides) DeepCopyInto(out *StorageClassOverrides) {
+	*out = *in
+	if in.ImageFeatures != nil {
+		in, out := &in.ImageFeatures, &out.ImageFeatures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReclaimPolicy != nil {
+		in, out := &in.ReclaimPolicy, &out.ReclaimPolicy
+		*out = new(corev1.PersistentVolumeReclaimPolicy)
+		**out = **in
+	}
+	if in.VolumeBindingMode != nil {
+		in, out := &in.VolumeBindingMode, &out.VolumeBindingMode
+		*out = new(storagev1.VolumeBindingMode)
+		**out = **in
+	}
+	if in.MountOptions != nil {
+		in, out := &in.MountOptions, &out.MountOptions
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = new(bool)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCSInitializationStatus.
-func (in *OCSInitializationStatus) DeepCopy() *OCSInitializationStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageClassOverrides.
+func (in *StorageClassOverrides) DeepCopy() *StorageClassOverrides {
 	if in == nil {
 		return nil
 	}
-	out := new(OCSInitializationStatus)
+	out := new(StorageClassOverrides)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -483,6 +1655,38 @@ func (in *StorageClusterList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageClusterPlan) DeepCopyInto(out *StorageClusterPlan) {
+	*out = *in
+	if in.AddedDeviceSets != nil {
+		in, out := &in.AddedDeviceSets, &out.AddedDeviceSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]PlannedChange, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageClusterPlan.
+func (in *StorageClusterPlan) DeepCopy() *StorageClusterPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageClusterPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageClusterSpec) DeepCopyInto(out *StorageClusterSpec) {
 	*out = *in
@@ -499,6 +1703,13 @@ func (in *StorageClusterSpec) DeepCopyInto(out *StorageClusterSpec) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	out.Mon = in.Mon
+	in.Mgr.DeepCopyInto(&out.Mgr)
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(HealthCheckSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
 		*out = make(map[string]corev1.ResourceRequirements, len(*in))
@@ -534,13 +1745,45 @@ func (in *StorageClusterSpec) DeepCopyInto(out *StorageClusterSpec) {
 		*out = new(rook_iov1.NetworkSpec)
 		(*in).DeepCopyInto(*out)
 	}
-	out.ManagedResources = in.ManagedResources
+	in.ManagedResources.DeepCopyInto(&out.ManagedResources)
 	if in.NodeTopologies != nil {
 		in, out := &in.NodeTopologies, &out.NodeTopologies
 		*out = new(NodeTopologyMap)
 		(*in).DeepCopyInto(*out)
 	}
 	in.Arbiter.DeepCopyInto(&out.Arbiter)
+	if in.CephConfig != nil {
+		in, out := &in.CephConfig, &out.CephConfig
+		*out = make(map[string]CephConfigSection, len(*in))
+		for key, val := range *in {
+			var outVal map[string]string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make(CephConfigSection, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.Capacity != nil {
+		in, out := &in.Capacity, &out.Capacity
+		*out = new(CapacitySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NFS != nil {
+		in, out := &in.NFS, &out.NFS
+		*out = new(NFSSpec)
+		**out = **in
+	}
+	if in.Mirroring != nil {
+		in, out := &in.Mirroring, &out.Mirroring
+		*out = new(MirroringSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageClusterSpec.
@@ -579,6 +1822,54 @@ func (in *StorageClusterStatus) DeepCopyInto(out *StorageClusterStatus) {
 		copy(*out, *in)
 	}
 	in.Images.DeepCopyInto(&out.Images)
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make(map[string]ComponentStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(StorageClusterPlan)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OSDRemovals != nil {
+		in, out := &in.OSDRemovals, &out.OSDRemovals
+		*out = make([]OSDRemovalRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FullRatio != nil {
+		in, out := &in.FullRatio, &out.FullRatio
+		*out = new(FullRatioStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeviceSetCapacity != nil {
+		in, out := &in.DeviceSetCapacity, &out.DeviceSetCapacity
+		*out = make([]DeviceSetCapacityStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Mon != nil {
+		in, out := &in.Mon, &out.Mon
+		*out = new(MonStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EffectiveResources != nil {
+		in, out := &in.EffectiveResources, &out.EffectiveResources
+		*out = make(map[string]corev1.ResourceRequirements, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Mirroring != nil {
+		in, out := &in.Mirroring, &out.Mirroring
+		*out = new(MirroringStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageClusterStatus.
@@ -594,10 +1885,15 @@ func (in *StorageClusterStatus) DeepCopy() *StorageClusterStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageDeviceSet) DeepCopyInto(out *StorageDeviceSet) {
 	*out = *in
+	if in.AutoExpand != nil {
+		in, out := &in.AutoExpand, &out.AutoExpand
+		*out = new(AutoExpandPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	in.Resources.DeepCopyInto(&out.Resources)
 	in.PreparePlacement.DeepCopyInto(&out.PreparePlacement)
 	in.Placement.DeepCopyInto(&out.Placement)
-	out.Config = in.Config
+	in.Config.DeepCopyInto(&out.Config)
 	in.DataPVCTemplate.DeepCopyInto(&out.DataPVCTemplate)
 	if in.MetadataPVCTemplate != nil {
 		in, out := &in.MetadataPVCTemplate, &out.MetadataPVCTemplate
@@ -624,6 +1920,18 @@ func (in *StorageDeviceSet) DeepCopy() *StorageDeviceSet {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageDeviceSetConfig) DeepCopyInto(out *StorageDeviceSetConfig) {
 	*out = *in
+	if in.EncryptedDevice != nil {
+		in, out := &in.EncryptedDevice, &out.EncryptedDevice
+		*out = new(bool)
+		**out = **in
+	}
+	if in.OsdConfig != nil {
+		in, out := &in.OsdConfig, &out.OsdConfig
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageDeviceSetConfig.
@@ -0,0 +1,160 @@
+package consistentsnapshot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// ValidatingWebhookPath is the path on which the ConsistentSnapshot
+	// validating webhook is served
+	ValidatingWebhookPath = "/validate-consistentsnapshot"
+
+	// RestoreValidatingWebhookPath is the path on which the
+	// ConsistentSnapshotRestore validating webhook is served
+	RestoreValidatingWebhookPath = "/validate-consistentsnapshotrestore"
+)
+
+// +kubebuilder:webhook:path=/validate-consistentsnapshot,mutating=false,failurePolicy=fail,sideEffects=None,groups=ocs.openshift.io,resources=consistentsnapshots,verbs=create;update,versions=v1,name=vconsistentsnapshot.ocs.openshift.io,admissionReviewVersions=v1
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// ConsistentSnapshotValidator rejects the ConsistentSnapshots whose hooks
+// the requester could not run by themselves. The operator runs the hook
+// Jobs with its own permissions, as any ServiceAccount of the namespace, so
+// only the users allowed to create Jobs in the namespace may set hooks.
+type ConsistentSnapshotValidator struct {
+	Client  client.Client
+	Log     logr.Logger
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &ConsistentSnapshotValidator{}
+
+// Handle validates the ConsistentSnapshot in the admission request
+func (v *ConsistentSnapshotValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	instance := &ocsv1.ConsistentSnapshot{}
+	if err := v.decoder.Decode(req, instance); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if instance.Spec.PreHook == nil && instance.Spec.PostHook == nil {
+		return admission.Allowed("")
+	}
+
+	if req.Operation == admissionv1.Update {
+		oldInstance := &ocsv1.ConsistentSnapshot{}
+		if err := v.decoder.DecodeRaw(req.OldObject, oldInstance); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if reflect.DeepEqual(oldInstance.Spec.PreHook, instance.Spec.PreHook) &&
+			reflect.DeepEqual(oldInstance.Spec.PostHook, instance.Spec.PostHook) {
+			return admission.Allowed("")
+		}
+	}
+
+	allowed, err := canCreate(ctx, v.Client, req.UserInfo, req.Namespace, "batch", "jobs")
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if !allowed {
+		reason := fmt.Sprintf("user %s is not allowed to create Jobs in namespace %s, which the hooks run as", req.UserInfo.Username, req.Namespace)
+		v.Log.Info("Rejecting ConsistentSnapshot.", "Operation", req.Operation, "Reason", reason)
+		return admission.Denied(reason)
+	}
+	return admission.Allowed("")
+}
+
+// InjectDecoder injects the decoder into the ConsistentSnapshotValidator
+func (v *ConsistentSnapshotValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-consistentsnapshotrestore,mutating=false,failurePolicy=fail,sideEffects=None,groups=ocs.openshift.io,resources=consistentsnapshotrestores,verbs=create;update,versions=v1,name=vconsistentsnapshotrestore.ocs.openshift.io,admissionReviewVersions=v1
+
+// ConsistentSnapshotRestoreValidator rejects the ConsistentSnapshotRestores
+// into namespaces the requester could not restore PVCs into by themselves.
+// The operator creates the restored objects with its own permissions, so
+// only the users allowed to create PVCs in the target namespace may set it.
+type ConsistentSnapshotRestoreValidator struct {
+	Client  client.Client
+	Log     logr.Logger
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &ConsistentSnapshotRestoreValidator{}
+
+// Handle validates the ConsistentSnapshotRestore in the admission request
+func (v *ConsistentSnapshotRestoreValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	instance := &ocsv1.ConsistentSnapshotRestore{}
+	if err := v.decoder.Decode(req, instance); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	targetNamespace := instance.Spec.TargetNamespace
+	if targetNamespace == "" || targetNamespace == req.Namespace {
+		return admission.Allowed("")
+	}
+
+	if req.Operation == admissionv1.Update {
+		oldInstance := &ocsv1.ConsistentSnapshotRestore{}
+		if err := v.decoder.DecodeRaw(req.OldObject, oldInstance); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if oldInstance.Spec.TargetNamespace == targetNamespace {
+			return admission.Allowed("")
+		}
+	}
+
+	allowed, err := canCreate(ctx, v.Client, req.UserInfo, targetNamespace, "", "persistentvolumeclaims")
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if !allowed {
+		reason := fmt.Sprintf("user %s is not allowed to create PVCs in target namespace %s", req.UserInfo.Username, targetNamespace)
+		v.Log.Info("Rejecting ConsistentSnapshotRestore.", "Operation", req.Operation, "Reason", reason)
+		return admission.Denied(reason)
+	}
+	return admission.Allowed("")
+}
+
+// InjectDecoder injects the decoder into the ConsistentSnapshotRestoreValidator
+func (v *ConsistentSnapshotRestoreValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// canCreate asks the API server whether the user may create the resources
+// of the API group in the namespace
+func canCreate(ctx context.Context, c client.Client, user authenticationv1.UserInfo, namespace, group, resource string) (bool, error) {
+	extra := map[string]authorizationv1.ExtraValue{}
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "create",
+				Group:     group,
+				Resource:  resource,
+			},
+		},
+	}
+	if err := c.Create(ctx, review); err != nil {
+		return false, fmt.Errorf("failed to review the access of user %s: %v", user.Username, err)
+	}
+	return review.Status.Allowed, nil
+}
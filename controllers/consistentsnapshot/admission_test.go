@@ -0,0 +1,238 @@
+package consistentsnapshot
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// fakeAccessReviewClient answers the SubjectAccessReviews, allowing the
+// users in allowedUsers to create anything
+type fakeAccessReviewClient struct {
+	client.Client
+	allowedUsers map[string]bool
+	reviews      []*authorizationv1.SubjectAccessReview
+}
+
+func (c *fakeAccessReviewClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	review, ok := obj.(*authorizationv1.SubjectAccessReview)
+	if !ok {
+		return c.Client.Create(ctx, obj, opts...)
+	}
+	c.reviews = append(c.reviews, review)
+	review.Status.Allowed = c.allowedUsers[review.Spec.User]
+	return nil
+}
+
+func TestConsistentSnapshotValidatorHandle(t *testing.T) {
+	withHooks := &ocsv1.ConsistentSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: testNamespace},
+		Spec: ocsv1.ConsistentSnapshotSpec{
+			PreHook: &ocsv1.SnapshotHook{Image: "busybox", Command: []string{"sync"}, ServiceAccountName: "privileged"},
+		},
+	}
+	withoutHooks := withHooks.DeepCopy()
+	withoutHooks.Spec.PreHook = nil
+	relabeled := withHooks.DeepCopy()
+	relabeled.Labels = map[string]string{"app": "db"}
+
+	cases := []struct {
+		label          string
+		operation      admissionv1.Operation
+		user           string
+		object         *ocsv1.ConsistentSnapshot
+		oldObject      *ocsv1.ConsistentSnapshot
+		expectAllowed  bool
+		expectReviewed bool
+	}{
+		{
+			label:         "no hooks",
+			operation:     admissionv1.Create,
+			user:          "viewer",
+			object:        withoutHooks,
+			expectAllowed: true,
+		},
+		{
+			label:          "hooks set by a user allowed to create Jobs",
+			operation:      admissionv1.Create,
+			user:           "admin",
+			object:         withHooks,
+			expectAllowed:  true,
+			expectReviewed: true,
+		},
+		{
+			label:          "hooks set by a user not allowed to create Jobs",
+			operation:      admissionv1.Create,
+			user:           "viewer",
+			object:         withHooks,
+			expectReviewed: true,
+		},
+		{
+			label:          "hooks added by a user not allowed to create Jobs",
+			operation:      admissionv1.Update,
+			user:           "viewer",
+			object:         withHooks,
+			oldObject:      withoutHooks,
+			expectReviewed: true,
+		},
+		{
+			label:         "hooks left unchanged",
+			operation:     admissionv1.Update,
+			user:          "viewer",
+			object:        relabeled,
+			oldObject:     withHooks,
+			expectAllowed: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		scheme := createFakeScheme(t)
+		fakeClient := &fakeAccessReviewClient{
+			Client:       fake.NewClientBuilder().WithScheme(scheme).Build(),
+			allowedUsers: map[string]bool{"admin": true},
+		}
+		validator := &ConsistentSnapshotValidator{Client: fakeClient, Log: logf.Log.WithName("validator_test")}
+		decoder, err := admission.NewDecoder(scheme)
+		assert.NoError(t, err)
+		assert.NoError(t, validator.InjectDecoder(decoder))
+
+		req := admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: c.operation,
+				Namespace: testNamespace,
+				UserInfo:  authenticationv1.UserInfo{Username: c.user},
+				Object:    runtime.RawExtension{Raw: marshalObject(t, c.object)},
+			},
+		}
+		if c.oldObject != nil {
+			req.OldObject = runtime.RawExtension{Raw: marshalObject(t, c.oldObject)}
+		}
+		resp := validator.Handle(context.TODO(), req)
+		assert.Equal(t, c.expectAllowed, resp.Allowed)
+		if !c.expectReviewed {
+			assert.Empty(t, fakeClient.reviews)
+			continue
+		}
+		assert.Len(t, fakeClient.reviews, 1)
+		attributes := fakeClient.reviews[0].Spec.ResourceAttributes
+		assert.Equal(t, testNamespace, attributes.Namespace)
+		assert.Equal(t, "create", attributes.Verb)
+		assert.Equal(t, "jobs", attributes.Resource)
+	}
+}
+
+func TestConsistentSnapshotRestoreValidatorHandle(t *testing.T) {
+	sameNamespace := &ocsv1.ConsistentSnapshotRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: testNamespace},
+		Spec:       ocsv1.ConsistentSnapshotRestoreSpec{ConsistentSnapshotName: "backup"},
+	}
+	otherNamespace := sameNamespace.DeepCopy()
+	otherNamespace.Spec.TargetNamespace = "my-app-restore"
+	relabeled := otherNamespace.DeepCopy()
+	relabeled.Labels = map[string]string{"app": "db"}
+
+	cases := []struct {
+		label          string
+		operation      admissionv1.Operation
+		user           string
+		object         *ocsv1.ConsistentSnapshotRestore
+		oldObject      *ocsv1.ConsistentSnapshotRestore
+		expectAllowed  bool
+		expectReviewed bool
+	}{
+		{
+			label:         "restore into the namespace of the restore",
+			operation:     admissionv1.Create,
+			user:          "viewer",
+			object:        sameNamespace,
+			expectAllowed: true,
+		},
+		{
+			label:          "restore into another namespace by a user allowed to create PVCs there",
+			operation:      admissionv1.Create,
+			user:           "admin",
+			object:         otherNamespace,
+			expectAllowed:  true,
+			expectReviewed: true,
+		},
+		{
+			label:          "restore into another namespace by a user not allowed to create PVCs there",
+			operation:      admissionv1.Create,
+			user:           "viewer",
+			object:         otherNamespace,
+			expectReviewed: true,
+		},
+		{
+			label:          "target namespace changed by a user not allowed to create PVCs there",
+			operation:      admissionv1.Update,
+			user:           "viewer",
+			object:         otherNamespace,
+			oldObject:      sameNamespace,
+			expectReviewed: true,
+		},
+		{
+			label:         "target namespace left unchanged",
+			operation:     admissionv1.Update,
+			user:          "viewer",
+			object:        relabeled,
+			oldObject:     otherNamespace,
+			expectAllowed: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		scheme := createFakeScheme(t)
+		fakeClient := &fakeAccessReviewClient{
+			Client:       fake.NewClientBuilder().WithScheme(scheme).Build(),
+			allowedUsers: map[string]bool{"admin": true},
+		}
+		validator := &ConsistentSnapshotRestoreValidator{Client: fakeClient, Log: logf.Log.WithName("validator_test")}
+		decoder, err := admission.NewDecoder(scheme)
+		assert.NoError(t, err)
+		assert.NoError(t, validator.InjectDecoder(decoder))
+
+		req := admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: c.operation,
+				Namespace: testNamespace,
+				UserInfo:  authenticationv1.UserInfo{Username: c.user},
+				Object:    runtime.RawExtension{Raw: marshalObject(t, c.object)},
+			},
+		}
+		if c.oldObject != nil {
+			req.OldObject = runtime.RawExtension{Raw: marshalObject(t, c.oldObject)}
+		}
+		resp := validator.Handle(context.TODO(), req)
+		assert.Equal(t, c.expectAllowed, resp.Allowed)
+		if !c.expectReviewed {
+			assert.Empty(t, fakeClient.reviews)
+			continue
+		}
+		assert.Len(t, fakeClient.reviews, 1)
+		attributes := fakeClient.reviews[0].Spec.ResourceAttributes
+		assert.Equal(t, "my-app-restore", attributes.Namespace)
+		assert.Equal(t, "create", attributes.Verb)
+		assert.Equal(t, "", attributes.Group)
+		assert.Equal(t, "persistentvolumeclaims", attributes.Resource)
+	}
+}
+
+func marshalObject(t *testing.T, instance interface{}) []byte {
+	raw, err := json.Marshal(instance)
+	assert.NoError(t, err)
+	return raw
+}
@@ -0,0 +1,51 @@
+package consistentsnapshot
+
+import (
+	"github.com/go-logr/logr"
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/util"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConsistentSnapshotReconciler reconciles a ConsistentSnapshot object
+//nolint
+type ConsistentSnapshotReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	recorder *util.EventReporter
+}
+
+// SetupWithManager sets up a controller with a manager
+func (r *ConsistentSnapshotReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = util.NewEventReporter(mgr.GetEventRecorderFor("controller_consistentsnapshot"))
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ocsv1.ConsistentSnapshot{}).
+		Owns(&batchv1.Job{}).
+		Owns(&snapapi.VolumeSnapshot{}).
+		Complete(r)
+}
+
+// ConsistentSnapshotRestoreReconciler reconciles a ConsistentSnapshotRestore
+// object
+//nolint
+type ConsistentSnapshotRestoreReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	recorder *util.EventReporter
+}
+
+// SetupWithManager sets up a controller with a manager
+func (r *ConsistentSnapshotRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = util.NewEventReporter(mgr.GetEventRecorderFor("controller_consistentsnapshotrestore"))
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ocsv1.ConsistentSnapshotRestore{}).
+		Complete(r)
+}
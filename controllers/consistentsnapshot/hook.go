@@ -0,0 +1,98 @@
+package consistentsnapshot
+
+import (
+	"fmt"
+	"time"
+
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	preHookType  = "pre"
+	postHookType = "post"
+
+	hookContainerName = "hook"
+
+	// defaultHookTimeout is how long a hook may run unless its timeout is
+	// set
+	defaultHookTimeout = 5 * time.Minute
+)
+
+func generateNameForHookJob(instance *ocsv1.ConsistentSnapshot, hookType string) string {
+	return fmt.Sprintf("%s-%s-hook", instance.Name, hookType)
+}
+
+func getHookTimeout(hook *ocsv1.SnapshotHook) time.Duration {
+	if hook.Timeout != nil && hook.Timeout.Duration > 0 {
+		return hook.Timeout.Duration
+	}
+	return defaultHookTimeout
+}
+
+// newHookJob returns the Job running a hook of the ConsistentSnapshot. The
+// Job runs in the namespace of the ConsistentSnapshot only, whose hooks can
+// only be set by the users allowed to create Jobs there, see
+// ConsistentSnapshotValidator. It is not retried, and is failed once the
+// timeout of the hook expires.
+func newHookJob(instance *ocsv1.ConsistentSnapshot, hook *ocsv1.SnapshotHook, hookType string) *batchv1.Job {
+	var backoffLimit int32
+	deadline := int64(getHookTimeout(hook).Seconds())
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateNameForHookJob(instance, hookType),
+			Namespace: instance.Namespace,
+			Labels: map[string]string{
+				consistentSnapshotLabel: instance.Name,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &deadline,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						consistentSnapshotLabel: instance.Name,
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            hookContainerName,
+							Image:           hook.Image,
+							Command:         hook.Command,
+							ImagePullPolicy: corev1.PullIfNotPresent,
+						},
+					},
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: hook.ServiceAccountName,
+				},
+			},
+		},
+	}
+}
+
+// getJobResult reports whether the Job finished, and whether it succeeded
+func getJobResult(job *batchv1.Job) (finished bool, succeeded bool) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, true
+		case batchv1.JobFailed:
+			return true, false
+		}
+	}
+	if job.Status.Succeeded > 0 {
+		return true, true
+	}
+	if job.Status.Failed > 0 {
+		return true, false
+	}
+	return false, false
+}
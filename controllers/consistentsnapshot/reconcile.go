@@ -0,0 +1,441 @@
+package consistentsnapshot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/util"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// consistentSnapshotLabel is set to the name of the ConsistentSnapshot
+	// on its VolumeSnapshots and hook Jobs
+	consistentSnapshotLabel = "ocs.openshift.io/consistent-snapshot"
+
+	reasonInvalidRequest = "InvalidRequest"
+	reasonQuiescing      = "Quiescing"
+	reasonSnapshotting   = "Snapshotting"
+	reasonResuming       = "Resuming"
+	reasonWaitingReady   = "WaitingForSnapshotsReady"
+	reasonSnapshotsReady = "SnapshotsReady"
+	reasonHookFailed     = "HookFailed"
+	reasonSnapshotFailed = "SnapshotFailed"
+)
+
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots;volumesnapshotclasses;volumesnapshotcontents,verbs=*
+
+// Reconcile takes the VolumeSnapshots of the PVCs selected by a
+// ConsistentSnapshot. The VolumeSnapshots are all created at once, between
+// the Jobs of the pre hook quiescing the application and of the post hook
+// resuming it.
+func (r *ConsistentSnapshotReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+
+	prevLogger := r.Log
+	defer func() { r.Log = prevLogger }()
+	r.Log = r.Log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+
+	instance := &ocsv1.ConsistentSnapshot{}
+	if err := r.Client.Get(ctx, request.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			r.Log.Info("No ConsistentSnapshot resource.", "ConsistentSnapshot", klog.KRef(request.Namespace, request.Name))
+			return reconcile.Result{}, nil
+		}
+		r.Log.Error(err, "Failed to retrieve ConsistentSnapshot.", "ConsistentSnapshot", klog.KRef(request.Namespace, request.Name))
+		return reconcile.Result{}, err
+	}
+
+	if instance.Status.Phase == ocsv1.ConsistentSnapshotPhaseReady ||
+		instance.Status.Phase == ocsv1.ConsistentSnapshotPhaseFailed ||
+		!instance.GetDeletionTimestamp().IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	var result reconcile.Result
+	var err error
+	switch instance.Status.Phase {
+	case ocsv1.ConsistentSnapshotPhaseQuiescing:
+		result, err = r.reconcileQuiescing(instance)
+	case ocsv1.ConsistentSnapshotPhaseSnapshotting:
+		result, err = r.reconcileSnapshotting(instance)
+	case ocsv1.ConsistentSnapshotPhaseResuming:
+		result, err = r.reconcileResuming(instance)
+	case ocsv1.ConsistentSnapshotPhaseWaitingForReady:
+		result, err = r.reconcileWaitingForReady(instance)
+	default:
+		result, err = r.reconcilePending(instance)
+	}
+
+	statusError := r.Client.Status().Update(ctx, instance)
+	if statusError != nil {
+		r.Log.Info("Could not update ConsistentSnapshot status.", "ConsistentSnapshot", klog.KRef(instance.Namespace, instance.Name))
+	}
+
+	if err != nil {
+		return result, err
+	}
+	return result, statusError
+}
+
+// reconcilePending resolves the PVCs of the group and their
+// VolumeSnapshotClasses, then quiesces the application if there is a pre
+// hook or takes the snapshots right away
+func (r *ConsistentSnapshotReconciler) reconcilePending(instance *ocsv1.ConsistentSnapshot) (reconcile.Result, error) {
+	if err := validateConsistentSnapshot(instance); err != nil {
+		r.Log.Error(err, "Invalid ConsistentSnapshot.", "ConsistentSnapshot", klog.KRef(instance.Namespace, instance.Name))
+		r.setFailed(instance, reasonInvalidRequest, err.Error())
+		return reconcile.Result{}, nil
+	}
+
+	volumes, message, err := r.getVolumes(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if message != "" {
+		r.setFailed(instance, reasonInvalidRequest, message)
+		return reconcile.Result{}, nil
+	}
+	instance.Status.Volumes = volumes
+
+	if instance.Spec.PreHook == nil {
+		return r.takeSnapshots(instance)
+	}
+	if err := r.startHook(instance, instance.Spec.PreHook, preHookType); err != nil {
+		return reconcile.Result{}, err
+	}
+	instance.Status.Phase = ocsv1.ConsistentSnapshotPhaseQuiescing
+	setProgressing(&instance.Status.Conditions, corev1.ConditionTrue, reasonQuiescing, "running the pre hook")
+	return reconcile.Result{}, nil
+}
+
+// reconcileQuiescing takes the snapshots once the pre hook succeeded. The
+// application is resumed right away if the pre hook failed.
+func (r *ConsistentSnapshotReconciler) reconcileQuiescing(instance *ocsv1.ConsistentSnapshot) (reconcile.Result, error) {
+	finished, succeeded, err := r.getHookResult(instance)
+	if err != nil || !finished {
+		return reconcile.Result{}, err
+	}
+	if !succeeded {
+		r.markFailed(instance, reasonHookFailed, fmt.Sprintf("the pre hook Job %s failed", instance.Status.HookJobName))
+		return r.resume(instance)
+	}
+	return r.takeSnapshots(instance)
+}
+
+// takeSnapshots creates the VolumeSnapshots of all the PVCs of the group,
+// one right after the other
+func (r *ConsistentSnapshotReconciler) takeSnapshots(instance *ocsv1.ConsistentSnapshot) (reconcile.Result, error) {
+	if instance.Status.SnapshotTime == nil {
+		now := metav1.Now()
+		instance.Status.SnapshotTime = &now
+	}
+	for i := range instance.Status.Volumes {
+		volumeSnapshot := newVolumeSnapshot(instance, &instance.Status.Volumes[i])
+		if err := controllerutil.SetControllerReference(instance, volumeSnapshot, r.Scheme); err != nil {
+			return reconcile.Result{}, err
+		}
+		err := r.Client.Create(context.TODO(), volumeSnapshot)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			r.Log.Error(err, "Failed to create VolumeSnapshot.", "VolumeSnapshot", klog.KRef(volumeSnapshot.Namespace, volumeSnapshot.Name))
+			return reconcile.Result{}, err
+		}
+	}
+	r.Log.Info("Created VolumeSnapshots.", "ConsistentSnapshot", klog.KRef(instance.Namespace, instance.Name), "Count", len(instance.Status.Volumes))
+
+	instance.Status.Phase = ocsv1.ConsistentSnapshotPhaseSnapshotting
+	setProgressing(&instance.Status.Conditions, corev1.ConditionTrue, reasonSnapshotting, "taking the snapshots")
+	return reconcile.Result{}, nil
+}
+
+// reconcileSnapshotting resumes the application once the storage took all
+// the snapshots, or one of them failed
+func (r *ConsistentSnapshotReconciler) reconcileSnapshotting(instance *ocsv1.ConsistentSnapshot) (reconcile.Result, error) {
+	taken, _, failure, err := r.updateVolumes(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if failure != "" {
+		r.markFailed(instance, reasonSnapshotFailed, failure)
+		return r.resume(instance)
+	}
+	if !taken {
+		// The VolumeSnapshots are owned by the ConsistentSnapshot, which is
+		// reconciled again once their status changes
+		return reconcile.Result{}, nil
+	}
+	return r.resume(instance)
+}
+
+// resume runs the post hook, if any
+func (r *ConsistentSnapshotReconciler) resume(instance *ocsv1.ConsistentSnapshot) (reconcile.Result, error) {
+	if instance.Spec.PostHook == nil {
+		return r.reconcileResumed(instance)
+	}
+	if err := r.startHook(instance, instance.Spec.PostHook, postHookType); err != nil {
+		return reconcile.Result{}, err
+	}
+	instance.Status.Phase = ocsv1.ConsistentSnapshotPhaseResuming
+	setProgressing(&instance.Status.Conditions, corev1.ConditionTrue, reasonResuming, "running the post hook")
+	return reconcile.Result{}, nil
+}
+
+func (r *ConsistentSnapshotReconciler) reconcileResuming(instance *ocsv1.ConsistentSnapshot) (reconcile.Result, error) {
+	finished, succeeded, err := r.getHookResult(instance)
+	if err != nil || !finished {
+		return reconcile.Result{}, err
+	}
+	if !succeeded {
+		r.markFailed(instance, reasonHookFailed, fmt.Sprintf("the post hook Job %s failed", instance.Status.HookJobName))
+	}
+	return r.reconcileResumed(instance)
+}
+
+// reconcileResumed fails the ConsistentSnapshot if anything failed so far,
+// or waits for the snapshots to be ready
+func (r *ConsistentSnapshotReconciler) reconcileResumed(instance *ocsv1.ConsistentSnapshot) (reconcile.Result, error) {
+	if instance.Status.Failed {
+		reason, message := reasonSnapshotFailed, "failed to take the snapshots"
+		if degraded := conditionsv1.FindStatusCondition(instance.Status.Conditions, conditionsv1.ConditionDegraded); degraded != nil {
+			reason, message = degraded.Reason, degraded.Message
+		}
+		r.setFailed(instance, reason, message)
+		return reconcile.Result{}, nil
+	}
+	instance.Status.Phase = ocsv1.ConsistentSnapshotPhaseWaitingForReady
+	setProgressing(&instance.Status.Conditions, corev1.ConditionTrue, reasonWaitingReady, "waiting for the snapshots to be ready")
+	return r.reconcileWaitingForReady(instance)
+}
+
+func (r *ConsistentSnapshotReconciler) reconcileWaitingForReady(instance *ocsv1.ConsistentSnapshot) (reconcile.Result, error) {
+	_, ready, failure, err := r.updateVolumes(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if failure != "" {
+		r.setFailed(instance, reasonSnapshotFailed, failure)
+		return reconcile.Result{}, nil
+	}
+	if !ready {
+		return reconcile.Result{}, nil
+	}
+
+	now := metav1.Now()
+	instance.Status.CompletionTime = &now
+	instance.Status.Phase = ocsv1.ConsistentSnapshotPhaseReady
+	util.SetCompleteCondition(&instance.Status.Conditions, reasonSnapshotsReady,
+		fmt.Sprintf("the snapshots of %d PVCs are ready", len(instance.Status.Volumes)))
+	return reconcile.Result{}, nil
+}
+
+// getVolumes returns the PVCs of the group and the VolumeSnapshotClasses
+// they are snapshotted with. The returned message explains why the PVCs can
+// not be snapshotted.
+func (r *ConsistentSnapshotReconciler) getVolumes(instance *ocsv1.ConsistentSnapshot) ([]ocsv1.ConsistentSnapshotVolume, string, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&instance.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Sprintf("invalid selector: %v", err), nil
+	}
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	err = r.Client.List(context.TODO(), pvcList, client.InNamespace(instance.Namespace), client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list PVCs: %v", err)
+	}
+	if len(pvcList.Items) == 0 {
+		return nil, "no PVC matches the selector", nil
+	}
+	sort.Slice(pvcList.Items, func(i, j int) bool { return pvcList.Items[i].Name < pvcList.Items[j].Name })
+
+	classes, err := util.ListVolumeSnapshotClasses(r.Client)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var volumes []ocsv1.ConsistentSnapshotVolume
+	for _, pvc := range pvcList.Items {
+		if pvc.Status.Phase != corev1.ClaimBound {
+			return nil, fmt.Sprintf("PVC %s is not bound", pvc.Name), nil
+		}
+		if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+			return nil, fmt.Sprintf("PVC %s has no StorageClass", pvc.Name), nil
+		}
+		className, message, err := util.GetVolumeSnapshotClassName(r.Client, instance.Spec.VolumeSnapshotClassName, &pvc, classes)
+		if err != nil || message != "" {
+			return nil, message, err
+		}
+		volumes = append(volumes, ocsv1.ConsistentSnapshotVolume{
+			PVCName:                 pvc.Name,
+			VolumeSnapshotName:      generateNameForVolumeSnapshot(instance, pvc.Name),
+			VolumeSnapshotClassName: className,
+			StorageClassName:        *pvc.Spec.StorageClassName,
+			AccessModes:             pvc.Spec.AccessModes,
+			VolumeMode:              pvc.Spec.VolumeMode,
+			Size:                    pvc.Spec.Resources.Requests[corev1.ResourceStorage],
+		})
+	}
+	return volumes, "", nil
+}
+
+// updateVolumes copies the status of the VolumeSnapshots into the status of
+// the ConsistentSnapshot. It reports whether all the snapshots were taken
+// and are ready, or why one of them failed.
+func (r *ConsistentSnapshotReconciler) updateVolumes(instance *ocsv1.ConsistentSnapshot) (taken bool, ready bool, failure string, err error) {
+	taken, ready = true, true
+	for i := range instance.Status.Volumes {
+		volume := &instance.Status.Volumes[i]
+		volumeSnapshot := &snapapi.VolumeSnapshot{}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{Name: volume.VolumeSnapshotName, Namespace: instance.Namespace}, volumeSnapshot)
+		if errors.IsNotFound(err) {
+			return false, false, fmt.Sprintf("VolumeSnapshot %s was deleted", volume.VolumeSnapshotName), nil
+		} else if err != nil {
+			return false, false, "", fmt.Errorf("failed to get VolumeSnapshot %s: %v", volume.VolumeSnapshotName, err)
+		}
+
+		status := volumeSnapshot.Status
+		if status == nil {
+			taken, ready = false, false
+			continue
+		}
+		volume.CreationTime = status.CreationTime
+		volume.ReadyToUse = status.ReadyToUse != nil && *status.ReadyToUse
+		volume.RestoreSize = status.RestoreSize
+		if status.Error != nil && status.Error.Message != nil {
+			volume.Error = *status.Error.Message
+			failure = fmt.Sprintf("VolumeSnapshot %s failed: %s", volume.VolumeSnapshotName, volume.Error)
+		}
+		if volume.CreationTime == nil {
+			taken = false
+		}
+		if !volume.ReadyToUse {
+			ready = false
+		}
+	}
+	return taken, ready, failure, nil
+}
+
+// startHook launches the Job of a hook
+func (r *ConsistentSnapshotReconciler) startHook(instance *ocsv1.ConsistentSnapshot, hook *ocsv1.SnapshotHook, hookType string) error {
+	job := newHookJob(instance, hook, hookType)
+	if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
+		return err
+	}
+	r.Log.Info("Creating hook Job.", "Job", klog.KRef(job.Namespace, job.Name))
+	if err := r.Client.Create(context.TODO(), job); err != nil && !errors.IsAlreadyExists(err) {
+		r.Log.Error(err, "Failed to create hook Job.", "Job", klog.KRef(job.Namespace, job.Name))
+		return err
+	}
+	instance.Status.HookJobName = job.Name
+	return nil
+}
+
+// getHookResult reports whether the Job of the running hook finished, and
+// whether it succeeded
+func (r *ConsistentSnapshotReconciler) getHookResult(instance *ocsv1.ConsistentSnapshot) (bool, bool, error) {
+	job := &batchv1.Job{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: instance.Status.HookJobName, Namespace: instance.Namespace}, job)
+	if errors.IsNotFound(err) {
+		return true, false, nil
+	} else if err != nil {
+		return false, false, fmt.Errorf("failed to get hook Job %s: %v", instance.Status.HookJobName, err)
+	}
+	finished, succeeded := getJobResult(job)
+	return finished, succeeded, nil
+}
+
+// markFailed records a failure, which fails the ConsistentSnapshot once the
+// application is resumed
+func (r *ConsistentSnapshotReconciler) markFailed(instance *ocsv1.ConsistentSnapshot, reason, message string) {
+	r.Log.Info("ConsistentSnapshot failed, resuming the application.", "ConsistentSnapshot", klog.KRef(instance.Namespace, instance.Name), "Reason", message)
+	instance.Status.Failed = true
+	conditionsv1.SetStatusCondition(&instance.Status.Conditions, conditionsv1.Condition{
+		Type:    conditionsv1.ConditionDegraded,
+		Status:  corev1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+func (r *ConsistentSnapshotReconciler) setFailed(instance *ocsv1.ConsistentSnapshot, reason, message string) {
+	now := metav1.Now()
+	instance.Status.Phase = ocsv1.ConsistentSnapshotPhaseFailed
+	instance.Status.Failed = true
+	instance.Status.CompletionTime = &now
+	util.SetErrorCondition(&instance.Status.Conditions, reason, message)
+	setProgressing(&instance.Status.Conditions, corev1.ConditionFalse, reason, message)
+	conditionsv1.SetStatusCondition(&instance.Status.Conditions, conditionsv1.Condition{
+		Type:    conditionsv1.ConditionDegraded,
+		Status:  corev1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	})
+	r.recorder.ReportIfNotPresent(instance, corev1.EventTypeWarning, util.EventReasonConsistentSnapshotFailed, message)
+}
+
+func validateConsistentSnapshot(instance *ocsv1.ConsistentSnapshot) error {
+	if len(instance.Spec.Selector.MatchLabels) == 0 && len(instance.Spec.Selector.MatchExpressions) == 0 {
+		return fmt.Errorf("the selector must not be empty")
+	}
+	if err := validateHook(instance.Spec.PreHook, preHookType); err != nil {
+		return err
+	}
+	return validateHook(instance.Spec.PostHook, postHookType)
+}
+
+func validateHook(hook *ocsv1.SnapshotHook, hookType string) error {
+	if hook == nil {
+		return nil
+	}
+	if hook.Image == "" || len(hook.Command) == 0 {
+		return fmt.Errorf("the %s hook requires an image and a command", hookType)
+	}
+	if hook.Timeout != nil && hook.Timeout.Duration < 0 {
+		return fmt.Errorf("the timeout of the %s hook must not be negative", hookType)
+	}
+	return nil
+}
+
+// newVolumeSnapshot returns the VolumeSnapshot of a PVC of the group
+func newVolumeSnapshot(instance *ocsv1.ConsistentSnapshot, volume *ocsv1.ConsistentSnapshotVolume) *snapapi.VolumeSnapshot {
+	pvcName := volume.PVCName
+	className := volume.VolumeSnapshotClassName
+	return &snapapi.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      volume.VolumeSnapshotName,
+			Namespace: instance.Namespace,
+			Labels: map[string]string{
+				consistentSnapshotLabel: instance.Name,
+			},
+		},
+		Spec: snapapi.VolumeSnapshotSpec{
+			Source: snapapi.VolumeSnapshotSource{
+				PersistentVolumeClaimName: &pvcName,
+			},
+			VolumeSnapshotClassName: &className,
+		},
+	}
+}
+
+func generateNameForVolumeSnapshot(instance *ocsv1.ConsistentSnapshot, pvcName string) string {
+	return fmt.Sprintf("%s-%s", instance.Name, pvcName)
+}
+
+func setProgressing(conditions *[]conditionsv1.Condition, status corev1.ConditionStatus, reason, message string) {
+	conditionsv1.SetStatusCondition(conditions, conditionsv1.Condition{
+		Type:    conditionsv1.ConditionProgressing,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
@@ -0,0 +1,394 @@
+package consistentsnapshot
+
+import (
+	"context"
+	"testing"
+
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/util"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	testNamespace      = "my-app"
+	testStorageClass   = "ocs-storagecluster-ceph-rbd"
+	testSnapshotClass  = "ocs-storagecluster-rbdplugin-snapclass"
+	testRBDProvisioner = "openshift-storage.rbd.csi.ceph.com"
+)
+
+func TestValidateConsistentSnapshot(t *testing.T) {
+	selector := metav1.LabelSelector{MatchLabels: map[string]string{"app": "my-app"}}
+	cases := []struct {
+		label     string
+		spec      ocsv1.ConsistentSnapshotSpec
+		expectErr bool
+	}{
+		{
+			label: "valid spec",
+			spec: ocsv1.ConsistentSnapshotSpec{
+				Selector: selector,
+				PreHook:  &ocsv1.SnapshotHook{Image: "busybox", Command: []string{"sync"}},
+			},
+		},
+		{
+			label:     "empty selector",
+			spec:      ocsv1.ConsistentSnapshotSpec{},
+			expectErr: true,
+		},
+		{
+			label: "hook without command",
+			spec: ocsv1.ConsistentSnapshotSpec{
+				Selector: selector,
+				PostHook: &ocsv1.SnapshotHook{Image: "busybox"},
+			},
+			expectErr: true,
+		},
+		{
+			label: "negative hook timeout",
+			spec: ocsv1.ConsistentSnapshotSpec{
+				Selector: selector,
+				PreHook:  &ocsv1.SnapshotHook{Image: "busybox", Command: []string{"sync"}, Timeout: &metav1.Duration{Duration: -1}},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		err := validateConsistentSnapshot(&ocsv1.ConsistentSnapshot{Spec: c.spec})
+		if c.expectErr {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestGetVolumes(t *testing.T) {
+	cases := []struct {
+		label           string
+		selector        map[string]string
+		className       string
+		pvcs            []runtime.Object
+		expectedClasses map[string]string
+		expectMessage   bool
+	}{
+		{
+			label:           "default snapshot class of the driver and cluster",
+			selector:        map[string]string{"app": "my-app"},
+			pvcs:            []runtime.Object{newTestPVC("db", testStorageClass, true), newUnselectedTestPVC("other")},
+			expectedClasses: map[string]string{"db": testSnapshotClass},
+		},
+		{
+			label:           "snapshot class of the spec",
+			selector:        map[string]string{"app": "my-app"},
+			className:       "a-other-cluster-snapclass",
+			pvcs:            []runtime.Object{newTestPVC("db", testStorageClass, true)},
+			expectedClasses: map[string]string{"db": "a-other-cluster-snapclass"},
+		},
+		{
+			label:         "no PVC selected",
+			selector:      map[string]string{"app": "none"},
+			pvcs:          []runtime.Object{newTestPVC("db", testStorageClass, true)},
+			expectMessage: true,
+		},
+		{
+			label:         "unbound PVC",
+			selector:      map[string]string{"app": "my-app"},
+			pvcs:          []runtime.Object{newTestPVC("db", testStorageClass, false)},
+			expectMessage: true,
+		},
+		{
+			label:         "PVC not provisioned by OCS",
+			selector:      map[string]string{"app": "my-app"},
+			pvcs:          []runtime.Object{newTestPVC("db", "gp2", true)},
+			expectMessage: true,
+		},
+		{
+			label:         "unknown snapshot class",
+			selector:      map[string]string{"app": "my-app"},
+			className:     "missing",
+			pvcs:          []runtime.Object{newTestPVC("db", testStorageClass, true)},
+			expectMessage: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		instance := &ocsv1.ConsistentSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: testNamespace},
+			Spec: ocsv1.ConsistentSnapshotSpec{
+				Selector:                metav1.LabelSelector{MatchLabels: c.selector},
+				VolumeSnapshotClassName: c.className,
+			},
+		}
+		objs := append(newTestStorageObjects(), c.pvcs...)
+		reconciler := createFakeConsistentSnapshotReconciler(t, objs...)
+		volumes, message, err := reconciler.getVolumes(instance)
+		assert.NoError(t, err)
+		if c.expectMessage {
+			assert.NotEmpty(t, message)
+			continue
+		}
+		assert.Empty(t, message)
+		assert.Len(t, volumes, len(c.expectedClasses))
+		for _, volume := range volumes {
+			assert.Equal(t, c.expectedClasses[volume.PVCName], volume.VolumeSnapshotClassName)
+			assert.Equal(t, "backup-"+volume.PVCName, volume.VolumeSnapshotName)
+			assert.Equal(t, testStorageClass, volume.StorageClassName)
+			assert.Equal(t, resource.MustParse("10Gi"), volume.Size)
+		}
+	}
+}
+
+func TestReconcileConsistentSnapshot(t *testing.T) {
+	instance := newTestConsistentSnapshot()
+	objs := append(newTestStorageObjects(), instance,
+		newTestPVC("db", testStorageClass, true), newTestPVC("logs", testStorageClass, true))
+	reconciler := createFakeConsistentSnapshotReconciler(t, objs...)
+	key := types.NamespacedName{Name: instance.Name, Namespace: testNamespace}
+
+	// The pre hook runs before any snapshot is taken
+	reconcileConsistentSnapshot(t, reconciler, key, instance)
+	assert.Equal(t, ocsv1.ConsistentSnapshotPhaseQuiescing, instance.Status.Phase)
+	assert.Len(t, instance.Status.Volumes, 2)
+	assert.Equal(t, "backup-pre-hook", instance.Status.HookJobName)
+	assert.Empty(t, listVolumeSnapshots(t, reconciler))
+	completeJob(t, reconciler, instance.Status.HookJobName, true)
+
+	// All the snapshots are taken at once
+	reconcileConsistentSnapshot(t, reconciler, key, instance)
+	assert.Equal(t, ocsv1.ConsistentSnapshotPhaseSnapshotting, instance.Status.Phase)
+	assert.NotNil(t, instance.Status.SnapshotTime)
+	volumeSnapshots := listVolumeSnapshots(t, reconciler)
+	assert.Len(t, volumeSnapshots, 2)
+	for _, volumeSnapshot := range volumeSnapshots {
+		assert.Equal(t, testSnapshotClass, *volumeSnapshot.Spec.VolumeSnapshotClassName)
+		assert.Len(t, volumeSnapshot.OwnerReferences, 1)
+	}
+
+	// The application is resumed once the storage took the snapshots
+	now := metav1.Now()
+	updateVolumeSnapshots(t, reconciler, &snapapi.VolumeSnapshotStatus{CreationTime: &now})
+	reconcileConsistentSnapshot(t, reconciler, key, instance)
+	assert.Equal(t, ocsv1.ConsistentSnapshotPhaseResuming, instance.Status.Phase)
+	assert.Equal(t, "backup-post-hook", instance.Status.HookJobName)
+	completeJob(t, reconciler, instance.Status.HookJobName, true)
+
+	reconcileConsistentSnapshot(t, reconciler, key, instance)
+	assert.Equal(t, ocsv1.ConsistentSnapshotPhaseWaitingForReady, instance.Status.Phase)
+
+	ready := true
+	restoreSize := resource.MustParse("10Gi")
+	updateVolumeSnapshots(t, reconciler, &snapapi.VolumeSnapshotStatus{CreationTime: &now, ReadyToUse: &ready, RestoreSize: &restoreSize})
+	reconcileConsistentSnapshot(t, reconciler, key, instance)
+	assert.Equal(t, ocsv1.ConsistentSnapshotPhaseReady, instance.Status.Phase)
+	assert.NotNil(t, instance.Status.CompletionTime)
+	for _, volume := range instance.Status.Volumes {
+		assert.True(t, volume.ReadyToUse)
+		assert.Equal(t, &restoreSize, volume.RestoreSize)
+	}
+}
+
+func TestReconcileConsistentSnapshotPreHookFailed(t *testing.T) {
+	instance := newTestConsistentSnapshot()
+	objs := append(newTestStorageObjects(), instance, newTestPVC("db", testStorageClass, true))
+	reconciler := createFakeConsistentSnapshotReconciler(t, objs...)
+	key := types.NamespacedName{Name: instance.Name, Namespace: testNamespace}
+
+	reconcileConsistentSnapshot(t, reconciler, key, instance)
+	completeJob(t, reconciler, instance.Status.HookJobName, false)
+
+	// The application is still resumed, without taking the snapshots
+	reconcileConsistentSnapshot(t, reconciler, key, instance)
+	assert.Equal(t, ocsv1.ConsistentSnapshotPhaseResuming, instance.Status.Phase)
+	assert.True(t, instance.Status.Failed)
+	assert.Empty(t, listVolumeSnapshots(t, reconciler))
+	completeJob(t, reconciler, instance.Status.HookJobName, true)
+
+	reconcileConsistentSnapshot(t, reconciler, key, instance)
+	assert.Equal(t, ocsv1.ConsistentSnapshotPhaseFailed, instance.Status.Phase)
+	degraded := conditionsv1.FindStatusCondition(instance.Status.Conditions, conditionsv1.ConditionDegraded)
+	assert.NotNil(t, degraded)
+	assert.Equal(t, reasonHookFailed, degraded.Reason)
+	assert.Equal(t, "the pre hook Job backup-pre-hook failed", degraded.Message)
+}
+
+func TestNewHookJob(t *testing.T) {
+	instance := newTestConsistentSnapshot()
+	job := newHookJob(instance, instance.Spec.PreHook, preHookType)
+	assert.Equal(t, "backup-pre-hook", job.Name)
+	assert.Equal(t, int32(0), *job.Spec.BackoffLimit)
+	assert.Equal(t, int64(300), *job.Spec.ActiveDeadlineSeconds)
+	assert.Equal(t, []string{"/bin/sh", "-c", "fsfreeze -f /data"}, job.Spec.Template.Spec.Containers[0].Command)
+	assert.Equal(t, "my-app-hooks", job.Spec.Template.Spec.ServiceAccountName)
+
+	instance.Spec.PreHook.Timeout = &metav1.Duration{Duration: 30e9}
+	job = newHookJob(instance, instance.Spec.PreHook, preHookType)
+	assert.Equal(t, int64(30), *job.Spec.ActiveDeadlineSeconds)
+}
+
+func newTestConsistentSnapshot() *ocsv1.ConsistentSnapshot {
+	return &ocsv1.ConsistentSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: testNamespace},
+		Spec: ocsv1.ConsistentSnapshotSpec{
+			Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "my-app"}},
+			PreHook: &ocsv1.SnapshotHook{
+				Image:              "registry.access.redhat.com/ubi8/ubi",
+				Command:            []string{"/bin/sh", "-c", "fsfreeze -f /data"},
+				ServiceAccountName: "my-app-hooks",
+			},
+			PostHook: &ocsv1.SnapshotHook{
+				Image:              "registry.access.redhat.com/ubi8/ubi",
+				Command:            []string{"/bin/sh", "-c", "fsfreeze -u /data"},
+				ServiceAccountName: "my-app-hooks",
+			},
+		},
+	}
+}
+
+// newTestStorageObjects returns the OCS RBD StorageClass and
+// VolumeSnapshotClass, and a VolumeSnapshotClass of another cluster with the
+// same driver
+func newTestStorageObjects() []runtime.Object {
+	return []runtime.Object{
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: testStorageClass},
+			Provisioner: testRBDProvisioner,
+			Parameters:  map[string]string{"clusterID": "openshift-storage"},
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "gp2"},
+			Provisioner: "kubernetes.io/aws-ebs",
+		},
+		&snapapi.VolumeSnapshotClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "a-other-cluster-snapclass"},
+			Driver:     testRBDProvisioner,
+			Parameters: map[string]string{"clusterID": "other-cluster"},
+		},
+		&snapapi.VolumeSnapshotClass{
+			ObjectMeta: metav1.ObjectMeta{Name: testSnapshotClass},
+			Driver:     testRBDProvisioner,
+			Parameters: map[string]string{"clusterID": "openshift-storage"},
+		},
+	}
+}
+
+func newTestPVC(name, storageClassName string, bound bool) *corev1.PersistentVolumeClaim {
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels:    map[string]string{"app": "my-app"},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: &storageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+			},
+		},
+	}
+	if bound {
+		pvc.Status.Phase = corev1.ClaimBound
+	}
+	return pvc
+}
+
+func newUnselectedTestPVC(name string) *corev1.PersistentVolumeClaim {
+	pvc := newTestPVC(name, testStorageClass, true)
+	pvc.Labels["app"] = "other"
+	return pvc
+}
+
+func reconcileConsistentSnapshot(t *testing.T, reconciler ConsistentSnapshotReconciler, key types.NamespacedName, instance *ocsv1.ConsistentSnapshot) {
+	_, err := reconciler.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), key, instance))
+}
+
+// completeJob simulates the completion of a hook Job
+func completeJob(t *testing.T, reconciler ConsistentSnapshotReconciler, name string, succeeded bool) {
+	job := &batchv1.Job{}
+	err := reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: testNamespace}, job)
+	assert.NoError(t, err)
+	if succeeded {
+		job.Status.Succeeded = 1
+	} else {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "DeadlineExceeded"}}
+	}
+	assert.NoError(t, reconciler.Client.Status().Update(context.TODO(), job))
+}
+
+func listVolumeSnapshots(t *testing.T, reconciler ConsistentSnapshotReconciler) []snapapi.VolumeSnapshot {
+	volumeSnapshotList := &snapapi.VolumeSnapshotList{}
+	err := reconciler.Client.List(context.TODO(), volumeSnapshotList, client.InNamespace(testNamespace), client.MatchingLabels{consistentSnapshotLabel: "backup"})
+	assert.NoError(t, err)
+	return volumeSnapshotList.Items
+}
+
+func updateVolumeSnapshots(t *testing.T, reconciler ConsistentSnapshotReconciler, status *snapapi.VolumeSnapshotStatus) {
+	for _, volumeSnapshot := range listVolumeSnapshots(t, reconciler) {
+		volumeSnapshot.Status = status.DeepCopy()
+		assert.NoError(t, reconciler.Client.Status().Update(context.TODO(), &volumeSnapshot))
+	}
+}
+
+func createFakeScheme(t *testing.T) *runtime.Scheme {
+	scheme, err := ocsv1.SchemeBuilder.Build()
+	if err != nil {
+		assert.Fail(t, "unable to build scheme")
+	}
+	err = corev1.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add corev1 scheme")
+	}
+	err = batchv1.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add batchv1 scheme")
+	}
+	err = storagev1.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add storagev1 scheme")
+	}
+	err = snapapi.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add snapshot scheme")
+	}
+	return scheme
+}
+
+func createFakeConsistentSnapshotReconciler(t *testing.T, obj ...runtime.Object) ConsistentSnapshotReconciler {
+	scheme := createFakeScheme(t)
+	client := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(obj...).Build()
+
+	return ConsistentSnapshotReconciler{
+		Client:   client,
+		Scheme:   scheme,
+		Log:      logf.Log.WithName("controller_consistentsnapshot_test"),
+		recorder: util.NewEventReporter(&record.FakeRecorder{}),
+	}
+}
+
+func isNotFound(t *testing.T, c client.Client, key types.NamespacedName, obj client.Object) bool {
+	err := c.Get(context.TODO(), key, obj)
+	if err != nil && !errors.IsNotFound(err) {
+		assert.NoError(t, err)
+	}
+	return errors.IsNotFound(err)
+}
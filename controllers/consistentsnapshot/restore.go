@@ -0,0 +1,383 @@
+package consistentsnapshot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/util"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// The restored objects must outlive the ConsistentSnapshotRestore, and
+	// may live in another namespace, so it can not own them. They are
+	// labeled with its name and namespace instead.
+	restoreLabel          = "ocs.openshift.io/consistent-snapshot-restore"
+	restoreNamespaceLabel = "ocs.openshift.io/consistent-snapshot-restore-namespace"
+
+	// restoreRequeueInterval is how often a restore checks whether its
+	// ConsistentSnapshot is ready and its PVCs are bound
+	restoreRequeueInterval = 10 * time.Second
+
+	reasonRestoring          = "Restoring"
+	reasonWaitingForSnapshot = "WaitingForSnapshot"
+	reasonRestored           = "Restored"
+	reasonRestoreFailed      = "RestoreFailed"
+)
+
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
+
+// Reconcile restores the PVCs of a ready ConsistentSnapshot from its
+// VolumeSnapshots. In the namespace of the ConsistentSnapshotRestore, the
+// PVCs are restored under new names, next to the snapshotted ones. In
+// another namespace, which the ConsistentSnapshotRestoreValidator only
+// allows to the users who may create PVCs there, they keep their names and
+// each snapshot is bound to a new VolumeSnapshot of that namespace through
+// a pre-provisioned VolumeSnapshotContent.
+func (r *ConsistentSnapshotRestoreReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+
+	prevLogger := r.Log
+	defer func() { r.Log = prevLogger }()
+	r.Log = r.Log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+
+	instance := &ocsv1.ConsistentSnapshotRestore{}
+	if err := r.Client.Get(ctx, request.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			r.Log.Info("No ConsistentSnapshotRestore resource.", "ConsistentSnapshotRestore", klog.KRef(request.Namespace, request.Name))
+			return reconcile.Result{}, nil
+		}
+		r.Log.Error(err, "Failed to retrieve ConsistentSnapshotRestore.", "ConsistentSnapshotRestore", klog.KRef(request.Namespace, request.Name))
+		return reconcile.Result{}, err
+	}
+
+	if instance.Status.Phase == ocsv1.ConsistentSnapshotRestorePhaseCompleted ||
+		instance.Status.Phase == ocsv1.ConsistentSnapshotRestorePhaseFailed ||
+		!instance.GetDeletionTimestamp().IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	result, err := r.reconcileRestore(instance)
+
+	statusError := r.Client.Status().Update(ctx, instance)
+	if statusError != nil {
+		r.Log.Info("Could not update ConsistentSnapshotRestore status.", "ConsistentSnapshotRestore", klog.KRef(instance.Namespace, instance.Name))
+	}
+
+	if err != nil {
+		return result, err
+	}
+	return result, statusError
+}
+
+func (r *ConsistentSnapshotRestoreReconciler) reconcileRestore(instance *ocsv1.ConsistentSnapshotRestore) (reconcile.Result, error) {
+	if err := validateConsistentSnapshotRestore(instance); err != nil {
+		r.Log.Error(err, "Invalid ConsistentSnapshotRestore.", "ConsistentSnapshotRestore", klog.KRef(instance.Namespace, instance.Name))
+		r.setFailed(instance, reasonInvalidRequest, err.Error())
+		return reconcile.Result{}, nil
+	}
+	instance.Status.Phase = ocsv1.ConsistentSnapshotRestorePhaseRestoring
+
+	snapshot := &ocsv1.ConsistentSnapshot{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: instance.Spec.ConsistentSnapshotName, Namespace: instance.Namespace}, snapshot)
+	if errors.IsNotFound(err) {
+		r.setFailed(instance, reasonInvalidRequest, fmt.Sprintf("ConsistentSnapshot %s not found", instance.Spec.ConsistentSnapshotName))
+		return reconcile.Result{}, nil
+	} else if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to get ConsistentSnapshot %s: %v", instance.Spec.ConsistentSnapshotName, err)
+	}
+	switch snapshot.Status.Phase {
+	case ocsv1.ConsistentSnapshotPhaseFailed:
+		r.setFailed(instance, reasonInvalidRequest, fmt.Sprintf("ConsistentSnapshot %s failed", snapshot.Name))
+		return reconcile.Result{}, nil
+	case ocsv1.ConsistentSnapshotPhaseReady:
+	default:
+		setProgressing(&instance.Status.Conditions, corev1.ConditionFalse, reasonWaitingForSnapshot,
+			fmt.Sprintf("waiting for ConsistentSnapshot %s to be ready", snapshot.Name))
+		return reconcile.Result{RequeueAfter: restoreRequeueInterval}, nil
+	}
+
+	targetNamespace := getTargetNamespace(instance)
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: targetNamespace}, &corev1.Namespace{})
+	if errors.IsNotFound(err) {
+		setProgressing(&instance.Status.Conditions, corev1.ConditionFalse, reasonWaitingForSnapshot,
+			fmt.Sprintf("waiting for namespace %s to be created", targetNamespace))
+		return reconcile.Result{RequeueAfter: restoreRequeueInterval}, nil
+	} else if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to get namespace %s: %v", targetNamespace, err)
+	}
+
+	var volumes []ocsv1.RestoredVolume
+	restored := true
+	for i := range snapshot.Status.Volumes {
+		volume, done, message, err := r.restoreVolume(instance, snapshot, &snapshot.Status.Volumes[i])
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if message != "" {
+			r.setFailed(instance, reasonRestoreFailed, message)
+			return reconcile.Result{}, nil
+		}
+		volumes = append(volumes, volume)
+		restored = restored && done
+	}
+	instance.Status.Volumes = volumes
+
+	if !restored {
+		setProgressing(&instance.Status.Conditions, corev1.ConditionTrue, reasonRestoring, "restoring the PVCs")
+		return reconcile.Result{RequeueAfter: restoreRequeueInterval}, nil
+	}
+	now := metav1.Now()
+	instance.Status.CompletionTime = &now
+	instance.Status.Phase = ocsv1.ConsistentSnapshotRestorePhaseCompleted
+	util.SetCompleteCondition(&instance.Status.Conditions, reasonRestored,
+		fmt.Sprintf("restored %d PVCs in namespace %s", len(volumes), targetNamespace))
+	return reconcile.Result{}, nil
+}
+
+// restoreVolume creates the PVC restoring a snapshot of the group, and in
+// another namespace the VolumeSnapshotContent and VolumeSnapshot it is
+// restored from. It reports whether the PVC is provisioned, or why it can
+// not be restored.
+func (r *ConsistentSnapshotRestoreReconciler) restoreVolume(instance *ocsv1.ConsistentSnapshotRestore, snapshot *ocsv1.ConsistentSnapshot,
+	volume *ocsv1.ConsistentSnapshotVolume) (ocsv1.RestoredVolume, bool, string, error) {
+	targetNamespace := getTargetNamespace(instance)
+	restored := ocsv1.RestoredVolume{
+		PVCName:            generateNameForRestoredPVC(instance, volume),
+		VolumeSnapshotName: volume.VolumeSnapshotName,
+	}
+	if isCrossNamespaceRestore(instance) {
+		restored.VolumeSnapshotContentName = generateNameForRestoredContent(instance, volume)
+	}
+
+	sourceSnapshot := &snapapi.VolumeSnapshot{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: volume.VolumeSnapshotName, Namespace: snapshot.Namespace}, sourceSnapshot)
+	if errors.IsNotFound(err) {
+		return restored, false, fmt.Sprintf("VolumeSnapshot %s not found", volume.VolumeSnapshotName), nil
+	} else if err != nil {
+		return restored, false, "", fmt.Errorf("failed to get VolumeSnapshot %s: %v", volume.VolumeSnapshotName, err)
+	}
+	if sourceSnapshot.Status == nil || sourceSnapshot.Status.BoundVolumeSnapshotContentName == nil {
+		return restored, false, fmt.Sprintf("VolumeSnapshot %s is not bound", volume.VolumeSnapshotName), nil
+	}
+
+	// Nothing is created unless the PVC can be restored under its name
+	pvcKey := types.NamespacedName{Name: restored.PVCName, Namespace: targetNamespace}
+	existing := &corev1.PersistentVolumeClaim{}
+	err = r.Client.Get(context.TODO(), pvcKey, existing)
+	if err == nil && !isRestoredBy(existing, instance) {
+		return restored, false, fmt.Sprintf("PVC %s already exists in namespace %s", restored.PVCName, targetNamespace), nil
+	} else if err != nil && !errors.IsNotFound(err) {
+		return restored, false, "", fmt.Errorf("failed to get PVC %s: %v", restored.PVCName, err)
+	}
+
+	if isCrossNamespaceRestore(instance) {
+		sourceContent := &snapapi.VolumeSnapshotContent{}
+		err = r.Client.Get(context.TODO(), types.NamespacedName{Name: *sourceSnapshot.Status.BoundVolumeSnapshotContentName}, sourceContent)
+		if errors.IsNotFound(err) {
+			return restored, false, fmt.Sprintf("VolumeSnapshotContent %s not found", *sourceSnapshot.Status.BoundVolumeSnapshotContentName), nil
+		} else if err != nil {
+			return restored, false, "", fmt.Errorf("failed to get VolumeSnapshotContent %s: %v", *sourceSnapshot.Status.BoundVolumeSnapshotContentName, err)
+		}
+		if sourceContent.Status == nil || sourceContent.Status.SnapshotHandle == nil {
+			return restored, false, fmt.Sprintf("VolumeSnapshotContent %s has no snapshot handle", sourceContent.Name), nil
+		}
+
+		content := newRestoredVolumeSnapshotContent(instance, volume, sourceContent)
+		if message, err := r.createRestoredObject(instance, "VolumeSnapshotContent", content, &snapapi.VolumeSnapshotContent{}); err != nil || message != "" {
+			return restored, false, message, err
+		}
+		volumeSnapshot := newRestoredVolumeSnapshot(instance, volume)
+		if message, err := r.createRestoredObject(instance, "VolumeSnapshot", volumeSnapshot, &snapapi.VolumeSnapshot{}); err != nil || message != "" {
+			return restored, false, message, err
+		}
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	if message, err := r.createRestoredObject(instance, "PersistentVolumeClaim", newRestoredPVC(instance, volume), pvc); err != nil || message != "" {
+		return restored, false, message, err
+	}
+
+	if pvc.Status.Phase == corev1.ClaimBound {
+		restored.Bound = true
+		return restored, true, "", nil
+	}
+	// A PVC of a StorageClass waiting for the first consumer is only bound
+	// once a pod uses it
+	storageClass := &storagev1.StorageClass{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: volume.StorageClassName}, storageClass)
+	if err != nil && !errors.IsNotFound(err) {
+		return restored, false, "", fmt.Errorf("failed to get StorageClass %s: %v", volume.StorageClassName, err)
+	}
+	waitForFirstConsumer := err == nil && storageClass.VolumeBindingMode != nil &&
+		*storageClass.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer
+	return restored, waitForFirstConsumer, "", nil
+}
+
+// createRestoredObject creates the object unless it already exists, and
+// fetches the existing object into found. Objects which were not created by
+// the restore are not reused.
+func (r *ConsistentSnapshotRestoreReconciler) createRestoredObject(instance *ocsv1.ConsistentSnapshotRestore, kind string, obj client.Object, found client.Object) (string, error) {
+	err := r.Client.Get(context.TODO(), client.ObjectKeyFromObject(obj), found)
+	if errors.IsNotFound(err) {
+		r.Log.Info("Creating restored object.", "Kind", kind, "Object", klog.KRef(obj.GetNamespace(), obj.GetName()))
+		if err := r.Client.Create(context.TODO(), obj); err != nil {
+			r.Log.Error(err, "Failed to create restored object.", "Kind", kind, "Object", klog.KRef(obj.GetNamespace(), obj.GetName()))
+			return "", err
+		}
+		return "", r.Client.Get(context.TODO(), client.ObjectKeyFromObject(obj), found)
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get %s %s: %v", kind, obj.GetName(), err)
+	}
+	if !isRestoredBy(found, instance) {
+		return fmt.Sprintf("%s %s already exists and was not created by the restore", kind, klog.KRef(obj.GetNamespace(), obj.GetName())), nil
+	}
+	return "", nil
+}
+
+func (r *ConsistentSnapshotRestoreReconciler) setFailed(instance *ocsv1.ConsistentSnapshotRestore, reason, message string) {
+	now := metav1.Now()
+	instance.Status.Phase = ocsv1.ConsistentSnapshotRestorePhaseFailed
+	instance.Status.CompletionTime = &now
+	util.SetErrorCondition(&instance.Status.Conditions, reason, message)
+	setProgressing(&instance.Status.Conditions, corev1.ConditionFalse, reason, message)
+	r.recorder.ReportIfNotPresent(instance, corev1.EventTypeWarning, util.EventReasonConsistentSnapshotRestoreFailed, message)
+}
+
+func validateConsistentSnapshotRestore(instance *ocsv1.ConsistentSnapshotRestore) error {
+	if instance.Spec.ConsistentSnapshotName == "" {
+		return fmt.Errorf("no ConsistentSnapshot to restore")
+	}
+	return nil
+}
+
+// getTargetNamespace returns the namespace the PVCs are restored into
+func getTargetNamespace(instance *ocsv1.ConsistentSnapshotRestore) string {
+	if instance.Spec.TargetNamespace == "" {
+		return instance.Namespace
+	}
+	return instance.Spec.TargetNamespace
+}
+
+// isCrossNamespaceRestore returns true if the PVCs are restored into another
+// namespace than the one of the ConsistentSnapshot
+func isCrossNamespaceRestore(instance *ocsv1.ConsistentSnapshotRestore) bool {
+	return getTargetNamespace(instance) != instance.Namespace
+}
+
+// isRestoredBy returns true if the object was created by the restore
+func isRestoredBy(obj client.Object, instance *ocsv1.ConsistentSnapshotRestore) bool {
+	labels := obj.GetLabels()
+	return labels[restoreLabel] == instance.Name && labels[restoreNamespaceLabel] == instance.Namespace
+}
+
+func getRestoreLabels(instance *ocsv1.ConsistentSnapshotRestore) map[string]string {
+	return map[string]string{
+		restoreLabel:          instance.Name,
+		restoreNamespaceLabel: instance.Namespace,
+	}
+}
+
+// generateNameForRestoredPVC returns the name of the restored PVC. The PVCs
+// restored next to the snapshotted ones are named after the restore, so
+// that they do not clash with them.
+func generateNameForRestoredPVC(instance *ocsv1.ConsistentSnapshotRestore, volume *ocsv1.ConsistentSnapshotVolume) string {
+	if isCrossNamespaceRestore(instance) {
+		return volume.PVCName
+	}
+	return fmt.Sprintf("%s-restore-%s", volume.PVCName, instance.Name)
+}
+
+func generateNameForRestoredContent(instance *ocsv1.ConsistentSnapshotRestore, volume *ocsv1.ConsistentSnapshotVolume) string {
+	return fmt.Sprintf("%s-%s", instance.Spec.TargetNamespace, volume.VolumeSnapshotName)
+}
+
+// newRestoredVolumeSnapshotContent returns a VolumeSnapshotContent of the
+// snapshot of the source content, pre-bound to the restored VolumeSnapshot.
+// The snapshot is retained when the restored VolumeSnapshot is deleted, as
+// it still belongs to the ConsistentSnapshot.
+func newRestoredVolumeSnapshotContent(instance *ocsv1.ConsistentSnapshotRestore, volume *ocsv1.ConsistentSnapshotVolume,
+	source *snapapi.VolumeSnapshotContent) *snapapi.VolumeSnapshotContent {
+	snapshotHandle := *source.Status.SnapshotHandle
+	className := volume.VolumeSnapshotClassName
+	return &snapapi.VolumeSnapshotContent{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   generateNameForRestoredContent(instance, volume),
+			Labels: getRestoreLabels(instance),
+		},
+		Spec: snapapi.VolumeSnapshotContentSpec{
+			VolumeSnapshotRef: corev1.ObjectReference{
+				Name:      volume.VolumeSnapshotName,
+				Namespace: instance.Spec.TargetNamespace,
+			},
+			DeletionPolicy:          snapapi.VolumeSnapshotContentRetain,
+			Driver:                  source.Spec.Driver,
+			VolumeSnapshotClassName: &className,
+			Source: snapapi.VolumeSnapshotContentSource{
+				SnapshotHandle: &snapshotHandle,
+			},
+		},
+	}
+}
+
+// newRestoredVolumeSnapshot returns the VolumeSnapshot of the target
+// namespace bound to the restored VolumeSnapshotContent
+func newRestoredVolumeSnapshot(instance *ocsv1.ConsistentSnapshotRestore, volume *ocsv1.ConsistentSnapshotVolume) *snapapi.VolumeSnapshot {
+	contentName := generateNameForRestoredContent(instance, volume)
+	className := volume.VolumeSnapshotClassName
+	return &snapapi.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      volume.VolumeSnapshotName,
+			Namespace: instance.Spec.TargetNamespace,
+			Labels:    getRestoreLabels(instance),
+		},
+		Spec: snapapi.VolumeSnapshotSpec{
+			Source: snapapi.VolumeSnapshotSource{
+				VolumeSnapshotContentName: &contentName,
+			},
+			VolumeSnapshotClassName: &className,
+		},
+	}
+}
+
+// newRestoredPVC returns the PVC restored from the VolumeSnapshot of the
+// target namespace, with the settings of the snapshotted PVC
+func newRestoredPVC(instance *ocsv1.ConsistentSnapshotRestore, volume *ocsv1.ConsistentSnapshotVolume) *corev1.PersistentVolumeClaim {
+	size := volume.Size.DeepCopy()
+	if volume.RestoreSize != nil && volume.RestoreSize.Cmp(size) > 0 {
+		size = volume.RestoreSize.DeepCopy()
+	}
+	storageClassName := volume.StorageClassName
+	apiGroup := snapapi.GroupName
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateNameForRestoredPVC(instance, volume),
+			Namespace: getTargetNamespace(instance),
+			Labels:    getRestoreLabels(instance),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      volume.AccessModes,
+			VolumeMode:       volume.VolumeMode,
+			StorageClassName: &storageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+			DataSource: &corev1.TypedLocalObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     "VolumeSnapshot",
+				Name:     volume.VolumeSnapshotName,
+			},
+		},
+	}
+}
@@ -0,0 +1,332 @@
+package consistentsnapshot
+
+import (
+	"context"
+	"testing"
+
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const testTargetNamespace = "my-app-restore"
+
+func TestValidateConsistentSnapshotRestore(t *testing.T) {
+	cases := []struct {
+		label     string
+		spec      ocsv1.ConsistentSnapshotRestoreSpec
+		expectErr bool
+	}{
+		{
+			label: "valid spec",
+			spec:  ocsv1.ConsistentSnapshotRestoreSpec{ConsistentSnapshotName: "backup"},
+		},
+		{
+			label: "restore into the namespace of the restore",
+			spec:  ocsv1.ConsistentSnapshotRestoreSpec{ConsistentSnapshotName: "backup", TargetNamespace: testNamespace},
+		},
+		{
+			label:     "no ConsistentSnapshot",
+			spec:      ocsv1.ConsistentSnapshotRestoreSpec{},
+			expectErr: true,
+		},
+		{
+			label: "restore into another namespace",
+			spec:  ocsv1.ConsistentSnapshotRestoreSpec{ConsistentSnapshotName: "backup", TargetNamespace: testTargetNamespace},
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		instance := &ocsv1.ConsistentSnapshotRestore{
+			ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: testNamespace},
+			Spec:       c.spec,
+		}
+		err := validateConsistentSnapshotRestore(instance)
+		if c.expectErr {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestReconcileConsistentSnapshotRestore(t *testing.T) {
+	cases := []struct {
+		label             string
+		snapshotPhase     string
+		bindingMode       storagev1.VolumeBindingMode
+		existingPVC       string
+		expectedPhase     string
+		expectRequeue     bool
+		expectRestoredPVC bool
+	}{
+		{
+			label:             "PVC restored and bound",
+			snapshotPhase:     ocsv1.ConsistentSnapshotPhaseReady,
+			bindingMode:       storagev1.VolumeBindingImmediate,
+			expectedPhase:     ocsv1.ConsistentSnapshotRestorePhaseCompleted,
+			expectRestoredPVC: true,
+		},
+		{
+			label:             "PVC restored and waiting for its first consumer",
+			snapshotPhase:     ocsv1.ConsistentSnapshotPhaseReady,
+			bindingMode:       storagev1.VolumeBindingWaitForFirstConsumer,
+			expectedPhase:     ocsv1.ConsistentSnapshotRestorePhaseCompleted,
+			expectRestoredPVC: true,
+		},
+		{
+			label:         "ConsistentSnapshot not ready",
+			snapshotPhase: ocsv1.ConsistentSnapshotPhaseSnapshotting,
+			bindingMode:   storagev1.VolumeBindingImmediate,
+			expectedPhase: ocsv1.ConsistentSnapshotRestorePhaseRestoring,
+			expectRequeue: true,
+		},
+		{
+			label:         "ConsistentSnapshot failed",
+			snapshotPhase: ocsv1.ConsistentSnapshotPhaseFailed,
+			bindingMode:   storagev1.VolumeBindingImmediate,
+			expectedPhase: ocsv1.ConsistentSnapshotRestorePhaseFailed,
+		},
+		{
+			label:             "PVC restored next to the snapshotted one",
+			snapshotPhase:     ocsv1.ConsistentSnapshotPhaseReady,
+			bindingMode:       storagev1.VolumeBindingWaitForFirstConsumer,
+			existingPVC:       "db",
+			expectedPhase:     ocsv1.ConsistentSnapshotRestorePhaseCompleted,
+			expectRestoredPVC: true,
+		},
+		{
+			label:         "PVC already exists",
+			snapshotPhase: ocsv1.ConsistentSnapshotPhaseReady,
+			bindingMode:   storagev1.VolumeBindingImmediate,
+			existingPVC:   "db-restore-restore",
+			expectedPhase: ocsv1.ConsistentSnapshotRestorePhaseFailed,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		objs := newTestRestoreObjects(c.snapshotPhase, c.bindingMode)
+		if c.existingPVC != "" {
+			objs = append(objs, newTestPVC(c.existingPVC, testStorageClass, true))
+		}
+		reconciler := createFakeConsistentSnapshotRestoreReconciler(t, objs...)
+		key := types.NamespacedName{Name: "restore", Namespace: testNamespace}
+		instance := &ocsv1.ConsistentSnapshotRestore{}
+		pvcKey := types.NamespacedName{Name: "db-restore-restore", Namespace: testNamespace}
+
+		result := reconcileConsistentSnapshotRestore(t, reconciler, key, instance)
+		if c.bindingMode == storagev1.VolumeBindingImmediate && c.expectRestoredPVC {
+			// The restored PVC is only bound by the provisioner
+			assert.Equal(t, ocsv1.ConsistentSnapshotRestorePhaseRestoring, instance.Status.Phase)
+			assert.Equal(t, restoreRequeueInterval, result.RequeueAfter)
+			bindRestoredPVC(t, reconciler, pvcKey)
+			result = reconcileConsistentSnapshotRestore(t, reconciler, key, instance)
+		}
+		assert.Equal(t, c.expectedPhase, instance.Status.Phase)
+		assert.Equal(t, c.expectRequeue, result.RequeueAfter > 0)
+
+		if !c.expectRestoredPVC {
+			if c.existingPVC == "" {
+				assert.True(t, isNotFound(t, reconciler.Client, pvcKey, &corev1.PersistentVolumeClaim{}))
+			}
+			continue
+		}
+		assert.NotNil(t, instance.Status.CompletionTime)
+		assert.Len(t, instance.Status.Volumes, 1)
+		assert.Equal(t, "db-restore-restore", instance.Status.Volumes[0].PVCName)
+		assert.Empty(t, instance.Status.Volumes[0].VolumeSnapshotContentName)
+		assert.Equal(t, c.bindingMode == storagev1.VolumeBindingImmediate, instance.Status.Volumes[0].Bound)
+
+		pvc := &corev1.PersistentVolumeClaim{}
+		assert.NoError(t, reconciler.Client.Get(context.TODO(), pvcKey, pvc))
+		assert.Equal(t, testStorageClass, *pvc.Spec.StorageClassName)
+		assert.Equal(t, "VolumeSnapshot", pvc.Spec.DataSource.Kind)
+		assert.Equal(t, "backup-db", pvc.Spec.DataSource.Name)
+		assert.Equal(t, "restore", pvc.Labels[restoreLabel])
+		// The restore size of the snapshot is larger than the snapshotted PVC
+		size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		assert.Equal(t, resource.MustParse("12Gi"), size)
+	}
+}
+
+func TestReconcileConsistentSnapshotRestoreIntoAnotherNamespace(t *testing.T) {
+	cases := []struct {
+		label             string
+		namespaceExists   bool
+		existingPVC       bool
+		expectedPhase     string
+		expectRestoredPVC bool
+	}{
+		{
+			label:             "PVC restored in the target namespace",
+			namespaceExists:   true,
+			expectedPhase:     ocsv1.ConsistentSnapshotRestorePhaseCompleted,
+			expectRestoredPVC: true,
+		},
+		{
+			label:         "target namespace not created yet",
+			expectedPhase: ocsv1.ConsistentSnapshotRestorePhaseRestoring,
+		},
+		{
+			label:           "PVC already exists in the target namespace",
+			namespaceExists: true,
+			existingPVC:     true,
+			expectedPhase:   ocsv1.ConsistentSnapshotRestorePhaseFailed,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		objs := newTestRestoreObjects(ocsv1.ConsistentSnapshotPhaseReady, storagev1.VolumeBindingImmediate)
+		for _, obj := range objs {
+			if restore, ok := obj.(*ocsv1.ConsistentSnapshotRestore); ok {
+				restore.Spec.TargetNamespace = testTargetNamespace
+			}
+		}
+		if c.namespaceExists {
+			objs = append(objs, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testTargetNamespace}})
+		}
+		if c.existingPVC {
+			pvc := newTestPVC("db", testStorageClass, true)
+			pvc.Namespace = testTargetNamespace
+			objs = append(objs, pvc)
+		}
+		reconciler := createFakeConsistentSnapshotRestoreReconciler(t, objs...)
+		key := types.NamespacedName{Name: "restore", Namespace: testNamespace}
+		instance := &ocsv1.ConsistentSnapshotRestore{}
+		pvcKey := types.NamespacedName{Name: "db", Namespace: testTargetNamespace}
+		contentKey := types.NamespacedName{Name: testTargetNamespace + "-backup-db"}
+		snapshotKey := types.NamespacedName{Name: "backup-db", Namespace: testTargetNamespace}
+
+		result := reconcileConsistentSnapshotRestore(t, reconciler, key, instance)
+		if c.expectRestoredPVC {
+			assert.Equal(t, ocsv1.ConsistentSnapshotRestorePhaseRestoring, instance.Status.Phase)
+			bindRestoredPVC(t, reconciler, pvcKey)
+			result = reconcileConsistentSnapshotRestore(t, reconciler, key, instance)
+		}
+		assert.Equal(t, c.expectedPhase, instance.Status.Phase)
+
+		if !c.expectRestoredPVC {
+			assert.True(t, isNotFound(t, reconciler.Client, contentKey, &snapapi.VolumeSnapshotContent{}))
+			assert.True(t, isNotFound(t, reconciler.Client, snapshotKey, &snapapi.VolumeSnapshot{}))
+			if !c.existingPVC {
+				assert.Equal(t, restoreRequeueInterval, result.RequeueAfter)
+				assert.True(t, isNotFound(t, reconciler.Client, pvcKey, &corev1.PersistentVolumeClaim{}))
+			}
+			continue
+		}
+		assert.Len(t, instance.Status.Volumes, 1)
+		assert.Equal(t, "db", instance.Status.Volumes[0].PVCName)
+		assert.Equal(t, contentKey.Name, instance.Status.Volumes[0].VolumeSnapshotContentName)
+
+		// The content is pre-bound to the VolumeSnapshot of the target
+		// namespace, and retains the snapshot of the ConsistentSnapshot
+		content := &snapapi.VolumeSnapshotContent{}
+		assert.NoError(t, reconciler.Client.Get(context.TODO(), contentKey, content))
+		assert.Equal(t, snapshotKey.Name, content.Spec.VolumeSnapshotRef.Name)
+		assert.Equal(t, testTargetNamespace, content.Spec.VolumeSnapshotRef.Namespace)
+		assert.Equal(t, snapapi.VolumeSnapshotContentRetain, content.Spec.DeletionPolicy)
+		assert.Equal(t, testRBDProvisioner, content.Spec.Driver)
+		assert.Equal(t, "snapshot-1234", *content.Spec.Source.SnapshotHandle)
+		assert.Equal(t, "restore", content.Labels[restoreLabel])
+
+		snapshot := &snapapi.VolumeSnapshot{}
+		assert.NoError(t, reconciler.Client.Get(context.TODO(), snapshotKey, snapshot))
+		assert.Equal(t, contentKey.Name, *snapshot.Spec.Source.VolumeSnapshotContentName)
+
+		pvc := &corev1.PersistentVolumeClaim{}
+		assert.NoError(t, reconciler.Client.Get(context.TODO(), pvcKey, pvc))
+		assert.Equal(t, snapshotKey.Name, pvc.Spec.DataSource.Name)
+		assert.Equal(t, testNamespace, pvc.Labels[restoreNamespaceLabel])
+	}
+}
+
+// newTestRestoreObjects returns a ConsistentSnapshot of a single PVC, its
+// VolumeSnapshot and VolumeSnapshotContent, and a restore of it
+func newTestRestoreObjects(snapshotPhase string, bindingMode storagev1.VolumeBindingMode) []runtime.Object {
+	restoreSize := resource.MustParse("12Gi")
+	contentName := "snapcontent-1234"
+	snapshotHandle := "snapshot-1234"
+	storageClass := &storagev1.StorageClass{
+		ObjectMeta:        metav1.ObjectMeta{Name: testStorageClass},
+		Provisioner:       testRBDProvisioner,
+		VolumeBindingMode: &bindingMode,
+	}
+	return []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}},
+		storageClass,
+		&ocsv1.ConsistentSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: testNamespace},
+			Status: ocsv1.ConsistentSnapshotStatus{
+				Phase: snapshotPhase,
+				Volumes: []ocsv1.ConsistentSnapshotVolume{
+					{
+						PVCName:                 "db",
+						VolumeSnapshotName:      "backup-db",
+						VolumeSnapshotClassName: testSnapshotClass,
+						StorageClassName:        testStorageClass,
+						AccessModes:             []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+						Size:                    resource.MustParse("10Gi"),
+						RestoreSize:             &restoreSize,
+						ReadyToUse:              true,
+					},
+				},
+			},
+		},
+		&snapapi.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup-db", Namespace: testNamespace},
+			Status:     &snapapi.VolumeSnapshotStatus{BoundVolumeSnapshotContentName: &contentName},
+		},
+		&snapapi.VolumeSnapshotContent{
+			ObjectMeta: metav1.ObjectMeta{Name: contentName},
+			Spec:       snapapi.VolumeSnapshotContentSpec{Driver: testRBDProvisioner},
+			Status:     &snapapi.VolumeSnapshotContentStatus{SnapshotHandle: &snapshotHandle},
+		},
+		&ocsv1.ConsistentSnapshotRestore{
+			ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: testNamespace},
+			Spec: ocsv1.ConsistentSnapshotRestoreSpec{
+				ConsistentSnapshotName: "backup",
+			},
+		},
+	}
+}
+
+// bindRestoredPVC binds the restored PVC, as its provisioner would
+func bindRestoredPVC(t *testing.T, reconciler ConsistentSnapshotRestoreReconciler, key types.NamespacedName) {
+	pvc := &corev1.PersistentVolumeClaim{}
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), key, pvc))
+	pvc.Status.Phase = corev1.ClaimBound
+	assert.NoError(t, reconciler.Client.Status().Update(context.TODO(), pvc))
+}
+
+func reconcileConsistentSnapshotRestore(t *testing.T, reconciler ConsistentSnapshotRestoreReconciler, key types.NamespacedName, instance *ocsv1.ConsistentSnapshotRestore) reconcile.Result {
+	result, err := reconciler.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), key, instance))
+	return result
+}
+
+func createFakeConsistentSnapshotRestoreReconciler(t *testing.T, obj ...runtime.Object) ConsistentSnapshotRestoreReconciler {
+	scheme := createFakeScheme(t)
+	client := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(obj...).Build()
+
+	return ConsistentSnapshotRestoreReconciler{
+		Client:   client,
+		Scheme:   scheme,
+		Log:      logf.Log.WithName("controller_consistentsnapshotrestore_test"),
+		recorder: util.NewEventReporter(&record.FakeRecorder{}),
+	}
+}
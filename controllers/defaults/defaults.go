@@ -24,6 +24,16 @@ var (
 	DefaultMonCount = 3
 	// ArbiterModeMonCount is the number of monitors to be configured for the CephCluster in arbiter mode
 	ArbiterModeMonCount = 5
+	// DefaultMgrCount is the number of managers to be configured for the CephCluster
+	DefaultMgrCount = 2
+	// SingleNodeMgrCount is the number of managers to be configured for the CephCluster
+	// when several mons are allowed per node, as the standby mgr would then share the
+	// node of the active one
+	SingleNodeMgrCount = 1
+	// ArbiterModeMgrCount is the number of managers to be configured for the CephCluster in arbiter mode
+	ArbiterModeMgrCount = 2
+	// MaxMgrCount is the maximum number of managers supported by Rook
+	MaxMgrCount = 2
 	// DeviceSetReplica is the default number of Rook-Ceph
 	// StorageClassDeviceSets per StorageCluster StorageDeviceSet
 	// This is equal to the default number of failure domains for OSDs
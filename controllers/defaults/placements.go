@@ -98,6 +98,23 @@ var (
 			},
 		},
 
+		"nfs": {
+			Tolerations: []corev1.Toleration{
+				getOcsToleration(),
+			},
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+					getWeightedPodAffinityTerm(100, "rook-ceph-nfs"),
+				},
+			},
+		},
+
+		"rbd-mirror": {
+			Tolerations: []corev1.Toleration{
+				getOcsToleration(),
+			},
+		},
+
 		"noobaa-core": {
 			Tolerations: []corev1.Toleration{
 				getOcsToleration(),
@@ -8,8 +8,19 @@ import (
 // name, if found in the passed resource map. If not, it returns the default
 // value for the given name.
 func GetDaemonResources(name string, custom map[string]corev1.ResourceRequirements) corev1.ResourceRequirements {
+	return GetProfileDaemonResources(ResourceProfileBalanced, name, custom)
+}
+
+// GetProfileDaemonResources returns a custom ResourceRequirements for the
+// passed name, if found in the passed resource map. If not, it returns the
+// value of the given resource profile, or the default value for the given
+// name if the profile does not set it.
+func GetProfileDaemonResources(profile, name string, custom map[string]corev1.ResourceRequirements) corev1.ResourceRequirements {
 	if res, ok := custom[name]; ok {
 		return res
 	}
+	if res, ok := ResourceProfiles[profile][name]; ok {
+		return res
+	}
 	return DaemonResources[name]
 }
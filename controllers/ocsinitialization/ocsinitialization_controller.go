@@ -19,11 +19,17 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
-// watchNamespace is the namespace the operator is watching.
+// watchNamespace is the namespace the operator is deployed in, which holds
+// the OCSInitialization.
 var watchNamespace string
 
 const wrongNamespacedName = "Ignoring this resource. Only one should exist, and this one has the wrong name and/or namespace."
@@ -183,7 +189,7 @@ func (r *OCSInitializationReconciler) ensureToolsDeployment(initialData *ocsv1.O
 }
 
 // +kubebuilder:rbac:groups=ocs.openshift.io,resources=*,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=security.openshift.io,resources=securitycontextconstraints,verbs=get;create;update
+// +kubebuilder:rbac:groups=security.openshift.io,resources=securitycontextconstraints,verbs=get;list;create;update;delete
 // +kubebuilder:rbac:groups=security.openshift.io,resourceNames=privileged,resources=securitycontextconstraints,verbs=get;create;update
 
 // Reconcile reads that state of the cluster for a OCSInitialization object and makes changes based on the state read
@@ -302,7 +308,7 @@ func (r *OCSInitializationReconciler) Reconcile(ctx context.Context, request rec
 
 // SetupWithManager sets up a controller with a manager
 func (r *OCSInitializationReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	ns, err := util.GetWatchNamespace()
+	ns, err := util.GetOperatorNamespace()
 	if err != nil {
 		return err
 	}
@@ -314,9 +320,24 @@ func (r *OCSInitializationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	}
 	r.RookImage = rookImage
 
+	// The SCCs depend on the namespaces holding a StorageCluster, which
+	// only change when a StorageCluster is created or deleted
+	enqueueInitialization := handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: InitNamespacedName()}}
+	})
+	storageClusterPredicate := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&ocsv1.OCSInitialization{}).
 		Owns(&appsv1.Deployment{}).
+		Watches(&source.Kind{Type: &ocsv1.StorageCluster{}}, enqueueInitialization, builder.WithPredicates(storageClusterPredicate)).
 		Complete(r)
 }
 
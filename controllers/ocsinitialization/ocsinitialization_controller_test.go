@@ -264,6 +264,37 @@ func TestCreateSCCs(t *testing.T) {
 	}
 }
 
+func TestGetStorageClusterNamespaces(t *testing.T) {
+	newStorageCluster := func(name, namespace string) *v1.StorageCluster {
+		return &v1.StorageCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+		}
+	}
+	reconciler := getReconciler(t,
+		newStorageCluster("ocs-storagecluster", "openshift-storage"),
+		newStorageCluster("ocs-external-storagecluster", "openshift-storage-external"),
+		newStorageCluster("ocs-storagecluster", "openshift-storage-extended"),
+		newStorageCluster("other", "openshift-storage-extended"),
+	)
+
+	namespaces, err := reconciler.getStorageClusterNamespaces("openshift-storage")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"openshift-storage-extended", "openshift-storage-external"}, namespaces)
+
+	sccs := getNamespaceSCCs("openshift-storage-external")
+	assert.Len(t, sccs, len(getAllSCCs("openshift-storage")))
+	for _, scc := range sccs {
+		assert.Contains(t, scc.Name, "-openshift-storage-external")
+		assert.Equal(t, "openshift-storage-external", scc.Labels[sccNamespaceLabel])
+		for _, user := range scc.Users {
+			assert.Contains(t, user, ":openshift-storage-external:")
+		}
+	}
+}
+
 func TestReconcileCompleteConditions(t *testing.T) {
 	_, request, reconciler := getTestParams(false, t)
 
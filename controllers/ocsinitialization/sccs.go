@@ -3,6 +3,7 @@ package ocsinitialization
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	secv1 "github.com/openshift/api/security/v1"
 	ocsv1 "github.com/openshift/ocs-operator/api/v1"
@@ -12,8 +13,20 @@ import (
 	"k8s.io/klog/v2"
 )
 
+// sccNamespaceLabel is set on the SCCs created for the StorageClusters
+// outside of the operator namespace, to the namespace they are created for
+const sccNamespaceLabel = "ocs.openshift.io/storagecluster-namespace"
+
 func (r *OCSInitializationReconciler) ensureSCCs(initialData *ocsv1.OCSInitialization) error {
 	sccs := getAllSCCs(initialData.Namespace)
+	namespaces, err := r.getStorageClusterNamespaces(initialData.Namespace)
+	if err != nil {
+		return err
+	}
+	for _, namespace := range namespaces {
+		sccs = append(sccs, getNamespaceSCCs(namespace)...)
+	}
+
 	for _, scc := range sccs {
 		found, err := r.SecurityClient.SecurityContextConstraints().Get(context.TODO(), scc.Name, metav1.GetOptions{})
 
@@ -37,9 +50,70 @@ func (r *OCSInitializationReconciler) ensureSCCs(initialData *ocsv1.OCSInitializ
 		}
 	}
 
+	return r.deleteStaleNamespaceSCCs(namespaces)
+}
+
+// getStorageClusterNamespaces returns the namespaces other than the operator
+// namespace holding a StorageCluster
+func (r *OCSInitializationReconciler) getStorageClusterNamespaces(operatorNamespace string) ([]string, error) {
+	storageClusterList := &ocsv1.StorageClusterList{}
+	if err := r.Client.List(context.TODO(), storageClusterList); err != nil {
+		return nil, fmt.Errorf("unable to list StorageClusters: %v", err)
+	}
+	found := map[string]bool{}
+	var namespaces []string
+	for _, storageCluster := range storageClusterList.Items {
+		if storageCluster.Namespace == operatorNamespace || found[storageCluster.Namespace] {
+			continue
+		}
+		found[storageCluster.Namespace] = true
+		namespaces = append(namespaces, storageCluster.Namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// deleteStaleNamespaceSCCs deletes the SCCs created for namespaces which no
+// longer hold a StorageCluster
+func (r *OCSInitializationReconciler) deleteStaleNamespaceSCCs(namespaces []string) error {
+	sccList, err := r.SecurityClient.SecurityContextConstraints().List(context.TODO(), metav1.ListOptions{LabelSelector: sccNamespaceLabel})
+	if err != nil {
+		return fmt.Errorf("unable to list SCCs: %v", err)
+	}
+	for _, scc := range sccList.Items {
+		if contains(namespaces, scc.Labels[sccNamespaceLabel]) {
+			continue
+		}
+		r.Log.Info("Deleting SecurityContextConstraint.", "SecurityContextConstraint", klog.KRef("", scc.Name))
+		err := r.SecurityClient.SecurityContextConstraints().Delete(context.TODO(), scc.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("unable to delete SCC %s: %v", scc.Name, err)
+		}
+	}
 	return nil
 }
 
+// getNamespaceSCCs returns the SCCs for the service accounts of a
+// StorageCluster outside of the operator namespace. They are named after the
+// namespace, so that they do not replace the SCCs of the operator namespace.
+func getNamespaceSCCs(namespace string) []*secv1.SecurityContextConstraints {
+	sccs := getAllSCCs(namespace)
+	for _, scc := range sccs {
+		scc.Name = fmt.Sprintf("%s-%s", scc.Name, namespace)
+		scc.Labels = map[string]string{sccNamespaceLabel: namespace}
+	}
+	return sccs
+}
+
+func contains(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 func getAllSCCs(namespace string) []*secv1.SecurityContextConstraints {
 	return []*secv1.SecurityContextConstraints{
 		newRookCephSCC(namespace),
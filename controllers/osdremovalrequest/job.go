@@ -0,0 +1,144 @@
+package osdremovalrequest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/util"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	removalContainerName = "remove"
+
+	// osdDownTimeout is how long the removal Job waits for the OSDs to be
+	// reported down, in seconds
+	osdDownTimeout = 300
+
+	// The OSD removal script writes one line per OSD to its termination
+	// message, in the form "<id> <phase> [message]". Messages are truncated,
+	// as the termination message is limited to 4096 bytes.
+	osdRemovalScript = `
+report() {
+	echo "$1 $2 ${3:0:200}" | tr -d '\r' >> /dev/termination-log
+}
+
+osd_is_up() {
+	ceph osd dump | grep -q "^osd.$1 up "
+}
+
+: > /dev/termination-log
+remove_ids=""
+unsafe=false
+# The operator scaled down the OSD deployments before starting the Job, an
+# OSD is only removed once Ceph reports it down
+deadline=$((SECONDS + ${OSD_DOWN_TIMEOUT}))
+for id in ${OSD_IDS}; do
+	if ! ceph osd find "${id}" > /dev/null 2>&1; then
+		report "${id}" NotFound "osd.${id} does not exist"
+		continue
+	fi
+	while osd_is_up "${id}" && [[ ${SECONDS} -lt ${deadline} ]]; do
+		sleep 5
+	done
+	if osd_is_up "${id}"; then
+		report "${id}" Failed "osd.${id} is still up"
+		unsafe=true
+	elif ! out=$(ceph osd safe-to-destroy "osd.${id}" 2>&1) && [[ "${FORCE}" != "true" ]]; then
+		report "${id}" Unsafe "$(echo ${out})"
+		unsafe=true
+	else
+		remove_ids="${remove_ids} ${id}"
+	fi
+done
+
+# Nothing is removed unless all the OSDs can be removed
+if [[ "${unsafe}" == "true" ]]; then
+	exit 0
+fi
+
+purge_args="--yes-i-really-mean-it"
+if [[ "${FORCE}" == "true" ]]; then
+	purge_args="${purge_args} --force"
+fi
+for id in ${remove_ids}; do
+	if out=$(ceph osd out "osd.${id}" 2>&1 && ceph osd purge "osd.${id}" ${purge_args} 2>&1); then
+		report "${id}" Removed "$(echo ${out})"
+	else
+		report "${id}" Failed "$(echo ${out})"
+	fi
+done
+`
+)
+
+func generateNameForOSDRemovalJob(request *ocsv1.OSDRemovalRequest) string {
+	return fmt.Sprintf("ocs-osd-removal-%s", request.Name)
+}
+
+// newOSDRemovalJob returns the Job checking that the OSDs of the request are
+// safe to destroy and removing them from the Ceph cluster
+func newOSDRemovalJob(request *ocsv1.OSDRemovalRequest, rookImage string) *batchv1.Job {
+	ids := make([]string, 0, len(request.Spec.OSDIDs))
+	for _, id := range request.Spec.OSDIDs {
+		ids = append(ids, strconv.Itoa(id))
+	}
+
+	job := util.NewToolboxJob(generateNameForOSDRemovalJob(request), request.Namespace, rookImage, corev1.Container{
+		Name:    removalContainerName,
+		Command: []string{"bash", "-c", osdRemovalScript},
+		Env: []corev1.EnvVar{
+			{
+				Name:  "OSD_IDS",
+				Value: strings.Join(ids, " "),
+			},
+			{
+				Name:  "FORCE",
+				Value: strconv.FormatBool(request.Spec.Force),
+			},
+			{
+				Name:  "OSD_DOWN_TIMEOUT",
+				Value: strconv.Itoa(osdDownTimeout),
+			},
+		},
+		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+	})
+	// The result is read from the pod, which must not be replaced
+	var backoffLimit int32
+	job.Spec.BackoffLimit = &backoffLimit
+	return job
+}
+
+// parseOSDRemovalResults parses the termination message of the removal Job
+// into the phase and message of each OSD
+func parseOSDRemovalResults(message string) (map[int]ocsv1.OSDRemovalStatus, error) {
+	results := map[int]ocsv1.OSDRemovalStatus{}
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid OSD removal result %q", line)
+		}
+		id, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid OSD ID in OSD removal result %q", line)
+		}
+		result := ocsv1.OSDRemovalStatus{ID: id}
+		switch phase := ocsv1.OSDRemovalPhase(fields[1]); phase {
+		case ocsv1.OSDRemovalRemoved, ocsv1.OSDRemovalUnsafe, ocsv1.OSDRemovalNotFound, ocsv1.OSDRemovalFailed:
+			result.Phase = phase
+		default:
+			return nil, fmt.Errorf("invalid OSD removal phase in OSD removal result %q", line)
+		}
+		if len(fields) == 3 {
+			result.Message = strings.TrimSpace(fields[2])
+		}
+		results[id] = result
+	}
+	return results, nil
+}
@@ -0,0 +1,85 @@
+package osdremovalrequest
+
+import (
+	"testing"
+
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewOSDRemovalJob(t *testing.T) {
+	request := &ocsv1.OSDRemovalRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "remove-failed", Namespace: "openshift-storage"},
+		Spec: ocsv1.OSDRemovalRequestSpec{
+			OSDIDs: []int{0, 3},
+			Force:  true,
+		},
+	}
+
+	job := newOSDRemovalJob(request, "rook/ceph:test")
+	assert.Equal(t, "ocs-osd-removal-remove-failed", job.Name)
+	assert.Equal(t, "openshift-storage", job.Namespace)
+	assert.Equal(t, int32(0), *job.Spec.BackoffLimit)
+
+	containers := job.Spec.Template.Spec.Containers
+	assert.Len(t, containers, 1)
+	assert.Equal(t, removalContainerName, containers[0].Name)
+	assert.Equal(t, "rook/ceph:test", containers[0].Image)
+	assert.Equal(t, corev1.TerminationMessageReadFile, containers[0].TerminationMessagePolicy)
+	assert.Contains(t, containers[0].Env, corev1.EnvVar{Name: "OSD_IDS", Value: "0 3"})
+	assert.Contains(t, containers[0].Env, corev1.EnvVar{Name: "FORCE", Value: "true"})
+	assert.Contains(t, containers[0].Env, corev1.EnvVar{Name: "OSD_DOWN_TIMEOUT", Value: "300"})
+}
+
+func TestParseOSDRemovalResults(t *testing.T) {
+	cases := []struct {
+		label     string
+		message   string
+		expected  map[int]ocsv1.OSDRemovalStatus
+		expectErr bool
+	}{
+		{
+			label:    "empty message",
+			message:  "",
+			expected: map[int]ocsv1.OSDRemovalStatus{},
+		},
+		{
+			label:   "all phases",
+			message: "0 Removed purged osd.0\n1 NotFound osd.1 does not exist\n2 Unsafe  Error EBUSY: osd.2 is not safe\n3 Failed\n",
+			expected: map[int]ocsv1.OSDRemovalStatus{
+				0: {ID: 0, Phase: ocsv1.OSDRemovalRemoved, Message: "purged osd.0"},
+				1: {ID: 1, Phase: ocsv1.OSDRemovalNotFound, Message: "osd.1 does not exist"},
+				2: {ID: 2, Phase: ocsv1.OSDRemovalUnsafe, Message: "Error EBUSY: osd.2 is not safe"},
+				3: {ID: 3, Phase: ocsv1.OSDRemovalFailed},
+			},
+		},
+		{
+			label:     "invalid ID",
+			message:   "osd.0 Removed",
+			expectErr: true,
+		},
+		{
+			label:     "invalid phase",
+			message:   "0 Pending",
+			expectErr: true,
+		},
+		{
+			label:     "missing phase",
+			message:   "0",
+			expectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		results, err := parseOSDRemovalResults(c.message)
+		if c.expectErr {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, results)
+	}
+}
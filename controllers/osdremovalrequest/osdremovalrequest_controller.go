@@ -0,0 +1,39 @@
+package osdremovalrequest
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-logr/logr"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/util"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// OSDRemovalRequestReconciler reconciles an OSDRemovalRequest object
+//nolint
+type OSDRemovalRequestReconciler struct {
+	client.Client
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	RookImage string
+	recorder  *util.EventReporter
+}
+
+// SetupWithManager sets up a controller with a manager
+func (r *OSDRemovalRequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	rookImage := os.Getenv("ROOK_CEPH_IMAGE")
+	if rookImage == "" {
+		return fmt.Errorf("No ROOK_CEPH_IMAGE environment variable set")
+	}
+	r.RookImage = rookImage
+	r.recorder = util.NewEventReporter(mgr.GetEventRecorderFor("controller_osdremovalrequest"))
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ocsv1.OSDRemovalRequest{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
@@ -0,0 +1,553 @@
+package osdremovalrequest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/util"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// The labels Rook sets on the OSD deployments
+	osdIDLabel  = "ceph-osd-id"
+	osdPVCLabel = "ceph.rook.io/pvc"
+
+	// removalLeaseName is the name of the Lease held by the request of the
+	// namespace which is running
+	removalLeaseName = "ocs-osd-removal"
+
+	// maxOSDRemovalHistory is the number of OSDRemovalRequests recorded in
+	// the status of the StorageCluster
+	maxOSDRemovalHistory = 10
+
+	// pendingRequeueInterval is how often a pending request checks whether
+	// it can start
+	pendingRequeueInterval = 30 * time.Second
+
+	reasonRemovingOSDs      = "RemovingOSDs"
+	reasonWaitingForRequest = "WaitingForOtherRequest"
+	reasonOSDsRemoved       = "OSDsRemoved"
+	reasonOSDsNotSafe       = "OSDsNotSafeToDestroy"
+	reasonOSDRemovalFailed  = "OSDRemovalFailed"
+	reasonInvalidRequest    = "InvalidRequest"
+)
+
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=delete
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;delete
+
+// Reconcile removes the OSDs listed in an OSDRemovalRequest. It scales down
+// the OSD deployments and launches a Job which checks that the OSDs are safe
+// to destroy and removes them from the Ceph cluster, then deletes the OSD
+// deployments and, if requested, the PVCs and PVs which backed the OSDs.
+func (r *OSDRemovalRequestReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+
+	prevLogger := r.Log
+	defer func() { r.Log = prevLogger }()
+	r.Log = r.Log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+
+	instance := &ocsv1.OSDRemovalRequest{}
+	if err := r.Client.Get(ctx, request.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			r.Log.Info("No OSDRemovalRequest resource.", "OSDRemovalRequest", klog.KRef(request.Namespace, request.Name))
+			return reconcile.Result{}, nil
+		}
+		r.Log.Error(err, "Failed to retrieve OSDRemovalRequest.", "OSDRemovalRequest", klog.KRef(request.Namespace, request.Name))
+		return reconcile.Result{}, err
+	}
+
+	// Completed requests are kept as a record only
+	if instance.Status.Phase == ocsv1.OSDRemovalRequestPhaseCompleted ||
+		instance.Status.Phase == ocsv1.OSDRemovalRequestPhaseFailed ||
+		!instance.GetDeletionTimestamp().IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	var result reconcile.Result
+	var err error
+	if instance.Status.Phase == ocsv1.OSDRemovalRequestPhaseRunning {
+		result, err = r.reconcileRunning(instance)
+	} else {
+		result, err = r.reconcilePending(instance)
+	}
+
+	statusError := r.Client.Status().Update(ctx, instance)
+	if statusError != nil {
+		r.Log.Info("Could not update OSDRemovalRequest status.", "OSDRemovalRequest", klog.KRef(instance.Namespace, instance.Name))
+	}
+
+	if err != nil {
+		return result, err
+	}
+	return result, statusError
+}
+
+// reconcilePending launches the removal Job, once no other request of the
+// namespace is running
+func (r *OSDRemovalRequestReconciler) reconcilePending(instance *ocsv1.OSDRemovalRequest) (reconcile.Result, error) {
+	if err := validateOSDRemovalRequest(instance); err != nil {
+		r.Log.Error(err, "Invalid OSDRemovalRequest.", "OSDRemovalRequest", klog.KRef(instance.Namespace, instance.Name))
+		r.setFailed(instance, reasonInvalidRequest, err.Error())
+		return reconcile.Result{}, nil
+	}
+
+	sc, err := r.getStorageCluster(instance.Namespace)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if sc == nil {
+		r.setFailed(instance, reasonInvalidRequest, "no internal StorageCluster found in the namespace")
+		return reconcile.Result{}, nil
+	}
+	instance.Status.StorageCluster = sc.Name
+
+	running, err := r.acquireRemovalLease(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if running != "" {
+		r.Log.Info("Waiting for another OSDRemovalRequest to complete.", "OSDRemovalRequest", klog.KRef(instance.Namespace, running))
+		instance.Status.Phase = ocsv1.OSDRemovalRequestPhasePending
+		setProgressing(instance, corev1.ConditionFalse, reasonWaitingForRequest,
+			fmt.Sprintf("waiting for OSDRemovalRequest %s to complete", running))
+		return reconcile.Result{RequeueAfter: pendingRequeueInterval}, nil
+	}
+
+	instance.Status.OSDs = nil
+	for _, id := range instance.Spec.OSDIDs {
+		status := ocsv1.OSDRemovalStatus{ID: id, Phase: ocsv1.OSDRemovalPending}
+		status.PVCName, err = r.getOSDPVCName(instance.Namespace, id)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		instance.Status.OSDs = append(instance.Status.OSDs, status)
+	}
+
+	// Ceph refuses to purge an OSD which is still running
+	for _, id := range instance.Spec.OSDIDs {
+		if err := r.scaleOSDDeployment(instance.Namespace, id, 0); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	job := newOSDRemovalJob(instance, r.RookImage)
+	if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
+		return reconcile.Result{}, err
+	}
+	r.Log.Info("Creating OSD removal Job.", "Job", klog.KRef(job.Namespace, job.Name), "OSDs", instance.Spec.OSDIDs)
+	if err := r.Client.Create(context.TODO(), job); err != nil && !errors.IsAlreadyExists(err) {
+		r.Log.Error(err, "Failed to create OSD removal Job.", "Job", klog.KRef(job.Namespace, job.Name))
+		return reconcile.Result{}, err
+	}
+
+	now := metav1.Now()
+	instance.Status.JobName = job.Name
+	instance.Status.StartTime = &now
+	instance.Status.Phase = ocsv1.OSDRemovalRequestPhaseRunning
+	setProgressing(instance, corev1.ConditionTrue, reasonRemovingOSDs, "removing the OSDs")
+	return reconcile.Result{}, nil
+}
+
+// reconcileRunning collects the result of the removal Job once it finished
+// and cleans up after the removed OSDs
+func (r *OSDRemovalRequestReconciler) reconcileRunning(instance *ocsv1.OSDRemovalRequest) (reconcile.Result, error) {
+	job := &batchv1.Job{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: instance.Status.JobName, Namespace: instance.Namespace}, job)
+	if errors.IsNotFound(err) {
+		r.setOSDsFailed(instance, "the OSD removal Job was deleted")
+		return reconcile.Result{}, r.complete(instance)
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+	if job.Status.Succeeded == 0 && job.Status.Failed == 0 {
+		// The Job is owned by the request, which is reconciled again once
+		// the Job finishes
+		return reconcile.Result{}, nil
+	}
+
+	message, err := r.getJobResult(job)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	results, err := parseOSDRemovalResults(message)
+	if err != nil || job.Status.Succeeded == 0 {
+		if err == nil {
+			err = fmt.Errorf("the OSD removal Job %s failed", job.Name)
+		}
+		r.Log.Error(err, "Failed to remove OSDs.", "Job", klog.KRef(job.Namespace, job.Name))
+		r.setOSDsFailed(instance, err.Error())
+		return reconcile.Result{}, r.complete(instance)
+	}
+
+	for i := range instance.Status.OSDs {
+		osd := &instance.Status.OSDs[i]
+		result, ok := results[osd.ID]
+		if !ok {
+			if osd.Phase == ocsv1.OSDRemovalPending {
+				osd.Message = "not removed as other OSDs are not safe to destroy"
+			}
+			continue
+		}
+		osd.Phase = result.Phase
+		osd.Message = result.Message
+		if osd.Phase == ocsv1.OSDRemovalRemoved {
+			if err := r.cleanupOSD(instance, osd); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+	}
+	return reconcile.Result{}, r.complete(instance)
+}
+
+// scaleOSDDeployment sets the number of replicas of the deployment of an OSD,
+// if it still exists
+func (r *OSDRemovalRequestReconciler) scaleOSDDeployment(namespace string, id int, replicas int32) error {
+	deployment := &appsv1.Deployment{}
+	name := fmt.Sprintf("rook-ceph-osd-%d", id)
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, deployment)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get deployment %s: %v", name, err)
+	}
+	if deployment.Spec.Replicas != nil && *deployment.Spec.Replicas == replicas {
+		return nil
+	}
+	r.Log.Info("Scaling the deployment of OSD.", "Deployment", klog.KRef(namespace, name), "Replicas", replicas)
+	deployment.Spec.Replicas = &replicas
+	if err := r.Client.Update(context.TODO(), deployment); err != nil {
+		return fmt.Errorf("failed to scale deployment %s: %v", name, err)
+	}
+	return nil
+}
+
+// cleanupOSD deletes the deployment of a removed OSD and, if requested, its
+// PVC and PV, so that Rook does not recreate an OSD on the same device
+func (r *OSDRemovalRequestReconciler) cleanupOSD(instance *ocsv1.OSDRemovalRequest, osd *ocsv1.OSDRemovalStatus) error {
+	deployment := &appsv1.Deployment{}
+	deployment.Name = fmt.Sprintf("rook-ceph-osd-%d", osd.ID)
+	deployment.Namespace = instance.Namespace
+	r.Log.Info("Deleting the deployment of removed OSD.", "Deployment", klog.KRef(deployment.Namespace, deployment.Name))
+	if err := r.Client.Delete(context.TODO(), deployment); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete deployment %s: %v", deployment.Name, err)
+	}
+
+	if !instance.Spec.DeletePVCs || osd.PVCName == "" {
+		return nil
+	}
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: osd.PVCName, Namespace: instance.Namespace}, pvc)
+	if err == nil {
+		if pvc.Spec.VolumeName != "" {
+			osd.PVName = pvc.Spec.VolumeName
+		}
+		r.Log.Info("Deleting the PVC of removed OSD.", "PersistentVolumeClaim", klog.KRef(pvc.Namespace, pvc.Name))
+		if err := r.Client.Delete(context.TODO(), pvc); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete PVC %s: %v", pvc.Name, err)
+		}
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get PVC %s: %v", osd.PVCName, err)
+	}
+	osd.PVCDeleted = true
+
+	if !instance.Spec.DeletePVs || osd.PVName == "" {
+		return nil
+	}
+	pv := &corev1.PersistentVolume{}
+	pv.Name = osd.PVName
+	r.Log.Info("Deleting the PV of removed OSD.", "PersistentVolume", klog.KRef("", pv.Name))
+	if err := r.Client.Delete(context.TODO(), pv); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete PV %s: %v", pv.Name, err)
+	}
+	osd.PVDeleted = true
+	return nil
+}
+
+// complete records the request on the StorageCluster, then sets its final
+// phase and conditions. The OSDs which were not removed are scaled up again.
+func (r *OSDRemovalRequestReconciler) complete(instance *ocsv1.OSDRemovalRequest) error {
+	record := ocsv1.OSDRemovalRecord{
+		RequestName: instance.Name,
+		Forced:      instance.Spec.Force,
+		Phase:       ocsv1.OSDRemovalRequestPhaseCompleted,
+	}
+	unsafe := false
+	for _, osd := range instance.Status.OSDs {
+		switch osd.Phase {
+		case ocsv1.OSDRemovalRemoved, ocsv1.OSDRemovalNotFound:
+			record.RemovedOSDs = append(record.RemovedOSDs, osd.ID)
+			continue
+		case ocsv1.OSDRemovalUnsafe:
+			unsafe = true
+		}
+		record.FailedOSDs = append(record.FailedOSDs, osd.ID)
+		record.Phase = ocsv1.OSDRemovalRequestPhaseFailed
+		if err := r.scaleOSDDeployment(instance.Namespace, osd.ID, 1); err != nil {
+			return err
+		}
+	}
+	record.CompletionTime = metav1.Now()
+
+	// The request only completes once it is recorded, so that the record
+	// is not lost if the StorageCluster cannot be updated
+	if err := r.recordOSDRemoval(instance, record); err != nil {
+		return err
+	}
+
+	instance.Status.CompletionTime = &record.CompletionTime
+	if record.Phase == ocsv1.OSDRemovalRequestPhaseCompleted {
+		instance.Status.Phase = ocsv1.OSDRemovalRequestPhaseCompleted
+		util.SetCompleteCondition(&instance.Status.Conditions, reasonOSDsRemoved, fmt.Sprintf("removed OSDs %v", record.RemovedOSDs))
+	} else {
+		reason := reasonOSDRemovalFailed
+		message := fmt.Sprintf("failed to remove OSDs %v", record.FailedOSDs)
+		if unsafe {
+			reason = reasonOSDsNotSafe
+			message = fmt.Sprintf("OSDs %v are not safe to destroy, set force to remove them anyway", getOSDIDs(instance, ocsv1.OSDRemovalUnsafe))
+		}
+		r.setFailed(instance, reason, message)
+	}
+	return r.releaseRemovalLease(instance)
+}
+
+// recordOSDRemoval appends the record of the request to the OSD removal
+// history of the StorageCluster
+func (r *OSDRemovalRequestReconciler) recordOSDRemoval(instance *ocsv1.OSDRemovalRequest, record ocsv1.OSDRemovalRecord) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		sc := &ocsv1.StorageCluster{}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{Name: instance.Status.StorageCluster, Namespace: instance.Namespace}, sc)
+		if errors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		for _, existing := range sc.Status.OSDRemovals {
+			if existing.RequestName == record.RequestName {
+				return nil
+			}
+		}
+		sc.Status.OSDRemovals = append(sc.Status.OSDRemovals, record)
+		if len(sc.Status.OSDRemovals) > maxOSDRemovalHistory {
+			sc.Status.OSDRemovals = sc.Status.OSDRemovals[len(sc.Status.OSDRemovals)-maxOSDRemovalHistory:]
+		}
+		return r.Client.Status().Update(context.TODO(), sc)
+	})
+	if err != nil {
+		r.Log.Error(err, "Failed to record the OSD removal on StorageCluster.", "StorageCluster", klog.KRef(instance.Namespace, instance.Status.StorageCluster))
+		return err
+	}
+	return nil
+}
+
+func (r *OSDRemovalRequestReconciler) setFailed(instance *ocsv1.OSDRemovalRequest, reason, message string) {
+	now := metav1.Now()
+	instance.Status.Phase = ocsv1.OSDRemovalRequestPhaseFailed
+	instance.Status.CompletionTime = &now
+	util.SetErrorCondition(&instance.Status.Conditions, reason, message)
+	setProgressing(instance, corev1.ConditionFalse, reason, message)
+	conditionsv1.SetStatusCondition(&instance.Status.Conditions, conditionsv1.Condition{
+		Type:    conditionsv1.ConditionDegraded,
+		Status:  corev1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	})
+	r.recorder.ReportIfNotPresent(instance, corev1.EventTypeWarning, util.EventReasonOSDRemovalFailed, message)
+}
+
+// setOSDsFailed marks all the OSDs which were not removed as failed
+func (r *OSDRemovalRequestReconciler) setOSDsFailed(instance *ocsv1.OSDRemovalRequest, message string) {
+	for i := range instance.Status.OSDs {
+		if instance.Status.OSDs[i].Phase == ocsv1.OSDRemovalPending {
+			instance.Status.OSDs[i].Phase = ocsv1.OSDRemovalFailed
+			instance.Status.OSDs[i].Message = message
+		}
+	}
+}
+
+// getJobResult returns the termination message of the removal container
+func (r *OSDRemovalRequestReconciler) getJobResult(job *batchv1.Job) (string, error) {
+	podList := &corev1.PodList{}
+	err := r.Client.List(context.TODO(), podList, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name})
+	if err != nil {
+		return "", fmt.Errorf("failed to list the pods of Job %s: %v", job.Name, err)
+	}
+	for _, pod := range podList.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == removalContainerName && status.State.Terminated != nil {
+				return status.State.Terminated.Message, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// getOSDPVCName returns the name of the PVC backing the OSD, or an empty
+// string if the OSD is not backed by a PVC or its deployment is gone
+func (r *OSDRemovalRequestReconciler) getOSDPVCName(namespace string, id int) (string, error) {
+	deploymentList := &appsv1.DeploymentList{}
+	err := r.Client.List(context.TODO(), deploymentList, client.InNamespace(namespace), client.MatchingLabels{osdIDLabel: fmt.Sprintf("%d", id)})
+	if err != nil {
+		return "", fmt.Errorf("failed to list the deployments of OSD %d: %v", id, err)
+	}
+	for _, deployment := range deploymentList.Items {
+		if pvcName := deployment.Labels[osdPVCLabel]; pvcName != "" {
+			return pvcName, nil
+		}
+	}
+	return "", nil
+}
+
+// getStorageCluster returns the internal StorageCluster being reconciled in
+// the namespace, or nil if there is none
+func (r *OSDRemovalRequestReconciler) getStorageCluster(namespace string) (*ocsv1.StorageCluster, error) {
+	storageClusterList := &ocsv1.StorageClusterList{}
+	if err := r.Client.List(context.TODO(), storageClusterList, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list StorageClusters: %v", err)
+	}
+	for i := range storageClusterList.Items {
+		sc := &storageClusterList.Items[i]
+		if sc.Status.Phase != util.PhaseIgnored && !sc.Spec.ExternalStorage.Enable {
+			return sc, nil
+		}
+	}
+	return nil, nil
+}
+
+// acquireRemovalLease makes the request the holder of the removal Lease of
+// the namespace, which ensures that the requests of a namespace run one at a
+// time. It returns the name of the request holding the Lease, which is
+// another request if that one is still running.
+func (r *OSDRemovalRequestReconciler) acquireRemovalLease(instance *ocsv1.OSDRemovalRequest) (string, error) {
+	lease := &coordinationv1.Lease{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: removalLeaseName, Namespace: instance.Namespace}, lease)
+	if errors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      removalLeaseName,
+				Namespace: instance.Namespace,
+			},
+		}
+		if err := r.setRemovalLeaseHolder(lease, instance); err != nil {
+			return "", err
+		}
+		// Creating the Lease fails if another request acquired it in the
+		// meantime, in which case it is checked again
+		if err := r.Client.Create(context.TODO(), lease); err != nil {
+			return "", fmt.Errorf("failed to create Lease %s: %v", lease.Name, err)
+		}
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get Lease %s: %v", removalLeaseName, err)
+	}
+
+	if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != "" {
+		holder := *lease.Spec.HolderIdentity
+		if holder == instance.Name {
+			return "", nil
+		}
+		request := &ocsv1.OSDRemovalRequest{}
+		err = r.Client.Get(context.TODO(), types.NamespacedName{Name: holder, Namespace: instance.Namespace}, request)
+		if err == nil && request.Status.Phase != ocsv1.OSDRemovalRequestPhaseCompleted &&
+			request.Status.Phase != ocsv1.OSDRemovalRequestPhaseFailed {
+			return holder, nil
+		} else if err != nil && !errors.IsNotFound(err) {
+			return "", fmt.Errorf("failed to get OSDRemovalRequest %s: %v", holder, err)
+		}
+	}
+
+	// The holder finished without releasing the Lease. Updating the Lease
+	// fails if another request took it over in the meantime.
+	if err := r.setRemovalLeaseHolder(lease, instance); err != nil {
+		return "", err
+	}
+	if err := r.Client.Update(context.TODO(), lease); err != nil {
+		return "", fmt.Errorf("failed to update Lease %s: %v", lease.Name, err)
+	}
+	return "", nil
+}
+
+// releaseRemovalLease releases the removal Lease of the namespace if it is
+// held by the request
+func (r *OSDRemovalRequestReconciler) releaseRemovalLease(instance *ocsv1.OSDRemovalRequest) error {
+	lease := &coordinationv1.Lease{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: removalLeaseName, Namespace: instance.Namespace}, lease)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get Lease %s: %v", removalLeaseName, err)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != instance.Name {
+		return nil
+	}
+	if err := r.Client.Delete(context.TODO(), lease); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete Lease %s: %v", lease.Name, err)
+	}
+	return nil
+}
+
+// setRemovalLeaseHolder makes the request the holder and the owner of the
+// Lease, so that the Lease is garbage collected along with it
+func (r *OSDRemovalRequestReconciler) setRemovalLeaseHolder(lease *coordinationv1.Lease, instance *ocsv1.OSDRemovalRequest) error {
+	holder := instance.Name
+	now := metav1.NewMicroTime(time.Now())
+	lease.Spec.HolderIdentity = &holder
+	lease.Spec.AcquireTime = &now
+	lease.OwnerReferences = nil
+	return controllerutil.SetControllerReference(instance, lease, r.Scheme)
+}
+
+func validateOSDRemovalRequest(instance *ocsv1.OSDRemovalRequest) error {
+	if len(instance.Spec.OSDIDs) == 0 {
+		return fmt.Errorf("no OSD to remove")
+	}
+	found := map[int]bool{}
+	for _, id := range instance.Spec.OSDIDs {
+		if id < 0 {
+			return fmt.Errorf("invalid OSD ID %d", id)
+		}
+		if found[id] {
+			return fmt.Errorf("OSD %d is listed more than once", id)
+		}
+		found[id] = true
+	}
+	if instance.Spec.DeletePVs && !instance.Spec.DeletePVCs {
+		return fmt.Errorf("deletePVs requires deletePVCs")
+	}
+	return nil
+}
+
+func getOSDIDs(instance *ocsv1.OSDRemovalRequest, phase ocsv1.OSDRemovalPhase) []int {
+	var ids []int
+	for _, osd := range instance.Status.OSDs {
+		if osd.Phase == phase {
+			ids = append(ids, osd.ID)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func setProgressing(instance *ocsv1.OSDRemovalRequest, status corev1.ConditionStatus, reason, message string) {
+	conditionsv1.SetStatusCondition(&instance.Status.Conditions, conditionsv1.Condition{
+		Type:    conditionsv1.ConditionProgressing,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
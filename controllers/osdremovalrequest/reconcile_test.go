@@ -0,0 +1,309 @@
+package osdremovalrequest
+
+import (
+	"context"
+	"testing"
+
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/util"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const testNamespace = "openshift-storage"
+
+func TestValidateOSDRemovalRequest(t *testing.T) {
+	cases := []struct {
+		label     string
+		spec      ocsv1.OSDRemovalRequestSpec
+		expectErr bool
+	}{
+		{
+			label: "valid request",
+			spec:  ocsv1.OSDRemovalRequestSpec{OSDIDs: []int{0, 1}, DeletePVCs: true, DeletePVs: true},
+		},
+		{
+			label:     "no OSD",
+			spec:      ocsv1.OSDRemovalRequestSpec{},
+			expectErr: true,
+		},
+		{
+			label:     "negative OSD ID",
+			spec:      ocsv1.OSDRemovalRequestSpec{OSDIDs: []int{-1}},
+			expectErr: true,
+		},
+		{
+			label:     "duplicate OSD ID",
+			spec:      ocsv1.OSDRemovalRequestSpec{OSDIDs: []int{2, 2}},
+			expectErr: true,
+		},
+		{
+			label:     "deletePVs without deletePVCs",
+			spec:      ocsv1.OSDRemovalRequestSpec{OSDIDs: []int{2}, DeletePVs: true},
+			expectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		err := validateOSDRemovalRequest(&ocsv1.OSDRemovalRequest{Spec: c.spec})
+		if c.expectErr {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestReconcileOSDRemovalRequest(t *testing.T) {
+	cases := []struct {
+		label             string
+		message           string
+		expectedPhase     string
+		expectedOSDPhases map[int]ocsv1.OSDRemovalPhase
+		expectCleanup     bool
+	}{
+		{
+			label:         "all OSDs removed",
+			message:       "0 Removed purged osd.0\n1 NotFound osd.1 does not exist\n",
+			expectedPhase: ocsv1.OSDRemovalRequestPhaseCompleted,
+			expectedOSDPhases: map[int]ocsv1.OSDRemovalPhase{
+				0: ocsv1.OSDRemovalRemoved,
+				1: ocsv1.OSDRemovalNotFound,
+			},
+			expectCleanup: true,
+		},
+		{
+			label:         "OSD not safe to destroy",
+			message:       "1 Unsafe osd.1 is not safe to destroy\n",
+			expectedPhase: ocsv1.OSDRemovalRequestPhaseFailed,
+			expectedOSDPhases: map[int]ocsv1.OSDRemovalPhase{
+				0: ocsv1.OSDRemovalPending,
+				1: ocsv1.OSDRemovalUnsafe,
+			},
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+
+		sc := &ocsv1.StorageCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "ocs-storagecluster", Namespace: testNamespace},
+		}
+		request := &ocsv1.OSDRemovalRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "remove", Namespace: testNamespace},
+			Spec: ocsv1.OSDRemovalRequestSpec{
+				OSDIDs:     []int{0, 1},
+				DeletePVCs: true,
+				DeletePVs:  true,
+			},
+		}
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rook-ceph-osd-0",
+				Namespace: testNamespace,
+				Labels: map[string]string{
+					osdIDLabel:  "0",
+					osdPVCLabel: "ocs-deviceset-0-data-0",
+				},
+			},
+		}
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "ocs-deviceset-0-data-0", Namespace: testNamespace},
+			Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "local-pv-0"},
+		}
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "local-pv-0"},
+		}
+		reconciler := createFakeOSDRemovalRequestReconciler(t, sc, request, deployment, pvc, pv)
+		key := types.NamespacedName{Name: request.Name, Namespace: testNamespace}
+
+		// The first reconcile launches the Job
+		_, err := reconciler.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+		assert.NoError(t, err)
+		err = reconciler.Client.Get(context.TODO(), key, request)
+		assert.NoError(t, err)
+		assert.Equal(t, ocsv1.OSDRemovalRequestPhaseRunning, request.Status.Phase)
+		assert.Equal(t, sc.Name, request.Status.StorageCluster)
+		assert.Equal(t, "ocs-deviceset-0-data-0", request.Status.OSDs[0].PVCName)
+
+		// The OSDs are stopped before they are removed
+		osdDeployment := &appsv1.Deployment{}
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: deployment.Name, Namespace: testNamespace}, osdDeployment)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(0), *osdDeployment.Spec.Replicas)
+		lease := &coordinationv1.Lease{}
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: removalLeaseName, Namespace: testNamespace}, lease)
+		assert.NoError(t, err)
+		assert.Equal(t, request.Name, *lease.Spec.HolderIdentity)
+
+		job := &batchv1.Job{}
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: request.Status.JobName, Namespace: testNamespace}, job)
+		assert.NoError(t, err)
+
+		// Simulate the completion of the Job
+		job.Status.Succeeded = 1
+		err = reconciler.Client.Status().Update(context.TODO(), job)
+		assert.NoError(t, err)
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      job.Name + "-abcde",
+				Namespace: testNamespace,
+				Labels:    map[string]string{"job-name": job.Name},
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name: removalContainerName,
+						State: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{Message: c.message},
+						},
+					},
+				},
+			},
+		}
+		err = reconciler.Client.Create(context.TODO(), pod)
+		assert.NoError(t, err)
+
+		_, err = reconciler.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+		assert.NoError(t, err)
+		err = reconciler.Client.Get(context.TODO(), key, request)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expectedPhase, request.Status.Phase)
+		assert.NotNil(t, request.Status.CompletionTime)
+		for _, osd := range request.Status.OSDs {
+			assert.Equal(t, c.expectedOSDPhases[osd.ID], osd.Phase)
+		}
+
+		osdDeployment = &appsv1.Deployment{}
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: deployment.Name, Namespace: testNamespace}, osdDeployment)
+		assert.Equal(t, c.expectCleanup, errors.IsNotFound(err))
+		if !c.expectCleanup {
+			// The OSDs which were not removed are started again
+			assert.Equal(t, int32(1), *osdDeployment.Spec.Replicas)
+		}
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: pvc.Name, Namespace: testNamespace}, &corev1.PersistentVolumeClaim{})
+		assert.Equal(t, c.expectCleanup, errors.IsNotFound(err))
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: pv.Name}, &corev1.PersistentVolume{})
+		assert.Equal(t, c.expectCleanup, errors.IsNotFound(err))
+
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: sc.Name, Namespace: testNamespace}, sc)
+		assert.NoError(t, err)
+		assert.Len(t, sc.Status.OSDRemovals, 1)
+		assert.Equal(t, request.Name, sc.Status.OSDRemovals[0].RequestName)
+		assert.Equal(t, c.expectedPhase, sc.Status.OSDRemovals[0].Phase)
+
+		// The next request can run
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: removalLeaseName, Namespace: testNamespace}, &coordinationv1.Lease{})
+		assert.True(t, errors.IsNotFound(err))
+	}
+}
+
+func TestReconcileOSDRemovalRequestWaitsForRunningRequest(t *testing.T) {
+	cases := []struct {
+		label         string
+		holderPhase   string
+		expectWaiting bool
+	}{
+		{
+			label:         "Lease held by a running request",
+			holderPhase:   ocsv1.OSDRemovalRequestPhaseRunning,
+			expectWaiting: true,
+		},
+		{
+			label:         "Lease left behind by a completed request",
+			holderPhase:   ocsv1.OSDRemovalRequestPhaseCompleted,
+			expectWaiting: false,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+
+		sc := &ocsv1.StorageCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "ocs-storagecluster", Namespace: testNamespace},
+		}
+		holder := &ocsv1.OSDRemovalRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "holder", Namespace: testNamespace},
+			Spec:       ocsv1.OSDRemovalRequestSpec{OSDIDs: []int{0}},
+			Status:     ocsv1.OSDRemovalRequestStatus{Phase: c.holderPhase},
+		}
+		holderName := holder.Name
+		lease := &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: removalLeaseName, Namespace: testNamespace},
+			Spec:       coordinationv1.LeaseSpec{HolderIdentity: &holderName},
+		}
+		request := &ocsv1.OSDRemovalRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "waiting", Namespace: testNamespace},
+			Spec:       ocsv1.OSDRemovalRequestSpec{OSDIDs: []int{1}},
+		}
+		reconciler := createFakeOSDRemovalRequestReconciler(t, sc, holder, lease, request)
+		key := types.NamespacedName{Name: request.Name, Namespace: testNamespace}
+
+		result, err := reconciler.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+		assert.NoError(t, err)
+
+		err = reconciler.Client.Get(context.TODO(), key, request)
+		assert.NoError(t, err)
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: removalLeaseName, Namespace: testNamespace}, lease)
+		assert.NoError(t, err)
+		if c.expectWaiting {
+			assert.Equal(t, pendingRequeueInterval, result.RequeueAfter)
+			assert.Equal(t, ocsv1.OSDRemovalRequestPhasePending, request.Status.Phase)
+			assert.Empty(t, request.Status.JobName)
+			assert.Equal(t, holder.Name, *lease.Spec.HolderIdentity)
+		} else {
+			assert.Equal(t, ocsv1.OSDRemovalRequestPhaseRunning, request.Status.Phase)
+			assert.NotEmpty(t, request.Status.JobName)
+			assert.Equal(t, request.Name, *lease.Spec.HolderIdentity)
+		}
+	}
+}
+
+func createFakeScheme(t *testing.T) *runtime.Scheme {
+	scheme, err := ocsv1.SchemeBuilder.Build()
+	if err != nil {
+		assert.Fail(t, "unable to build scheme")
+	}
+	err = corev1.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add corev1 scheme")
+	}
+	err = appsv1.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add appsv1 scheme")
+	}
+	err = batchv1.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add batchv1 scheme")
+	}
+	err = coordinationv1.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add coordinationv1 scheme")
+	}
+	return scheme
+}
+
+func createFakeOSDRemovalRequestReconciler(t *testing.T, obj ...runtime.Object) OSDRemovalRequestReconciler {
+	scheme := createFakeScheme(t)
+	client := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(obj...).Build()
+
+	return OSDRemovalRequestReconciler{
+		Client:    client,
+		Scheme:    scheme,
+		Log:       logf.Log.WithName("controller_osdremovalrequest_test"),
+		RookImage: "rook/ceph:test",
+		recorder:  util.NewEventReporter(&record.FakeRecorder{}),
+	}
+}
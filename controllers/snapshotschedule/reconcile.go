@@ -0,0 +1,366 @@
+package snapshotschedule
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/util"
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// snapshotScheduleLabel is set to the name of the SnapshotSchedule on
+	// its VolumeSnapshots
+	snapshotScheduleLabel = "ocs.openshift.io/snapshot-schedule"
+	// scheduleTimeAnnotation records the schedule time of a VolumeSnapshot,
+	// in RFC 3339 format
+	scheduleTimeAnnotation = "ocs.openshift.io/schedule-time"
+
+	// defaultRetentionCount is the number of snapshots of each PVC kept
+	// when no retention is set
+	defaultRetentionCount = 7
+
+	reasonInvalidSchedule = "InvalidSchedule"
+	reasonSnapshotFailed  = "SnapshotFailed"
+	reasonSnapshotsReady  = "SnapshotsReady"
+)
+
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots;volumesnapshotclasses,verbs=*
+
+// Reconcile takes the VolumeSnapshots of the PVCs selected by a
+// SnapshotSchedule whenever its cron schedule is due, records whether they
+// became ready, and prunes the snapshots beyond its retention.
+func (r *SnapshotScheduleReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+
+	prevLogger := r.Log
+	defer func() { r.Log = prevLogger }()
+	r.Log = r.Log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+
+	instance := &ocsv1.SnapshotSchedule{}
+	if err := r.Client.Get(ctx, request.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			r.Log.Info("No SnapshotSchedule resource.", "SnapshotSchedule", klog.KRef(request.Namespace, request.Name))
+			return reconcile.Result{}, nil
+		}
+		r.Log.Error(err, "Failed to retrieve SnapshotSchedule.", "SnapshotSchedule", klog.KRef(request.Namespace, request.Name))
+		return reconcile.Result{}, err
+	}
+	if !instance.GetDeletionTimestamp().IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	result, err := r.reconcileSchedule(instance, time.Now())
+
+	statusError := r.Client.Status().Update(ctx, instance)
+	if statusError != nil {
+		r.Log.Info("Could not update SnapshotSchedule status.", "SnapshotSchedule", klog.KRef(instance.Namespace, instance.Name))
+	}
+
+	if err != nil {
+		return result, err
+	}
+	return result, statusError
+}
+
+func (r *SnapshotScheduleReconciler) reconcileSchedule(instance *ocsv1.SnapshotSchedule, now time.Time) (reconcile.Result, error) {
+	schedule, err := cron.ParseStandard(instance.Spec.Schedule)
+	if err != nil {
+		err = fmt.Errorf("invalid schedule %q: %v", instance.Spec.Schedule, err)
+	} else {
+		err = validateSnapshotSchedule(instance)
+	}
+	if err != nil {
+		r.Log.Error(err, "Invalid SnapshotSchedule.", "SnapshotSchedule", klog.KRef(instance.Namespace, instance.Name))
+		util.SetErrorCondition(&instance.Status.Conditions, reasonInvalidSchedule, err.Error())
+		r.recorder.ReportIfNotPresent(instance, corev1.EventTypeWarning, util.EventReasonValidationFailed, err.Error())
+		instance.Status.NextScheduleTime = nil
+		return reconcile.Result{}, nil
+	}
+
+	snapshots, err := r.listSnapshots(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	r.updateLastRun(instance, snapshots)
+
+	// Missed runs are not caught up, only the latest one is taken
+	last := instance.CreationTimestamp.Time
+	if instance.Status.LastScheduleTime != nil {
+		last = instance.Status.LastScheduleTime.Time
+	}
+	// The schedule is in UTC, the times it returns are in the location of
+	// the time they follow
+	next := schedule.Next(last.UTC())
+	if !instance.Spec.Suspend && !next.IsZero() && !now.Before(next) {
+		runTime := next
+		for n := schedule.Next(runTime); !n.IsZero() && !n.After(now); n = schedule.Next(n) {
+			runTime = n
+		}
+		if err := r.takeSnapshots(instance, runTime); err != nil {
+			return reconcile.Result{}, err
+		}
+		next = schedule.Next(runTime)
+		if snapshots, err = r.listSnapshots(instance); err != nil {
+			return reconcile.Result{}, err
+		}
+		r.updateLastRun(instance, snapshots)
+	}
+
+	retained, expiry, err := r.pruneSnapshots(instance, snapshots, now)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	instance.Status.Snapshots = retained
+
+	var result reconcile.Result
+	if instance.Spec.Suspend || next.IsZero() {
+		instance.Status.NextScheduleTime = nil
+	} else {
+		instance.Status.NextScheduleTime = &metav1.Time{Time: next}
+		result.RequeueAfter = next.Sub(now)
+	}
+	if !expiry.IsZero() && (result.RequeueAfter == 0 || expiry.Sub(now) < result.RequeueAfter) {
+		result.RequeueAfter = expiry.Sub(now)
+	}
+	return result, nil
+}
+
+// takeSnapshots creates the VolumeSnapshots of the bound PVCs selected by
+// the schedule. A PVC which can not be snapshotted fails the run, without
+// preventing the snapshots of the other PVCs.
+func (r *SnapshotScheduleReconciler) takeSnapshots(instance *ocsv1.SnapshotSchedule, runTime time.Time) error {
+	instance.Status.LastScheduleTime = &metav1.Time{Time: runTime}
+
+	selector, err := metav1.LabelSelectorAsSelector(&instance.Spec.Selector)
+	if err != nil {
+		return err
+	}
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	err = r.Client.List(context.TODO(), pvcList, client.InNamespace(instance.Namespace), client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list PVCs: %v", err)
+	}
+	classes, err := util.ListVolumeSnapshotClasses(r.Client)
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	taken := 0
+	for i := range pvcList.Items {
+		pvc := &pvcList.Items[i]
+		if pvc.Status.Phase != corev1.ClaimBound {
+			r.Log.Info("Skipping unbound PVC.", "PersistentVolumeClaim", klog.KRef(pvc.Namespace, pvc.Name))
+			continue
+		}
+		className, message, err := util.GetVolumeSnapshotClassName(r.Client, instance.Spec.VolumeSnapshotClassName, pvc, classes)
+		if err != nil {
+			return err
+		}
+		if message != "" {
+			failures = append(failures, message)
+			continue
+		}
+
+		volumeSnapshot := newScheduledVolumeSnapshot(instance, pvc.Name, className, runTime)
+		err = r.Client.Create(context.TODO(), volumeSnapshot)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			r.Log.Error(err, "Failed to create VolumeSnapshot.", "VolumeSnapshot", klog.KRef(volumeSnapshot.Namespace, volumeSnapshot.Name))
+			failures = append(failures, fmt.Sprintf("failed to create VolumeSnapshot %s: %v", volumeSnapshot.Name, err))
+			continue
+		}
+		taken++
+	}
+	r.Log.Info("Took scheduled snapshots.", "SnapshotSchedule", klog.KRef(instance.Namespace, instance.Name), "Count", taken)
+
+	if taken == 0 && len(failures) == 0 {
+		failures = append(failures, "no bound PVC matches the selector")
+	}
+	if len(failures) > 0 {
+		r.setRunFailed(instance, strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// updateLastRun records whether the snapshots of the latest run all became
+// ready, or one of them failed
+func (r *SnapshotScheduleReconciler) updateLastRun(instance *ocsv1.SnapshotSchedule, snapshots []snapapi.VolumeSnapshot) {
+	last := instance.Status.LastScheduleTime
+	if last == nil || (instance.Status.LastSuccessTime != nil && instance.Status.LastSuccessTime.Equal(last)) ||
+		(instance.Status.LastFailureTime != nil && instance.Status.LastFailureTime.Equal(last)) {
+		return
+	}
+
+	count := 0
+	ready := true
+	for _, snapshot := range snapshots {
+		if !getScheduleTime(&snapshot).Equal(last.Time) {
+			continue
+		}
+		count++
+		if snapshot.Status != nil && snapshot.Status.Error != nil && snapshot.Status.Error.Message != nil {
+			r.setRunFailed(instance, fmt.Sprintf("VolumeSnapshot %s failed: %s", snapshot.Name, *snapshot.Status.Error.Message))
+			return
+		}
+		if snapshot.Status == nil || snapshot.Status.ReadyToUse == nil || !*snapshot.Status.ReadyToUse {
+			ready = false
+		}
+	}
+	if count == 0 || !ready {
+		return
+	}
+	instance.Status.LastSuccessTime = last.DeepCopy()
+	util.SetCompleteCondition(&instance.Status.Conditions, reasonSnapshotsReady,
+		fmt.Sprintf("the %d snapshots taken at %s are ready", count, last.UTC().Format(time.RFC3339)))
+}
+
+// pruneSnapshots deletes the snapshots of each PVC beyond the retention of
+// the schedule. It returns the number of retained snapshots, and the time
+// the next retained snapshot expires, if any.
+func (r *SnapshotScheduleReconciler) pruneSnapshots(instance *ocsv1.SnapshotSchedule, snapshots []snapapi.VolumeSnapshot, now time.Time) (int, time.Time, error) {
+	maxCount := getRetentionCount(instance)
+	var maxAge time.Duration
+	if instance.Spec.Retention.MaxAge != nil {
+		maxAge = instance.Spec.Retention.MaxAge.Duration
+	}
+
+	byPVC := map[string][]snapapi.VolumeSnapshot{}
+	for _, snapshot := range snapshots {
+		pvcName := ""
+		if snapshot.Spec.Source.PersistentVolumeClaimName != nil {
+			pvcName = *snapshot.Spec.Source.PersistentVolumeClaimName
+		}
+		byPVC[pvcName] = append(byPVC[pvcName], snapshot)
+	}
+
+	retained := 0
+	var expiry time.Time
+	for _, pvcSnapshots := range byPVC {
+		// The most recent first
+		sort.Slice(pvcSnapshots, func(i, j int) bool {
+			return getScheduleTime(&pvcSnapshots[i]).After(getScheduleTime(&pvcSnapshots[j]))
+		})
+		for i := range pvcSnapshots {
+			snapshot := &pvcSnapshots[i]
+			expires := time.Time{}
+			if maxAge > 0 {
+				expires = getScheduleTime(snapshot).Add(maxAge)
+			}
+			if (maxCount > 0 && i >= maxCount) || (!expires.IsZero() && !now.Before(expires)) {
+				r.Log.Info("Pruning VolumeSnapshot.", "VolumeSnapshot", klog.KRef(snapshot.Namespace, snapshot.Name))
+				if err := r.Client.Delete(context.TODO(), snapshot); err != nil && !errors.IsNotFound(err) {
+					return 0, time.Time{}, fmt.Errorf("failed to delete VolumeSnapshot %s: %v", snapshot.Name, err)
+				}
+				continue
+			}
+			retained++
+			if !expires.IsZero() && (expiry.IsZero() || expires.Before(expiry)) {
+				expiry = expires
+			}
+		}
+	}
+	return retained, expiry, nil
+}
+
+func (r *SnapshotScheduleReconciler) listSnapshots(instance *ocsv1.SnapshotSchedule) ([]snapapi.VolumeSnapshot, error) {
+	snapshotList := &snapapi.VolumeSnapshotList{}
+	err := r.Client.List(context.TODO(), snapshotList, client.InNamespace(instance.Namespace),
+		client.MatchingLabels{snapshotScheduleLabel: instance.Name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VolumeSnapshots: %v", err)
+	}
+	return snapshotList.Items, nil
+}
+
+// setRunFailed records the failure of the latest run
+func (r *SnapshotScheduleReconciler) setRunFailed(instance *ocsv1.SnapshotSchedule, message string) {
+	r.Log.Info("Scheduled snapshots failed.", "SnapshotSchedule", klog.KRef(instance.Namespace, instance.Name), "Reason", message)
+	instance.Status.LastFailureTime = instance.Status.LastScheduleTime.DeepCopy()
+	instance.Status.LastFailureMessage = message
+	conditionsv1.SetStatusCondition(&instance.Status.Conditions, conditionsv1.Condition{
+		Type:    conditionsv1.ConditionDegraded,
+		Status:  corev1.ConditionTrue,
+		Reason:  reasonSnapshotFailed,
+		Message: message,
+	})
+	r.recorder.ReportIfNotPresent(instance, corev1.EventTypeWarning, util.EventReasonScheduledSnapshotFailed, message)
+}
+
+func validateSnapshotSchedule(instance *ocsv1.SnapshotSchedule) error {
+	if len(instance.Spec.Selector.MatchLabels) == 0 && len(instance.Spec.Selector.MatchExpressions) == 0 {
+		return fmt.Errorf("the selector must not be empty")
+	}
+	if _, err := metav1.LabelSelectorAsSelector(&instance.Spec.Selector); err != nil {
+		return fmt.Errorf("invalid selector: %v", err)
+	}
+	retention := instance.Spec.Retention
+	if retention.MaxCount != nil && *retention.MaxCount < 1 {
+		return fmt.Errorf("the retention maxCount must be at least 1")
+	}
+	if retention.MaxAge != nil && retention.MaxAge.Duration <= 0 {
+		return fmt.Errorf("the retention maxAge must be positive")
+	}
+	return nil
+}
+
+// getRetentionCount returns the number of snapshots of each PVC to keep, or
+// 0 if only their age is limited
+func getRetentionCount(instance *ocsv1.SnapshotSchedule) int {
+	retention := instance.Spec.Retention
+	if retention.MaxCount != nil {
+		return int(*retention.MaxCount)
+	}
+	if retention.MaxAge != nil {
+		return 0
+	}
+	return defaultRetentionCount
+}
+
+// getScheduleTime returns the schedule time of a VolumeSnapshot, or its
+// creation time if it has none
+func getScheduleTime(snapshot *snapapi.VolumeSnapshot) time.Time {
+	if t, err := time.Parse(time.RFC3339, snapshot.Annotations[scheduleTimeAnnotation]); err == nil {
+		return t
+	}
+	return snapshot.CreationTimestamp.Time
+}
+
+func generateNameForScheduledSnapshot(instance *ocsv1.SnapshotSchedule, pvcName string, runTime time.Time) string {
+	return fmt.Sprintf("%s-%s-%s", instance.Name, pvcName, runTime.UTC().Format("20060102-1504"))
+}
+
+// newScheduledVolumeSnapshot returns the VolumeSnapshot of a PVC for the run
+// of the schedule at runTime
+func newScheduledVolumeSnapshot(instance *ocsv1.SnapshotSchedule, pvcName, className string, runTime time.Time) *snapapi.VolumeSnapshot {
+	return &snapapi.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateNameForScheduledSnapshot(instance, pvcName, runTime),
+			Namespace: instance.Namespace,
+			Labels: labels.Set{
+				snapshotScheduleLabel: instance.Name,
+			},
+			Annotations: map[string]string{
+				scheduleTimeAnnotation: runTime.UTC().Format(time.RFC3339),
+			},
+		},
+		Spec: snapapi.VolumeSnapshotSpec{
+			Source: snapapi.VolumeSnapshotSource{
+				PersistentVolumeClaimName: &pvcName,
+			},
+			VolumeSnapshotClassName: &className,
+		},
+	}
+}
@@ -0,0 +1,399 @@
+package snapshotschedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	testNamespace      = "my-app"
+	testStorageClass   = "ocs-storagecluster-ceph-rbd"
+	testSnapshotClass  = "ocs-storagecluster-rbdplugin-snapclass"
+	testRBDProvisioner = "openshift-storage.rbd.csi.ceph.com"
+)
+
+func TestValidateSnapshotSchedule(t *testing.T) {
+	selector := metav1.LabelSelector{MatchLabels: map[string]string{"app": "my-app"}}
+	zero := int32(0)
+	cases := []struct {
+		label     string
+		spec      ocsv1.SnapshotScheduleSpec
+		expectErr bool
+	}{
+		{
+			label: "valid spec",
+			spec:  ocsv1.SnapshotScheduleSpec{Schedule: "@daily", Selector: selector},
+		},
+		{
+			label:     "empty selector",
+			spec:      ocsv1.SnapshotScheduleSpec{Schedule: "@daily"},
+			expectErr: true,
+		},
+		{
+			label: "invalid selector",
+			spec: ocsv1.SnapshotScheduleSpec{
+				Schedule: "@daily",
+				Selector: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "app", Operator: "Matches"}}},
+			},
+			expectErr: true,
+		},
+		{
+			label:     "zero retention count",
+			spec:      ocsv1.SnapshotScheduleSpec{Schedule: "@daily", Selector: selector, Retention: ocsv1.SnapshotRetention{MaxCount: &zero}},
+			expectErr: true,
+		},
+		{
+			label:     "negative retention age",
+			spec:      ocsv1.SnapshotScheduleSpec{Schedule: "@daily", Selector: selector, Retention: ocsv1.SnapshotRetention{MaxAge: &metav1.Duration{Duration: -time.Hour}}},
+			expectErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		err := validateSnapshotSchedule(&ocsv1.SnapshotSchedule{Spec: c.spec})
+		if c.expectErr {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestReconcileSnapshotSchedule(t *testing.T) {
+	cases := []struct {
+		label                 string
+		schedule              string
+		age                   time.Duration
+		suspend               bool
+		snapshotClass         string
+		provisioner           string
+		selector              map[string]string
+		expectedSnapshotClass string
+		expectFailure         bool
+		expectInvalid         bool
+	}{
+		{
+			label:                 "snapshots taken with the OCS snapshot class",
+			schedule:              "@hourly",
+			age:                   2 * time.Hour,
+			provisioner:           testRBDProvisioner,
+			expectedSnapshotClass: testSnapshotClass,
+		},
+		{
+			label:                 "snapshots taken with the snapshot class of the spec",
+			schedule:              "@hourly",
+			age:                   2 * time.Hour,
+			snapshotClass:         "my-snapclass",
+			provisioner:           "ebs.csi.aws.com",
+			expectedSnapshotClass: "my-snapclass",
+		},
+		{
+			label:         "snapshot class of the spec not found",
+			schedule:      "@hourly",
+			age:           2 * time.Hour,
+			snapshotClass: "missing-snapclass",
+			provisioner:   testRBDProvisioner,
+			expectFailure: true,
+		},
+		{
+			label:       "schedule not due yet",
+			schedule:    "0 0 1 1 *",
+			age:         time.Minute,
+			provisioner: testRBDProvisioner,
+		},
+		{
+			label:       "schedule suspended",
+			schedule:    "@hourly",
+			age:         2 * time.Hour,
+			suspend:     true,
+			provisioner: testRBDProvisioner,
+		},
+		{
+			label:         "PVC not provisioned by OCS",
+			schedule:      "@hourly",
+			age:           2 * time.Hour,
+			provisioner:   "ebs.csi.aws.com",
+			expectFailure: true,
+		},
+		{
+			label:         "no PVC matches the selector",
+			schedule:      "@hourly",
+			age:           2 * time.Hour,
+			provisioner:   testRBDProvisioner,
+			selector:      map[string]string{"app": "other-app"},
+			expectFailure: true,
+		},
+		{
+			label:         "invalid schedule",
+			schedule:      "0 2 * *",
+			age:           2 * time.Hour,
+			provisioner:   testRBDProvisioner,
+			expectInvalid: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		instance := newTestSnapshotSchedule(c.schedule, c.age)
+		instance.Spec.Suspend = c.suspend
+		instance.Spec.VolumeSnapshotClassName = c.snapshotClass
+		if c.selector != nil {
+			instance.Spec.Selector.MatchLabels = c.selector
+		}
+		objs := append(newTestObjects(c.provisioner), instance)
+		reconciler := createFakeSnapshotScheduleReconciler(t, objs...)
+
+		result := reconcileSnapshotSchedule(t, reconciler, instance)
+		snapshots := listVolumeSnapshots(t, reconciler)
+
+		if c.expectInvalid {
+			assert.Equal(t, reconcile.Result{}, result)
+			assert.Nil(t, instance.Status.NextScheduleTime)
+			assert.True(t, conditionsv1.IsStatusConditionFalse(instance.Status.Conditions, ocsv1.ConditionReconcileComplete))
+			assert.Empty(t, snapshots)
+			continue
+		}
+		if c.suspend {
+			assert.Nil(t, instance.Status.NextScheduleTime)
+			assert.Equal(t, time.Duration(0), result.RequeueAfter)
+		} else {
+			assert.NotNil(t, instance.Status.NextScheduleTime)
+			assert.True(t, result.RequeueAfter > 0)
+		}
+		if c.expectFailure {
+			assert.NotNil(t, instance.Status.LastFailureTime)
+			assert.NotEmpty(t, instance.Status.LastFailureMessage)
+			assert.True(t, conditionsv1.IsStatusConditionTrue(instance.Status.Conditions, conditionsv1.ConditionDegraded))
+			assert.Empty(t, snapshots)
+			continue
+		}
+		if c.expectedSnapshotClass == "" {
+			assert.Nil(t, instance.Status.LastScheduleTime)
+			assert.Empty(t, snapshots)
+			continue
+		}
+
+		// Only the latest missed run is taken, and only for the bound PVC
+		assert.NotNil(t, instance.Status.LastScheduleTime)
+		assert.Nil(t, instance.Status.LastFailureTime)
+		assert.Len(t, snapshots, 1)
+		assert.Equal(t, 1, instance.Status.Snapshots)
+		snapshot := snapshots[0]
+		assert.Equal(t, "db", *snapshot.Spec.Source.PersistentVolumeClaimName)
+		assert.Equal(t, c.expectedSnapshotClass, *snapshot.Spec.VolumeSnapshotClassName)
+		assert.True(t, getScheduleTime(&snapshot).Equal(instance.Status.LastScheduleTime.Time))
+		assert.True(t, time.Since(instance.Status.LastScheduleTime.Time) < time.Hour)
+		assert.Nil(t, instance.Status.LastSuccessTime)
+
+		// The run succeeds once all its snapshots are ready
+		ready := true
+		snapshot.Status = &snapapi.VolumeSnapshotStatus{ReadyToUse: &ready}
+		assert.NoError(t, reconciler.Client.Status().Update(context.TODO(), &snapshot))
+		reconcileSnapshotSchedule(t, reconciler, instance)
+		assert.NotNil(t, instance.Status.LastSuccessTime)
+		assert.True(t, instance.Status.LastSuccessTime.Equal(instance.Status.LastScheduleTime))
+		assert.Len(t, listVolumeSnapshots(t, reconciler), 1)
+	}
+}
+
+func TestSnapshotScheduleFailedSnapshot(t *testing.T) {
+	instance := newTestSnapshotSchedule("@hourly", 2*time.Hour)
+	reconciler := createFakeSnapshotScheduleReconciler(t, append(newTestObjects(testRBDProvisioner), instance)...)
+	reconcileSnapshotSchedule(t, reconciler, instance)
+
+	snapshots := listVolumeSnapshots(t, reconciler)
+	assert.Len(t, snapshots, 1)
+	message := "rbd: snapshot failed"
+	snapshots[0].Status = &snapapi.VolumeSnapshotStatus{Error: &snapapi.VolumeSnapshotError{Message: &message}}
+	assert.NoError(t, reconciler.Client.Status().Update(context.TODO(), &snapshots[0]))
+
+	reconcileSnapshotSchedule(t, reconciler, instance)
+	assert.Nil(t, instance.Status.LastSuccessTime)
+	assert.NotNil(t, instance.Status.LastFailureTime)
+	assert.True(t, instance.Status.LastFailureTime.Equal(instance.Status.LastScheduleTime))
+	assert.Contains(t, instance.Status.LastFailureMessage, message)
+	assert.True(t, conditionsv1.IsStatusConditionTrue(instance.Status.Conditions, conditionsv1.ConditionDegraded))
+}
+
+func TestPruneSnapshots(t *testing.T) {
+	two := int32(2)
+	cases := []struct {
+		label             string
+		retention         ocsv1.SnapshotRetention
+		ages              []time.Duration
+		expectedRetained  []time.Duration
+		expectedRequeueIn time.Duration
+	}{
+		{
+			label:            "seven snapshots kept by default",
+			ages:             []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9},
+			expectedRetained: []time.Duration{1, 2, 3, 4, 5, 6, 7},
+		},
+		{
+			label:            "retention count",
+			retention:        ocsv1.SnapshotRetention{MaxCount: &two},
+			ages:             []time.Duration{3, 1, 2},
+			expectedRetained: []time.Duration{1, 2},
+		},
+		{
+			label:             "retention age",
+			retention:         ocsv1.SnapshotRetention{MaxAge: &metav1.Duration{Duration: 4 * time.Hour}},
+			ages:              []time.Duration{1, 3, 5, 9},
+			expectedRetained:  []time.Duration{1, 3},
+			expectedRequeueIn: time.Hour,
+		},
+		{
+			label:             "retention count and age",
+			retention:         ocsv1.SnapshotRetention{MaxCount: &two, MaxAge: &metav1.Duration{Duration: 4 * time.Hour}},
+			ages:              []time.Duration{1, 2, 3, 5},
+			expectedRetained:  []time.Duration{1, 2},
+			expectedRequeueIn: 2 * time.Hour,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		// Suspended, so that no new snapshot is taken
+		instance := newTestSnapshotSchedule("@hourly", 24*time.Hour)
+		instance.Spec.Suspend = true
+		instance.Spec.Retention = c.retention
+		now := time.Now().UTC().Truncate(time.Minute)
+		objs := append(newTestObjects(testRBDProvisioner), instance)
+		for _, age := range c.ages {
+			objs = append(objs, newScheduledVolumeSnapshot(instance, "db", testSnapshotClass, now.Add(-age*time.Hour)))
+		}
+		// Snapshots of other PVCs are retained separately
+		objs = append(objs, newScheduledVolumeSnapshot(instance, "logs", testSnapshotClass, now.Add(-30*time.Minute)))
+		reconciler := createFakeSnapshotScheduleReconciler(t, objs...)
+
+		result := reconcileSnapshotSchedule(t, reconciler, instance)
+		var retained []time.Duration
+		for _, snapshot := range listVolumeSnapshots(t, reconciler) {
+			if *snapshot.Spec.Source.PersistentVolumeClaimName == "db" {
+				retained = append(retained, now.Sub(getScheduleTime(&snapshot))/time.Hour)
+			}
+		}
+		assert.ElementsMatch(t, c.expectedRetained, retained)
+		assert.Equal(t, len(c.expectedRetained)+1, instance.Status.Snapshots)
+		if c.expectedRequeueIn == 0 {
+			assert.Equal(t, time.Duration(0), result.RequeueAfter)
+		} else {
+			assert.True(t, result.RequeueAfter > 0 && result.RequeueAfter <= c.expectedRequeueIn)
+		}
+	}
+}
+
+func newTestSnapshotSchedule(schedule string, age time.Duration) *ocsv1.SnapshotSchedule {
+	return &ocsv1.SnapshotSchedule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "nightly",
+			Namespace:         testNamespace,
+			CreationTimestamp: metav1.Time{Time: time.Now().Add(-age)},
+		},
+		Spec: ocsv1.SnapshotScheduleSpec{
+			Schedule: schedule,
+			Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "my-app"}},
+		},
+	}
+}
+
+// newTestObjects returns a StorageClass of the given provisioner, the OCS
+// RBD VolumeSnapshotClass and another VolumeSnapshotClass, and a bound and an
+// unbound PVC of the StorageClass
+func newTestObjects(provisioner string) []runtime.Object {
+	storageClassName := testStorageClass
+	newPVC := func(name string, phase corev1.PersistentVolumeClaimPhase) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: testNamespace,
+				Labels:    map[string]string{"app": "my-app"},
+			},
+			Spec:   corev1.PersistentVolumeClaimSpec{StorageClassName: &storageClassName},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: phase},
+		}
+	}
+	return []runtime.Object{
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: testStorageClass},
+			Provisioner: provisioner,
+			Parameters:  map[string]string{"clusterID": "openshift-storage"},
+		},
+		&snapapi.VolumeSnapshotClass{
+			ObjectMeta: metav1.ObjectMeta{Name: testSnapshotClass},
+			Driver:     testRBDProvisioner,
+			Parameters: map[string]string{"clusterID": "openshift-storage"},
+		},
+		&snapapi.VolumeSnapshotClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-snapclass"},
+			Driver:     "ebs.csi.aws.com",
+		},
+		newPVC("db", corev1.ClaimBound),
+		newPVC("cache", corev1.ClaimPending),
+	}
+}
+
+func reconcileSnapshotSchedule(t *testing.T, reconciler SnapshotScheduleReconciler, instance *ocsv1.SnapshotSchedule) reconcile.Result {
+	key := types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}
+	result, err := reconciler.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), key, instance))
+	return result
+}
+
+func listVolumeSnapshots(t *testing.T, reconciler SnapshotScheduleReconciler) []snapapi.VolumeSnapshot {
+	volumeSnapshotList := &snapapi.VolumeSnapshotList{}
+	err := reconciler.Client.List(context.TODO(), volumeSnapshotList, client.InNamespace(testNamespace), client.MatchingLabels{snapshotScheduleLabel: "nightly"})
+	assert.NoError(t, err)
+	return volumeSnapshotList.Items
+}
+
+func createFakeScheme(t *testing.T) *runtime.Scheme {
+	scheme, err := ocsv1.SchemeBuilder.Build()
+	if err != nil {
+		assert.Fail(t, "unable to build scheme")
+	}
+	err = corev1.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add corev1 scheme")
+	}
+	err = storagev1.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add storagev1 scheme")
+	}
+	err = snapapi.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add snapshot scheme")
+	}
+	return scheme
+}
+
+func createFakeSnapshotScheduleReconciler(t *testing.T, obj ...runtime.Object) SnapshotScheduleReconciler {
+	scheme := createFakeScheme(t)
+	client := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(obj...).Build()
+
+	return SnapshotScheduleReconciler{
+		Client:   client,
+		Scheme:   scheme,
+		Log:      logf.Log.WithName("controller_snapshotschedule_test"),
+		recorder: util.NewEventReporter(&record.FakeRecorder{}),
+	}
+}
@@ -0,0 +1,44 @@
+package snapshotschedule
+
+import (
+	"github.com/go-logr/logr"
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/util"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// SnapshotScheduleReconciler reconciles a SnapshotSchedule object
+//nolint
+type SnapshotScheduleReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	recorder *util.EventReporter
+}
+
+// SetupWithManager sets up a controller with a manager
+func (r *SnapshotScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = util.NewEventReporter(mgr.GetEventRecorderFor("controller_snapshotschedule"))
+
+	// The snapshots outlive their schedule, so they are not owned by it but
+	// labeled with its name
+	enqueueSchedule := handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		name := obj.GetLabels()[snapshotScheduleLabel]
+		if name == "" {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: obj.GetNamespace()}}}
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ocsv1.SnapshotSchedule{}).
+		Watches(&source.Kind{Type: &snapapi.VolumeSnapshot{}}, enqueueSchedule).
+		Complete(r)
+}
@@ -0,0 +1,149 @@
+package storagecluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	admissionv1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// ValidatingWebhookPath is the path on which the StorageCluster validating webhook is served
+	ValidatingWebhookPath = "/validate"
+	// MutatingWebhookPath is the path on which the StorageCluster mutating webhook is served
+	MutatingWebhookPath = "/mutate"
+)
+
+// +kubebuilder:webhook:path=/validate,mutating=false,failurePolicy=fail,sideEffects=None,groups=ocs.openshift.io,resources=storageclusters,verbs=create;update,versions=v1,name=vstoragecluster.ocs.openshift.io,admissionReviewVersions=v1
+
+// StorageClusterValidator rejects StorageCluster creates and updates that
+// would otherwise only be caught by the reconciler after being persisted.
+type StorageClusterValidator struct {
+	Log     logr.Logger
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &StorageClusterValidator{}
+
+// Handle validates the StorageCluster in the admission request
+func (v *StorageClusterValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	sc := &ocsv1.StorageCluster{}
+	if err := v.decoder.Decode(req, sc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// Never stand in the way of an uninstall, otherwise the finalizer could
+	// not be removed from a StorageCluster that became invalid.
+	if !sc.GetDeletionTimestamp().IsZero() {
+		return admission.Allowed("")
+	}
+
+	// validateStorageCluster may default fields, work on a copy so the
+	// decision here is not based on a modified object
+	if err := validateStorageCluster(sc.DeepCopy(), v.Log); err != nil {
+		v.Log.Info("Rejecting StorageCluster.", "Operation", req.Operation, "Reason", err.Error())
+		return admission.Denied(err.Error())
+	}
+
+	if req.Operation == admissionv1.Update {
+		oldSc := &ocsv1.StorageCluster{}
+		if err := v.decoder.DecodeRaw(req.OldObject, oldSc); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if err := validateStorageClusterUpdate(oldSc, sc); err != nil {
+			v.Log.Info("Rejecting StorageCluster.", "Operation", req.Operation, "Reason", err.Error())
+			return admission.Denied(err.Error())
+		}
+	}
+
+	return admission.Allowed("")
+}
+
+// InjectDecoder injects the decoder into the StorageClusterValidator
+func (v *StorageClusterValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// validateStorageClusterUpdate checks that none of the fields which can not be
+// changed once the StorageCluster is created are being modified
+func validateStorageClusterUpdate(oldSc, newSc *ocsv1.StorageCluster) error {
+	if oldSc.Spec.ExternalStorage.Enable != newSc.Spec.ExternalStorage.Enable {
+		return fmt.Errorf("externalStorage.enable can not be changed once the StorageCluster is created")
+	}
+
+	newDeviceSets := make(map[string]ocsv1.StorageDeviceSet, len(newSc.Spec.StorageDeviceSets))
+	for _, ds := range newSc.Spec.StorageDeviceSets {
+		newDeviceSets[ds.Name] = ds
+	}
+	for _, oldDs := range oldSc.Spec.StorageDeviceSets {
+		newDs, found := newDeviceSets[oldDs.Name]
+		if !found {
+			return fmt.Errorf("StorageDeviceSet %q can not be renamed or removed", oldDs.Name)
+		}
+		if newDs.Replica < oldDs.Replica {
+			return fmt.Errorf("replica of StorageDeviceSet %q can not be lowered from %d to %d", oldDs.Name, oldDs.Replica, newDs.Replica)
+		}
+	}
+	return nil
+}
+
+// +kubebuilder:webhook:path=/mutate,mutating=true,failurePolicy=fail,sideEffects=None,groups=ocs.openshift.io,resources=storageclusters,verbs=create;update,versions=v1,name=mstoragecluster.ocs.openshift.io,admissionReviewVersions=v1
+
+// StorageClusterMutator sets the finalizer and the default uninstall
+// annotations on a StorageCluster before it is persisted, so that the
+// reconciler does not need a separate update for them.
+type StorageClusterMutator struct {
+	Log     logr.Logger
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &StorageClusterMutator{}
+
+// Handle mutates the StorageCluster in the admission request
+func (m *StorageClusterMutator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	sc := &ocsv1.StorageCluster{}
+	if err := m.decoder.Decode(req, sc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if !mutateStorageCluster(sc, m.Log) {
+		return admission.Allowed("")
+	}
+
+	marshaledSc, err := json.Marshal(sc)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledSc)
+}
+
+// InjectDecoder injects the decoder into the StorageClusterMutator
+func (m *StorageClusterMutator) InjectDecoder(d *admission.Decoder) error {
+	m.decoder = d
+	return nil
+}
+
+// mutateStorageCluster adds the finalizer and the default uninstall
+// annotations to the StorageCluster. It returns true if anything was changed.
+func mutateStorageCluster(sc *ocsv1.StorageCluster, reqLogger logr.Logger) bool {
+	// The finalizer is being removed by the reconciler, leave it alone
+	if !sc.GetDeletionTimestamp().IsZero() {
+		return false
+	}
+
+	var updateRequired bool
+	if !contains(sc.GetFinalizers(), storageClusterFinalizer) {
+		sc.ObjectMeta.Finalizers = append(sc.ObjectMeta.Finalizers, storageClusterFinalizer)
+		updateRequired = true
+	}
+	if setDefaultUninstallAnnotations(sc, reqLogger) {
+		updateRequired = true
+	}
+	return updateRequired
+}
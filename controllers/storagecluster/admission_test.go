@@ -0,0 +1,182 @@
+package storagecluster
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	api "github.com/openshift/ocs-operator/api/v1"
+)
+
+func TestValidateStorageClusterUpdate(t *testing.T) {
+	cases := []struct {
+		label         string
+		modify        func(sc *api.StorageCluster)
+		expectAllowed bool
+	}{
+		{
+			label:         "no change",
+			modify:        func(sc *api.StorageCluster) {},
+			expectAllowed: true,
+		},
+		{
+			label: "increase device set count",
+			modify: func(sc *api.StorageCluster) {
+				sc.Spec.StorageDeviceSets[0].Count++
+			},
+			expectAllowed: true,
+		},
+		{
+			label: "add a device set",
+			modify: func(sc *api.StorageCluster) {
+				ds := sc.Spec.StorageDeviceSets[0]
+				ds.Name = "mock-sds-2"
+				sc.Spec.StorageDeviceSets = append(sc.Spec.StorageDeviceSets, ds)
+			},
+			expectAllowed: true,
+		},
+		{
+			label: "lower device set replica",
+			modify: func(sc *api.StorageCluster) {
+				sc.Spec.StorageDeviceSets[0].Replica--
+			},
+			expectAllowed: false,
+		},
+		{
+			label: "rename a device set",
+			modify: func(sc *api.StorageCluster) {
+				sc.Spec.StorageDeviceSets[0].Name = "renamed-sds"
+			},
+			expectAllowed: false,
+		},
+		{
+			label: "flip external storage",
+			modify: func(sc *api.StorageCluster) {
+				sc.Spec.ExternalStorage.Enable = true
+			},
+			expectAllowed: false,
+		},
+	}
+
+	for _, c := range cases {
+		oldSc := createDefaultStorageCluster()
+		oldSc.Spec.StorageDeviceSets = []api.StorageDeviceSet{mockDeviceSets[0]}
+		oldSc.Spec.StorageDeviceSets[0].Replica = 3
+		newSc := oldSc.DeepCopy()
+		c.modify(newSc)
+
+		err := validateStorageClusterUpdate(oldSc, newSc)
+		if c.expectAllowed {
+			assert.NoErrorf(t, err, "[%s]: unexpected error", c.label)
+		} else {
+			assert.Errorf(t, err, "[%s]: expected update to be rejected", c.label)
+		}
+	}
+}
+
+func TestStorageClusterValidatorHandle(t *testing.T) {
+	validator := &StorageClusterValidator{Log: logf.Log.WithName("validator_test")}
+	decoder, err := admission.NewDecoder(createFakeScheme(t))
+	assert.NoError(t, err)
+	assert.NoError(t, validator.InjectDecoder(decoder))
+
+	oldSc := createDefaultStorageCluster()
+	oldSc.Spec.StorageDeviceSets = []api.StorageDeviceSet{mockDeviceSets[0]}
+
+	invalidSc := oldSc.DeepCopy()
+	invalidSc.Spec.Arbiter.Enable = true
+
+	lowerReplicaSc := oldSc.DeepCopy()
+	lowerReplicaSc.Spec.StorageDeviceSets[0].Replica--
+
+	deletingSc := invalidSc.DeepCopy()
+	now := metav1.Now()
+	deletingSc.DeletionTimestamp = &now
+
+	cases := []struct {
+		label         string
+		operation     admissionv1.Operation
+		object        *api.StorageCluster
+		expectAllowed bool
+	}{
+		{
+			label:         "valid create",
+			operation:     admissionv1.Create,
+			object:        oldSc,
+			expectAllowed: true,
+		},
+		{
+			label:         "invalid spec",
+			operation:     admissionv1.Create,
+			object:        invalidSc,
+			expectAllowed: false,
+		},
+		{
+			label:         "immutable field changed",
+			operation:     admissionv1.Update,
+			object:        lowerReplicaSc,
+			expectAllowed: false,
+		},
+		{
+			label:         "StorageCluster being deleted",
+			operation:     admissionv1.Update,
+			object:        deletingSc,
+			expectAllowed: true,
+		},
+	}
+
+	for _, c := range cases {
+		req := admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: c.operation,
+				Object:    runtime.RawExtension{Raw: marshalStorageCluster(t, c.object)},
+				OldObject: runtime.RawExtension{Raw: marshalStorageCluster(t, oldSc)},
+			},
+		}
+		resp := validator.Handle(context.TODO(), req)
+		assert.Equalf(t, c.expectAllowed, resp.Allowed, "[%s]: unexpected admission result", c.label)
+	}
+}
+
+func TestStorageClusterMutatorHandle(t *testing.T) {
+	mutator := &StorageClusterMutator{Log: logf.Log.WithName("mutator_test")}
+	decoder, err := admission.NewDecoder(createFakeScheme(t))
+	assert.NoError(t, err)
+	assert.NoError(t, mutator.InjectDecoder(decoder))
+
+	sc := createDefaultStorageCluster()
+	req := admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: marshalStorageCluster(t, sc)},
+		},
+	}
+	resp := mutator.Handle(context.TODO(), req)
+	assert.True(t, resp.Allowed)
+	assert.NotEmpty(t, resp.Patches)
+
+	// The mutation must be idempotent
+	mutated := sc.DeepCopy()
+	assert.True(t, mutateStorageCluster(mutated, mutator.Log))
+	assert.Contains(t, mutated.GetFinalizers(), storageClusterFinalizer)
+	assert.Equal(t, string(UninstallModeGraceful), mutated.GetAnnotations()[UninstallModeAnnotation])
+	assert.Equal(t, string(CleanupPolicyDelete), mutated.GetAnnotations()[CleanupPolicyAnnotation])
+
+	req.Object = runtime.RawExtension{Raw: marshalStorageCluster(t, mutated)}
+	resp = mutator.Handle(context.TODO(), req)
+	assert.True(t, resp.Allowed)
+	assert.Empty(t, resp.Patches)
+}
+
+func marshalStorageCluster(t *testing.T, sc *api.StorageCluster) []byte {
+	raw, err := json.Marshal(sc)
+	assert.NoError(t, err)
+	return raw
+}
@@ -0,0 +1,184 @@
+package storagecluster
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/defaults"
+	statusutil "github.com/openshift/ocs-operator/controllers/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// defaultAutoExpandStep is the amount Count is increased by when the
+	// policy sets no step. It matches the default Replica.
+	defaultAutoExpandStep = 3
+
+	// defaultAutoExpandCooldown is the minimum time between two expansions
+	// when the policy sets no cooldown
+	defaultAutoExpandCooldown = time.Hour
+)
+
+// validateAutoExpandPolicy checks that the autoExpand policy of the
+// StorageDeviceSet can increase its Count
+func validateAutoExpandPolicy(ds ocsv1.StorageDeviceSet) error {
+	policy := ds.AutoExpand
+	if policy == nil || !policy.Enable {
+		return nil
+	}
+	threshold, err := strconv.ParseFloat(policy.UsageThreshold, 64)
+	if err != nil || threshold <= 0 || threshold >= 1 {
+		return fmt.Errorf("invalid autoExpand usageThreshold %q: must be between 0 and 1", policy.UsageThreshold)
+	}
+	if policy.Step < 0 {
+		return fmt.Errorf("invalid autoExpand step %d: must be at least 1", policy.Step)
+	}
+	if ds.Replica == 0 && getAutoExpandStep(policy)%defaults.DeviceSetReplica != 0 {
+		return fmt.Errorf("invalid autoExpand step %d: must be a multiple of %d with the default replica", policy.Step, defaults.DeviceSetReplica)
+	}
+	if policy.MaxCount < ds.Count {
+		return fmt.Errorf("invalid autoExpand maxCount %d: must not be below count %d", policy.MaxCount, ds.Count)
+	}
+	if policy.Cooldown != nil && policy.Cooldown.Duration < 0 {
+		return fmt.Errorf("invalid autoExpand cooldown %v: must not be negative", policy.Cooldown.Duration)
+	}
+	return nil
+}
+
+func getAutoExpandStep(policy *ocsv1.AutoExpandPolicy) int {
+	if policy.Step == 0 {
+		return defaultAutoExpandStep
+	}
+	return policy.Step
+}
+
+func getAutoExpandCooldown(policy *ocsv1.AutoExpandPolicy) time.Duration {
+	if policy.Cooldown == nil {
+		return defaultAutoExpandCooldown
+	}
+	return policy.Cooldown.Duration
+}
+
+// reconcileAutoExpansion increases the Count of the StorageDeviceSets whose
+// autoExpand policy is triggered by the usage of the Ceph cluster, and
+// reports their capacity in the status. The StorageCluster spec is updated
+// right away, so that the CephCluster is expanded in the same reconcile.
+func (r *StorageClusterReconciler) reconcileAutoExpansion(sc *ocsv1.StorageCluster, now time.Time) error {
+	previous := map[string]ocsv1.DeviceSetCapacityStatus{}
+	for _, status := range sc.Status.DeviceSetCapacity {
+		previous[status.Name] = status
+	}
+
+	usage, usageKnown, err := r.getCephUsageRatio(sc)
+	if err != nil {
+		return err
+	}
+	// The data is only rebalanced once the previous expansion completed
+	expanding := sc.Status.Phase == statusutil.PhaseClusterExpanding
+
+	var statuses []ocsv1.DeviceSetCapacityStatus
+	expanded := false
+	for i := range sc.Spec.StorageDeviceSets {
+		ds := &sc.Spec.StorageDeviceSets[i]
+		policy := ds.AutoExpand
+		if policy == nil || !policy.Enable {
+			continue
+		}
+		status := previous[ds.Name]
+		status.Name = ds.Name
+		if !expanding {
+			status.PendingCapacity = nil
+		}
+
+		threshold, err := strconv.ParseFloat(policy.UsageThreshold, 64)
+		if err != nil {
+			return fmt.Errorf("invalid autoExpand usageThreshold %q of StorageDeviceSet %s: %v", policy.UsageThreshold, ds.Name, err)
+		}
+		if usageKnown && usage >= threshold && !expanding {
+			if r.expandDeviceSet(sc, ds, &status, usage, now) {
+				expanded = true
+			}
+		}
+
+		status.Count = ds.Count
+		status.Capacity = getDeviceSetCapacity(ds, ds.Count)
+		status.MaxCapacity = getDeviceSetCapacity(ds, policy.MaxCount)
+		statuses = append(statuses, status)
+	}
+	sc.Status.DeviceSetCapacity = statuses
+
+	if expanded {
+		// The status is lost on update, it is written back afterwards
+		status := sc.Status.DeepCopy()
+		if err := r.Client.Update(context.TODO(), sc); err != nil {
+			r.Log.Error(err, "Failed to update StorageCluster with the expanded StorageDeviceSets.", "StorageCluster", klog.KRef(sc.Namespace, sc.Name))
+			return err
+		}
+		sc.Status = *status
+	}
+	return nil
+}
+
+// expandDeviceSet increases the Count of the StorageDeviceSet by one step
+// unless its policy forbids it, and reports the decision as an event. It
+// returns true if the Count was increased.
+func (r *StorageClusterReconciler) expandDeviceSet(sc *ocsv1.StorageCluster, ds *ocsv1.StorageDeviceSet,
+	status *ocsv1.DeviceSetCapacityStatus, usage float64, now time.Time) bool {
+	policy := ds.AutoExpand
+
+	if ds.Count >= policy.MaxCount {
+		r.Log.Info("StorageDeviceSet reached its maximum count, not expanding it.", "StorageDeviceSet", ds.Name, "Count", ds.Count)
+		r.recorder.ReportIfNotPresent(sc, corev1.EventTypeWarning, statusutil.EventReasonDeviceSetExpansionSkipped,
+			fmt.Sprintf("Usage of the Ceph cluster is above %s but StorageDeviceSet %s reached its maxCount %d", policy.UsageThreshold, ds.Name, policy.MaxCount))
+		return false
+	}
+
+	cooldown := getAutoExpandCooldown(policy)
+	if status.LastExpansionTime != nil && now.Before(status.LastExpansionTime.Add(cooldown)) {
+		r.Log.Info("StorageDeviceSet expanded recently, not expanding it.", "StorageDeviceSet", ds.Name, "LastExpansionTime", status.LastExpansionTime)
+		r.recorder.ReportIfNotPresent(sc, corev1.EventTypeNormal, statusutil.EventReasonDeviceSetExpansionSkipped,
+			fmt.Sprintf("Usage of the Ceph cluster is above %s but StorageDeviceSet %s is cooling down until %s",
+				policy.UsageThreshold, ds.Name, status.LastExpansionTime.Add(cooldown).UTC().Format(time.RFC3339)))
+		return false
+	}
+
+	count := ds.Count + getAutoExpandStep(policy)
+	if count > policy.MaxCount {
+		count = policy.MaxCount
+	}
+	added := getDeviceSetCapacity(ds, count)
+	added.Sub(getDeviceSetCapacity(ds, ds.Count))
+
+	r.Log.Info("Expanding StorageDeviceSet.", "StorageDeviceSet", ds.Name, "Count", ds.Count, "NewCount", count, "Usage", usage)
+	r.recorder.ReportIfNotPresent(sc, corev1.EventTypeNormal, statusutil.EventReasonDeviceSetExpanded,
+		fmt.Sprintf("Usage of the Ceph cluster is %.2f, expanding StorageDeviceSet %s from count %d to %d", usage, ds.Name, ds.Count, count))
+	ds.Count = count
+	expansionTime := metav1.NewTime(now)
+	status.LastExpansionTime = &expansionTime
+	status.PendingCapacity = &added
+	return true
+}
+
+// getDeviceSetCapacity returns the raw capacity of the StorageDeviceSet with
+// the given Count
+func getDeviceSetCapacity(ds *ocsv1.StorageDeviceSet, count int) resource.Quantity {
+	size := ds.DataPVCTemplate.Spec.Resources.Requests[corev1.ResourceStorage]
+	return *resource.NewQuantity(size.Value()*int64(getDeviceSetOSDCount(ds, count)), resource.BinarySI)
+}
+
+// getDeviceSetOSDCount returns the number of OSDs of the StorageDeviceSet
+// with the given Count
+func getDeviceSetOSDCount(ds *ocsv1.StorageDeviceSet, count int) int {
+	if ds.Replica == 0 {
+		// Count is split over the default number of replicas, see
+		// newStorageClassDeviceSets
+		return (count / defaults.DeviceSetReplica) * defaults.DeviceSetReplica
+	}
+	return count * ds.Replica
+}
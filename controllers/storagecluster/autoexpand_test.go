@@ -0,0 +1,194 @@
+package storagecluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	api "github.com/openshift/ocs-operator/api/v1"
+	statusutil "github.com/openshift/ocs-operator/controllers/util"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newAutoExpandStorageCluster(count int, policy *api.AutoExpandPolicy) *api.StorageCluster {
+	return &api.StorageCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocsinit", Namespace: "openshift-storage"},
+		Spec: api.StorageClusterSpec{
+			StorageDeviceSets: []api.StorageDeviceSet{
+				{
+					Name:       "ocs-deviceset",
+					Count:      count,
+					AutoExpand: policy,
+					DataPVCTemplate: corev1.PersistentVolumeClaim{
+						Spec: corev1.PersistentVolumeClaimSpec{
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceStorage: resource.MustParse("1Ti"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateAutoExpandPolicy(t *testing.T) {
+	cases := []struct {
+		label         string
+		replica       int
+		policy        *api.AutoExpandPolicy
+		expectedError bool
+	}{
+		{
+			label: "no policy",
+		},
+		{
+			label:  "disabled policy",
+			policy: &api.AutoExpandPolicy{UsageThreshold: "2"},
+		},
+		{
+			label:  "valid policy",
+			policy: &api.AutoExpandPolicy{Enable: true, UsageThreshold: "0.7", MaxCount: 12},
+		},
+		{
+			label:         "invalid threshold",
+			policy:        &api.AutoExpandPolicy{Enable: true, UsageThreshold: "1.5", MaxCount: 12},
+			expectedError: true,
+		},
+		{
+			label:         "step not a multiple of the default replica",
+			policy:        &api.AutoExpandPolicy{Enable: true, UsageThreshold: "0.7", Step: 2, MaxCount: 12},
+			expectedError: true,
+		},
+		{
+			label:   "step with an explicit replica",
+			replica: 3,
+			policy:  &api.AutoExpandPolicy{Enable: true, UsageThreshold: "0.7", Step: 2, MaxCount: 12},
+		},
+		{
+			label:         "maxCount below count",
+			policy:        &api.AutoExpandPolicy{Enable: true, UsageThreshold: "0.7", MaxCount: 1},
+			expectedError: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := newAutoExpandStorageCluster(3, c.policy)
+		sc.Spec.StorageDeviceSets[0].Replica = c.replica
+		err := validateAutoExpandPolicy(sc.Spec.StorageDeviceSets[0])
+		if c.expectedError {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestReconcileAutoExpansion(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		label             string
+		count             int
+		usedBytes         uint64
+		phase             string
+		lastExpansionTime *metav1.Time
+		expectedCount     int
+		expectedPending   bool
+	}{
+		{
+			label:         "usage below threshold",
+			count:         3,
+			usedBytes:     50,
+			expectedCount: 3,
+		},
+		{
+			label:           "usage above threshold",
+			count:           3,
+			usedBytes:       80,
+			expectedCount:   6,
+			expectedPending: true,
+		},
+		{
+			label:         "maximum count reached",
+			count:         9,
+			usedBytes:     80,
+			expectedCount: 9,
+		},
+		{
+			label:             "cooling down",
+			count:             3,
+			usedBytes:         80,
+			lastExpansionTime: &metav1.Time{Time: now.Add(-time.Minute)},
+			expectedCount:     3,
+		},
+		{
+			label:             "cooldown elapsed",
+			count:             3,
+			usedBytes:         80,
+			lastExpansionTime: &metav1.Time{Time: now.Add(-2 * time.Hour)},
+			expectedCount:     6,
+			expectedPending:   true,
+		},
+		{
+			label:         "previous expansion in progress",
+			count:         3,
+			usedBytes:     80,
+			phase:         statusutil.PhaseClusterExpanding,
+			expectedCount: 3,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := newAutoExpandStorageCluster(c.count, &api.AutoExpandPolicy{
+			Enable:         true,
+			UsageThreshold: "0.75",
+			MaxCount:       9,
+		})
+		sc.Status.Phase = c.phase
+		if c.lastExpansionTime != nil {
+			sc.Status.DeviceSetCapacity = []api.DeviceSetCapacityStatus{
+				{Name: "ocs-deviceset", LastExpansionTime: c.lastExpansionTime},
+			}
+		}
+		cephCluster := &cephv1.CephCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: generateNameForCephCluster(sc), Namespace: sc.Namespace},
+			Status: cephv1.ClusterStatus{
+				CephStatus: &cephv1.CephStatus{
+					Capacity: cephv1.Capacity{TotalBytes: 100, UsedBytes: c.usedBytes},
+				},
+			},
+		}
+		reconciler := createFakeStorageClusterReconciler(t, sc, cephCluster)
+
+		err := reconciler.reconcileAutoExpansion(sc, now)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expectedCount, sc.Spec.StorageDeviceSets[0].Count)
+
+		// The expanded Count is persisted
+		actual := &api.StorageCluster{}
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: sc.Name, Namespace: sc.Namespace}, actual)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expectedCount, actual.Spec.StorageDeviceSets[0].Count)
+
+		assert.Len(t, sc.Status.DeviceSetCapacity, 1)
+		status := sc.Status.DeviceSetCapacity[0]
+		assert.Equal(t, c.expectedCount, status.Count)
+		assert.Equal(t, int64(9<<40), status.MaxCapacity.Value())
+		assert.Equal(t, int64(c.expectedCount)<<40, status.Capacity.Value())
+		if c.expectedPending {
+			assert.Equal(t, int64(3<<40), status.PendingCapacity.Value())
+			assert.Equal(t, now.Unix(), status.LastExpansionTime.Unix())
+		} else {
+			assert.Nil(t, status.PendingCapacity)
+		}
+	}
+}
@@ -3,18 +3,25 @@ package storagecluster
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	ocsv1 "github.com/openshift/ocs-operator/api/v1"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 type ocsCephBlockPools struct{}
 
+// erasureCodedBlockPoolSuffix is appended to the name of the default
+// CephBlockPool to name the erasure-coded pool holding RBD image data
+const erasureCodedBlockPoolSuffix = "ecdata"
+
 // newCephBlockPoolInstances returns the cephBlockPool instances that should be created
 // on first run.
 func (r *StorageClusterReconciler) newCephBlockPoolInstances(initData *ocsv1.StorageCluster) ([]*cephv1.CephBlockPool, error) {
@@ -31,7 +38,25 @@ func (r *StorageClusterReconciler) newCephBlockPoolInstances(initData *ocsv1.Sto
 			},
 		},
 	}
+	if ec := initData.Spec.ManagedResources.CephBlockPools.ErasureCoded; ec != nil {
+		ret = append(ret, &cephv1.CephBlockPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      generateNameForErasureCodedCephBlockPool(initData),
+				Namespace: initData.Namespace,
+			},
+			Spec: cephv1.PoolSpec{
+				FailureDomain:  getFailureDomain(initData),
+				ErasureCoded:   generateCephErasureCodedSpec(ec),
+				EnableRBDStats: true,
+			},
+		})
+	}
+	for _, pool := range initData.Spec.ManagedResources.CephBlockPools.AdditionalPools {
+		ret = append(ret, newAdditionalCephBlockPool(initData, pool))
+	}
+	ret = append(ret, newTopologyCephBlockPools(initData)...)
 	for _, obj := range ret {
+		setCephBlockPoolMirroring(initData, obj)
 		err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
 		if err != nil {
 			r.Log.Error(err, "Unable to set controller reference for CephBlockPool.", "CephBlockPool", klog.KRef(obj.Namespace, obj.Name))
@@ -41,6 +66,74 @@ func (r *StorageClusterReconciler) newCephBlockPoolInstances(initData *ocsv1.Sto
 	return ret, nil
 }
 
+// newAdditionalCephBlockPool returns the CephBlockPool for an entry of
+// spec.managedResources.cephBlockPools.additionalPools
+func newAdditionalCephBlockPool(initData *ocsv1.StorageCluster, pool ocsv1.AdditionalBlockPool) *cephv1.CephBlockPool {
+	cephBlockPool := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateNameForAdditionalCephBlockPool(initData, pool.Name),
+			Namespace: initData.Namespace,
+		},
+		Spec: cephv1.PoolSpec{
+			FailureDomain:   pool.FailureDomain,
+			DeviceClass:     pool.DeviceClass,
+			CompressionMode: pool.CompressionMode,
+			EnableRBDStats:  true,
+		},
+	}
+	if cephBlockPool.Spec.FailureDomain == "" {
+		cephBlockPool.Spec.FailureDomain = getFailureDomain(initData)
+	}
+	switch {
+	case pool.ErasureCoded != nil:
+		cephBlockPool.Spec.ErasureCoded = generateCephErasureCodedSpec(pool.ErasureCoded)
+	case pool.Replica != 0:
+		cephBlockPool.Spec.Replicated = cephv1.ReplicatedSpec{Size: pool.Replica}
+	default:
+		cephBlockPool.Spec.Replicated = generateCephReplicatedSpec(initData, "")
+	}
+	return cephBlockPool
+}
+
+// validateAdditionalBlockPools checks that the additional CephBlockPools
+// can be turned into uniquely named resources
+func validateAdditionalBlockPools(sc *ocsv1.StorageCluster) error {
+	names := map[string]bool{}
+	for _, pool := range sc.Spec.ManagedResources.CephBlockPools.AdditionalPools {
+		if errs := validation.IsDNS1123Label(pool.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid name %q for additional CephBlockPool: %s", pool.Name, strings.Join(errs, ", "))
+		}
+		// the StorageClass would clash with the thick provisioned one, and
+		// the pool with the erasure-coded one for the default StorageClass
+		if pool.Name == "thick" || pool.Name == erasureCodedBlockPoolSuffix {
+			return fmt.Errorf("invalid name %q for additional CephBlockPool: name is reserved", pool.Name)
+		}
+		if names[pool.Name] {
+			return fmt.Errorf("additional CephBlockPool %q is defined more than once", pool.Name)
+		}
+		names[pool.Name] = true
+	}
+	return nil
+}
+
+// validateBlockPoolsErasureCoding checks that every erasure-coded
+// CephBlockPool spreading its chunks across the failure domain of the
+// StorageCluster can be placed
+func validateBlockPoolsErasureCoding(sc *ocsv1.StorageCluster) error {
+	if err := validateErasureCodedSpec(sc, sc.Spec.ManagedResources.CephBlockPools.ErasureCoded); err != nil {
+		return err
+	}
+	for _, pool := range sc.Spec.ManagedResources.CephBlockPools.AdditionalPools {
+		if pool.FailureDomain != "" && pool.FailureDomain != getFailureDomain(sc) {
+			continue
+		}
+		if err := validateErasureCodedSpec(sc, pool.ErasureCoded); err != nil {
+			return fmt.Errorf("additional CephBlockPool %q: %v", pool.Name, err)
+		}
+	}
+	return nil
+}
+
 // ensureCreated ensures that cephBlockPool resources exist in the desired
 // state.
 func (obj *ocsCephBlockPools) ensureCreated(r *StorageClusterReconciler, instance *ocsv1.StorageCluster) error {
@@ -49,6 +142,15 @@ func (obj *ocsCephBlockPools) ensureCreated(r *StorageClusterReconciler, instanc
 		return nil
 	}
 
+	if err := validateBlockPoolsErasureCoding(instance); err != nil {
+		r.Log.Error(err, "Invalid erasure coding for CephBlockPools.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
+		return err
+	}
+	if err := validateTopologyConstrainedPools(instance); err != nil {
+		r.Log.Error(err, "Invalid topology constrained CephBlockPools.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
+		return err
+	}
+
 	cephBlockPools, err := r.newCephBlockPoolInstances(instance)
 	if err != nil {
 		return err
@@ -60,14 +162,23 @@ func (obj *ocsCephBlockPools) ensureCreated(r *StorageClusterReconciler, instanc
 		switch {
 		case err == nil:
 			if reconcileStrategy == ReconcileStrategyInit {
-				return nil
+				continue
 			}
 			if existing.DeletionTimestamp != nil {
 				r.Log.Info("Unable to restore CephBlockPool because it is marked for deletion.", "CephBlockPool", klog.KRef(existing.Namespace, existing.Name))
 				return fmt.Errorf("failed to restore initialization object %s because it is marked for deletion", existing.Name)
 			}
 
-			r.Log.Info("Restoring original CephBlockPool.", "CephBlockPool", klog.KRef(cephBlockPool.Namespace, cephBlockPool.Name))
+			drifted, err := getDriftedFields(cephBlockPool, &existing, "spec", "metadata.ownerReferences")
+			if err != nil {
+				return err
+			}
+			if len(drifted) == 0 {
+				continue
+			}
+			r.reportDrift(instance, componentBlockPools, "CephBlockPool", cephBlockPool.Namespace, cephBlockPool.Name, drifted)
+
+			r.Log.Info("Restoring original CephBlockPool.", "CephBlockPool", klog.KRef(cephBlockPool.Namespace, cephBlockPool.Name), "Fields", drifted)
 			existing.ObjectMeta.OwnerReferences = cephBlockPool.ObjectMeta.OwnerReferences
 			cephBlockPool.ObjectMeta = existing.ObjectMeta
 			err = r.Client.Update(context.TODO(), cephBlockPool)
@@ -85,6 +196,41 @@ func (obj *ocsCephBlockPools) ensureCreated(r *StorageClusterReconciler, instanc
 		}
 	}
 
+	if reconcileStrategy == ReconcileStrategyInit {
+		return nil
+	}
+	return r.deleteRemovedCephBlockPools(instance, cephBlockPools)
+}
+
+// deleteRemovedCephBlockPools deletes the CephBlockPools owned by the
+// StorageCluster which are no longer desired, i.e. the additional pools
+// removed from the spec. The erasure-coded data pool of the default pool is
+// kept, as it holds the data of the images of the default pool.
+func (r *StorageClusterReconciler) deleteRemovedCephBlockPools(instance *ocsv1.StorageCluster, cephBlockPools []*cephv1.CephBlockPool) error {
+	desired := map[string]bool{
+		generateNameForErasureCodedCephBlockPool(instance): true,
+	}
+	for _, cephBlockPool := range cephBlockPools {
+		desired[cephBlockPool.Name] = true
+	}
+
+	existing := &cephv1.CephBlockPoolList{}
+	err := r.Client.List(context.TODO(), existing, client.InNamespace(instance.Namespace))
+	if err != nil {
+		return fmt.Errorf("failed to list CephBlockPools: %v", err)
+	}
+	for i := range existing.Items {
+		cephBlockPool := &existing.Items[i]
+		if desired[cephBlockPool.Name] || !metav1.IsControlledBy(cephBlockPool, instance) || cephBlockPool.DeletionTimestamp != nil {
+			continue
+		}
+		r.Log.Info("Deleting CephBlockPool removed from the StorageCluster.", "CephBlockPool", klog.KRef(cephBlockPool.Namespace, cephBlockPool.Name))
+		err = r.Client.Delete(context.TODO(), cephBlockPool)
+		if err != nil && !errors.IsNotFound(err) {
+			r.Log.Error(err, "Failed to delete CephBlockPool.", "CephBlockPool", klog.KRef(cephBlockPool.Namespace, cephBlockPool.Name))
+			return err
+		}
+	}
 	return nil
 }
 
@@ -96,7 +242,10 @@ func (obj *ocsCephBlockPools) ensureDeleted(r *StorageClusterReconciler, sc *ocs
 		return err
 	}
 
-	for _, cephBlockPool := range cephBlockPools {
+	// Delete in reverse order so that the default pool, which holds the
+	// image metadata of erasure-coded additional pools, goes last
+	for i := len(cephBlockPools) - 1; i >= 0; i-- {
+		cephBlockPool := cephBlockPools[i]
 		err := r.Client.Get(context.TODO(), types.NamespacedName{Name: cephBlockPool.Name, Namespace: sc.Namespace}, foundCephBlockPool)
 		if err != nil {
 			if errors.IsNotFound(err) {
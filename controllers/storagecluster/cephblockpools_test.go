@@ -4,9 +4,13 @@ import (
 	"context"
 	"testing"
 
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/stretchr/testify/assert"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -56,3 +60,183 @@ func assertCephBlockPools(t *testing.T, reconciler StorageClusterReconciler, cr
 	assert.Equal(t, expectedCbp[0].ObjectMeta.Name, actualCbp.ObjectMeta.Name)
 	assert.Equal(t, expectedCbp[0].Spec, actualCbp.Spec)
 }
+
+func TestAdditionalCephBlockPools(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	cr := createDefaultStorageCluster()
+	cr.Status.FailureDomainValues = []string{"zone1", "zone2", "zone3"}
+	cr.Spec.ManagedResources.CephBlockPools.AdditionalPools = []api.AdditionalBlockPool{
+		{
+			Name:        "cheap",
+			Replica:     2,
+			DeviceClass: "hdd",
+		},
+		{
+			Name:            "db",
+			DeviceClass:     "nvme",
+			CompressionMode: "aggressive",
+			FailureDomain:   "host",
+		},
+		{
+			Name:         "ec",
+			ErasureCoded: &api.ErasureCodedSpec{DataChunks: 2, CodingChunks: 1},
+		},
+	}
+
+	var obj ocsCephBlockPools
+	err := obj.ensureCreated(&reconciler, cr)
+	assert.NoError(t, err)
+
+	pools := map[string]*cephv1.CephBlockPool{}
+	for _, name := range []string{"ocsinit-cephblockpool", "ocsinit-cephblockpool-cheap", "ocsinit-cephblockpool-db", "ocsinit-cephblockpool-ec"} {
+		pool := &cephv1.CephBlockPool{}
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: cr.Namespace}, pool)
+		assert.NoErrorf(t, err, "CephBlockPool %q was not created", name)
+		pools[name] = pool
+	}
+	assert.Equal(t, uint(2), pools["ocsinit-cephblockpool-cheap"].Spec.Replicated.Size)
+	assert.Equal(t, "hdd", pools["ocsinit-cephblockpool-cheap"].Spec.DeviceClass)
+	assert.Equal(t, getFailureDomain(cr), pools["ocsinit-cephblockpool-cheap"].Spec.FailureDomain)
+	assert.Equal(t, getCephPoolReplicatedSize(cr), pools["ocsinit-cephblockpool-db"].Spec.Replicated.Size)
+	assert.Equal(t, "aggressive", pools["ocsinit-cephblockpool-db"].Spec.CompressionMode)
+	assert.Equal(t, "host", pools["ocsinit-cephblockpool-db"].Spec.FailureDomain)
+	assert.Equal(t, uint(2), pools["ocsinit-cephblockpool-ec"].Spec.ErasureCoded.DataChunks)
+	assert.Equal(t, uint(1), pools["ocsinit-cephblockpool-ec"].Spec.ErasureCoded.CodingChunks)
+	assert.Equal(t, uint(0), pools["ocsinit-cephblockpool-ec"].Spec.Replicated.Size)
+
+	sccs, err := reconciler.newStorageClassConfigurations(cr)
+	assert.NoError(t, err)
+	storageClasses := map[string]StorageClassConfiguration{}
+	for _, scc := range sccs {
+		storageClasses[scc.storageClass.Name] = scc
+	}
+	assert.Equal(t, "ocsinit-cephblockpool-cheap", storageClasses["ocsinit-ceph-rbd-cheap"].storageClass.Parameters["pool"])
+	assert.Equal(t, "ocsinit-cephblockpool", storageClasses["ocsinit-ceph-rbd-ec"].storageClass.Parameters["pool"])
+	assert.Equal(t, "ocsinit-cephblockpool-ec", storageClasses["ocsinit-ceph-rbd-ec"].storageClass.Parameters["dataPool"])
+
+	vsccs := newSnapshotClassConfigurations(cr)
+	snapshotClassNames := []string{}
+	for _, vscc := range vsccs {
+		snapshotClassNames = append(snapshotClassNames, vscc.snapshotClass.Name)
+	}
+	for _, pool := range cr.Spec.ManagedResources.CephBlockPools.AdditionalPools {
+		assert.Contains(t, snapshotClassNames, generateNameForAdditionalSnapshotClass(cr, rbdSnapshotter, pool.Name))
+	}
+}
+
+func TestAdditionalCephBlockPoolRemoved(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	cr := createDefaultStorageCluster()
+	cr.Spec.ManagedResources.CephBlockPools.AdditionalPools = []api.AdditionalBlockPool{
+		{Name: "cheap", Replica: 2},
+		{Name: "db"},
+	}
+
+	var pools ocsCephBlockPools
+	var storageClasses ocsStorageClass
+	var snapshotClasses ocsSnapshotClass
+	ensureCreated := func() {
+		assert.NoError(t, pools.ensureCreated(&reconciler, cr))
+		assert.NoError(t, storageClasses.ensureCreated(&reconciler, cr))
+		assert.NoError(t, snapshotClasses.ensureCreated(&reconciler, cr))
+	}
+	ensureCreated()
+
+	// Removing a pool from the spec deletes its pool, StorageClass and
+	// SnapshotClass, and leaves the other pools alone
+	cr.Spec.ManagedResources.CephBlockPools.AdditionalPools = cr.Spec.ManagedResources.CephBlockPools.AdditionalPools[1:]
+	ensureCreated()
+
+	err := reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephblockpool-cheap", Namespace: cr.Namespace}, &cephv1.CephBlockPool{})
+	assert.True(t, errors.IsNotFound(err))
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-ceph-rbd-cheap"}, &storagev1.StorageClass{})
+	assert.True(t, errors.IsNotFound(err))
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: generateNameForAdditionalSnapshotClass(cr, rbdSnapshotter, "cheap")}, &snapapi.VolumeSnapshotClass{})
+	assert.True(t, errors.IsNotFound(err))
+
+	for _, name := range []string{"ocsinit-cephblockpool", "ocsinit-cephblockpool-db"} {
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: cr.Namespace}, &cephv1.CephBlockPool{})
+		assert.NoErrorf(t, err, "CephBlockPool %q was deleted", name)
+	}
+	for _, name := range []string{"ocsinit-ceph-rbd", "ocsinit-ceph-rbd-db"} {
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name}, &storagev1.StorageClass{})
+		assert.NoErrorf(t, err, "StorageClass %q was deleted", name)
+	}
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: generateNameForAdditionalSnapshotClass(cr, rbdSnapshotter, "db")}, &snapapi.VolumeSnapshotClass{})
+	assert.NoError(t, err)
+}
+
+func TestValidateAdditionalBlockPools(t *testing.T) {
+	cases := []struct {
+		label         string
+		pools         []api.AdditionalBlockPool
+		expectedError bool
+	}{
+		{
+			label: "unique pools",
+			pools: []api.AdditionalBlockPool{{Name: "a"}, {Name: "b"}},
+		},
+		{
+			label:         "duplicate pools",
+			pools:         []api.AdditionalBlockPool{{Name: "a"}, {Name: "a"}},
+			expectedError: true,
+		},
+		{
+			label:         "invalid name",
+			pools:         []api.AdditionalBlockPool{{Name: "Not_Valid"}},
+			expectedError: true,
+		},
+		{
+			label:         "reserved name",
+			pools:         []api.AdditionalBlockPool{{Name: "thick"}},
+			expectedError: true,
+		},
+	}
+
+	for _, c := range cases {
+		sc := createDefaultStorageCluster()
+		sc.Spec.ManagedResources.CephBlockPools.AdditionalPools = c.pools
+		err := validateAdditionalBlockPools(sc)
+		if c.expectedError {
+			assert.Errorf(t, err, "[%s]: expected error", c.label)
+		} else {
+			assert.NoErrorf(t, err, "[%s]: unexpected error", c.label)
+		}
+	}
+}
+
+func TestCephBlockPoolsErasureCoded(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	cr := createDefaultStorageCluster()
+	cr.Status.FailureDomainValues = []string{"zone1", "zone2", "zone3"}
+	cr.Spec.ManagedResources.CephBlockPools.ErasureCoded = &api.ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}
+
+	var obj ocsCephBlockPools
+	assert.NoError(t, obj.ensureCreated(&reconciler, cr))
+
+	ecPool := &cephv1.CephBlockPool{}
+	err := reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephblockpool-ecdata", Namespace: cr.Namespace}, ecPool)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), ecPool.Spec.ErasureCoded.DataChunks)
+	assert.Equal(t, uint(1), ecPool.Spec.ErasureCoded.CodingChunks)
+
+	for _, thick := range []bool{false, true} {
+		scc := newCephBlockPoolStorageClassConfiguration(cr, thick)
+		assert.Equal(t, "ocsinit-cephblockpool", scc.storageClass.Parameters["pool"])
+		assert.Equal(t, "ocsinit-cephblockpool-ecdata", scc.storageClass.Parameters["dataPool"])
+	}
+
+	// Additional pools are only checked against the failure domains of the
+	// StorageCluster if they use the same failure domain
+	cr.Spec.ManagedResources.CephBlockPools.ErasureCoded = nil
+	cr.Spec.ManagedResources.CephBlockPools.AdditionalPools = []api.AdditionalBlockPool{
+		{
+			Name:          "ec-host",
+			FailureDomain: "host",
+			ErasureCoded:  &api.ErasureCodedSpec{DataChunks: 4, CodingChunks: 2},
+		},
+	}
+	assert.NoError(t, validateBlockPoolsErasureCoding(cr))
+	cr.Spec.ManagedResources.CephBlockPools.AdditionalPools[0].FailureDomain = ""
+	assert.Error(t, validateBlockPoolsErasureCoding(cr))
+}
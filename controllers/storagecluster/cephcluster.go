@@ -4,12 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"reflect"
 	"strconv"
 	"strings"
 
 	"github.com/go-logr/logr"
-	v1 "github.com/openshift/api/config/v1"
 	objectreferencesv1 "github.com/openshift/custom-resource-status/objectreferences/v1"
 	ocsv1 "github.com/openshift/ocs-operator/api/v1"
 	"github.com/openshift/ocs-operator/controllers/defaults"
@@ -77,62 +75,22 @@ func (obj *ocsCephCluster) ensureCreated(r *StorageClusterReconciler, sc *ocsv1.
 		return fmt.Errorf("'StorageDeviceSets' should not be initialized in an external CephCluster")
 	}
 
-	for i, ds := range sc.Spec.StorageDeviceSets {
-		sc.Spec.StorageDeviceSets[i].Config.TuneSlowDeviceClass = false
-		sc.Spec.StorageDeviceSets[i].Config.TuneFastDeviceClass = false
-
-		diskSpeed, err := r.checkTuneStorageDevices(ds)
-		if err != nil {
-			return fmt.Errorf("Failed to check for known device types: %+v", err)
-		}
-		switch diskSpeed {
-		case diskSpeedSlow:
-			sc.Spec.StorageDeviceSets[i].Config.TuneSlowDeviceClass = true
-		case diskSpeedFast:
-			sc.Spec.StorageDeviceSets[i].Config.TuneFastDeviceClass = true
-		default:
-		}
-	}
-
-	if isMultus(sc.Spec.Network) {
-		err := validateMultusSelectors(sc.Spec.Network.Selectors)
-		if err != nil {
-			r.Log.Error(err, "Failed to validate Multus Selectors specified in StorageCluster.", "StorageCluster", klog.KRef(sc.Namespace, sc.Name))
-			return err
-		}
+	cephCluster, err := r.newCephClusterInstance(sc)
+	if err != nil {
+		return err
 	}
 
-	var cephCluster *cephv1.CephCluster
-	// Define a new CephCluster object
-	if sc.Spec.ExternalStorage.Enable {
-		cephCluster = newExternalCephCluster(sc, r.images.Ceph, r.monitoringIP, r.monitoringPort)
-	} else {
-		kmsConfigMap, err := getKMSConfigMap(KMSConfigMapName, sc, r.Client)
+	// OSDs which cannot be placed would stay Pending, so the StorageDeviceSets
+	// are held back while the storage nodes cannot host them, unless the
+	// feasibility is not enforced. This is reported by the
+	// CapacityInsufficient condition either way.
+	feasible := true
+	if !sc.Spec.ExternalStorage.Enable {
+		feasible, err = r.checkCapacityFeasibility(sc)
 		if err != nil {
-			r.Log.Error(err, "Failed to procure KMS ConfigMap.", "KMSConfigMap", klog.KRef(sc.Namespace, KMSConfigMapName))
+			r.Log.Error(err, "Failed to check the capacity of the storage nodes.", "StorageCluster", klog.KRef(sc.Namespace, sc.Name))
 			return err
 		}
-		if kmsConfigMap != nil {
-			if err = reachKMSProvider(kmsConfigMap); err != nil {
-				r.Log.Error(err, "Address provided in KMS ConfigMap is not reachable.", "KMSConfigMap", klog.KRef(kmsConfigMap.Namespace, kmsConfigMap.Name))
-				return err
-			}
-		}
-		cephCluster = newCephCluster(sc, r.images.Ceph, r.nodeCount, r.serverVersion, kmsConfigMap, r.Log)
-	}
-
-	// Set StorageCluster instance as the owner and controller
-	if err := controllerutil.SetControllerReference(sc, cephCluster, r.Scheme); err != nil {
-		r.Log.Error(err, "Unable to set controller reference for CephCluster.", "CephCluster", klog.KRef(cephCluster.Namespace, cephCluster.Name))
-		return err
-	}
-
-	platform, err := r.platform.GetPlatform(r.Client)
-	if err != nil {
-		r.Log.Error(err, "Failed to get Platform.", "Platform", platform)
-	} else if platform == v1.IBMCloudPlatformType || platform == IBMCloudCosPlatformType {
-		r.Log.Info("Increasing Mon failover timeout to 15m.", "Platform", platform)
-		cephCluster.Spec.HealthCheck.DaemonHealth.Monitor.Timeout = "15m"
 	}
 
 	// Check if this CephCluster already exists
@@ -140,6 +98,12 @@ func (obj *ocsCephCluster) ensureCreated(r *StorageClusterReconciler, sc *ocsv1.
 	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: cephCluster.Name, Namespace: cephCluster.Namespace}, found)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			if !feasible {
+				r.reportCapacityInsufficient(sc)
+				if isFeasibilityEnforced(sc) {
+					return fmt.Errorf("not creating CephCluster as the storage nodes cannot host the OSDs of the StorageDeviceSets")
+				}
+			}
 			if sc.Spec.ExternalStorage.Enable {
 				r.Log.Info("Creating external CephCluster.", "CephCluster", klog.KRef(cephCluster.Namespace, cephCluster.Name))
 			} else {
@@ -210,26 +174,29 @@ func (obj *ocsCephCluster) ensureCreated(r *StorageClusterReconciler, sc *ocsv1.
 		}
 	}
 
+	// Keep the current StorageClassDeviceSets rather than expanding them
+	// beyond the capacity of the storage nodes, unless the feasibility is
+	// not enforced
+	if !feasible && isCephClusterExpanding(found, cephCluster) {
+		r.reportCapacityInsufficient(sc)
+		if isFeasibilityEnforced(sc) {
+			r.Log.Info("Not expanding CephCluster as the storage nodes cannot host the new OSDs.", "CephCluster", klog.KRef(found.Namespace, found.Name))
+			cephCluster.Spec.Storage.StorageClassDeviceSets = found.Spec.Storage.StorageClassDeviceSets
+		}
+	}
+
+	disableRemovedMgrModules(&cephCluster.Spec.Mgr, found.Spec.Mgr)
+
 	// Update the CephCluster if it is not in the desired state
-	if !reflect.DeepEqual(cephCluster.Spec, found.Spec) {
-		r.Log.Info("Updating spec for CephCluster.", "CephCluster", klog.KRef(found.Namespace, found.Name))
-		if !sc.Spec.ExternalStorage.Enable {
-			// Check if Cluster is Expanding
-			if len(found.Spec.Storage.StorageClassDeviceSets) < len(cephCluster.Spec.Storage.StorageClassDeviceSets) {
-				r.phase = statusutil.PhaseClusterExpanding
-			} else if len(found.Spec.Storage.StorageClassDeviceSets) == len(cephCluster.Spec.Storage.StorageClassDeviceSets) {
-				for _, countInFoundSpec := range found.Spec.Storage.StorageClassDeviceSets {
-					for _, countInCephClusterSpec := range cephCluster.Spec.Storage.StorageClassDeviceSets {
-						if countInFoundSpec.Name == countInCephClusterSpec.Name && countInCephClusterSpec.Count > countInFoundSpec.Count {
-							r.phase = statusutil.PhaseClusterExpanding
-							break
-						}
-					}
-					if r.phase == statusutil.PhaseClusterExpanding {
-						break
-					}
-				}
-			}
+	drifted, err := getDriftedFields(cephCluster, found, "spec")
+	if err != nil {
+		return err
+	}
+	if len(drifted) > 0 {
+		r.Log.Info("Updating spec for CephCluster.", "CephCluster", klog.KRef(found.Namespace, found.Name), "Fields", drifted)
+		r.reportDrift(sc, componentCephCluster, "CephCluster", found.Namespace, found.Name, drifted)
+		if !sc.Spec.ExternalStorage.Enable && isCephClusterExpanding(found, cephCluster) {
+			r.phase = statusutil.PhaseClusterExpanding
 		}
 		found.Spec = cephCluster.Spec
 		if err := r.Client.Update(context.TODO(), found); err != nil {
@@ -238,9 +205,44 @@ func (obj *ocsCephCluster) ensureCreated(r *StorageClusterReconciler, sc *ocsv1.
 		}
 	}
 
+	if !sc.Spec.ExternalStorage.Enable {
+		return r.reconcileMonStatus(sc, found)
+	}
 	return nil
 }
 
+// reportCapacityInsufficient reports that the StorageDeviceSets do not fit,
+// and that they are not applied to the CephCluster if the feasibility is
+// enforced
+func (r *StorageClusterReconciler) reportCapacityInsufficient(sc *ocsv1.StorageCluster) {
+	if isFeasibilityEnforced(sc) {
+		reason := capacityInsufficientReason
+		message := fmt.Sprintf("StorageDeviceSets are not applied, see the %s condition", ocsv1.ConditionCapacityInsufficient)
+		statusutil.SetProgressingCondition(&r.conditions, reason, message)
+	}
+	r.recorder.ReportIfNotPresent(sc, corev1.EventTypeWarning, statusutil.EventReasonCapacityInsufficient,
+		"Storage nodes cannot host the OSDs of the StorageDeviceSets, see the "+string(ocsv1.ConditionCapacityInsufficient)+" condition")
+}
+
+// isCephClusterExpanding returns true if updating the found CephCluster to
+// the desired one adds StorageClassDeviceSets or increases their count
+func isCephClusterExpanding(found, desired *cephv1.CephCluster) bool {
+	foundDeviceSets := found.Spec.Storage.StorageClassDeviceSets
+	desiredDeviceSets := desired.Spec.Storage.StorageClassDeviceSets
+	if len(foundDeviceSets) < len(desiredDeviceSets) {
+		return true
+	} else if len(foundDeviceSets) == len(desiredDeviceSets) {
+		for _, countInFoundSpec := range foundDeviceSets {
+			for _, countInCephClusterSpec := range desiredDeviceSets {
+				if countInFoundSpec.Name == countInCephClusterSpec.Name && countInCephClusterSpec.Count > countInFoundSpec.Count {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 // ensureDeleted deletes the CephCluster owned by the StorageCluster
 func (obj *ocsCephCluster) ensureDeleted(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) error {
 	cephCluster := &cephv1.CephCluster{}
@@ -276,8 +278,63 @@ func (obj *ocsCephCluster) ensureDeleted(r *StorageClusterReconciler, sc *ocsv1.
 
 }
 
+// newCephClusterInstance returns the CephCluster in the desired state for
+// the StorageCluster, with the StorageCluster as its owner
+func (r *StorageClusterReconciler) newCephClusterInstance(sc *ocsv1.StorageCluster) (*cephv1.CephCluster, error) {
+	for i, ds := range sc.Spec.StorageDeviceSets {
+		sc.Spec.StorageDeviceSets[i].Config.TuneSlowDeviceClass = false
+		sc.Spec.StorageDeviceSets[i].Config.TuneFastDeviceClass = false
+
+		diskSpeed, err := r.checkTuneStorageDevices(ds)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to check for known device types: %+v", err)
+		}
+		switch diskSpeed {
+		case diskSpeedSlow:
+			sc.Spec.StorageDeviceSets[i].Config.TuneSlowDeviceClass = true
+		case diskSpeedFast:
+			sc.Spec.StorageDeviceSets[i].Config.TuneFastDeviceClass = true
+		default:
+		}
+	}
+
+	var cephCluster *cephv1.CephCluster
+	// Define a new CephCluster object
+	if sc.Spec.ExternalStorage.Enable {
+		cephCluster = newExternalCephCluster(sc, r.images.Ceph, r.monitoringIP, r.monitoringPort)
+	} else {
+		kmsConfigMap, err := getKMSConfigMap(KMSConfigMapName, sc, r.Client)
+		if err != nil {
+			r.Log.Error(err, "Failed to procure KMS ConfigMap.", "KMSConfigMap", klog.KRef(sc.Namespace, KMSConfigMapName))
+			return nil, err
+		}
+		if kmsConfigMap != nil {
+			if err = reachKMSProvider(kmsConfigMap); err != nil {
+				r.Log.Error(err, "Address provided in KMS ConfigMap is not reachable.", "KMSConfigMap", klog.KRef(kmsConfigMap.Namespace, kmsConfigMap.Name))
+				return nil, err
+			}
+		}
+		cephCluster = newCephCluster(sc, r.images.Ceph, r.serverVersion, kmsConfigMap, r.Log)
+	}
+
+	// Set StorageCluster instance as the owner and controller
+	if err := controllerutil.SetControllerReference(sc, cephCluster, r.Scheme); err != nil {
+		r.Log.Error(err, "Unable to set controller reference for CephCluster.", "CephCluster", klog.KRef(cephCluster.Namespace, cephCluster.Name))
+		return nil, err
+	}
+
+	platform, err := r.platform.GetPlatform(r.Client)
+	if err != nil {
+		r.Log.Error(err, "Failed to get Platform.", "Platform", platform)
+		platform = ""
+	}
+	cephCluster.Spec.HealthCheck = generateHealthCheckSpec(sc, platform)
+
+	return cephCluster, nil
+}
+
 // newCephCluster returns a CephCluster object.
-func newCephCluster(sc *ocsv1.StorageCluster, cephImage string, nodeCount int, serverVersion *version.Info, kmsConfigMap *corev1.ConfigMap, reqLogger logr.Logger) *cephv1.CephCluster {
+func newCephCluster(sc *ocsv1.StorageCluster, cephImage string, serverVersion *version.Info, kmsConfigMap *corev1.ConfigMap, reqLogger logr.Logger) *cephv1.CephCluster {
 	labels := map[string]string{
 		"app": sc.Name,
 	}
@@ -293,7 +350,7 @@ func newCephCluster(sc *ocsv1.StorageCluster, cephImage string, nodeCount int, s
 				Image:            cephImage,
 				AllowUnsupported: allowUnsupportedCephVersion(),
 			},
-			Mon:             generateMonSpec(sc, nodeCount),
+			Mon:             generateMonSpec(sc),
 			Mgr:             generateMgrSpec(sc),
 			DataDirHostPath: "/var/lib/rook",
 			DisruptionManagement: cephv1.DisruptionManagementSpec{
@@ -310,7 +367,7 @@ func newCephCluster(sc *ocsv1.StorageCluster, cephImage string, nodeCount int, s
 			},
 			Monitoring: cephv1.MonitoringSpec{
 				Enabled:        true,
-				RulesNamespace: "openshift-storage",
+				RulesNamespace: sc.Namespace,
 			},
 			Storage: rook.StorageScopeSpec{
 				StorageClassDeviceSets: newStorageClassDeviceSets(sc, serverVersion),
@@ -496,7 +553,12 @@ func getMinimumNodes(sc *ocsv1.StorageCluster) int {
 	return maxReplica
 }
 
-func getMonCount(nodeCount int, arbiter bool) int {
+// getMonCount returns the number of mons of the StorageCluster. The spec
+// takes precedence over the MON_COUNT_OVERRIDE env var.
+func getMonCount(sc *ocsv1.StorageCluster) int {
+	if sc.Spec.Mon.Count != 0 {
+		return sc.Spec.Mon.Count
+	}
 	// return static value if overridden
 	override := os.Getenv(monCountOverrideEnvVar)
 	if override != "" {
@@ -507,8 +569,7 @@ func getMonCount(nodeCount int, arbiter bool) int {
 			return count
 		}
 	}
-
-	if arbiter {
+	if arbiterEnabled(sc) {
 		return defaults.ArbiterModeMonCount
 	}
 	return defaults.DefaultMonCount
@@ -526,10 +587,7 @@ func newStorageClassDeviceSets(sc *ocsv1.StorageCluster, serverVersion *version.
 	supportTSC := serverVersion.Major >= defaults.KubeMajorTopologySpreadConstraints && serverVersion.Minor >= defaults.KubeMinorTopologySpreadConstraints
 
 	for _, ds := range storageDeviceSets {
-		resources := ds.Resources
-		if resources.Requests == nil && resources.Limits == nil {
-			resources = defaults.DaemonResources["osd"]
-		}
+		resources := getDeviceSetResources(sc, &ds)
 
 		portable := ds.Portable
 
@@ -672,6 +730,10 @@ func newStorageClassDeviceSets(sc *ocsv1.StorageCluster, serverVersion *version.
 				Encrypted:            sc.Spec.Encryption.Enable,
 			}
 
+			if ds.Config.EncryptedDevice != nil {
+				set.Encrypted = *ds.Config.EncryptedDevice
+			}
+
 			if ds.MetadataPVCTemplate != nil {
 				ds.MetadataPVCTemplate.ObjectMeta.Name = metadataPVCName
 				set.VolumeClaimTemplates = append(set.VolumeClaimTemplates, *ds.MetadataPVCTemplate)
@@ -692,12 +754,13 @@ func newCephDaemonResources(sc *ocsv1.StorageCluster) map[string]corev1.Resource
 
 	custom := sc.Spec.Resources
 	resources := map[string]corev1.ResourceRequirements{
-		"mon": defaults.DaemonResources["mon"],
-		"mgr": defaults.DaemonResources["mgr"],
-		"mds": defaults.DaemonResources["mds"],
-		"rgw": defaults.DaemonResources["rgw"],
+		"mon": getProfileResources(sc, "mon"),
+		"mgr": getProfileResources(sc, "mgr"),
+		"mds": getProfileResources(sc, "mds"),
+		"rgw": getProfileResources(sc, "rgw"),
 	}
-	if arbiterEnabled(sc) {
+	// The sidecar of the mgrs selects the active one when there are several
+	if getMgrCount(sc) > 1 {
 		resources["mgr-sidecar"] = defaults.DaemonResources["mgr-sidecar"]
 	}
 
@@ -786,21 +849,71 @@ func generateStretchClusterSpec(sc *ocsv1.StorageCluster) *cephv1.StretchCluster
 	return &stretchClusterSpec
 }
 
-func generateMonSpec(sc *ocsv1.StorageCluster, nodeCount int) cephv1.MonSpec {
+func generateMonSpec(sc *ocsv1.StorageCluster) cephv1.MonSpec {
 	if arbiterEnabled(sc) {
 		return cephv1.MonSpec{
-			Count:                getMonCount(nodeCount, true),
-			AllowMultiplePerNode: false,
+			Count:                getMonCount(sc),
+			AllowMultiplePerNode: sc.Spec.Mon.AllowMultiplePerNode,
 			StretchCluster:       generateStretchClusterSpec(sc),
 		}
 	}
 
 	return cephv1.MonSpec{
-		Count:                getMonCount(nodeCount, false),
-		AllowMultiplePerNode: false,
+		Count:                getMonCount(sc),
+		AllowMultiplePerNode: sc.Spec.Mon.AllowMultiplePerNode,
 	}
 }
 
+// knownMgrModules are the mgr modules which can be set in spec.mgr.modules
+var knownMgrModules = []string{
+	"balancer",
+	"crash",
+	"dashboard",
+	"iostat",
+	"nfs",
+	"pg_autoscaler",
+	"prometheus",
+	"rook",
+	"status",
+	"telemetry",
+}
+
+// validateMgrSpec checks the mgr count and modules of the StorageCluster
+func validateMgrSpec(sc *ocsv1.StorageCluster) error {
+	mgr := sc.Spec.Mgr
+	if mgr.Count < 0 || mgr.Count > defaults.MaxMgrCount {
+		return fmt.Errorf("invalid mgr count %d: must be between 1 and %d", mgr.Count, defaults.MaxMgrCount)
+	}
+	if arbiterEnabled(sc) && mgr.Count == 1 {
+		return fmt.Errorf("invalid mgr count %d: arbiter mode requires %d mgrs", mgr.Count, defaults.ArbiterModeMgrCount)
+	}
+	seen := map[string]bool{}
+	for _, module := range mgr.Modules {
+		if !contains(knownMgrModules, module.Name) {
+			return fmt.Errorf("invalid mgr module %q: must be one of %s", module.Name, strings.Join(knownMgrModules, ", "))
+		}
+		if seen[module.Name] {
+			return fmt.Errorf("invalid mgr module %q: listed more than once", module.Name)
+		}
+		seen[module.Name] = true
+	}
+	return nil
+}
+
+// getMgrCount returns the number of mgrs of the StorageCluster
+func getMgrCount(sc *ocsv1.StorageCluster) int {
+	if sc.Spec.Mgr.Count != 0 {
+		return sc.Spec.Mgr.Count
+	}
+	if arbiterEnabled(sc) {
+		return defaults.ArbiterModeMgrCount
+	}
+	if sc.Spec.Mon.AllowMultiplePerNode {
+		return defaults.SingleNodeMgrCount
+	}
+	return defaults.DefaultMgrCount
+}
+
 func generateMgrSpec(sc *ocsv1.StorageCluster) cephv1.MgrSpec {
 	spec := cephv1.MgrSpec{
 		Modules: []cephv1.Module{
@@ -809,13 +922,43 @@ func generateMgrSpec(sc *ocsv1.StorageCluster) cephv1.MgrSpec {
 		},
 	}
 
-	if arbiterEnabled(sc) {
-		spec.Count = 2
+	// The modules of spec.mgr replace the default ones with the same name
+	for _, module := range sc.Spec.Mgr.Modules {
+		found := false
+		for i := range spec.Modules {
+			if spec.Modules[i].Name == module.Name {
+				spec.Modules[i].Enabled = module.Enabled
+				found = true
+			}
+		}
+		if !found {
+			spec.Modules = append(spec.Modules, cephv1.Module{Name: module.Name, Enabled: module.Enabled})
+		}
 	}
 
+	spec.Count = getMgrCount(sc)
+
 	return spec
 }
 
+// disableRemovedMgrModules keeps the mgr modules of the current CephCluster
+// which are no longer requested, but disabled, as Rook leaves the modules
+// which are not listed in their current state.
+func disableRemovedMgrModules(desired *cephv1.MgrSpec, current cephv1.MgrSpec) {
+	for _, module := range current.Modules {
+		found := false
+		for _, desiredModule := range desired.Modules {
+			if desiredModule.Name == module.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			desired.Modules = append(desired.Modules, cephv1.Module{Name: module.Name, Enabled: false})
+		}
+	}
+}
+
 func getCephObjectStoreGatewayInstances(sc *ocsv1.StorageCluster) int32 {
 	if arbiterEnabled(sc) {
 		return int32(defaults.ArbiterCephObjectStoreGatewayInstances)
@@ -70,7 +70,7 @@ func TestEnsureCephCluster(t *testing.T) {
 
 		reconciler := createFakeStorageClusterReconciler(t)
 
-		expected := newCephCluster(mockStorageCluster, "", 3, reconciler.serverVersion, nil, log)
+		expected := newCephCluster(mockStorageCluster, "", reconciler.serverVersion, nil, log)
 		expected.ObjectMeta.SelfLink = "/api/v1/namespaces/ceph/secrets/pvc-ceph-client-key"
 		expected.Status.State = c.cephClusterState
 
@@ -165,7 +165,7 @@ func TestCephClusterMonTimeout(t *testing.T) {
 		err := obj.ensureCreated(&reconciler, sc)
 		assert.NoError(t, err)
 
-		cc := newCephCluster(sc, "", 3, reconciler.serverVersion, nil, log)
+		cc := newCephCluster(sc, "", reconciler.serverVersion, nil, log)
 		err = reconciler.Client.Get(context.TODO(), mockCephClusterNamespacedName, cc)
 		assert.NoError(t, err)
 		if c.platform == v1.IBMCloudPlatformType || c.platform == IBMCloudCosPlatformType {
@@ -229,7 +229,7 @@ func TestNewCephClusterMonData(t *testing.T) {
 		c.sc.Spec.MonDataDirHostPath = c.monDataPath
 		c.sc.Status.Images.Ceph = &api.ComponentImageStatus{}
 
-		actual := newCephCluster(c.sc, "", 3, serverVersion, nil, log)
+		actual := newCephCluster(c.sc, "", serverVersion, nil, log)
 		assert.Equal(t, generateNameForCephCluster(c.sc), actual.Name)
 		assert.Equal(t, c.sc.Namespace, actual.Namespace)
 		assert.Equal(t, c.expectedMonDataPath, actual.Spec.DataDirHostPath)
@@ -457,6 +457,96 @@ func createDummyKMSConfigMap(kmsProvider, kmsAddr string) *corev1.ConfigMap {
 	return cm
 }
 
+func TestStorageClassDeviceSetConfig(t *testing.T) {
+	encrypted := true
+	notEncrypted := false
+
+	cases := []struct {
+		label             string
+		clusterEncryption bool
+		config            api.StorageDeviceSetConfig
+		expectedConfig    map[string]string
+		expectedEncrypted bool
+	}{
+		{
+			label:             "no config options set",
+			config:            api.StorageDeviceSetConfig{},
+			expectedConfig:    nil,
+			expectedEncrypted: false,
+		},
+		{
+			label:             "osdsPerDevice",
+			config:            api.StorageDeviceSetConfig{OsdsPerDevice: 2},
+			expectedConfig:    map[string]string{api.OsdsPerDeviceKey: "2"},
+			expectedEncrypted: false,
+		},
+		{
+			label:             "encryptedDevice enabled for the device set",
+			config:            api.StorageDeviceSetConfig{EncryptedDevice: &encrypted},
+			expectedConfig:    map[string]string{api.EncryptedDeviceKey: "true"},
+			expectedEncrypted: true,
+		},
+		{
+			label:             "encryptedDevice disabled for the device set",
+			clusterEncryption: true,
+			config:            api.StorageDeviceSetConfig{EncryptedDevice: &notEncrypted},
+			expectedConfig:    map[string]string{api.EncryptedDeviceKey: "false"},
+			expectedEncrypted: false,
+		},
+		{
+			label:             "metadataDevice",
+			config:            api.StorageDeviceSetConfig{MetadataDevice: "nvme0n1"},
+			expectedConfig:    map[string]string{api.MetadataDeviceKey: "nvme0n1"},
+			expectedEncrypted: false,
+		},
+		{
+			label: "osdConfig",
+			config: api.StorageDeviceSetConfig{
+				OsdConfig: map[string]string{
+					api.DatabaseSizeMBKey: "1024",
+					api.WalSizeMBKey:      "512",
+					api.JournalSizeMBKey:  "256",
+				},
+			},
+			expectedConfig: map[string]string{
+				api.DatabaseSizeMBKey: "1024",
+				api.WalSizeMBKey:      "512",
+				api.JournalSizeMBKey:  "256",
+			},
+			expectedEncrypted: false,
+		},
+		{
+			label: "typed fields take precedence over osdConfig",
+			config: api.StorageDeviceSetConfig{
+				OsdsPerDevice: 4,
+				OsdConfig: map[string]string{
+					api.OsdsPerDeviceKey:  "1",
+					api.DatabaseSizeMBKey: "1024",
+				},
+			},
+			expectedConfig: map[string]string{
+				api.OsdsPerDeviceKey:  "4",
+				api.DatabaseSizeMBKey: "1024",
+			},
+			expectedEncrypted: false,
+		},
+	}
+
+	for _, c := range cases {
+		sc := &api.StorageCluster{}
+		sc.Spec.Encryption.Enable = c.clusterEncryption
+		sc.Spec.StorageDeviceSets = []api.StorageDeviceSet{mockDeviceSets[0]}
+		sc.Spec.StorageDeviceSets[0].Config = c.config
+
+		sets := newStorageClassDeviceSets(sc, &version.Info{})
+		assert.NotEmptyf(t, sets, "[%s]: no StorageClassDeviceSets generated", c.label)
+		for _, set := range sets {
+			assert.Equalf(t, c.expectedConfig, set.Config, "[%s]: unexpected config", c.label)
+			assert.Equalf(t, c.expectedEncrypted, set.Encrypted, "[%s]: unexpected encryption", c.label)
+		}
+	}
+}
+
 func TestKMSConfigChanges(t *testing.T) {
 	validKMSArgs := []struct {
 		testLabel       string
@@ -626,10 +716,26 @@ func TestNewCephDaemonResources(t *testing.T) {
 				},
 			},
 			expected: map[string]corev1.ResourceRequirements{
-				"mon": defaults.DaemonResources["mon"],
-				"mgr": defaults.DaemonResources["mgr"],
-				"mds": defaults.DaemonResources["mds"],
-				"rgw": defaults.DaemonResources["rgw"],
+				"mon":         defaults.DaemonResources["mon"],
+				"mgr":         defaults.DaemonResources["mgr"],
+				"mds":         defaults.DaemonResources["mds"],
+				"rgw":         defaults.DaemonResources["rgw"],
+				"mgr-sidecar": defaults.DaemonResources["mgr-sidecar"],
+			},
+		},
+		{
+			name: "Selecting the performance profile",
+			spec: &api.StorageCluster{
+				Spec: api.StorageClusterSpec{
+					ResourceProfile: defaults.ResourceProfilePerformance,
+				},
+			},
+			expected: map[string]corev1.ResourceRequirements{
+				"mon":         defaults.PerformanceDaemonResources["mon"],
+				"mgr":         defaults.PerformanceDaemonResources["mgr"],
+				"mds":         defaults.PerformanceDaemonResources["mds"],
+				"rgw":         defaults.PerformanceDaemonResources["rgw"],
+				"mgr-sidecar": defaults.DaemonResources["mgr-sidecar"],
 			},
 		},
 		{
@@ -663,7 +769,8 @@ func TestNewCephDaemonResources(t *testing.T) {
 						corev1.ResourceMemory: resource.MustParse("16Gi"),
 					},
 				},
-				"rgw": defaults.DaemonResources["rgw"],
+				"rgw":         defaults.DaemonResources["rgw"],
+				"mgr-sidecar": defaults.DaemonResources["mgr-sidecar"],
 			},
 		},
 		{
@@ -685,10 +792,11 @@ func TestNewCephDaemonResources(t *testing.T) {
 				},
 			},
 			expected: map[string]corev1.ResourceRequirements{
-				"mon": defaults.DaemonResources["mon"],
-				"mgr": defaults.DaemonResources["mgr"],
-				"mds": defaults.DaemonResources["mds"],
-				"rgw": defaults.DaemonResources["rgw"],
+				"mon":         defaults.DaemonResources["mon"],
+				"mgr":         defaults.DaemonResources["mgr"],
+				"mds":         defaults.DaemonResources["mds"],
+				"rgw":         defaults.DaemonResources["rgw"],
+				"mgr-sidecar": defaults.DaemonResources["mgr-sidecar"],
 				"crashcollector": {
 					Requests: corev1.ResourceList{
 						corev1.ResourceCPU:    resource.MustParse("6"),
@@ -719,6 +827,38 @@ func TestNewCephDaemonResources(t *testing.T) {
 				"mgr-sidecar": defaults.DaemonResources["mgr-sidecar"],
 			},
 		},
+		{
+			name: "When a single mgr is requested",
+			spec: &api.StorageCluster{
+				Spec: api.StorageClusterSpec{
+					Mgr: api.MgrSpec{
+						Count: 1,
+					},
+				},
+			},
+			expected: map[string]corev1.ResourceRequirements{
+				"mon": defaults.DaemonResources["mon"],
+				"mgr": defaults.DaemonResources["mgr"],
+				"mds": defaults.DaemonResources["mds"],
+				"rgw": defaults.DaemonResources["rgw"],
+			},
+		},
+		{
+			name: "When the mons are allowed on the same node",
+			spec: &api.StorageCluster{
+				Spec: api.StorageClusterSpec{
+					Mon: api.MonSpec{
+						AllowMultiplePerNode: true,
+					},
+				},
+			},
+			expected: map[string]corev1.ResourceRequirements{
+				"mon": defaults.DaemonResources["mon"],
+				"mgr": defaults.DaemonResources["mgr"],
+				"mds": defaults.DaemonResources["mds"],
+				"rgw": defaults.DaemonResources["rgw"],
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -727,3 +867,171 @@ func TestNewCephDaemonResources(t *testing.T) {
 		assert.Equalf(t, c.expected, got, c.name)
 	}
 }
+
+func TestGenerateMgrSpec(t *testing.T) {
+	cases := []struct {
+		label                string
+		mgr                  api.MgrSpec
+		arbiter              bool
+		allowMultiplePerNode bool
+		expected             rookCephv1.MgrSpec
+	}{
+		{
+			label: "default mgr",
+			expected: rookCephv1.MgrSpec{
+				Count: 2,
+				Modules: []rookCephv1.Module{
+					{Name: "pg_autoscaler", Enabled: true},
+					{Name: "balancer", Enabled: true},
+				},
+			},
+		},
+		{
+			label:                "single node",
+			allowMultiplePerNode: true,
+			expected: rookCephv1.MgrSpec{
+				Count: 1,
+				Modules: []rookCephv1.Module{
+					{Name: "pg_autoscaler", Enabled: true},
+					{Name: "balancer", Enabled: true},
+				},
+			},
+		},
+		{
+			label:                "two mgrs on a single node",
+			mgr:                  api.MgrSpec{Count: 2},
+			allowMultiplePerNode: true,
+			expected: rookCephv1.MgrSpec{
+				Count: 2,
+				Modules: []rookCephv1.Module{
+					{Name: "pg_autoscaler", Enabled: true},
+					{Name: "balancer", Enabled: true},
+				},
+			},
+		},
+		{
+			label:   "arbiter mode",
+			arbiter: true,
+			expected: rookCephv1.MgrSpec{
+				Count: 2,
+				Modules: []rookCephv1.Module{
+					{Name: "pg_autoscaler", Enabled: true},
+					{Name: "balancer", Enabled: true},
+				},
+			},
+		},
+		{
+			label: "two mgrs with additional modules",
+			mgr: api.MgrSpec{
+				Count: 2,
+				Modules: []api.MgrModuleSpec{
+					{Name: "rook", Enabled: true},
+					{Name: "balancer", Enabled: false},
+					{Name: "crash", Enabled: true},
+				},
+			},
+			expected: rookCephv1.MgrSpec{
+				Count: 2,
+				Modules: []rookCephv1.Module{
+					{Name: "pg_autoscaler", Enabled: true},
+					{Name: "balancer", Enabled: false},
+					{Name: "rook", Enabled: true},
+					{Name: "crash", Enabled: true},
+				},
+			},
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := &api.StorageCluster{}
+		sc.Spec.Mgr = c.mgr
+		sc.Spec.Arbiter.Enable = c.arbiter
+		sc.Spec.Mon.AllowMultiplePerNode = c.allowMultiplePerNode
+		assert.Equal(t, c.expected, generateMgrSpec(sc))
+	}
+}
+
+func TestMgrSpecRevert(t *testing.T) {
+	sc := &api.StorageCluster{}
+	mockStorageCluster.DeepCopyInto(sc)
+	sc.Status.Images.Ceph = &api.ComponentImageStatus{}
+	sc.Spec.Mgr = api.MgrSpec{
+		Count:   1,
+		Modules: []api.MgrModuleSpec{{Name: "nfs", Enabled: true}},
+	}
+
+	reconciler := createFakeStorageClusterReconciler(t)
+	var obj ocsCephCluster
+	err := obj.ensureCreated(&reconciler, sc)
+	assert.NoError(t, err)
+
+	// Removing the mgr settings reverts the count and disables the module
+	sc.Spec.Mgr = api.MgrSpec{}
+	err = obj.ensureCreated(&reconciler, sc)
+	assert.NoError(t, err)
+
+	actual := &rookCephv1.CephCluster{}
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: generateNameForCephCluster(sc), Namespace: sc.Namespace}, actual)
+	assert.NoError(t, err)
+	assert.Equal(t, defaults.DefaultMgrCount, actual.Spec.Mgr.Count)
+	assert.Equal(t, []rookCephv1.Module{
+		{Name: "pg_autoscaler", Enabled: true},
+		{Name: "balancer", Enabled: true},
+		{Name: "nfs", Enabled: false},
+	}, actual.Spec.Mgr.Modules)
+}
+
+func TestValidateMgrSpec(t *testing.T) {
+	cases := []struct {
+		label         string
+		mgr           api.MgrSpec
+		arbiter       bool
+		expectedError bool
+	}{
+		{
+			label: "default mgr",
+		},
+		{
+			label: "known modules",
+			mgr: api.MgrSpec{
+				Count:   2,
+				Modules: []api.MgrModuleSpec{{Name: "nfs", Enabled: true}, {Name: "prometheus", Enabled: true}},
+			},
+		},
+		{
+			label:         "unknown module",
+			mgr:           api.MgrSpec{Modules: []api.MgrModuleSpec{{Name: "influx", Enabled: true}}},
+			expectedError: true,
+		},
+		{
+			label:         "module listed twice",
+			mgr:           api.MgrSpec{Modules: []api.MgrModuleSpec{{Name: "crash", Enabled: true}, {Name: "crash"}}},
+			expectedError: true,
+		},
+		{
+			label:         "too many mgrs",
+			mgr:           api.MgrSpec{Count: 3},
+			expectedError: true,
+		},
+		{
+			label:         "single mgr in arbiter mode",
+			mgr:           api.MgrSpec{Count: 1},
+			arbiter:       true,
+			expectedError: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := &api.StorageCluster{}
+		sc.Spec.Mgr = c.mgr
+		sc.Spec.Arbiter.Enable = c.arbiter
+		err := validateMgrSpec(sc)
+		if c.expectedError {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package storagecluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+type ocsCephConfig struct{}
+
+const (
+	rookConfigMapName = "rook-config-override"
+)
+
+// cephConfigSections are the Ceph config sections that can be set in the
+// rook-config-override ConfigMap, in the order they are written. A section
+// may also be narrowed down to a single daemon, e.g. "osd.0".
+var cephConfigSections = []string{"global", "mon", "mgr", "osd", "mds", "client"}
+
+// defaultRookConfig holds the Ceph config options set by the operator unless
+// they are overridden in the StorageCluster spec
+var defaultRookConfig = map[string]ocsv1.CephConfigSection{
+	"global": {
+		"bdev_flock_retry":           "20",
+		"mon_osd_full_ratio":         ".85",
+		"mon_osd_backfillfull_ratio": ".8",
+		"mon_osd_nearfull_ratio":     ".75",
+		"mon_max_pg_per_osd":         "600",
+	},
+	"osd": {
+		"osd_memory_target_cgroup_limit_ratio": "0.5",
+	},
+}
+
+// defaultRookConfigOrder is the order in which the default options are
+// written, which matches the rook-config-override ConfigMap created by
+// earlier releases. Options only set in spec.cephConfig follow them sorted
+// by name.
+var defaultRookConfigOrder = map[string][]string{
+	"global": {
+		"bdev_flock_retry",
+		"mon_osd_full_ratio",
+		"mon_osd_backfillfull_ratio",
+		"mon_osd_nearfull_ratio",
+		"mon_max_pg_per_osd",
+	},
+	"osd": {
+		"osd_memory_target_cgroup_limit_ratio",
+	},
+}
+
+var cephConfigOptionRegex = regexp.MustCompile(`^[a-zA-Z0-9_ ]+$`)
+
+// validateCephConfig checks that all the sections and options in
+// spec.cephConfig can be written to the Ceph config file
+func validateCephConfig(sc *ocsv1.StorageCluster) error {
+	for section, options := range sc.Spec.CephConfig {
+		if !isValidCephConfigSection(section) {
+			return fmt.Errorf("failed to validate cephConfig: unknown section %q, must be one of %v or <section>.<id>", section, cephConfigSections)
+		}
+		for option, value := range options {
+			if !cephConfigOptionRegex.MatchString(option) {
+				return fmt.Errorf("failed to validate cephConfig: invalid option name %q in section %q", option, section)
+			}
+			if strings.ContainsAny(value, "\n\r") {
+				return fmt.Errorf("failed to validate cephConfig: value of option %q in section %q must be a single line", option, section)
+			}
+		}
+	}
+	return nil
+}
+
+func isValidCephConfigSection(section string) bool {
+	base, id := section, ""
+	if i := strings.Index(section, "."); i >= 0 {
+		base, id = section[:i], section[i+1:]
+		if id == "" || base == "global" || strings.ContainsAny(id, "[] \t") {
+			return false
+		}
+	}
+	return contains(cephConfigSections, base)
+}
+
+// getCephConfigData merges spec.cephConfig over the default Ceph config
+// options and renders them in the Ceph config file format
+func getCephConfigData(sc *ocsv1.StorageCluster) string {
+	config := map[string]ocsv1.CephConfigSection{}
+	for _, source := range []map[string]ocsv1.CephConfigSection{defaultRookConfig, sc.Spec.CephConfig} {
+		for section, options := range source {
+			if config[section] == nil {
+				config[section] = ocsv1.CephConfigSection{}
+			}
+			for option, value := range options {
+				config[section][option] = value
+			}
+		}
+	}
+
+	// Sections which apply to all daemons of a type come first, so that
+	// the ones narrowed down to a single daemon are easy to spot
+	sections := []string{}
+	for _, section := range cephConfigSections {
+		if _, ok := config[section]; ok {
+			sections = append(sections, section)
+		}
+	}
+	daemonSections := []string{}
+	for section := range config {
+		if !contains(cephConfigSections, section) {
+			daemonSections = append(daemonSections, section)
+		}
+	}
+	sort.Strings(daemonSections)
+	sections = append(sections, daemonSections...)
+
+	var b strings.Builder
+	b.WriteString("\n")
+	for _, section := range sections {
+		options := config[section]
+		if len(options) == 0 {
+			continue
+		}
+		names := []string{}
+		for _, option := range defaultRookConfigOrder[section] {
+			if _, ok := options[option]; ok {
+				names = append(names, option)
+			}
+		}
+		extraNames := []string{}
+		for option := range options {
+			if !contains(defaultRookConfigOrder[section], option) {
+				extraNames = append(extraNames, option)
+			}
+		}
+		sort.Strings(extraNames)
+		names = append(names, extraNames...)
+		fmt.Fprintf(&b, "[%s]\n", section)
+		for _, option := range names {
+			fmt.Fprintf(&b, "%s = %s\n", option, options[option])
+		}
+	}
+	return b.String()
+}
+
+// parseCephConfigData parses a Ceph config file into its sections and
+// options, ignoring the order, blank lines and comments
+func parseCephConfigData(data string) map[string]map[string]string {
+	config := map[string]map[string]string{}
+	section := ""
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if config[section] == nil {
+				config[section] = map[string]string{}
+			}
+			continue
+		}
+		if config[section] == nil {
+			config[section] = map[string]string{}
+		}
+		option, value := line, ""
+		if i := strings.Index(line, "="); i >= 0 {
+			option, value = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		}
+		config[section][option] = value
+	}
+	for section, options := range config {
+		if len(options) == 0 {
+			delete(config, section)
+		}
+	}
+	return config
+}
+
+// ensureCreated ensures that a ConfigMap resource exists with its Spec in
+// the desired state.
+func (obj *ocsCephConfig) ensureCreated(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) error {
+	reconcileStrategy := ReconcileStrategy(sc.Spec.ManagedResources.CephConfig.ReconcileStrategy)
+	if reconcileStrategy == ReconcileStrategyIgnore {
+		return nil
+	}
+
+	found := &corev1.ConfigMap{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: rookConfigMapName, Namespace: sc.Namespace}, found)
+
+	if err == nil && reconcileStrategy == ReconcileStrategyInit {
+		return setCephConfigHash(sc, found.Data["config"])
+	}
+
+	configData := getCephConfigData(sc)
+	ownerRef := metav1.OwnerReference{
+		UID:        sc.UID,
+		APIVersion: sc.APIVersion,
+		Kind:       sc.Kind,
+		Name:       sc.Name,
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            rookConfigMapName,
+			Namespace:       sc.Namespace,
+			OwnerReferences: []metav1.OwnerReference{ownerRef},
+		},
+		Data: map[string]string{
+			"config": configData,
+		},
+	}
+
+	if err != nil {
+		if errors.IsNotFound(err) {
+			r.Log.Info("Creating Ceph ConfigMap.", "ConfigMap", klog.KRef(sc.Namespace, rookConfigMapName))
+			err = r.Client.Create(context.TODO(), cm)
+			if err != nil {
+				r.Log.Error(err, "Failed to create Ceph ConfigMap.", "ConfigMap", klog.KRef(sc.Namespace, rookConfigMapName))
+				return err
+			}
+			return setCephConfigHash(sc, configData)
+		}
+		return err
+	}
+
+	ownerRefFound := false
+	for _, ownerRef := range found.OwnerReferences {
+		if ownerRef.UID == sc.UID {
+			ownerRefFound = true
+		}
+	}
+	// The ConfigMap may have been written with a different option order or
+	// formatting, which doesn't need an update as long as Ceph reads the
+	// same options from it
+	if reflect.DeepEqual(parseCephConfigData(found.Data["config"]), parseCephConfigData(configData)) {
+		cm.Data["config"] = found.Data["config"]
+	}
+	drifted, err := getDriftedFields(cm, found, "data")
+	if err != nil {
+		return err
+	}
+	if !ownerRefFound {
+		drifted = append(drifted, "metadata.ownerReferences")
+	}
+	if len(drifted) > 0 {
+		r.Log.Info("Updating Ceph ConfigMap.", "ConfigMap", klog.KRef(sc.Namespace, cm.Name), "Fields", drifted)
+		r.reportDrift(sc, componentCephConfig, "ConfigMap", sc.Namespace, cm.Name, drifted)
+		if err = r.Client.Update(context.TODO(), cm); err != nil {
+			r.Log.Error(err, "Failed to update Ceph ConfigMap.", "ConfigMap", klog.KRef(sc.Namespace, cm.Name))
+			return err
+		}
+	}
+	return setCephConfigHash(sc, configData)
+}
+
+// setCephConfigHash records the checksum of the effective Ceph config in the
+// StorageCluster status
+func setCephConfigHash(sc *ocsv1.StorageCluster, configData string) error {
+	hash, err := sha512sum([]byte(configData))
+	if err != nil {
+		return err
+	}
+	sc.Status.CephConfigHash = hash
+	return nil
+}
+
+// ensureDeleted is dummy func for the ocsCephConfig
+func (obj *ocsCephConfig) ensureDeleted(r *StorageClusterReconciler, instance *ocsv1.StorageCluster) error {
+	return nil
+}
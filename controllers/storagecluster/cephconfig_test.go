@@ -0,0 +1,182 @@
+package storagecluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/openshift/ocs-operator/api/v1"
+)
+
+func TestValidateCephConfig(t *testing.T) {
+	cases := []struct {
+		label         string
+		cephConfig    map[string]api.CephConfigSection
+		expectedError bool
+	}{
+		{
+			label:         "no cephConfig",
+			cephConfig:    nil,
+			expectedError: false,
+		},
+		{
+			label: "known sections",
+			cephConfig: map[string]api.CephConfigSection{
+				"global": {"mon_osd_full_ratio": ".9"},
+				"osd":    {"osd_memory_target": "4294967296"},
+				"osd.3":  {"osd_max_backfills": "4"},
+				"client": {"rbd cache": "true"},
+			},
+			expectedError: false,
+		},
+		{
+			label: "unknown section",
+			cephConfig: map[string]api.CephConfigSection{
+				"osds": {"osd_memory_target": "4294967296"},
+			},
+			expectedError: true,
+		},
+		{
+			label: "global narrowed down to a daemon",
+			cephConfig: map[string]api.CephConfigSection{
+				"global.a": {"mon_osd_full_ratio": ".9"},
+			},
+			expectedError: true,
+		},
+		{
+			label: "empty daemon id",
+			cephConfig: map[string]api.CephConfigSection{
+				"osd.": {"osd_max_backfills": "4"},
+			},
+			expectedError: true,
+		},
+		{
+			label: "invalid option name",
+			cephConfig: map[string]api.CephConfigSection{
+				"global": {"mon_osd_full_ratio = .5\n[osd]": ".9"},
+			},
+			expectedError: true,
+		},
+		{
+			label: "multi-line value",
+			cephConfig: map[string]api.CephConfigSection{
+				"global": {"mon_osd_full_ratio": ".9\n[osd]"},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, c := range cases {
+		sc := &api.StorageCluster{}
+		sc.Spec.CephConfig = c.cephConfig
+		err := validateCephConfig(sc)
+		if c.expectedError {
+			assert.Errorf(t, err, "[%s]: expected cephConfig to be rejected", c.label)
+		} else {
+			assert.NoErrorf(t, err, "[%s]: unexpected error", c.label)
+		}
+	}
+}
+
+func TestGetCephConfigData(t *testing.T) {
+	sc := &api.StorageCluster{}
+	// Without overrides the options are written in the order of earlier
+	// releases
+	assert.Equal(t, `
+[global]
+bdev_flock_retry = 20
+mon_osd_full_ratio = .85
+mon_osd_backfillfull_ratio = .8
+mon_osd_nearfull_ratio = .75
+mon_max_pg_per_osd = 600
+[osd]
+osd_memory_target_cgroup_limit_ratio = 0.5
+`, getCephConfigData(sc))
+
+	sc.Spec.CephConfig = map[string]api.CephConfigSection{
+		"osd.1":  {"osd_max_backfills": "4"},
+		"osd":    {"osd_memory_target": "4294967296"},
+		"global": {"mon_osd_full_ratio": ".9"},
+		"mgr":    {},
+	}
+	assert.Equal(t, `
+[global]
+bdev_flock_retry = 20
+mon_osd_full_ratio = .9
+mon_osd_backfillfull_ratio = .8
+mon_osd_nearfull_ratio = .75
+mon_max_pg_per_osd = 600
+[osd]
+osd_memory_target_cgroup_limit_ratio = 0.5
+osd_memory_target = 4294967296
+[osd.1]
+osd_max_backfills = 4
+`, getCephConfigData(sc))
+
+	// The overrides must not leak into the defaults
+	assert.Equal(t, ".85", defaultRookConfig["global"]["mon_osd_full_ratio"])
+}
+
+func TestCephConfigEnsureCreated(t *testing.T) {
+	sc := createDefaultStorageCluster()
+	reconciler := createFakeStorageClusterReconciler(t, sc)
+
+	err := (&ocsCephConfig{}).ensureCreated(&reconciler, sc)
+	assert.NoError(t, err)
+	defaultHash := sc.Status.CephConfigHash
+	assert.NotEmpty(t, defaultHash)
+
+	cm := &corev1.ConfigMap{}
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: rookConfigMapName, Namespace: sc.Namespace}, cm)
+	assert.NoError(t, err)
+	assert.Equal(t, getCephConfigData(sc), cm.Data["config"])
+
+	// An override is merged into the existing ConfigMap
+	sc.Spec.CephConfig = map[string]api.CephConfigSection{
+		"global": {"mon_osd_full_ratio": ".9"},
+	}
+	err = (&ocsCephConfig{}).ensureCreated(&reconciler, sc)
+	assert.NoError(t, err)
+	assert.NotEqual(t, defaultHash, sc.Status.CephConfigHash)
+
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: rookConfigMapName, Namespace: sc.Namespace}, cm)
+	assert.NoError(t, err)
+	assert.Contains(t, cm.Data["config"], "mon_osd_full_ratio = .9\n")
+	assert.NotContains(t, cm.Data["config"], "mon_osd_full_ratio = .85\n")
+
+	// A ConfigMap with the same options in another order is not updated
+	sc.Spec.CephConfig = nil
+	reordered := `
+[osd]
+osd_memory_target_cgroup_limit_ratio = 0.5
+[global]
+mon_max_pg_per_osd = 600
+mon_osd_nearfull_ratio = .75
+mon_osd_backfillfull_ratio = .8
+mon_osd_full_ratio = .85
+bdev_flock_retry = 20
+`
+	cm.Data["config"] = reordered
+	assert.NoError(t, reconciler.Client.Update(context.TODO(), cm))
+	err = (&ocsCephConfig{}).ensureCreated(&reconciler, sc)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultHash, sc.Status.CephConfigHash)
+	cm = &corev1.ConfigMap{}
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: rookConfigMapName, Namespace: sc.Namespace}, cm)
+	assert.NoError(t, err)
+	assert.Equal(t, reordered, cm.Data["config"])
+
+	// With the init strategy an existing ConfigMap is left alone
+	sc.Spec.ManagedResources.CephConfig.ReconcileStrategy = string(ReconcileStrategyInit)
+	sc.Spec.CephConfig = map[string]api.CephConfigSection{
+		"global": {"mon_osd_full_ratio": ".9"},
+	}
+	err = (&ocsCephConfig{}).ensureCreated(&reconciler, sc)
+	assert.NoError(t, err)
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: rookConfigMapName, Namespace: sc.Namespace}, cm)
+	assert.NoError(t, err)
+	assert.Equal(t, reordered, cm.Data["config"])
+}
@@ -3,14 +3,16 @@ package storagecluster
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	ocsv1 "github.com/openshift/ocs-operator/api/v1"
-	"github.com/openshift/ocs-operator/controllers/defaults"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
@@ -28,25 +30,37 @@ func (r *StorageClusterReconciler) newCephFilesystemInstances(initData *ocsv1.St
 			Spec: cephv1.FilesystemSpec{
 				MetadataPool: cephv1.PoolSpec{
 					Replicated:    generateCephReplicatedSpec(initData, "metadata"),
-					FailureDomain: initData.Status.FailureDomain,
+					FailureDomain: getFailureDomain(initData),
 				},
 				DataPools: []cephv1.PoolSpec{
 					{
 						Replicated:    generateCephReplicatedSpec(initData, "data"),
-						FailureDomain: initData.Status.FailureDomain,
+						FailureDomain: getFailureDomain(initData),
 					},
 				},
 				MetadataServer: cephv1.MetadataServerSpec{
 					ActiveCount:   1,
 					ActiveStandby: true,
 					Placement:     getPlacement(initData, "mds"),
-					Resources:     defaults.GetDaemonResources("mds", initData.Spec.Resources),
+					Resources:     getDaemonResources(initData, "mds"),
 					// set PriorityClassName for the MDS pods
 					PriorityClassName: openshiftUserCritical,
 				},
 			},
 		},
 	}
+	if ec := initData.Spec.ManagedResources.CephFilesystems.ErasureCoded; ec != nil {
+		// CephFS keeps backtrace information in the first data pool, which
+		// is why it stays replicated and the erasure-coded pool is added
+		// next to it
+		ret[0].Spec.DataPools = append(ret[0].Spec.DataPools, cephv1.PoolSpec{
+			ErasureCoded:  generateCephErasureCodedSpec(ec),
+			FailureDomain: getFailureDomain(initData),
+		})
+	}
+	for _, fs := range initData.Spec.ManagedResources.CephFilesystems.AdditionalFilesystems {
+		ret = append(ret, newAdditionalCephFilesystem(initData, fs))
+	}
 	for _, obj := range ret {
 		err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
 		if err != nil {
@@ -57,6 +71,105 @@ func (r *StorageClusterReconciler) newCephFilesystemInstances(initData *ocsv1.St
 	return ret, nil
 }
 
+// newAdditionalCephFilesystem returns the CephFilesystem for an entry of
+// spec.managedResources.cephFilesystems.additionalFilesystems
+func newAdditionalCephFilesystem(initData *ocsv1.StorageCluster, fs ocsv1.AdditionalFilesystem) *cephv1.CephFilesystem {
+	activeCount := fs.ActiveMetadataServers
+	if activeCount == 0 {
+		activeCount = 1
+	}
+	resources := getDaemonResources(initData, "mds")
+	if fs.Resources != nil {
+		resources = *fs.Resources
+	}
+	placement := getPlacement(initData, "mds")
+	if fs.Placement != nil {
+		placement = *fs.Placement.DeepCopy()
+	}
+
+	dataPools := []cephv1.PoolSpec{}
+	for _, pool := range fs.DataPools {
+		dataPool := cephv1.PoolSpec{
+			FailureDomain:   getFailureDomain(initData),
+			DeviceClass:     pool.DeviceClass,
+			CompressionMode: pool.CompressionMode,
+		}
+		switch {
+		case pool.ErasureCoded != nil:
+			dataPool.ErasureCoded = generateCephErasureCodedSpec(pool.ErasureCoded)
+		case pool.Replica != 0:
+			dataPool.Replicated = cephv1.ReplicatedSpec{Size: pool.Replica}
+		default:
+			dataPool.Replicated = generateCephReplicatedSpec(initData, "")
+		}
+		dataPools = append(dataPools, dataPool)
+	}
+	if len(dataPools) == 0 {
+		dataPools = append(dataPools, cephv1.PoolSpec{
+			Replicated:    generateCephReplicatedSpec(initData, ""),
+			FailureDomain: getFailureDomain(initData),
+		})
+	}
+
+	return &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateNameForAdditionalCephFilesystem(initData, fs.Name),
+			Namespace: initData.Namespace,
+		},
+		Spec: cephv1.FilesystemSpec{
+			MetadataPool: cephv1.PoolSpec{
+				Replicated:    generateCephReplicatedSpec(initData, "metadata"),
+				FailureDomain: getFailureDomain(initData),
+			},
+			DataPools: dataPools,
+			MetadataServer: cephv1.MetadataServerSpec{
+				ActiveCount:       activeCount,
+				ActiveStandby:     true,
+				Placement:         placement,
+				Resources:         resources,
+				PriorityClassName: openshiftUserCritical,
+			},
+		},
+	}
+}
+
+// validateAdditionalFilesystems checks that the additional CephFilesystems
+// can be turned into uniquely named resources with a usable first data pool
+func validateAdditionalFilesystems(sc *ocsv1.StorageCluster) error {
+	names := map[string]bool{}
+	for _, fs := range sc.Spec.ManagedResources.CephFilesystems.AdditionalFilesystems {
+		if errs := validation.IsDNS1123Label(fs.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid name %q for additional CephFilesystem: %s", fs.Name, strings.Join(errs, ", "))
+		}
+		if names[fs.Name] {
+			return fmt.Errorf("additional CephFilesystem %q is defined more than once", fs.Name)
+		}
+		names[fs.Name] = true
+		// CephFS keeps backtrace information in the first data pool, which
+		// must therefore be replicated
+		if len(fs.DataPools) > 0 && fs.DataPools[0].ErasureCoded != nil {
+			return fmt.Errorf("additional CephFilesystem %q: the first data pool can not be erasure-coded", fs.Name)
+		}
+	}
+	return nil
+}
+
+// validateFilesystemsErasureCoding checks that every erasure-coded data pool
+// of the CephFilesystems can be placed
+func validateFilesystemsErasureCoding(sc *ocsv1.StorageCluster) error {
+	if err := validateErasureCodedSpec(sc, sc.Spec.ManagedResources.CephFilesystems.ErasureCoded); err != nil {
+		return err
+	}
+	for _, fs := range sc.Spec.ManagedResources.CephFilesystems.AdditionalFilesystems {
+		for _, pool := range fs.DataPools {
+			if err := validateErasureCodedSpec(sc, pool.ErasureCoded); err != nil {
+				return fmt.Errorf("additional CephFilesystem %q: %v", fs.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
 // ensureCreated ensures that cephFilesystem resources exist in the desired
 // state.
 func (obj *ocsCephFilesystems) ensureCreated(r *StorageClusterReconciler, instance *ocsv1.StorageCluster) error {
@@ -65,6 +178,11 @@ func (obj *ocsCephFilesystems) ensureCreated(r *StorageClusterReconciler, instan
 		return nil
 	}
 
+	if err := validateFilesystemsErasureCoding(instance); err != nil {
+		r.Log.Error(err, "Invalid erasure coding for CephFilesystems.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
+		return err
+	}
+
 	cephFilesystems, err := r.newCephFilesystemInstances(instance)
 	if err != nil {
 		return err
@@ -75,14 +193,23 @@ func (obj *ocsCephFilesystems) ensureCreated(r *StorageClusterReconciler, instan
 		switch {
 		case err == nil:
 			if reconcileStrategy == ReconcileStrategyInit {
-				return nil
+				continue
 			}
 			if existing.DeletionTimestamp != nil {
 				r.Log.Info("Unable to restore CephFileSystem because it is marked for deletion.", "CephFileSystem", klog.KRef(existing.Namespace, existing.Name))
 				return fmt.Errorf("failed to restore initialization object %s because it is marked for deletion", existing.Name)
 			}
 
-			r.Log.Info("Restoring original CephFilesystem.", "CephFileSystem", klog.KRef(cephFilesystem.Namespace, cephFilesystem.Name))
+			drifted, err := getDriftedFields(cephFilesystem, &existing, "spec", "metadata.ownerReferences")
+			if err != nil {
+				return err
+			}
+			if len(drifted) == 0 {
+				continue
+			}
+			r.reportDrift(instance, componentFilesystems, "CephFilesystem", cephFilesystem.Namespace, cephFilesystem.Name, drifted)
+
+			r.Log.Info("Restoring original CephFilesystem.", "CephFileSystem", klog.KRef(cephFilesystem.Namespace, cephFilesystem.Name), "Fields", drifted)
 			existing.ObjectMeta.OwnerReferences = cephFilesystem.ObjectMeta.OwnerReferences
 			cephFilesystem.ObjectMeta = existing.ObjectMeta
 			err = r.Client.Update(context.TODO(), cephFilesystem)
@@ -100,6 +227,38 @@ func (obj *ocsCephFilesystems) ensureCreated(r *StorageClusterReconciler, instan
 		}
 	}
 
+	if reconcileStrategy == ReconcileStrategyInit {
+		return nil
+	}
+	return r.deleteRemovedCephFilesystems(instance, cephFilesystems)
+}
+
+// deleteRemovedCephFilesystems deletes the CephFilesystems owned by the
+// StorageCluster which are no longer desired, i.e. the additional
+// filesystems removed from the spec
+func (r *StorageClusterReconciler) deleteRemovedCephFilesystems(instance *ocsv1.StorageCluster, cephFilesystems []*cephv1.CephFilesystem) error {
+	desired := map[string]bool{}
+	for _, cephFilesystem := range cephFilesystems {
+		desired[cephFilesystem.Name] = true
+	}
+
+	existing := &cephv1.CephFilesystemList{}
+	err := r.Client.List(context.TODO(), existing, client.InNamespace(instance.Namespace))
+	if err != nil {
+		return fmt.Errorf("failed to list CephFilesystems: %v", err)
+	}
+	for i := range existing.Items {
+		cephFilesystem := &existing.Items[i]
+		if desired[cephFilesystem.Name] || !metav1.IsControlledBy(cephFilesystem, instance) || cephFilesystem.DeletionTimestamp != nil {
+			continue
+		}
+		r.Log.Info("Deleting CephFileSystem removed from the StorageCluster.", "CephFileSystem", klog.KRef(cephFilesystem.Namespace, cephFilesystem.Name))
+		err = r.Client.Delete(context.TODO(), cephFilesystem)
+		if err != nil && !errors.IsNotFound(err) {
+			r.Log.Error(err, "Failed to delete CephFileSystem.", "CephFileSystem", klog.KRef(cephFilesystem.Namespace, cephFilesystem.Name))
+			return err
+		}
+	}
 	return nil
 }
 
@@ -4,9 +4,15 @@ import (
 	"context"
 	"testing"
 
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -56,3 +62,153 @@ func assertCephFileSystem(t *testing.T, reconciler StorageClusterReconciler, cr
 	assert.Equal(t, expectedAf[0].ObjectMeta.Name, actualFs.ObjectMeta.Name)
 	assert.Equal(t, expectedAf[0].Spec, actualFs.Spec)
 }
+
+func TestCephFileSystemErasureCoded(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	cr := createDefaultStorageCluster()
+	cr.Status.FailureDomain = "zone"
+	cr.Status.FailureDomainValues = []string{"zone1", "zone2", "zone3"}
+	cr.Spec.ManagedResources.CephFilesystems.ErasureCoded = &api.ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}
+
+	var obj ocsCephFilesystems
+	assert.NoError(t, obj.ensureCreated(&reconciler, cr))
+
+	fs, err := reconciler.newCephFilesystemInstances(cr)
+	assert.NoError(t, err)
+	dataPools := fs[0].Spec.DataPools
+	assert.Equal(t, 2, len(dataPools))
+	assert.NotZero(t, dataPools[0].Replicated.Size)
+	assert.Equal(t, uint(2), dataPools[1].ErasureCoded.DataChunks)
+	assert.Equal(t, uint(1), dataPools[1].ErasureCoded.CodingChunks)
+	assert.Equal(t, getFailureDomain(cr), dataPools[1].FailureDomain)
+
+	scc := newCephFilesystemStorageClassConfiguration(cr)
+	assert.Equal(t, "ocsinit-cephfilesystem-data1", scc.storageClass.Parameters["pool"])
+
+	// Not enough failure domains for the chunks
+	cr.Spec.ManagedResources.CephFilesystems.ErasureCoded = &api.ErasureCodedSpec{DataChunks: 4, CodingChunks: 2}
+	assert.Error(t, obj.ensureCreated(&reconciler, cr))
+}
+
+func TestAdditionalCephFilesystems(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	cr := createDefaultStorageCluster()
+	cr.Status.FailureDomainValues = []string{"zone1", "zone2", "zone3"}
+	tenantResources := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("16Gi"),
+		},
+	}
+	cr.Spec.ManagedResources.CephFilesystems.AdditionalFilesystems = []api.AdditionalFilesystem{
+		{
+			Name: "tenant-a",
+		},
+		{
+			Name:                  "tenant-b",
+			ActiveMetadataServers: 2,
+			Resources:             &tenantResources,
+			DataPools: []api.FilesystemDataPool{
+				{Replica: 2},
+				{ErasureCoded: &api.ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}},
+			},
+		},
+	}
+
+	var obj ocsCephFilesystems
+	assert.NoError(t, obj.ensureCreated(&reconciler, cr))
+
+	tenantA := &cephv1.CephFilesystem{}
+	err := reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephfilesystem-tenant-a", Namespace: cr.Namespace}, tenantA)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), tenantA.Spec.MetadataServer.ActiveCount)
+	assert.Equal(t, 1, len(tenantA.Spec.DataPools))
+	assert.Equal(t, getPlacement(cr, "mds"), tenantA.Spec.MetadataServer.Placement)
+
+	tenantB := &cephv1.CephFilesystem{}
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephfilesystem-tenant-b", Namespace: cr.Namespace}, tenantB)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), tenantB.Spec.MetadataServer.ActiveCount)
+	assert.Equal(t, tenantResources, tenantB.Spec.MetadataServer.Resources)
+	assert.Equal(t, 2, len(tenantB.Spec.DataPools))
+	assert.Equal(t, uint(2), tenantB.Spec.DataPools[0].Replicated.Size)
+	assert.Equal(t, uint(2), tenantB.Spec.DataPools[1].ErasureCoded.DataChunks)
+
+	sccs, err := reconciler.newStorageClassConfigurations(cr)
+	assert.NoError(t, err)
+	fsNames := map[string]string{}
+	for _, scc := range sccs {
+		fsNames[scc.storageClass.Name] = scc.storageClass.Parameters["fsName"]
+	}
+	assert.Equal(t, "ocsinit-cephfilesystem-tenant-a", fsNames["ocsinit-cephfs-tenant-a"])
+	assert.Equal(t, "ocsinit-cephfilesystem-tenant-b", fsNames["ocsinit-cephfs-tenant-b"])
+
+	snapshotClassNames := []string{}
+	for _, vscc := range newSnapshotClassConfigurations(cr) {
+		snapshotClassNames = append(snapshotClassNames, vscc.snapshotClass.Name)
+	}
+	assert.Contains(t, snapshotClassNames, "ocsinit-cephfsplugin-snapclass-tenant-a")
+	assert.Contains(t, snapshotClassNames, "ocsinit-cephfsplugin-snapclass-tenant-b")
+
+	// The first data pool must stay replicated
+	cr.Spec.ManagedResources.CephFilesystems.AdditionalFilesystems[1].DataPools = []api.FilesystemDataPool{
+		{ErasureCoded: &api.ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}},
+	}
+	assert.Error(t, validateAdditionalFilesystems(cr))
+
+	// Uninstall removes the additional filesystems as well
+	assert.NoError(t, obj.ensureDeleted(&reconciler, cr))
+	for _, name := range []string{"ocsinit-cephfilesystem", "ocsinit-cephfilesystem-tenant-a", "ocsinit-cephfilesystem-tenant-b"} {
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: cr.Namespace}, &cephv1.CephFilesystem{})
+		assert.Truef(t, errors.IsNotFound(err), "CephFilesystem %q was not deleted", name)
+	}
+}
+
+func TestAdditionalCephFilesystemRemoved(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	cr := createDefaultStorageCluster()
+	cr.Spec.ManagedResources.CephFilesystems.AdditionalFilesystems = []api.AdditionalFilesystem{
+		{Name: "tenant-a"},
+		{Name: "tenant-b"},
+	}
+
+	var filesystems ocsCephFilesystems
+	var storageClasses ocsStorageClass
+	var snapshotClasses ocsSnapshotClass
+	ensureCreated := func() {
+		assert.NoError(t, filesystems.ensureCreated(&reconciler, cr))
+		assert.NoError(t, storageClasses.ensureCreated(&reconciler, cr))
+		assert.NoError(t, snapshotClasses.ensureCreated(&reconciler, cr))
+	}
+	ensureCreated()
+
+	// Removing a filesystem from the spec deletes its filesystem,
+	// StorageClass and SnapshotClass, and leaves the other filesystems alone
+	cr.Spec.ManagedResources.CephFilesystems.AdditionalFilesystems = cr.Spec.ManagedResources.CephFilesystems.AdditionalFilesystems[1:]
+	ensureCreated()
+
+	err := reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephfilesystem-tenant-a", Namespace: cr.Namespace}, &cephv1.CephFilesystem{})
+	assert.True(t, errors.IsNotFound(err))
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephfs-tenant-a"}, &storagev1.StorageClass{})
+	assert.True(t, errors.IsNotFound(err))
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: generateNameForAdditionalSnapshotClass(cr, cephfsSnapshotter, "tenant-a")}, &snapapi.VolumeSnapshotClass{})
+	assert.True(t, errors.IsNotFound(err))
+
+	for _, name := range []string{"ocsinit-cephfilesystem", "ocsinit-cephfilesystem-tenant-b"} {
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: cr.Namespace}, &cephv1.CephFilesystem{})
+		assert.NoErrorf(t, err, "CephFilesystem %q was deleted", name)
+	}
+	for _, name := range []string{"ocsinit-cephfs", "ocsinit-cephfs-tenant-b"} {
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name}, &storagev1.StorageClass{})
+		assert.NoErrorf(t, err, "StorageClass %q was deleted", name)
+	}
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: generateNameForAdditionalSnapshotClass(cr, cephfsSnapshotter, "tenant-b")}, &snapapi.VolumeSnapshotClass{})
+	assert.NoError(t, err)
+
+	// The removed classes of the filesystems are kept while their
+	// reconciliation is ignored
+	cr.Spec.ManagedResources.CephFilesystems.ReconcileStrategy = string(ReconcileStrategyIgnore)
+	cr.Spec.ManagedResources.CephFilesystems.AdditionalFilesystems = nil
+	ensureCreated()
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephfs-tenant-b"}, &storagev1.StorageClass{})
+	assert.NoError(t, err)
+}
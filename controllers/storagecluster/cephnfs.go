@@ -0,0 +1,403 @@
+package storagecluster
+
+import (
+	"context"
+	"fmt"
+
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	statusutil "github.com/openshift/ocs-operator/controllers/util"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// nfsPort is the port of the NFS servers
+	nfsPort = 2049
+	// nfsRadosNamespace is the RADOS namespace of the data pool of the
+	// CephFilesystem holding the NFS client recovery data
+	nfsRadosNamespace = "nfs-ns"
+	// nfsAppLabel is the value of the "app" label of the NFS server pods
+	nfsAppLabel = "rook-ceph-nfs"
+	// nfsNameLabel is the label of the NFS server pods holding the name of
+	// their CephNFS
+	nfsNameLabel = "ceph_nfs"
+
+	nfsVolumesInUseReason = "NFSVolumesInUse"
+)
+
+type ocsCephNFS struct{}
+
+// nfsEnabled returns true if the StorageCluster exports its CephFilesystem
+// over NFS
+func nfsEnabled(sc *ocsv1.StorageCluster) bool {
+	return sc.Spec.NFS != nil && sc.Spec.NFS.Enable
+}
+
+// validateNFSSpec checks that the NFS server can be created
+func validateNFSSpec(sc *ocsv1.StorageCluster) error {
+	if nfsEnabled(sc) && sc.Spec.ExternalStorage.Enable {
+		return fmt.Errorf("NFS is not supported with external storage")
+	}
+	return nil
+}
+
+// newCephNFSInstance returns the CephNFS serving the default CephFilesystem
+func (r *StorageClusterReconciler) newCephNFSInstance(initData *ocsv1.StorageCluster) (*cephv1.CephNFS, error) {
+	obj := &cephv1.CephNFS{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateNameForCephNFS(initData),
+			Namespace: initData.Namespace,
+		},
+		Spec: cephv1.NFSGaneshaSpec{
+			RADOS: cephv1.GaneshaRADOSSpec{
+				Pool:      generateNameForCephFilesystemDataPool(initData, 0),
+				Namespace: nfsRadosNamespace,
+			},
+			Server: cephv1.GaneshaServerSpec{
+				Active:            1,
+				Placement:         getPlacement(initData, "nfs"),
+				Resources:         getDaemonResources(initData, "nfs"),
+				PriorityClassName: openshiftUserCritical,
+			},
+		},
+	}
+	err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
+	if err != nil {
+		r.Log.Error(err, "Unable to set Controller Reference for CephNFS.", "CephNFS", klog.KRef(obj.Namespace, obj.Name))
+		return nil, err
+	}
+	return obj, nil
+}
+
+// newCephNFSService returns the Service exposing the NFS servers to clients.
+// The servers are only reachable from outside of the cluster if another
+// ServiceType is requested.
+func (r *StorageClusterReconciler) newCephNFSService(initData *ocsv1.StorageCluster) (*corev1.Service, error) {
+	serviceType := initData.Spec.NFS.ServiceType
+	if serviceType == "" {
+		serviceType = corev1.ServiceTypeClusterIP
+	}
+	obj := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateNameForCephNFSService(initData),
+			Namespace: initData.Namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: serviceType,
+			Selector: map[string]string{
+				"app":        nfsAppLabel,
+				nfsNameLabel: generateNameForCephNFS(initData),
+			},
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "nfs",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       nfsPort,
+					TargetPort: intstr.FromInt(nfsPort),
+				},
+			},
+		},
+	}
+	err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
+	if err != nil {
+		r.Log.Error(err, "Unable to set Controller Reference for NFS Service.", "Service", klog.KRef(obj.Namespace, obj.Name))
+		return nil, err
+	}
+	return obj, nil
+}
+
+// newCephNFSStorageClassConfiguration generates configuration options for an NFS StorageClass.
+func newCephNFSStorageClassConfiguration(initData *ocsv1.StorageCluster) StorageClassConfiguration {
+	persistentVolumeReclaimDelete := corev1.PersistentVolumeReclaimDelete
+	allowVolumeExpansion := true
+	nfsSpec := initData.Spec.NFS
+	return StorageClassConfiguration{
+		storageClass: &storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: generateNameForCephNFSSC(initData),
+				Annotations: map[string]string{
+					"description": "Provides RWO and RWX Filesystem volumes exported over NFS",
+				},
+			},
+			Provisioner:   generateNameForCSIDriver(initData, "nfs"),
+			ReclaimPolicy: &persistentVolumeReclaimDelete,
+			// AllowVolumeExpansion is set to true to enable expansion of OCS backed Volumes
+			AllowVolumeExpansion: &allowVolumeExpansion,
+			Parameters: map[string]string{
+				"clusterID":  initData.Namespace,
+				"fsName":     generateNameForCephFilesystem(initData),
+				"nfsCluster": generateNameForCephNFS(initData),
+				"server":     fmt.Sprintf("%s.%s.svc", generateNameForCephNFSService(initData), initData.Namespace),
+				"csi.storage.k8s.io/provisioner-secret-name":            "rook-csi-cephfs-provisioner",
+				"csi.storage.k8s.io/provisioner-secret-namespace":       initData.Namespace,
+				"csi.storage.k8s.io/node-stage-secret-name":             "rook-csi-cephfs-node",
+				"csi.storage.k8s.io/node-stage-secret-namespace":        initData.Namespace,
+				"csi.storage.k8s.io/controller-expand-secret-name":      "rook-csi-cephfs-provisioner",
+				"csi.storage.k8s.io/controller-expand-secret-namespace": initData.Namespace,
+			},
+		},
+		reconcileStrategy: ReconcileStrategy(nfsSpec.ReconcileStrategy),
+		disable:           nfsSpec.DisableStorageClass,
+	}
+}
+
+// nfsCSIDriverExists returns true if the CSI driver of the NFS StorageClass
+// is installed. Rook does not deploy it, so it has to be installed separately.
+func (r *StorageClusterReconciler) nfsCSIDriverExists(initData *ocsv1.StorageCluster) (bool, error) {
+	driverName := generateNameForCSIDriver(initData, "nfs")
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: driverName}, &storagev1.CSIDriver{})
+	if err == nil {
+		return true, nil
+	}
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	r.Log.Error(err, "Unable to get NFS CSIDriver.", "CSIDriver", klog.KRef("", driverName))
+	return false, err
+}
+
+// listCephNFSVolumes returns the names of the PVs provisioned from the NFS
+// StorageClass
+func (r *StorageClusterReconciler) listCephNFSVolumes(sc *ocsv1.StorageCluster) ([]string, error) {
+	storageClassName := generateNameForCephNFSSC(sc)
+	pvs := &corev1.PersistentVolumeList{}
+	if err := r.Client.List(context.TODO(), pvs, client.MatchingFields{pvStorageClassNameField: storageClassName}); err != nil {
+		return nil, fmt.Errorf("failed to list the PVs of StorageClass %s: %v", storageClassName, err)
+	}
+	var names []string
+	for _, pv := range pvs.Items {
+		if pv.Spec.StorageClassName == storageClassName {
+			names = append(names, pv.Name)
+		}
+	}
+	return names, nil
+}
+
+// ensureCreated ensures that the CephNFS, its Service and its StorageClass
+// exist in the desired state when NFS is enabled, and that they are deleted
+// when it is disabled. The StorageClass is only created once its CSI driver
+// is installed. Nothing is deleted while volumes of the NFS StorageClass
+// remain, as they would lose their NFS server.
+func (obj *ocsCephNFS) ensureCreated(r *StorageClusterReconciler, instance *ocsv1.StorageCluster) error {
+	reconcileStrategy := ReconcileStrategyUnknown
+	if instance.Spec.NFS != nil {
+		reconcileStrategy = ReconcileStrategy(instance.Spec.NFS.ReconcileStrategy)
+	}
+	if reconcileStrategy == ReconcileStrategyIgnore {
+		return nil
+	}
+	if !nfsEnabled(instance) {
+		// The NFS resources are left alone once created with the init
+		// strategy
+		if reconcileStrategy == ReconcileStrategyInit {
+			return nil
+		}
+		volumes, err := r.listCephNFSVolumes(instance)
+		if err != nil {
+			return err
+		}
+		if len(volumes) > 0 {
+			r.Log.Info("Not deleting the NFS resources as volumes of the NFS StorageClass remain.", "StorageClass", klog.KRef("", generateNameForCephNFSSC(instance)), "PersistentVolumes", volumes)
+			message := fmt.Sprintf("NFS is disabled but is not removed as %d PersistentVolumes of StorageClass %s remain", len(volumes), generateNameForCephNFSSC(instance))
+			statusutil.SetProgressingCondition(&r.conditions, nfsVolumesInUseReason, message)
+			r.recorder.ReportIfNotPresent(instance, corev1.EventTypeWarning, statusutil.EventReasonNFSRemovalBlocked, message)
+			return nil
+		}
+		return r.deleteCephNFSResources(instance)
+	}
+
+	if err := r.ensureCephNFS(instance, reconcileStrategy); err != nil {
+		return err
+	}
+	if err := r.ensureCephNFSService(instance, reconcileStrategy); err != nil {
+		return err
+	}
+
+	scc := newCephNFSStorageClassConfiguration(instance)
+	if !scc.disable {
+		driverExists, err := r.nfsCSIDriverExists(instance)
+		if err != nil {
+			return err
+		}
+		if !driverExists {
+			r.Log.Info("Not creating the NFS StorageClass as its CSI driver is not installed.", "StorageClass", klog.KRef("", scc.storageClass.Name), "CSIDriver", klog.KRef("", scc.storageClass.Provisioner))
+			scc.disable = true
+		}
+	}
+	return r.createStorageClasses([]StorageClassConfiguration{scc}, instance, componentNFS)
+}
+
+func (r *StorageClusterReconciler) ensureCephNFS(instance *ocsv1.StorageCluster, reconcileStrategy ReconcileStrategy) error {
+	cephNFS, err := r.newCephNFSInstance(instance)
+	if err != nil {
+		return err
+	}
+
+	existing := cephv1.CephNFS{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: cephNFS.Name, Namespace: cephNFS.Namespace}, &existing)
+	switch {
+	case err == nil:
+		if reconcileStrategy == ReconcileStrategyInit {
+			return nil
+		}
+		if existing.DeletionTimestamp != nil {
+			r.Log.Info("Unable to restore CephNFS because it is marked for deletion.", "CephNFS", klog.KRef(existing.Namespace, existing.Name))
+			return fmt.Errorf("failed to restore initialization object %s because it is marked for deletion", existing.Name)
+		}
+
+		drifted, err := getDriftedFields(cephNFS, &existing, "spec", "metadata.ownerReferences")
+		if err != nil {
+			return err
+		}
+		if len(drifted) == 0 {
+			return nil
+		}
+		r.reportDrift(instance, componentNFS, "CephNFS", cephNFS.Namespace, cephNFS.Name, drifted)
+
+		r.Log.Info("Restoring original CephNFS.", "CephNFS", klog.KRef(cephNFS.Namespace, cephNFS.Name), "Fields", drifted)
+		existing.ObjectMeta.OwnerReferences = cephNFS.ObjectMeta.OwnerReferences
+		cephNFS.ObjectMeta = existing.ObjectMeta
+		err = r.Client.Update(context.TODO(), cephNFS)
+		if err != nil {
+			r.Log.Error(err, "Unable to update CephNFS.", "CephNFS", klog.KRef(cephNFS.Namespace, cephNFS.Name))
+			return err
+		}
+	case errors.IsNotFound(err):
+		r.Log.Info("Creating CephNFS.", "CephNFS", klog.KRef(cephNFS.Namespace, cephNFS.Name))
+		err = r.Client.Create(context.TODO(), cephNFS)
+		if err != nil {
+			r.Log.Error(err, "Unable to create CephNFS.", "CephNFS", klog.KRef(cephNFS.Namespace, cephNFS.Name))
+			return err
+		}
+	default:
+		r.Log.Error(err, "Unable to get CephNFS.", "CephNFS", klog.KRef(cephNFS.Namespace, cephNFS.Name))
+		return err
+	}
+	return nil
+}
+
+func (r *StorageClusterReconciler) ensureCephNFSService(instance *ocsv1.StorageCluster, reconcileStrategy ReconcileStrategy) error {
+	service, err := r.newCephNFSService(instance)
+	if err != nil {
+		return err
+	}
+
+	existing := corev1.Service{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, &existing)
+	switch {
+	case err == nil:
+		if reconcileStrategy == ReconcileStrategyInit {
+			return nil
+		}
+		// The API server allocates the cluster IP and node ports
+		drifted, err := getDriftedSetFields(service, &existing, "spec", "metadata.ownerReferences")
+		if err != nil {
+			return err
+		}
+		if len(drifted) == 0 {
+			return nil
+		}
+		r.reportDrift(instance, componentNFS, "Service", service.Namespace, service.Name, drifted)
+
+		r.Log.Info("Restoring original NFS Service.", "Service", klog.KRef(service.Namespace, service.Name), "Fields", drifted)
+		// The cluster IP is allocated by Kubernetes, so only the fields set
+		// by the operator are restored
+		existing.ObjectMeta.OwnerReferences = service.ObjectMeta.OwnerReferences
+		existing.Spec.Type = service.Spec.Type
+		existing.Spec.Selector = service.Spec.Selector
+		existing.Spec.Ports = service.Spec.Ports
+		err = r.Client.Update(context.TODO(), &existing)
+		if err != nil {
+			r.Log.Error(err, "Unable to update NFS Service.", "Service", klog.KRef(service.Namespace, service.Name))
+			return err
+		}
+	case errors.IsNotFound(err):
+		r.Log.Info("Creating NFS Service.", "Service", klog.KRef(service.Namespace, service.Name))
+		err = r.Client.Create(context.TODO(), service)
+		if err != nil {
+			r.Log.Error(err, "Unable to create NFS Service.", "Service", klog.KRef(service.Namespace, service.Name))
+			return err
+		}
+	default:
+		r.Log.Error(err, "Unable to get NFS Service.", "Service", klog.KRef(service.Namespace, service.Name))
+		return err
+	}
+	return nil
+}
+
+// deleteCephNFSResources deletes the NFS StorageClass, the NFS Service and
+// the CephNFS of the StorageCluster without waiting for them to be gone
+func (r *StorageClusterReconciler) deleteCephNFSResources(sc *ocsv1.StorageCluster) error {
+	storageClass := &storagev1.StorageClass{}
+	storageClassName := generateNameForCephNFSSC(sc)
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: storageClassName}, storageClass)
+	if err == nil && storageClass.DeletionTimestamp == nil {
+		r.Log.Info("Deleting NFS StorageClass.", "StorageClass", klog.KRef("", storageClassName))
+		err = r.Client.Delete(context.TODO(), storageClass)
+		if err != nil && !errors.IsNotFound(err) {
+			r.Log.Error(err, "Failed to delete NFS StorageClass.", "StorageClass", klog.KRef("", storageClassName))
+			return fmt.Errorf("failed to delete NFS StorageClass %v: %v", storageClassName, err)
+		}
+	} else if err != nil && !errors.IsNotFound(err) {
+		r.Log.Error(err, "Unable to retrieve NFS StorageClass.", "StorageClass", klog.KRef("", storageClassName))
+		return fmt.Errorf("unable to retrieve NFS StorageClass %v: %v", storageClassName, err)
+	}
+
+	service := &corev1.Service{}
+	serviceName := generateNameForCephNFSService(sc)
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: serviceName, Namespace: sc.Namespace}, service)
+	if err == nil && service.DeletionTimestamp == nil {
+		r.Log.Info("Deleting NFS Service.", "Service", klog.KRef(sc.Namespace, serviceName))
+		err = r.Client.Delete(context.TODO(), service)
+		if err != nil && !errors.IsNotFound(err) {
+			r.Log.Error(err, "Failed to delete NFS Service.", "Service", klog.KRef(sc.Namespace, serviceName))
+			return fmt.Errorf("failed to delete NFS Service %v: %v", serviceName, err)
+		}
+	} else if err != nil && !errors.IsNotFound(err) {
+		r.Log.Error(err, "Unable to retrieve NFS Service.", "Service", klog.KRef(sc.Namespace, serviceName))
+		return fmt.Errorf("unable to retrieve NFS Service %v: %v", serviceName, err)
+	}
+
+	cephNFS := &cephv1.CephNFS{}
+	cephNFSName := generateNameForCephNFS(sc)
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: cephNFSName, Namespace: sc.Namespace}, cephNFS)
+	if err == nil && cephNFS.DeletionTimestamp == nil {
+		r.Log.Info("Deleting CephNFS.", "CephNFS", klog.KRef(sc.Namespace, cephNFSName))
+		err = r.Client.Delete(context.TODO(), cephNFS)
+		if err != nil && !errors.IsNotFound(err) {
+			r.Log.Error(err, "Failed to delete CephNFS.", "CephNFS", klog.KRef(sc.Namespace, cephNFSName))
+			return fmt.Errorf("failed to delete CephNFS %v: %v", cephNFSName, err)
+		}
+	} else if err != nil && !errors.IsNotFound(err) {
+		r.Log.Error(err, "Unable to retrieve CephNFS.", "CephNFS", klog.KRef(sc.Namespace, cephNFSName))
+		return fmt.Errorf("unable to retrieve CephNFS %v: %v", cephNFSName, err)
+	}
+	return nil
+}
+
+// ensureDeleted deletes the NFS StorageClass, the NFS Service and the CephNFS
+// owned by the StorageCluster, and waits for the CephNFS to be gone so that
+// the CephFilesystem backing it can be deleted next
+func (obj *ocsCephNFS) ensureDeleted(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) error {
+	if err := r.deleteCephNFSResources(sc); err != nil {
+		return fmt.Errorf("uninstall: %v", err)
+	}
+
+	foundCephNFS := &cephv1.CephNFS{}
+	cephNFSName := generateNameForCephNFS(sc)
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: cephNFSName, Namespace: sc.Namespace}, foundCephNFS)
+	if err != nil && errors.IsNotFound(err) {
+		r.Log.Info("Uninstall: CephNFS is deleted.", "CephNFS", klog.KRef(sc.Namespace, cephNFSName))
+		return nil
+	}
+	r.Log.Error(err, "Uninstall: Waiting for CephNFS to be deleted.", "CephNFS", klog.KRef(sc.Namespace, cephNFSName))
+	return fmt.Errorf("uninstall: Waiting for CephNFS %v to be deleted", cephNFSName)
+}
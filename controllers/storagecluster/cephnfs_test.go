@@ -0,0 +1,252 @@
+package storagecluster
+
+import (
+	"context"
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	api "github.com/openshift/ocs-operator/api/v1"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestValidateNFSSpec(t *testing.T) {
+	cases := []struct {
+		label         string
+		nfs           *api.NFSSpec
+		external      bool
+		expectedError bool
+	}{
+		{
+			label: "no NFS",
+		},
+		{
+			label: "NFS enabled",
+			nfs:   &api.NFSSpec{Enable: true},
+		},
+		{
+			label:    "NFS disabled with external storage",
+			nfs:      &api.NFSSpec{},
+			external: true,
+		},
+		{
+			label:         "NFS enabled with external storage",
+			nfs:           &api.NFSSpec{Enable: true},
+			external:      true,
+			expectedError: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := &api.StorageCluster{}
+		sc.Spec.NFS = c.nfs
+		sc.Spec.ExternalStorage.Enable = c.external
+		err := validateNFSSpec(sc)
+		if c.expectedError {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestCephNFSEnsureCreated(t *testing.T) {
+	cases := []struct {
+		label           string
+		nfs             *api.NFSSpec
+		csiDriver       bool
+		expectedCreated bool
+		expectedSC      bool
+		expectedSvcType corev1.ServiceType
+	}{
+		{
+			label: "NFS not configured",
+		},
+		{
+			label: "NFS disabled",
+			nfs:   &api.NFSSpec{},
+		},
+		{
+			label:           "NFS enabled",
+			nfs:             &api.NFSSpec{Enable: true},
+			csiDriver:       true,
+			expectedCreated: true,
+			expectedSC:      true,
+			expectedSvcType: corev1.ServiceTypeClusterIP,
+		},
+		{
+			label:           "NFS enabled without its CSI driver",
+			nfs:             &api.NFSSpec{Enable: true, ServiceType: corev1.ServiceTypeLoadBalancer},
+			expectedCreated: true,
+			expectedSvcType: corev1.ServiceTypeLoadBalancer,
+		},
+		{
+			label:           "NFS enabled without a StorageClass",
+			nfs:             &api.NFSSpec{Enable: true, DisableStorageClass: true, ServiceType: corev1.ServiceTypeNodePort},
+			csiDriver:       true,
+			expectedCreated: true,
+			expectedSvcType: corev1.ServiceTypeNodePort,
+		},
+		{
+			label: "NFS ignored",
+			nfs:   &api.NFSSpec{Enable: true, ReconcileStrategy: string(ReconcileStrategyIgnore)},
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		reconciler := createFakeStorageClusterReconciler(t)
+		sc := createDefaultStorageCluster()
+		sc.Spec.NFS = c.nfs
+		if c.csiDriver {
+			driver := &storagev1.CSIDriver{ObjectMeta: metav1.ObjectMeta{Name: sc.Namespace + ".nfs.csi.ceph.com"}}
+			assert.NoError(t, reconciler.Client.Create(context.TODO(), driver))
+		}
+
+		var obj ocsCephNFS
+		assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+
+		cephNFS := &cephv1.CephNFS{}
+		err := reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephnfs", Namespace: sc.Namespace}, cephNFS)
+		service := &corev1.Service{}
+		svcErr := reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephnfs-service", Namespace: sc.Namespace}, service)
+		if !c.expectedCreated {
+			assert.True(t, errors.IsNotFound(err))
+			assert.True(t, errors.IsNotFound(svcErr))
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, "ocsinit-cephfilesystem-data0", cephNFS.Spec.RADOS.Pool)
+			assert.Equal(t, 1, cephNFS.Spec.Server.Active)
+			assert.Len(t, cephNFS.OwnerReferences, 1)
+
+			assert.NoError(t, svcErr)
+			assert.Equal(t, c.expectedSvcType, service.Spec.Type)
+			assert.Equal(t, "ocsinit-cephnfs", service.Spec.Selector[nfsNameLabel])
+			assert.Equal(t, int32(nfsPort), service.Spec.Ports[0].Port)
+		}
+
+		storageClass := &storagev1.StorageClass{}
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-ceph-nfs"}, storageClass)
+		if !c.expectedSC {
+			assert.True(t, errors.IsNotFound(err))
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, sc.Namespace+".nfs.csi.ceph.com", storageClass.Provisioner)
+			assert.Equal(t, "ocsinit-cephnfs", storageClass.Parameters["nfsCluster"])
+			assert.Equal(t, "ocsinit-cephnfs-service."+sc.Namespace+".svc", storageClass.Parameters["server"])
+		}
+	}
+}
+
+func TestCephNFSRestoresDrift(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	sc := createDefaultStorageCluster()
+	sc.Spec.NFS = &api.NFSSpec{Enable: true}
+
+	var obj ocsCephNFS
+	assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+
+	key := types.NamespacedName{Name: "ocsinit-cephnfs", Namespace: sc.Namespace}
+	cephNFS := &cephv1.CephNFS{}
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), key, cephNFS))
+	cephNFS.Spec.Server.Active = 3
+	assert.NoError(t, reconciler.Client.Update(context.TODO(), cephNFS))
+
+	svcKey := types.NamespacedName{Name: "ocsinit-cephnfs-service", Namespace: sc.Namespace}
+	service := &corev1.Service{}
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), svcKey, service))
+	service.Spec.Type = corev1.ServiceTypeNodePort
+	service.Spec.ClusterIP = "172.30.0.10"
+	assert.NoError(t, reconciler.Client.Update(context.TODO(), service))
+
+	assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), key, cephNFS))
+	assert.Equal(t, 1, cephNFS.Spec.Server.Active)
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), svcKey, service))
+	assert.Equal(t, corev1.ServiceTypeClusterIP, service.Spec.Type)
+	assert.Equal(t, "172.30.0.10", service.Spec.ClusterIP)
+
+	// The init strategy leaves the created resources alone
+	sc.Spec.NFS.ReconcileStrategy = string(ReconcileStrategyInit)
+	cephNFS.Spec.Server.Active = 3
+	assert.NoError(t, reconciler.Client.Update(context.TODO(), cephNFS))
+	assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), key, cephNFS))
+	assert.Equal(t, 3, cephNFS.Spec.Server.Active)
+}
+
+func TestCephNFSEnsureDeleted(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	sc := createDefaultStorageCluster()
+	sc.Spec.NFS = &api.NFSSpec{Enable: true}
+	driver := &storagev1.CSIDriver{ObjectMeta: metav1.ObjectMeta{Name: sc.Namespace + ".nfs.csi.ceph.com"}}
+	assert.NoError(t, reconciler.Client.Create(context.TODO(), driver))
+
+	var obj ocsCephNFS
+	// Nothing to delete
+	assert.NoError(t, obj.ensureDeleted(&reconciler, sc))
+
+	assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+	assert.NoError(t, obj.ensureDeleted(&reconciler, sc))
+
+	err := reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephnfs", Namespace: sc.Namespace}, &cephv1.CephNFS{})
+	assert.True(t, errors.IsNotFound(err))
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephnfs-service", Namespace: sc.Namespace}, &corev1.Service{})
+	assert.True(t, errors.IsNotFound(err))
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-ceph-nfs"}, &storagev1.StorageClass{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestCephNFSDisable(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	sc := createDefaultStorageCluster()
+	sc.Spec.NFS = &api.NFSSpec{Enable: true}
+	driver := &storagev1.CSIDriver{ObjectMeta: metav1.ObjectMeta{Name: sc.Namespace + ".nfs.csi.ceph.com"}}
+	assert.NoError(t, reconciler.Client.Create(context.TODO(), driver))
+
+	var obj ocsCephNFS
+	assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+	err := reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-ceph-nfs"}, &storagev1.StorageClass{})
+	assert.NoError(t, err)
+
+	// Nothing is deleted with the init strategy
+	sc.Spec.NFS.Enable = false
+	sc.Spec.NFS.ReconcileStrategy = string(ReconcileStrategyInit)
+	assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephnfs", Namespace: sc.Namespace}, &cephv1.CephNFS{})
+	assert.NoError(t, err)
+
+	// Nothing is deleted while a volume of the NFS StorageClass remains
+	sc.Spec.NFS.ReconcileStrategy = ""
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-nfs"},
+		Spec:       corev1.PersistentVolumeSpec{StorageClassName: "ocsinit-ceph-nfs"},
+	}
+	assert.NoError(t, reconciler.Client.Create(context.TODO(), pv))
+	reconciler.conditions = nil
+	assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+	assert.True(t, conditionsv1.IsStatusConditionTrue(reconciler.conditions, conditionsv1.ConditionProgressing))
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephnfs", Namespace: sc.Namespace}, &cephv1.CephNFS{})
+	assert.NoError(t, err)
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-ceph-nfs"}, &storagev1.StorageClass{})
+	assert.NoError(t, err)
+
+	// Disabling NFS deletes its resources once its volumes are gone
+	assert.NoError(t, reconciler.Client.Delete(context.TODO(), pv))
+	reconciler.conditions = nil
+	assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+	assert.Empty(t, reconciler.conditions)
+
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephnfs", Namespace: sc.Namespace}, &cephv1.CephNFS{})
+	assert.True(t, errors.IsNotFound(err))
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephnfs-service", Namespace: sc.Namespace}, &corev1.Service{})
+	assert.True(t, errors.IsNotFound(err))
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-ceph-nfs"}, &storagev1.StorageClass{})
+	assert.True(t, errors.IsNotFound(err))
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	ocsv1 "github.com/openshift/ocs-operator/api/v1"
-	"github.com/openshift/ocs-operator/controllers/defaults"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -38,6 +37,11 @@ func (obj *ocsCephObjectStores) ensureCreated(r *StorageClusterReconciler, insta
 		return nil
 	}
 
+	if err := validateErasureCodedSpec(instance, instance.Spec.ManagedResources.CephObjectStores.ErasureCoded); err != nil {
+		r.Log.Error(err, "Invalid erasure coding for CephObjectStore.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
+		return err
+	}
+
 	cephObjectStores, err := r.newCephObjectStoreInstances(instance)
 	if err != nil {
 		return err
@@ -109,7 +113,16 @@ func (r *StorageClusterReconciler) createCephObjectStores(cephObjectStores []*ce
 				return err
 			}
 
-			r.Log.Info("Restoring original CephObjectStore.", "CephObjectStore", klog.KRef(cephObjectStore.Namespace, cephObjectStore.Name))
+			drifted, err := getDriftedFields(cephObjectStore, &existing, "spec", "metadata.ownerReferences")
+			if err != nil {
+				return err
+			}
+			if len(drifted) == 0 {
+				continue
+			}
+			r.reportDrift(instance, componentObjectStores, "CephObjectStore", cephObjectStore.Namespace, cephObjectStore.Name, drifted)
+
+			r.Log.Info("Restoring original CephObjectStore.", "CephObjectStore", klog.KRef(cephObjectStore.Namespace, cephObjectStore.Name), "Fields", drifted)
 			existing.ObjectMeta.OwnerReferences = cephObjectStore.ObjectMeta.OwnerReferences
 			cephObjectStore.ObjectMeta = existing.ObjectMeta
 			err = r.Client.Update(context.TODO(), cephObjectStore)
@@ -156,13 +169,17 @@ func (r *StorageClusterReconciler) newCephObjectStoreInstances(initData *ocsv1.S
 					Port:      80,
 					Instances: gatewayInstances,
 					Placement: getPlacement(initData, "rgw"),
-					Resources: defaults.GetDaemonResources("rgw", initData.Spec.Resources),
+					Resources: getDaemonResources(initData, "rgw"),
 					// set PriorityClassName for the rgw pods
 					PriorityClassName: openshiftUserCritical,
 				},
 			},
 		},
 	}
+	if ec := initData.Spec.ManagedResources.CephObjectStores.ErasureCoded; ec != nil {
+		ret[0].Spec.DataPool.Replicated = cephv1.ReplicatedSpec{}
+		ret[0].Spec.DataPool.ErasureCoded = generateCephErasureCodedSpec(ec)
+	}
 	for _, obj := range ret {
 		err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
 		if err != nil {
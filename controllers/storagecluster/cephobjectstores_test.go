@@ -69,3 +69,17 @@ func assertCephObjectStores(t *testing.T, reconciler StorageClusterReconciler, c
 	expectedCos, _ = reconciler.newCephObjectStoreInstances(cr)
 	assert.Equal(t, expectedCos[0].Spec.Gateway.Instances, int32(2))
 }
+
+func TestCephObjectStoresErasureCoded(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	cr := createDefaultStorageCluster()
+	cr.Spec.ManagedResources.CephObjectStores.ErasureCoded = &api.ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}
+
+	objectStores, err := reconciler.newCephObjectStoreInstances(cr)
+	assert.NoError(t, err)
+	dataPool := objectStores[0].Spec.DataPool
+	assert.Equal(t, uint(0), dataPool.Replicated.Size)
+	assert.Equal(t, uint(2), dataPool.ErasureCoded.DataChunks)
+	assert.Equal(t, uint(1), dataPool.ErasureCoded.CodingChunks)
+	assert.NotZero(t, objectStores[0].Spec.MetadataPool.Replicated.Size)
+}
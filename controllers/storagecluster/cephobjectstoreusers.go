@@ -134,7 +134,16 @@ func (r *StorageClusterReconciler) createCephObjectStoreUsers(cephObjectStoreUse
 				return fmt.Errorf("failed to restore CephObjectStoreUser %s because it is marked for deletion", existing.Name)
 			}
 
-			r.Log.Info("Restoring original CephObjectStoreUser.", "CephObjectStoreUser", klog.KRef(cephObjectStoreUser.Namespace, cephObjectStoreUser.Name))
+			drifted, err := getDriftedFields(cephObjectStoreUser, &existing, "spec", "metadata.ownerReferences")
+			if err != nil {
+				return err
+			}
+			if len(drifted) == 0 {
+				continue
+			}
+			r.reportDrift(instance, componentObjectStoreUsers, "CephObjectStoreUser", cephObjectStoreUser.Namespace, cephObjectStoreUser.Name, drifted)
+
+			r.Log.Info("Restoring original CephObjectStoreUser.", "CephObjectStoreUser", klog.KRef(cephObjectStoreUser.Namespace, cephObjectStoreUser.Name), "Fields", drifted)
 			existing.ObjectMeta.OwnerReferences = cephObjectStoreUser.ObjectMeta.OwnerReferences
 			cephObjectStoreUser.ObjectMeta = existing.ObjectMeta
 			err = r.Client.Update(context.TODO(), cephObjectStoreUser)
@@ -0,0 +1,207 @@
+package storagecluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	statusutil "github.com/openshift/ocs-operator/controllers/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The names under which the resourceManagers report their status in
+// StorageCluster.Status.Components
+const (
+	componentStorageClasses    = "storageClasses"
+	componentSnapshotClasses   = "snapshotClasses"
+	componentObjectStores      = "objectStores"
+	componentObjectStoreUsers  = "objectStoreUsers"
+	componentRGWRoutes         = "rgwRoutes"
+	componentBlockPools        = "blockPools"
+	componentFilesystems       = "filesystems"
+	componentNFS               = "nfs"
+	componentMirroring         = "mirroring"
+	componentCephConfig        = "cephConfig"
+	componentCephCluster       = "cephCluster"
+	componentNoobaa            = "noobaa"
+	componentJobTemplates      = "jobTemplates"
+	componentFullRatio         = "fullRatio"
+	componentQuickStarts       = "quickStarts"
+	componentExternalResources = "externalResources"
+)
+
+// componentDependencies lists, for each component, the components that must
+// reconcile successfully before it can be reconciled. A component is skipped
+// when one of its dependencies failed or was skipped itself, all the other
+// components are reconciled regardless of failures.
+var componentDependencies = map[string][]string{
+	componentObjectStoreUsers: {componentObjectStores},
+	componentRGWRoutes:        {componentObjectStores},
+	componentNFS:              {componentFilesystems},
+	componentMirroring:        {componentBlockPools},
+	componentNoobaa:           {componentCephCluster},
+	componentFullRatio:        {componentCephCluster},
+	// The external CephCluster connects using the details imported by the
+	// external resources
+	componentCephCluster: {componentExternalResources},
+}
+
+// getFailedDependency returns the first dependency of the component which
+// is in the failed set, or an empty string if there is none
+func getFailedDependency(name string, failed map[string]bool) string {
+	for _, dependency := range componentDependencies[name] {
+		if failed[dependency] {
+			return dependency
+		}
+	}
+	return ""
+}
+
+// getComponentName returns the name under which the resourceManager reports
+// its status
+func getComponentName(obj resourceManager) string {
+	switch obj.(type) {
+	case *ocsStorageClass:
+		return componentStorageClasses
+	case *ocsSnapshotClass:
+		return componentSnapshotClasses
+	case *ocsCephObjectStores:
+		return componentObjectStores
+	case *ocsCephObjectStoreUsers:
+		return componentObjectStoreUsers
+	case *ocsCephRGWRoutes:
+		return componentRGWRoutes
+	case *ocsCephBlockPools:
+		return componentBlockPools
+	case *ocsCephFilesystems:
+		return componentFilesystems
+	case *ocsCephNFS:
+		return componentNFS
+	case *ocsMirroring:
+		return componentMirroring
+	case *ocsCephConfig:
+		return componentCephConfig
+	case *ocsCephCluster:
+		return componentCephCluster
+	case *ocsNoobaaSystem:
+		return componentNoobaa
+	case *ocsJobTemplates:
+		return componentJobTemplates
+	case *ocsFullRatio:
+		return componentFullRatio
+	case *ocsQuickStarts:
+		return componentQuickStarts
+	case *ocsExternalResources:
+		return componentExternalResources
+	default:
+		return fmt.Sprintf("%T", obj)
+	}
+}
+
+// setComponentStatus records the outcome of reconciling a single component.
+// The conditions are the negative conditions reported by the component, they
+// replace the ones recorded by the previous reconcile. A failed component
+// additionally reports itself as Degraded.
+func setComponentStatus(sc *ocsv1.StorageCluster, name string, conditions []conditionsv1.Condition, reconcileErr error) {
+	if sc.Status.Components == nil {
+		sc.Status.Components = map[string]ocsv1.ComponentStatus{}
+	}
+	status := sc.Status.Components[name]
+	status.ObservedGeneration = sc.Generation
+	status.Conditions = conditions
+
+	if reconcileErr != nil {
+		status.Phase = statusutil.PhaseError
+		status.LastError = reconcileErr.Error()
+		status.Conditions = append(status.Conditions, conditionsv1.Condition{
+			Type:    conditionsv1.ConditionDegraded,
+			Status:  corev1.ConditionTrue,
+			Reason:  ocsv1.ReconcileFailed,
+			Message: reconcileErr.Error(),
+		})
+		sc.Status.Components[name] = status
+		return
+	}
+
+	status.LastError = ""
+	now := metav1.Now()
+	status.LastSuccessTime = &now
+	if conditions == nil {
+		status.Phase = statusutil.PhaseReady
+	} else if conditionsv1.IsStatusConditionTrue(conditions, conditionsv1.ConditionProgressing) {
+		status.Phase = statusutil.PhaseProgressing
+	} else {
+		status.Phase = statusutil.PhaseNotReady
+	}
+	sc.Status.Components[name] = status
+}
+
+// pruneComponentStatus removes the status of the components which are no
+// longer reconciled, e.g. after switching to external mode
+func pruneComponentStatus(sc *ocsv1.StorageCluster, reconciled map[string]bool) {
+	for name := range sc.Status.Components {
+		if !reconciled[name] {
+			delete(sc.Status.Components, name)
+		}
+	}
+}
+
+// aggregateComponentConditions combines the conditions of all components
+// into one condition per type, so that a component reporting a condition
+// does not hide the same condition reported by another one. It returns nil
+// if no component reported any condition.
+func aggregateComponentConditions(components map[string]ocsv1.ComponentStatus) []conditionsv1.Condition {
+	names := make([]string, 0, len(components))
+	for name := range components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var conditionTypes []conditionsv1.ConditionType
+	byType := map[conditionsv1.ConditionType][]string{}
+	for _, name := range names {
+		for _, condition := range components[name].Conditions {
+			if _, ok := byType[condition.Type]; !ok {
+				conditionTypes = append(conditionTypes, condition.Type)
+			}
+			byType[condition.Type] = append(byType[condition.Type], name)
+		}
+	}
+
+	var aggregated []conditionsv1.Condition
+	for _, conditionType := range conditionTypes {
+		// A negative condition outweighs any positive one of the same type
+		var result *conditionsv1.Condition
+		var messages []string
+		for _, name := range byType[conditionType] {
+			condition := conditionsv1.FindStatusCondition(components[name].Conditions, conditionType)
+			if result != nil && isNegativeCondition(*result) && !isNegativeCondition(*condition) {
+				continue
+			}
+			if result == nil || (!isNegativeCondition(*result) && isNegativeCondition(*condition)) {
+				result = condition.DeepCopy()
+				messages = nil
+			}
+			messages = append(messages, fmt.Sprintf("%s: %s", name, condition.Message))
+		}
+		result.Message = strings.Join(messages, "; ")
+		result.LastHeartbeatTime = metav1.Time{}
+		result.LastTransitionTime = metav1.Time{}
+		aggregated = append(aggregated, *result)
+	}
+	return aggregated
+}
+
+// isNegativeCondition returns true if the condition reports that the
+// component is not in the desired state
+func isNegativeCondition(condition conditionsv1.Condition) bool {
+	switch condition.Type {
+	case conditionsv1.ConditionAvailable, conditionsv1.ConditionUpgradeable, ocsv1.ConditionExternalClusterConnected:
+		return condition.Status != corev1.ConditionTrue
+	default:
+		return condition.Status == corev1.ConditionTrue
+	}
+}
@@ -0,0 +1,187 @@
+package storagecluster
+
+import (
+	"fmt"
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	api "github.com/openshift/ocs-operator/api/v1"
+	statusutil "github.com/openshift/ocs-operator/controllers/util"
+)
+
+func TestSetComponentStatus(t *testing.T) {
+	sc := &api.StorageCluster{}
+	sc.Generation = 2
+
+	progressing := []conditionsv1.Condition{
+		{
+			Type:    conditionsv1.ConditionProgressing,
+			Status:  corev1.ConditionTrue,
+			Reason:  "ClusterStateCreating",
+			Message: "CephCluster is creating",
+		},
+	}
+	setComponentStatus(sc, componentCephCluster, progressing, nil)
+	setComponentStatus(sc, componentBlockPools, nil, nil)
+	setComponentStatus(sc, componentNoobaa, nil, fmt.Errorf("failed to create NooBaa"))
+
+	cephCluster := sc.Status.Components[componentCephCluster]
+	assert.Equal(t, statusutil.PhaseProgressing, cephCluster.Phase)
+	assert.Equal(t, progressing, cephCluster.Conditions)
+	assert.Equal(t, int64(2), cephCluster.ObservedGeneration)
+	assert.NotNil(t, cephCluster.LastSuccessTime)
+
+	blockPools := sc.Status.Components[componentBlockPools]
+	assert.Equal(t, statusutil.PhaseReady, blockPools.Phase)
+	assert.Empty(t, blockPools.LastError)
+
+	noobaa := sc.Status.Components[componentNoobaa]
+	assert.Equal(t, statusutil.PhaseError, noobaa.Phase)
+	assert.Equal(t, "failed to create NooBaa", noobaa.LastError)
+	assert.Nil(t, noobaa.LastSuccessTime)
+	degraded := conditionsv1.FindStatusCondition(noobaa.Conditions, conditionsv1.ConditionDegraded)
+	assert.NotNil(t, degraded)
+	assert.Equal(t, api.ReconcileFailed, degraded.Reason)
+
+	// A failure replaces the conditions reported by the previous reconcile
+	setComponentStatus(sc, componentCephCluster, nil, fmt.Errorf("failed to update CephCluster"))
+	cephCluster = sc.Status.Components[componentCephCluster]
+	assert.Equal(t, statusutil.PhaseError, cephCluster.Phase)
+	assert.Nil(t, conditionsv1.FindStatusCondition(cephCluster.Conditions, conditionsv1.ConditionProgressing))
+	assert.NotNil(t, conditionsv1.FindStatusCondition(cephCluster.Conditions, conditionsv1.ConditionDegraded))
+
+	// A successful reconcile clears the last error but keeps the conditions
+	// of the component up to date
+	sc.Generation = 3
+	setComponentStatus(sc, componentNoobaa, nil, nil)
+	noobaa = sc.Status.Components[componentNoobaa]
+	assert.Equal(t, statusutil.PhaseReady, noobaa.Phase)
+	assert.Empty(t, noobaa.LastError)
+	assert.Equal(t, int64(3), noobaa.ObservedGeneration)
+	assert.NotNil(t, noobaa.LastSuccessTime)
+	assert.Empty(t, noobaa.Conditions)
+
+	// Components which are no longer reconciled are dropped
+	pruneComponentStatus(sc, map[string]bool{componentNoobaa: true})
+	assert.Equal(t, []string{componentNoobaa}, getComponentNames(sc))
+}
+
+func getComponentNames(sc *api.StorageCluster) []string {
+	names := []string{}
+	for name := range sc.Status.Components {
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestAggregateComponentConditions(t *testing.T) {
+	components := map[string]api.ComponentStatus{
+		componentNoobaa: {
+			Conditions: []conditionsv1.Condition{
+				{
+					Type:    conditionsv1.ConditionAvailable,
+					Status:  corev1.ConditionFalse,
+					Reason:  "NoobaaPhaseConfiguring",
+					Message: "NooBaa is configuring",
+				},
+			},
+		},
+		componentCephCluster: {
+			Conditions: []conditionsv1.Condition{
+				{
+					Type:    conditionsv1.ConditionAvailable,
+					Status:  corev1.ConditionFalse,
+					Reason:  "ClusterStateCreating",
+					Message: "CephCluster is creating",
+				},
+				{
+					Type:    conditionsv1.ConditionUpgradeable,
+					Status:  corev1.ConditionFalse,
+					Reason:  "ClusterStateCreating",
+					Message: "CephCluster is creating",
+				},
+			},
+		},
+		componentExternalResources: {
+			Conditions: []conditionsv1.Condition{
+				{
+					Type:    conditionsv1.ConditionUpgradeable,
+					Status:  corev1.ConditionTrue,
+					Reason:  "ExternalClusterStateConnected",
+					Message: "External cluster is connected",
+				},
+			},
+		},
+		componentBlockPools: {},
+	}
+
+	aggregated := aggregateComponentConditions(components)
+	assert.Len(t, aggregated, 2)
+
+	available := conditionsv1.FindStatusCondition(aggregated, conditionsv1.ConditionAvailable)
+	assert.NotNil(t, available)
+	assert.Equal(t, corev1.ConditionFalse, available.Status)
+	assert.Equal(t, "ClusterStateCreating", available.Reason)
+	assert.Equal(t, "cephCluster: CephCluster is creating; noobaa: NooBaa is configuring", available.Message)
+
+	// The negative condition wins over the positive one
+	upgradeable := conditionsv1.FindStatusCondition(aggregated, conditionsv1.ConditionUpgradeable)
+	assert.NotNil(t, upgradeable)
+	assert.Equal(t, corev1.ConditionFalse, upgradeable.Status)
+	assert.Equal(t, "cephCluster: CephCluster is creating", upgradeable.Message)
+
+	assert.Nil(t, aggregateComponentConditions(map[string]api.ComponentStatus{componentBlockPools: {}}))
+}
+
+// fakeComponent is a resourceManager which records whether it was
+// reconciled and fails with the given error
+type fakeComponent struct {
+	err        error
+	reconciled bool
+}
+
+func (obj *fakeComponent) ensureCreated(r *StorageClusterReconciler, sc *api.StorageCluster) error {
+	obj.reconciled = true
+	return obj.err
+}
+
+func (obj *fakeComponent) ensureDeleted(r *StorageClusterReconciler, sc *api.StorageCluster) error {
+	return nil
+}
+
+func TestEnsureComponentsCreated(t *testing.T) {
+	reconciler := &StorageClusterReconciler{
+		Log:      logf.Log.WithName("components_test"),
+		recorder: statusutil.NewEventReporter(&record.FakeRecorder{}),
+	}
+	sc := &api.StorageCluster{}
+
+	first := &fakeComponent{err: fmt.Errorf("transient failure")}
+	second := &fakeComponent{}
+	err := reconciler.ensureComponentsCreated(sc, []resourceManager{first, second})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "transient failure")
+	assert.True(t, first.reconciled)
+	assert.True(t, second.reconciled, "a failure must not keep independent components from being reconciled")
+
+	err = reconciler.ensureComponentsCreated(sc, []resourceManager{&fakeComponent{}, &fakeComponent{}})
+	assert.NoError(t, err)
+}
+
+func TestGetFailedDependency(t *testing.T) {
+	failed := map[string]bool{
+		componentCephCluster:  true,
+		componentObjectStores: true,
+	}
+	assert.Equal(t, componentCephCluster, getFailedDependency(componentNoobaa, failed))
+	assert.Equal(t, componentObjectStores, getFailedDependency(componentRGWRoutes, failed))
+	assert.Equal(t, componentObjectStores, getFailedDependency(componentObjectStoreUsers, failed))
+	assert.Empty(t, getFailedDependency(componentBlockPools, failed))
+	assert.Equal(t, componentExternalResources, getFailedDependency(componentCephCluster, map[string]bool{componentExternalResources: true}))
+	assert.Empty(t, getFailedDependency(componentNoobaa, map[string]bool{componentStorageClasses: true}))
+}
@@ -0,0 +1,179 @@
+package storagecluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	statusutil "github.com/openshift/ocs-operator/controllers/util"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// managedResourceDriftTotal counts the managed resources restored to their
+// desired state after being modified outside of the operator
+var managedResourceDriftTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "ocs_managed_resource_drift_total",
+		Help: "Number of times a resource managed by the StorageCluster was found modified and restored",
+	},
+	[]string{"kind", "namespace", "name"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(managedResourceDriftTotal)
+}
+
+// getDriftedFields returns the paths of the fields under the given managed
+// paths (e.g. "spec" or "metadata.ownerReferences") whose value differs
+// between the desired object and the live one. A field set in the live
+// object but not in the desired one is drifted as well, as the operator
+// unsets it, unless it holds the value the API server defaults it to.
+func getDriftedFields(desired, live interface{}, managedPaths ...string) ([]string, error) {
+	return diffObjects(desired, live, false, managedPaths)
+}
+
+// getDriftedSetFields is like getDriftedFields, but only compares the fields
+// which are set in the desired object. It is meant for the resources whose
+// unset fields are generated by the API server or other controllers, such
+// as the cluster IP of a Service, and for maps like labels and annotations
+// which are shared with other clients.
+func getDriftedSetFields(desired, live interface{}, managedPaths ...string) ([]string, error) {
+	return diffObjects(desired, live, true, managedPaths)
+}
+
+func diffObjects(desired, live interface{}, setOnly bool, managedPaths []string) ([]string, error) {
+	desiredValue, err := toJSONValue(desired)
+	if err != nil {
+		return nil, err
+	}
+	liveValue, err := toJSONValue(live)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	for _, path := range managedPaths {
+		fields = append(fields, diffJSONValues(path, getJSONField(desiredValue, path), getJSONField(liveValue, path), setOnly)...)
+	}
+	return fields, nil
+}
+
+// serverDefaults are the values the CRDs of the managed resources and the
+// API server default the fields with the given name to. A field which is
+// not set in the desired object is not drifted if it holds its default.
+var serverDefaults = map[string]interface{}{
+	// the pools of CephBlockPools, CephFilesystems and CephObjectStores
+	"compressionMode": "none",
+	// the network of CephClusters
+	"ipFamily": "IPv4",
+	// StorageClasses
+	"reclaimPolicy":     "Delete",
+	"volumeBindingMode": "Immediate",
+}
+
+func isServerDefault(path string, value interface{}) bool {
+	field := path[strings.LastIndex(path, ".")+1:]
+	defaultValue, ok := serverDefaults[field]
+	return ok && reflect.DeepEqual(defaultValue, value)
+}
+
+// getJSONField returns the value at the dot separated path, or nil if
+// there is none
+func getJSONField(value interface{}, path string) interface{} {
+	for _, field := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[field]
+	}
+	return value
+}
+
+// toJSONValue converts the object into its generic JSON representation, so
+// that it is compared the way it is serialized, e.g. without the empty
+// fields and with resource quantities in their canonical form
+func toJSONValue(obj interface{}) (interface{}, error) {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func diffJSONValues(path string, desired, live interface{}, setOnly bool) []string {
+	switch desiredValue := desired.(type) {
+	case nil:
+		if setOnly || live == nil || isServerDefault(path, live) {
+			return nil
+		}
+		return []string{path}
+	case map[string]interface{}:
+		liveValue, ok := live.(map[string]interface{})
+		if !ok {
+			return []string{path}
+		}
+		keys := make([]string, 0, len(desiredValue))
+		for key := range desiredValue {
+			keys = append(keys, key)
+		}
+		if !setOnly {
+			for key := range liveValue {
+				if _, ok := desiredValue[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+		}
+		sort.Strings(keys)
+		var fields []string
+		for _, key := range keys {
+			fields = append(fields, diffJSONValues(joinFieldPath(path, key), desiredValue[key], liveValue[key], setOnly)...)
+		}
+		return fields
+	case []interface{}:
+		liveValue, ok := live.([]interface{})
+		if !ok || len(liveValue) != len(desiredValue) {
+			return []string{path}
+		}
+		var fields []string
+		for i := range desiredValue {
+			fields = append(fields, diffJSONValues(fmt.Sprintf("%s[%d]", path, i), desiredValue[i], liveValue[i], setOnly)...)
+		}
+		return fields
+	default:
+		if !reflect.DeepEqual(desired, live) {
+			return []string{path}
+		}
+		return nil
+	}
+}
+
+func joinFieldPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// reportDrift records that a resource managed by the component was found
+// modified outside of the operator and is being restored. Differences
+// showing up after the StorageCluster spec changed are expected, so they
+// are not reported.
+func (r *StorageClusterReconciler) reportDrift(sc *ocsv1.StorageCluster, component, kind, namespace, name string, fields []string) {
+	status, ok := sc.Status.Components[component]
+	if !ok || status.ObservedGeneration != sc.Generation {
+		return
+	}
+	managedResourceDriftTotal.WithLabelValues(kind, namespace, name).Inc()
+	r.recorder.ReportIfNotPresent(sc, corev1.EventTypeWarning, statusutil.EventReasonResourceDrift,
+		fmt.Sprintf("%s %s was modified, restoring %s", kind, name, strings.Join(fields, ", ")))
+}
@@ -0,0 +1,268 @@
+package storagecluster
+
+import (
+	"context"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestGetDriftedFields(t *testing.T) {
+	desired := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{
+			OwnerReferences: []metav1.OwnerReference{{Name: "ocsinit", UID: "1"}},
+		},
+		Spec: cephv1.PoolSpec{
+			FailureDomain: "zone",
+			Replicated:    cephv1.ReplicatedSpec{Size: 3},
+		},
+	}
+
+	cases := []struct {
+		label          string
+		modify         func(live *cephv1.CephBlockPool)
+		expectedFields []string
+	}{
+		{
+			label:          "no change",
+			modify:         func(live *cephv1.CephBlockPool) {},
+			expectedFields: nil,
+		},
+		{
+			label: "managed field changed",
+			modify: func(live *cephv1.CephBlockPool) {
+				live.Spec.Replicated.Size = 1
+			},
+			expectedFields: []string{"spec.replicated.size"},
+		},
+		{
+			label: "unmanaged field set",
+			modify: func(live *cephv1.CephBlockPool) {
+				live.Labels = map[string]string{"app": "test"}
+			},
+			expectedFields: nil,
+		},
+		{
+			label: "managed field set which is unset in the desired object",
+			modify: func(live *cephv1.CephBlockPool) {
+				live.Spec.CompressionMode = "aggressive"
+				live.Spec.Parameters = map[string]string{"target_size_ratio": "0.5"}
+			},
+			expectedFields: []string{"spec.compressionMode", "spec.parameters"},
+		},
+		{
+			label: "managed field set to its server default",
+			modify: func(live *cephv1.CephBlockPool) {
+				live.Spec.CompressionMode = "none"
+			},
+			expectedFields: nil,
+		},
+		{
+			label: "several managed fields changed",
+			modify: func(live *cephv1.CephBlockPool) {
+				live.Spec.FailureDomain = "host"
+				live.OwnerReferences = nil
+			},
+			expectedFields: []string{"spec.failureDomain", "metadata.ownerReferences"},
+		},
+		{
+			label: "owner reference changed",
+			modify: func(live *cephv1.CephBlockPool) {
+				live.OwnerReferences[0].UID = "2"
+			},
+			expectedFields: []string{"metadata.ownerReferences[0].uid"},
+		},
+	}
+
+	for _, c := range cases {
+		live := desired.DeepCopy()
+		c.modify(live)
+		fields, err := getDriftedFields(desired, live, "spec", "metadata.ownerReferences")
+		assert.NoErrorf(t, err, "[%s]: unexpected error", c.label)
+		assert.Equalf(t, c.expectedFields, fields, "[%s]: unexpected drifted fields", c.label)
+	}
+
+	// Only the fields set in the desired object are compared by
+	// getDriftedSetFields
+	live := desired.DeepCopy()
+	live.Spec.CompressionMode = "aggressive"
+	live.Spec.Replicated.Size = 1
+	fields, err := getDriftedSetFields(desired, live, "spec")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"spec.replicated.size"}, fields)
+
+	// Quantities are compared by value, not by their representation
+	desiredResources := corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}
+	liveResources := corev1.ResourceList{corev1.ResourceMemory: *resource.NewQuantity(1024*1024*1024, resource.BinarySI)}
+	fields, err = getDriftedFields(map[string]interface{}{"spec": desiredResources}, map[string]interface{}{"spec": liveResources}, "spec")
+	assert.NoError(t, err)
+	assert.Empty(t, fields)
+}
+
+func TestGetDriftedFieldsRevertToDefault(t *testing.T) {
+	desired := &cephv1.CephCluster{
+		Spec: cephv1.ClusterSpec{
+			Mon: cephv1.MonSpec{Count: 3},
+			Mgr: cephv1.MgrSpec{Modules: []cephv1.Module{{Name: "pg_autoscaler", Enabled: true}}},
+		},
+	}
+	live := desired.DeepCopy()
+	live.Spec.Mgr.Count = 2
+	live.Spec.Mon.AllowMultiplePerNode = true
+	live.Spec.HealthCheck.DaemonHealth.Monitor.Timeout = "15m"
+
+	fields, err := getDriftedFields(desired, live, "spec")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"spec.healthCheck.daemonHealth.mon.timeout",
+		"spec.mgr.count",
+		"spec.mon.allowMultiplePerNode",
+	}, fields)
+}
+
+func TestCephBlockPoolDrift(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	cr := createDefaultStorageCluster()
+	cr.Generation = 1
+
+	var obj ocsCephBlockPools
+	err := obj.ensureCreated(&reconciler, cr)
+	assert.NoError(t, err)
+	setComponentStatus(cr, componentBlockPools, nil, nil)
+
+	name := types.NamespacedName{Name: "ocsinit-cephblockpool", Namespace: cr.Namespace}
+	pool := &cephv1.CephBlockPool{}
+	err = reconciler.Client.Get(context.TODO(), name, pool)
+	assert.NoError(t, err)
+	desiredSize := pool.Spec.Replicated.Size
+	resourceVersion := pool.ResourceVersion
+
+	// Nothing changed, the pool must not be updated
+	err = obj.ensureCreated(&reconciler, cr)
+	assert.NoError(t, err)
+	err = reconciler.Client.Get(context.TODO(), name, pool)
+	assert.NoError(t, err)
+	assert.Equal(t, resourceVersion, pool.ResourceVersion)
+	assert.Equal(t, float64(0), getDriftCount(t, "CephBlockPool", name))
+
+	// A hand-edited pool is restored and the drift is counted
+	pool.Spec.Replicated.Size = 1
+	err = reconciler.Client.Update(context.TODO(), pool)
+	assert.NoError(t, err)
+
+	err = obj.ensureCreated(&reconciler, cr)
+	assert.NoError(t, err)
+	err = reconciler.Client.Get(context.TODO(), name, pool)
+	assert.NoError(t, err)
+	assert.Equal(t, desiredSize, pool.Spec.Replicated.Size)
+	assert.Equal(t, float64(1), getDriftCount(t, "CephBlockPool", name))
+
+	// A field set by hand which the operator leaves unset is reverted
+	pool.Spec.Parameters = map[string]string{"target_size_ratio": "0.5"}
+	err = reconciler.Client.Update(context.TODO(), pool)
+	assert.NoError(t, err)
+
+	err = obj.ensureCreated(&reconciler, cr)
+	assert.NoError(t, err)
+	pool = &cephv1.CephBlockPool{}
+	err = reconciler.Client.Get(context.TODO(), name, pool)
+	assert.NoError(t, err)
+	assert.Empty(t, pool.Spec.Parameters)
+	assert.Equal(t, float64(2), getDriftCount(t, "CephBlockPool", name))
+
+	// A change of the StorageCluster spec is not drift
+	cr.Generation = 2
+	cr.Status.FailureDomain = "host"
+	err = obj.ensureCreated(&reconciler, cr)
+	assert.NoError(t, err)
+	err = reconciler.Client.Get(context.TODO(), name, pool)
+	assert.NoError(t, err)
+	assert.Equal(t, "host", pool.Spec.FailureDomain)
+	assert.Equal(t, float64(2), getDriftCount(t, "CephBlockPool", name))
+}
+
+func TestGetStorageClassDrift(t *testing.T) {
+	reclaimPolicy := corev1.PersistentVolumeReclaimDelete
+	desired := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "ocsinit-ceph-rbd-zone-a",
+			Labels: map[string]string{"app": "ocs-storagecluster"},
+		},
+		Provisioner:   "openshift-storage.rbd.csi.ceph.com",
+		Parameters:    map[string]string{"pool": "ocsinit-cephblockpool-zone-a"},
+		ReclaimPolicy: &reclaimPolicy,
+		AllowedTopologies: []corev1.TopologySelectorTerm{{
+			MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{{
+				Key:    corev1.LabelTopologyZone,
+				Values: []string{"a"},
+			}},
+		}},
+	}
+
+	cases := []struct {
+		label            string
+		modify           func(live *storagev1.StorageClass)
+		expectedFields   []string
+		expectedRecreate bool
+	}{
+		{
+			label:  "nothing changed",
+			modify: func(live *storagev1.StorageClass) {},
+		},
+		{
+			label: "parameter changed",
+			modify: func(live *storagev1.StorageClass) {
+				live.Parameters["pool"] = "replicapool"
+			},
+			expectedFields:   []string{"parameters.pool"},
+			expectedRecreate: true,
+		},
+		{
+			label: "allowed topology changed",
+			modify: func(live *storagev1.StorageClass) {
+				live.AllowedTopologies[0].MatchLabelExpressions[0].Values = []string{"b"}
+			},
+			expectedFields:   []string{"allowedTopologies[0].matchLabelExpressions[0].values[0]"},
+			expectedRecreate: true,
+		},
+		{
+			label: "allowed topologies removed",
+			modify: func(live *storagev1.StorageClass) {
+				live.AllowedTopologies = nil
+			},
+			expectedFields:   []string{"allowedTopologies"},
+			expectedRecreate: true,
+		},
+		{
+			label: "label changed",
+			modify: func(live *storagev1.StorageClass) {
+				live.Labels["app"] = "other"
+			},
+			expectedFields: []string{"metadata.labels.app"},
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		live := desired.DeepCopy()
+		c.modify(live)
+		fields, recreate, err := getStorageClassDrift(desired, live)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expectedFields, fields)
+		assert.Equal(t, c.expectedRecreate, recreate)
+	}
+}
+
+func getDriftCount(t *testing.T, kind string, name types.NamespacedName) float64 {
+	metric := &dto.Metric{}
+	err := managedResourceDriftTotal.WithLabelValues(kind, name.Namespace, name.Name).Write(metric)
+	assert.NoError(t, err)
+	return metric.GetCounter().GetValue()
+}
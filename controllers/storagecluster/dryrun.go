@@ -0,0 +1,492 @@
+package storagecluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	nbv1 "github.com/noobaa/noobaa-operator/v2/pkg/apis/noobaa/v1alpha1"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DryRunAnnotation makes the operator only compute the changes it would
+	// make to the managed resources when set to "true" on a StorageCluster.
+	// The changes are recorded in status.plan and no resource is modified.
+	DryRunAnnotation = "ocs.openshift.io/dry-run"
+)
+
+// plannedObject is a managed resource in its desired state
+type plannedObject struct {
+	kind    string
+	desired client.Object
+	// managedPaths are the paths of the fields managed by the operator
+	managedPaths []string
+	// createOnly is set for resources reconciled with ReconcileStrategyInit
+	createOnly bool
+	// setFieldsOnly is set for resources whose unset fields are generated
+	// by the API server, see getDriftedSetFields
+	setFieldsOnly bool
+	// drift replaces managedPaths for resources with both mutable and
+	// immutable fields. It returns the drifted fields and whether they
+	// require the resource to be recreated.
+	drift func(desired, live client.Object) ([]string, bool, error)
+}
+
+// planGenerator returns the desired managed resources of a component
+type planGenerator func(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) ([]plannedObject, error)
+
+func isDryRun(sc *ocsv1.StorageCluster) bool {
+	return sc.GetAnnotations()[DryRunAnnotation] == "true"
+}
+
+// planStorageCluster runs the generators of all the components and compares
+// the desired resources with the existing ones, without changing anything
+func (r *StorageClusterReconciler) planStorageCluster(sc *ocsv1.StorageCluster) *ocsv1.StorageClusterPlan {
+	// The generators may default fields of the StorageCluster, none of
+	// which must be persisted in dry-run mode
+	sc = sc.DeepCopy()
+	plan := &ocsv1.StorageClusterPlan{ObservedGeneration: sc.Generation}
+
+	var generators []planGenerator
+	if !sc.Spec.ExternalStorage.Enable {
+		generators = []planGenerator{
+			planStorageClasses,
+			planSnapshotClasses,
+			planCephObjectStores,
+			planCephObjectStoreUsers,
+			planCephRGWRoutes,
+			planCephBlockPools,
+			planMirroring,
+			planCephFilesystems,
+			planCephNFS,
+			planCephConfig,
+			planCephCluster,
+			planNoobaaSystem,
+		}
+	} else {
+		generators = []planGenerator{
+			planCephCluster,
+			planSnapshotClasses,
+			planNoobaaSystem,
+		}
+	}
+
+	for _, generator := range generators {
+		objs, err := generator(r, sc)
+		if err != nil {
+			plan.Errors = append(plan.Errors, err.Error())
+			continue
+		}
+		for _, obj := range objs {
+			change, err := r.planObject(obj)
+			if err != nil {
+				plan.Errors = append(plan.Errors, err.Error())
+				continue
+			}
+			if change != nil {
+				plan.Changes = append(plan.Changes, *change)
+			}
+		}
+	}
+
+	if !sc.Spec.ExternalStorage.Enable {
+		if err := r.planClusterExpansion(sc, plan); err != nil {
+			plan.Errors = append(plan.Errors, err.Error())
+		}
+	}
+	return plan
+}
+
+// planObject compares the desired resource with the existing one. It
+// returns nil if the resource is already in the desired state.
+func (r *StorageClusterReconciler) planObject(obj plannedObject) (*ocsv1.PlannedChange, error) {
+	change := &ocsv1.PlannedChange{
+		Kind:      obj.kind,
+		Name:      obj.desired.GetName(),
+		Namespace: obj.desired.GetNamespace(),
+	}
+
+	// The live object is read into an empty one, as the fields it omits
+	// would otherwise keep their desired values
+	live := newEmptyObject(obj.desired)
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: change.Name, Namespace: change.Namespace}, live)
+	if errors.IsNotFound(err) {
+		change.Action = ocsv1.PlannedActionCreate
+		return change, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get %s %s: %v", obj.kind, change.Name, err)
+	}
+	if obj.createOnly {
+		return nil, nil
+	}
+
+	recreate := false
+	if obj.drift != nil {
+		change.Fields, recreate, err = obj.drift(obj.desired, live)
+	} else if obj.setFieldsOnly {
+		change.Fields, err = getDriftedSetFields(obj.desired, live, obj.managedPaths...)
+	} else {
+		change.Fields, err = getDriftedFields(obj.desired, live, obj.managedPaths...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(change.Fields) == 0 {
+		return nil, nil
+	}
+	change.Action = ocsv1.PlannedActionUpdate
+	if recreate {
+		change.Action = ocsv1.PlannedActionRecreate
+	}
+	return change, nil
+}
+
+// newEmptyObject returns an object of the same kind as obj with no field set
+func newEmptyObject(obj client.Object) client.Object {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		empty := &unstructured.Unstructured{}
+		empty.SetGroupVersionKind(u.GroupVersionKind())
+		return empty
+	}
+	return reflect.New(reflect.TypeOf(obj).Elem()).Interface().(client.Object)
+}
+
+// planClusterExpansion records whether the CephCluster would be expanded
+// and which StorageClassDeviceSets would be added to it
+func (r *StorageClusterReconciler) planClusterExpansion(sc *ocsv1.StorageCluster, plan *ocsv1.StorageClusterPlan) error {
+	desired, err := r.newCephClusterInstance(sc)
+	if err != nil {
+		return err
+	}
+	found := &cephv1.CephCluster{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, found)
+	if errors.IsNotFound(err) {
+		found = &cephv1.CephCluster{}
+	} else if err != nil {
+		return fmt.Errorf("failed to get CephCluster %s: %v", desired.Name, err)
+	}
+
+	foundDeviceSets := map[string]bool{}
+	for _, ds := range found.Spec.Storage.StorageClassDeviceSets {
+		foundDeviceSets[ds.Name] = true
+	}
+	for _, ds := range desired.Spec.Storage.StorageClassDeviceSets {
+		if !foundDeviceSets[ds.Name] {
+			plan.AddedDeviceSets = append(plan.AddedDeviceSets, ds.Name)
+		}
+	}
+	// A new CephCluster is being created, not expanded
+	plan.ClusterExpansion = found.Name != "" && isCephClusterExpanding(found, desired)
+	return nil
+}
+
+func planStorageClasses(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) ([]plannedObject, error) {
+	sccs, err := r.newStorageClassConfigurations(sc)
+	if err != nil {
+		return nil, err
+	}
+	var objs []plannedObject
+	for _, scc := range sccs {
+		if scc.reconcileStrategy == ReconcileStrategyIgnore || scc.disable {
+			continue
+		}
+		objs = append(objs, plannedObject{
+			kind:       "StorageClass",
+			desired:    scc.storageClass,
+			createOnly: scc.reconcileStrategy == ReconcileStrategyInit,
+			drift: func(desired, live client.Object) ([]string, bool, error) {
+				return getStorageClassDrift(desired.(*storagev1.StorageClass), live.(*storagev1.StorageClass))
+			},
+		})
+	}
+	return objs, nil
+}
+
+func planSnapshotClasses(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) ([]plannedObject, error) {
+	var objs []plannedObject
+	for _, vscc := range newSnapshotClassConfigurations(sc) {
+		if vscc.reconcileStrategy == ReconcileStrategyIgnore || vscc.disable {
+			continue
+		}
+		objs = append(objs, plannedObject{
+			kind:         "VolumeSnapshotClass",
+			desired:      vscc.snapshotClass,
+			managedPaths: []string{"parameters"},
+			createOnly:   vscc.reconcileStrategy == ReconcileStrategyInit,
+		})
+	}
+	return objs, nil
+}
+
+func planCephObjectStores(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) ([]plannedObject, error) {
+	reconcileStrategy := ReconcileStrategy(sc.Spec.ManagedResources.CephObjectStores.ReconcileStrategy)
+	if reconcileStrategy == ReconcileStrategyIgnore {
+		return nil, nil
+	}
+	if avoid, err := r.PlatformsShouldAvoidObjectStore(); err != nil || avoid {
+		return nil, err
+	}
+	if err := validateErasureCodedSpec(sc, sc.Spec.ManagedResources.CephObjectStores.ErasureCoded); err != nil {
+		return nil, err
+	}
+	cephObjectStores, err := r.newCephObjectStoreInstances(sc)
+	if err != nil {
+		return nil, err
+	}
+	var objs []plannedObject
+	for _, cephObjectStore := range cephObjectStores {
+		objs = append(objs, newPlannedCephObject("CephObjectStore", cephObjectStore, reconcileStrategy))
+	}
+	return objs, nil
+}
+
+func planCephObjectStoreUsers(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) ([]plannedObject, error) {
+	reconcileStrategy := ReconcileStrategy(sc.Spec.ManagedResources.CephObjectStoreUsers.ReconcileStrategy)
+	if reconcileStrategy == ReconcileStrategyIgnore {
+		return nil, nil
+	}
+	if avoid, err := r.PlatformsShouldAvoidObjectStore(); err != nil || avoid {
+		return nil, err
+	}
+	cephObjectStoreUsers, err := r.newCephObjectStoreUserInstances(sc)
+	if err != nil {
+		return nil, err
+	}
+	var objs []plannedObject
+	for _, cephObjectStoreUser := range cephObjectStoreUsers {
+		objs = append(objs, newPlannedCephObject("CephObjectStoreUser", cephObjectStoreUser, reconcileStrategy))
+	}
+	return objs, nil
+}
+
+func planCephRGWRoutes(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) ([]plannedObject, error) {
+	reconcileStrategy := ReconcileStrategy(sc.Spec.ManagedResources.CephObjectStores.ReconcileStrategy)
+	if reconcileStrategy == ReconcileStrategyIgnore {
+		return nil, nil
+	}
+	if avoid, err := r.PlatformsShouldAvoidObjectStore(); err != nil || avoid {
+		return nil, err
+	}
+	routes, err := r.newCephRGWRoutes(sc)
+	if err != nil {
+		return nil, err
+	}
+	var objs []plannedObject
+	for _, route := range routes {
+		obj := newPlannedCephObject("Route", route, reconcileStrategy)
+		obj.setFieldsOnly = true
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+func planCephBlockPools(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) ([]plannedObject, error) {
+	reconcileStrategy := ReconcileStrategy(sc.Spec.ManagedResources.CephBlockPools.ReconcileStrategy)
+	if reconcileStrategy == ReconcileStrategyIgnore {
+		return nil, nil
+	}
+	if err := validateBlockPoolsErasureCoding(sc); err != nil {
+		return nil, err
+	}
+	if err := validateTopologyConstrainedPools(sc); err != nil {
+		return nil, err
+	}
+	cephBlockPools, err := r.newCephBlockPoolInstances(sc)
+	if err != nil {
+		return nil, err
+	}
+	var objs []plannedObject
+	for _, cephBlockPool := range cephBlockPools {
+		objs = append(objs, newPlannedCephObject("CephBlockPool", cephBlockPool, reconcileStrategy))
+	}
+	return objs, nil
+}
+
+// planMirroring plans the CephRBDMirror and the VolumeReplicationClasses.
+// The mirroring of the pools is planned with the CephBlockPools.
+func planMirroring(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) ([]plannedObject, error) {
+	if !mirroringEnabled(sc) {
+		return nil, nil
+	}
+	reconcileStrategy := ReconcileStrategy(sc.Spec.Mirroring.ReconcileStrategy)
+	if reconcileStrategy == ReconcileStrategyIgnore {
+		return nil, nil
+	}
+	if err := validateMirroringSpec(sc); err != nil {
+		return nil, err
+	}
+	cephRBDMirror, err := r.newCephRBDMirrorInstance(sc)
+	if err != nil {
+		return nil, err
+	}
+	objs := []plannedObject{newPlannedCephObject("CephRBDMirror", cephRBDMirror, reconcileStrategy)}
+
+	if _, err := r.listVolumeReplicationClasses(sc); meta.IsNoMatchError(err) {
+		return objs, nil
+	} else if err != nil {
+		return objs, fmt.Errorf("failed to list VolumeReplicationClasses: %v", err)
+	}
+	for _, vrc := range newVolumeReplicationClasses(sc) {
+		objs = append(objs, plannedObject{
+			kind:       "VolumeReplicationClass",
+			desired:    vrc,
+			createOnly: reconcileStrategy == ReconcileStrategyInit,
+			drift: func(desired, live client.Object) ([]string, bool, error) {
+				drifted, err := getDriftedFields(desired, live, "spec")
+				if err != nil {
+					return nil, false, err
+				}
+				driftedLabels, err := getDriftedSetFields(desired, live, "metadata.labels")
+				return append(drifted, driftedLabels...), false, err
+			},
+		})
+	}
+	return objs, nil
+}
+
+func planCephFilesystems(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) ([]plannedObject, error) {
+	reconcileStrategy := ReconcileStrategy(sc.Spec.ManagedResources.CephFilesystems.ReconcileStrategy)
+	if reconcileStrategy == ReconcileStrategyIgnore {
+		return nil, nil
+	}
+	if err := validateFilesystemsErasureCoding(sc); err != nil {
+		return nil, err
+	}
+	cephFilesystems, err := r.newCephFilesystemInstances(sc)
+	if err != nil {
+		return nil, err
+	}
+	var objs []plannedObject
+	for _, cephFilesystem := range cephFilesystems {
+		objs = append(objs, newPlannedCephObject("CephFilesystem", cephFilesystem, reconcileStrategy))
+	}
+	return objs, nil
+}
+
+func planCephNFS(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) ([]plannedObject, error) {
+	if !nfsEnabled(sc) {
+		return nil, nil
+	}
+	reconcileStrategy := ReconcileStrategy(sc.Spec.NFS.ReconcileStrategy)
+	if reconcileStrategy == ReconcileStrategyIgnore {
+		return nil, nil
+	}
+	cephNFS, err := r.newCephNFSInstance(sc)
+	if err != nil {
+		return nil, err
+	}
+	service, err := r.newCephNFSService(sc)
+	if err != nil {
+		return nil, err
+	}
+	serviceObj := newPlannedCephObject("Service", service, reconcileStrategy)
+	serviceObj.setFieldsOnly = true
+	objs := []plannedObject{
+		newPlannedCephObject("CephNFS", cephNFS, reconcileStrategy),
+		serviceObj,
+	}
+
+	scc := newCephNFSStorageClassConfiguration(sc)
+	if scc.disable {
+		return objs, nil
+	}
+	driverExists, err := r.nfsCSIDriverExists(sc)
+	if err != nil || !driverExists {
+		return objs, err
+	}
+	return append(objs, plannedObject{
+		kind:       "StorageClass",
+		desired:    scc.storageClass,
+		createOnly: reconcileStrategy == ReconcileStrategyInit,
+		drift: func(desired, live client.Object) ([]string, bool, error) {
+			return getStorageClassDrift(desired.(*storagev1.StorageClass), live.(*storagev1.StorageClass))
+		},
+	}), nil
+}
+
+func planCephConfig(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) ([]plannedObject, error) {
+	reconcileStrategy := ReconcileStrategy(sc.Spec.ManagedResources.CephConfig.ReconcileStrategy)
+	if reconcileStrategy == ReconcileStrategyIgnore {
+		return nil, nil
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rookConfigMapName,
+			Namespace: sc.Namespace,
+		},
+		Data: map[string]string{
+			"config": getCephConfigData(sc),
+		},
+	}
+	return []plannedObject{{
+		kind:         "ConfigMap",
+		desired:      cm,
+		managedPaths: []string{"data"},
+		createOnly:   reconcileStrategy == ReconcileStrategyInit,
+	}}, nil
+}
+
+func planCephCluster(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) ([]plannedObject, error) {
+	cephCluster, err := r.newCephClusterInstance(sc)
+	if err != nil {
+		return nil, err
+	}
+	return []plannedObject{{
+		kind:         "CephCluster",
+		desired:      cephCluster,
+		managedPaths: []string{"spec"},
+	}}, nil
+}
+
+func planNoobaaSystem(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) ([]plannedObject, error) {
+	if sc.Spec.MultiCloudGateway != nil {
+		reconcileStrategy := ReconcileStrategy(sc.Spec.MultiCloudGateway.ReconcileStrategy)
+		if reconcileStrategy == ReconcileStrategyIgnore || reconcileStrategy == ReconcileStrategyStandalone {
+			return nil, nil
+		}
+	}
+
+	nb := &nbv1.NooBaa{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: "noobaa", Namespace: sc.Namespace}, nb)
+	if errors.IsNotFound(err) {
+		nb = &nbv1.NooBaa{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "noobaa",
+				Namespace: sc.Namespace,
+			},
+		}
+	} else if err != nil {
+		r.Log.Error(err, "Failed to retrieve NooBaa system.", "Noobaa", klog.KRef(sc.Namespace, "noobaa"))
+		return nil, err
+	}
+	if err := r.setNooBaaDesiredState(nb, sc); err != nil {
+		return nil, err
+	}
+	return []plannedObject{{
+		kind:         "NooBaa",
+		desired:      nb,
+		managedPaths: []string{"spec"},
+	}}, nil
+}
+
+// newPlannedCephObject returns the plannedObject of a resource reconciled
+// the way the Ceph resources are, i.e. restoring its spec and owner
+func newPlannedCephObject(kind string, desired client.Object, reconcileStrategy ReconcileStrategy) plannedObject {
+	return plannedObject{
+		kind:         kind,
+		desired:      desired,
+		managedPaths: []string{"spec", "metadata.ownerReferences"},
+		createOnly:   reconcileStrategy == ReconcileStrategyInit,
+	}
+}
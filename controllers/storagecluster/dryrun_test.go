@@ -0,0 +1,210 @@
+package storagecluster
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/openshift/ocs-operator/api/v1"
+)
+
+func TestIsDryRun(t *testing.T) {
+	cases := []struct {
+		label       string
+		annotations map[string]string
+		expected    bool
+	}{
+		{label: "no annotation", annotations: nil, expected: false},
+		{label: "enabled", annotations: map[string]string{DryRunAnnotation: "true"}, expected: true},
+		{label: "disabled", annotations: map[string]string{DryRunAnnotation: "false"}, expected: false},
+	}
+
+	for _, c := range cases {
+		sc := &api.StorageCluster{ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations}}
+		assert.Equalf(t, c.expected, isDryRun(sc), "[%s]: unexpected dry-run mode", c.label)
+	}
+}
+
+func TestPlanStorageCluster(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	cr := createDefaultStorageCluster()
+	cr.Generation = 1
+
+	// Nothing exists yet, everything is planned to be created and nothing
+	// is written
+	plan := reconciler.planStorageCluster(cr)
+	assert.Equal(t, int64(1), plan.ObservedGeneration)
+	poolName := types.NamespacedName{Name: "ocsinit-cephblockpool", Namespace: cr.Namespace}
+	change := findPlannedChange(plan, "CephBlockPool", poolName.Name)
+	assert.NotNil(t, change)
+	assert.Equal(t, api.PlannedActionCreate, change.Action)
+	assert.NotNil(t, findPlannedChange(plan, "CephCluster", generateNameForCephCluster(cr)))
+	assert.False(t, plan.ClusterExpansion)
+
+	pools := &cephv1.CephBlockPoolList{}
+	err := reconciler.Client.List(context.TODO(), pools)
+	assert.NoError(t, err)
+	assert.Empty(t, pools.Items)
+	assert.Nil(t, cr.Status.Plan)
+
+	// Resources already in the desired state are left out of the plan
+	var obj ocsCephBlockPools
+	err = obj.ensureCreated(&reconciler, cr)
+	assert.NoError(t, err)
+	plan = reconciler.planStorageCluster(cr)
+	assert.Nil(t, findPlannedChange(plan, "CephBlockPool", poolName.Name))
+
+	// A change of the StorageCluster is planned as an update of the fields
+	// it would change
+	cr.Status.FailureDomain = "host"
+	plan = reconciler.planStorageCluster(cr)
+	change = findPlannedChange(plan, "CephBlockPool", poolName.Name)
+	assert.NotNil(t, change)
+	assert.Equal(t, api.PlannedActionUpdate, change.Action)
+	assert.Equal(t, []string{"spec.failureDomain"}, change.Fields)
+
+	pool := &cephv1.CephBlockPool{}
+	err = reconciler.Client.Get(context.TODO(), poolName, pool)
+	assert.NoError(t, err)
+	assert.Equal(t, "zone", pool.Spec.FailureDomain)
+
+	// A field unset by hand, and so left out of the live object, is
+	// planned to be restored
+	cr.Status.FailureDomain = "zone"
+	pool.Spec.EnableRBDStats = false
+	err = reconciler.Client.Update(context.TODO(), pool)
+	assert.NoError(t, err)
+	change = findPlannedChange(reconciler.planStorageCluster(cr), "CephBlockPool", poolName.Name)
+	assert.NotNil(t, change)
+	assert.Equal(t, []string{"spec.enableRBDStats"}, change.Fields)
+}
+
+func TestPlanStorageClusterRevertToDefault(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	cr := createDefaultStorageCluster()
+	cr.Spec.ManagedResources.CephBlockPools.AdditionalPools = []api.AdditionalBlockPool{
+		{Name: "fast", CompressionMode: "aggressive"},
+	}
+
+	var obj ocsCephBlockPools
+	err := obj.ensureCreated(&reconciler, cr)
+	assert.NoError(t, err)
+	poolName := generateNameForAdditionalCephBlockPool(cr, "fast")
+	assert.Nil(t, findPlannedChange(reconciler.planStorageCluster(cr), "CephBlockPool", poolName))
+
+	// Removing a setting of the StorageCluster is planned as an update of
+	// the field going back to its default
+	cr.Spec.ManagedResources.CephBlockPools.AdditionalPools[0].CompressionMode = ""
+	change := findPlannedChange(reconciler.planStorageCluster(cr), "CephBlockPool", poolName)
+	assert.NotNil(t, change)
+	assert.Equal(t, api.PlannedActionUpdate, change.Action)
+	assert.Equal(t, []string{"spec.compressionMode"}, change.Fields)
+}
+
+func TestPlanCephNFS(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	cr := createDefaultStorageCluster()
+	cr.Spec.NFS = &api.NFSSpec{Enable: true}
+
+	// The StorageClass is not planned until its CSI driver is installed
+	plan := reconciler.planStorageCluster(cr)
+	change := findPlannedChange(plan, "CephNFS", generateNameForCephNFS(cr))
+	assert.NotNil(t, change)
+	assert.Equal(t, api.PlannedActionCreate, change.Action)
+	change = findPlannedChange(plan, "Service", generateNameForCephNFSService(cr))
+	assert.NotNil(t, change)
+	assert.Equal(t, api.PlannedActionCreate, change.Action)
+	assert.Nil(t, findPlannedChange(plan, "StorageClass", generateNameForCephNFSSC(cr)))
+
+	driver := &storagev1.CSIDriver{ObjectMeta: metav1.ObjectMeta{Name: cr.Namespace + ".nfs.csi.ceph.com"}}
+	assert.NoError(t, reconciler.Client.Create(context.TODO(), driver))
+	change = findPlannedChange(reconciler.planStorageCluster(cr), "StorageClass", generateNameForCephNFSSC(cr))
+	assert.NotNil(t, change)
+	assert.Equal(t, api.PlannedActionCreate, change.Action)
+
+	// Resources already in the desired state are left out of the plan
+	var obj ocsCephNFS
+	assert.NoError(t, obj.ensureCreated(&reconciler, cr))
+	plan = reconciler.planStorageCluster(cr)
+	assert.Nil(t, findPlannedChange(plan, "CephNFS", generateNameForCephNFS(cr)))
+	assert.Nil(t, findPlannedChange(plan, "Service", generateNameForCephNFSService(cr)))
+	assert.Nil(t, findPlannedChange(plan, "StorageClass", generateNameForCephNFSSC(cr)))
+
+	// A change of the service type is planned as an update of the Service
+	cr.Spec.NFS.ServiceType = corev1.ServiceTypeNodePort
+	change = findPlannedChange(reconciler.planStorageCluster(cr), "Service", generateNameForCephNFSService(cr))
+	assert.NotNil(t, change)
+	assert.Equal(t, api.PlannedActionUpdate, change.Action)
+	assert.Equal(t, []string{"spec.type"}, change.Fields)
+
+	// Nothing is planned for an ignored CephNFS
+	cr.Spec.NFS.ReconcileStrategy = string(ReconcileStrategyIgnore)
+	assert.Nil(t, findPlannedChange(reconciler.planStorageCluster(cr), "Service", generateNameForCephNFSService(cr)))
+}
+
+func TestPlanMirroring(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	cr := createDefaultStorageCluster()
+	poolName := "ocsinit-cephblockpool"
+
+	var pools ocsCephBlockPools
+	assert.NoError(t, pools.ensureCreated(&reconciler, cr))
+
+	// Enabling mirroring plans the CephRBDMirror, the
+	// VolumeReplicationClasses and the mirroring of the pools
+	cr.Spec.Mirroring = &api.MirroringSpec{Enabled: true, SchedulingIntervals: []string{"1h"}}
+	plan := reconciler.planStorageCluster(cr)
+	change := findPlannedChange(plan, "CephRBDMirror", generateNameForCephRBDMirror(cr))
+	assert.NotNil(t, change)
+	assert.Equal(t, api.PlannedActionCreate, change.Action)
+	change = findPlannedChange(plan, "VolumeReplicationClass", generateNameForVolumeReplicationClass(cr, "1h"))
+	assert.NotNil(t, change)
+	assert.Equal(t, api.PlannedActionCreate, change.Action)
+	change = findPlannedChange(plan, "CephBlockPool", poolName)
+	assert.NotNil(t, change)
+	assert.Equal(t, api.PlannedActionUpdate, change.Action)
+	assert.Contains(t, change.Fields, "spec.mirroring.enabled")
+
+	// Resources already in the desired state are left out of the plan
+	var mirroring ocsMirroring
+	assert.NoError(t, pools.ensureCreated(&reconciler, cr))
+	assert.NoError(t, mirroring.ensureCreated(&reconciler, cr))
+	plan = reconciler.planStorageCluster(cr)
+	assert.Nil(t, findPlannedChange(plan, "CephRBDMirror", generateNameForCephRBDMirror(cr)))
+	assert.Nil(t, findPlannedChange(plan, "VolumeReplicationClass", generateNameForVolumeReplicationClass(cr, "1h")))
+	assert.Nil(t, findPlannedChange(plan, "CephBlockPool", poolName))
+
+	// A hand-edited VolumeReplicationClass is planned to be restored
+	vrc := &unstructured.Unstructured{}
+	vrc.SetGroupVersionKind(volumeReplicationClassGVK)
+	err := reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: generateNameForVolumeReplicationClass(cr, "1h")}, vrc)
+	assert.NoError(t, err)
+	err = unstructured.SetNestedField(vrc.Object, "2h", "spec", "parameters", "schedulingInterval")
+	assert.NoError(t, err)
+	assert.NoError(t, reconciler.Client.Update(context.TODO(), vrc))
+	change = findPlannedChange(reconciler.planStorageCluster(cr), "VolumeReplicationClass", generateNameForVolumeReplicationClass(cr, "1h"))
+	assert.NotNil(t, change)
+	assert.Equal(t, api.PlannedActionUpdate, change.Action)
+	assert.Equal(t, []string{"spec.parameters.schedulingInterval"}, change.Fields)
+
+	// Nothing is planned for ignored mirroring resources
+	cr.Spec.Mirroring.ReconcileStrategy = string(ReconcileStrategyIgnore)
+	cr.Spec.Mirroring.SchedulingIntervals = []string{"1d"}
+	assert.Nil(t, findPlannedChange(reconciler.planStorageCluster(cr), "VolumeReplicationClass", generateNameForVolumeReplicationClass(cr, "1d")))
+}
+
+func findPlannedChange(plan *api.StorageClusterPlan, kind, name string) *api.PlannedChange {
+	for i := range plan.Changes {
+		if plan.Changes[i].Kind == kind && plan.Changes[i].Name == name {
+			return &plan.Changes[i]
+		}
+	}
+	return nil
+}
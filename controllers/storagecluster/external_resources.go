@@ -351,7 +351,7 @@ func (r *StorageClusterReconciler) createExternalStorageClusterResources(instanc
 		}
 	}
 	// creating only the available storageClasses
-	err = r.createStorageClasses(availableSCCs)
+	err = r.createStorageClasses(availableSCCs, instance, componentExternalResources)
 	if err != nil {
 		r.Log.Error(err, "Failed to create needed StorageClasses.")
 		return err
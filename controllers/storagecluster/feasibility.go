@@ -0,0 +1,302 @@
+package storagecluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// noProvisioner is the provisioner of StorageClasses whose volumes are
+	// created in advance, such as local volumes
+	noProvisioner = "kubernetes.io/no-provisioner"
+
+	// osdDeviceSetLabel is set by Rook on the PVCs of the OSDs to the name
+	// of their StorageClassDeviceSet
+	osdDeviceSetLabel = "ceph.rook.io/DeviceSet"
+
+	capacityInsufficientReason = "CapacityInsufficient"
+
+	// pvStorageClassNameField is the name of the cache index of the PVs by
+	// StorageClass
+	pvStorageClassNameField = "spec.storageClassName"
+)
+
+// failureDomain identifies the nodes OSDs are spread over. An empty key
+// stands for all the storage nodes.
+type failureDomain struct {
+	key   string
+	value string
+}
+
+func (fd failureDomain) String() string {
+	if fd.key == "" {
+		return "the storage nodes"
+	}
+	return fmt.Sprintf("%s=%s", fd.key, fd.value)
+}
+
+// osdDemand is the number of OSDs placed in a failure domain and the
+// resources they request
+type osdDemand struct {
+	osds   int
+	cpu    resource.Quantity
+	memory resource.Quantity
+	// maxCPU and maxMemory are the largest requests of a single OSD, which
+	// has to fit on one node
+	maxCPU    resource.Quantity
+	maxMemory resource.Quantity
+}
+
+func (d *osdDemand) add(osds int, requests corev1.ResourceList) {
+	d.osds += osds
+	for name, total := range map[corev1.ResourceName]*resource.Quantity{corev1.ResourceCPU: &d.cpu, corev1.ResourceMemory: &d.memory} {
+		request, ok := requests[name]
+		if !ok {
+			continue
+		}
+		for i := 0; i < osds; i++ {
+			total.Add(request)
+		}
+	}
+	if cpu, ok := requests[corev1.ResourceCPU]; ok && cpu.Cmp(d.maxCPU) > 0 {
+		d.maxCPU = cpu
+	}
+	if memory, ok := requests[corev1.ResourceMemory]; ok && memory.Cmp(d.maxMemory) > 0 {
+		d.maxMemory = memory
+	}
+}
+
+// getOSDDemand returns the OSDs of the StorageDeviceSets per failure domain.
+// OSDs are spread evenly over the failure domain values, as the placement of
+// newStorageClassDeviceSets does, so each value gets its rounded up share.
+// The OSDs of StorageDeviceSets with a custom placement may run anywhere.
+func getOSDDemand(sc *ocsv1.StorageCluster) map[failureDomain]*osdDemand {
+	demands := map[failureDomain]*osdDemand{}
+	addDemand := func(fd failureDomain, osds int, requests corev1.ResourceList) {
+		if demands[fd] == nil {
+			demands[fd] = &osdDemand{}
+		}
+		demands[fd].add(osds, requests)
+	}
+
+	for i := range sc.Spec.StorageDeviceSets {
+		ds := &sc.Spec.StorageDeviceSets[i]
+		resources := getDeviceSetResources(sc, ds)
+		requests := resources.Requests
+		if requests == nil {
+			// Limits are used as requests when only limits are set
+			requests = resources.Limits
+		}
+		osds := getDeviceSetOSDCount(ds, ds.Count)
+		if osds == 0 {
+			continue
+		}
+
+		var topologyKey string
+		var topologyKeyValues []string
+		if ds.Placement.NodeAffinity == nil && ds.Placement.PodAffinity == nil &&
+			ds.Placement.PodAntiAffinity == nil && ds.Placement.TopologySpreadConstraints == nil {
+			topologyKey = ds.TopologyKey
+			if topologyKey == "" {
+				topologyKey = getFailureDomain(sc)
+			}
+			if sc.Status.NodeTopologies != nil {
+				topologyKey, topologyKeyValues = sc.Status.NodeTopologies.GetKeyValues(topologyKey)
+			}
+		}
+		if len(topologyKeyValues) < getMinDeviceSetReplica(sc) {
+			addDemand(failureDomain{}, osds, requests)
+			continue
+		}
+		share := (osds + len(topologyKeyValues) - 1) / len(topologyKeyValues)
+		for _, value := range topologyKeyValues {
+			addDemand(failureDomain{key: topologyKey, value: value}, share, requests)
+		}
+	}
+	return demands
+}
+
+// checkNodeCapacity compares the resources requested by the OSDs of each
+// failure domain with the allocatable resources of its nodes, and returns a
+// description of each failure domain that cannot host its OSDs. Nodes which
+// do not report allocatable resources yet are not taken into account, the
+// minimum number of storage nodes is checked by reconcileNodeTopologyMap.
+func checkNodeCapacity(sc *ocsv1.StorageCluster, nodes []corev1.Node) []string {
+	demands := getOSDDemand(sc)
+	domains := make([]failureDomain, 0, len(demands))
+	for fd := range demands {
+		domains = append(domains, fd)
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		return domains[i].String() < domains[j].String()
+	})
+
+	var problems []string
+	for _, fd := range domains {
+		demand := demands[fd]
+		var cpu, memory resource.Quantity
+		var nodeDetails []string
+		reported, fits := false, false
+		for _, node := range nodes {
+			if fd.key != "" && node.Labels[fd.key] != fd.value {
+				continue
+			}
+			nodeCPU, hasCPU := node.Status.Allocatable[corev1.ResourceCPU]
+			nodeMemory, hasMemory := node.Status.Allocatable[corev1.ResourceMemory]
+			if !hasCPU || !hasMemory {
+				continue
+			}
+			reported = true
+			cpu.Add(nodeCPU)
+			memory.Add(nodeMemory)
+			if nodeCPU.Cmp(demand.maxCPU) >= 0 && nodeMemory.Cmp(demand.maxMemory) >= 0 {
+				fits = true
+			}
+			nodeDetails = append(nodeDetails, fmt.Sprintf("%s (cpu %s, memory %s)", node.Name, nodeCPU.String(), nodeMemory.String()))
+		}
+		if !reported {
+			continue
+		}
+		if fits && demand.cpu.Cmp(cpu) <= 0 && demand.memory.Cmp(memory) <= 0 {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("%d OSDs in %s request cpu %s and memory %s but its nodes can allocate %s",
+			demand.osds, fd, demand.cpu.String(), demand.memory.String(), strings.Join(nodeDetails, ", ")))
+	}
+	return problems
+}
+
+// checkVolumeAvailability returns a description of each StorageClass of the
+// StorageDeviceSets which cannot provide a volume to each of their OSDs.
+// Only StorageClasses without a provisioner are checked, as their volumes
+// have to exist beforehand.
+func (r *StorageClusterReconciler) checkVolumeAvailability(sc *ocsv1.StorageCluster) ([]string, error) {
+	requested := map[string]int{}
+	for i := range sc.Spec.StorageDeviceSets {
+		ds := &sc.Spec.StorageDeviceSets[i]
+		osds := getDeviceSetOSDCount(ds, ds.Count)
+		for _, template := range []*corev1.PersistentVolumeClaim{&ds.DataPVCTemplate, ds.MetadataPVCTemplate, ds.WalPVCTemplate} {
+			if template != nil && template.Spec.StorageClassName != nil && *template.Spec.StorageClassName != "" {
+				requested[*template.Spec.StorageClassName] += osds
+			}
+		}
+	}
+	if len(requested) == 0 {
+		return nil, nil
+	}
+
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	err := r.Client.List(context.TODO(), pvcs, client.InNamespace(sc.Namespace), client.HasLabels{osdDeviceSetLabel})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list OSD PVCs: %v", err)
+	}
+
+	storageClassNames := make([]string, 0, len(requested))
+	for name := range requested {
+		storageClassNames = append(storageClassNames, name)
+	}
+	sort.Strings(storageClassNames)
+
+	var problems []string
+	for _, name := range storageClassNames {
+		storageClass := &storagev1.StorageClass{}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{Name: name}, storageClass)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				// The StorageClass may be created later, e.g. by the
+				// local storage operator
+				r.Log.Info("StorageClass of the StorageDeviceSets not found, not checking its volumes.", "StorageClass", klog.KRef("", name))
+				continue
+			}
+			return nil, fmt.Errorf("failed to get StorageClass %s: %v", name, err)
+		}
+		if storageClass.Provisioner != noProvisioner {
+			continue
+		}
+
+		bound, available := 0, 0
+		for _, pvc := range pvcs.Items {
+			if pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName == name && pvc.Status.Phase == corev1.ClaimBound {
+				bound++
+			}
+		}
+		// Only the PVs of the StorageClass are listed, using the index of
+		// the cache
+		pvs := &corev1.PersistentVolumeList{}
+		if err := r.Client.List(context.TODO(), pvs, client.MatchingFields{pvStorageClassNameField: name}); err != nil {
+			return nil, fmt.Errorf("failed to list the PVs of StorageClass %s: %v", name, err)
+		}
+		for _, pv := range pvs.Items {
+			if pv.Spec.StorageClassName == name && pv.Status.Phase == corev1.VolumeAvailable {
+				available++
+			}
+		}
+		if bound+available < requested[name] {
+			problems = append(problems, fmt.Sprintf("StorageClass %s has %d bound and %d available volumes for %d OSD volumes",
+				name, bound, available, requested[name]))
+		}
+	}
+	return problems, nil
+}
+
+// isFeasibilityEnforced returns true if the CephCluster must not be created
+// or expanded while the OSDs of the StorageDeviceSets do not fit, which is
+// the default
+func isFeasibilityEnforced(sc *ocsv1.StorageCluster) bool {
+	if sc.Spec.Capacity == nil || sc.Spec.Capacity.EnforceFeasibility == nil {
+		return true
+	}
+	return *sc.Spec.Capacity.EnforceFeasibility
+}
+
+// indexPVStorageClassName indexes the PVs by StorageClass, so that the volume
+// availability check does not go through all the PVs of the cluster
+func indexPVStorageClassName(obj client.Object) []string {
+	pv, ok := obj.(*corev1.PersistentVolume)
+	if !ok || pv.Spec.StorageClassName == "" {
+		return nil
+	}
+	return []string{pv.Spec.StorageClassName}
+}
+
+// checkCapacityFeasibility checks that the storage nodes and the
+// StorageClasses of the StorageDeviceSets can host all of their OSDs, and
+// sets the CapacityInsufficient condition accordingly. The condition is only
+// present while the OSDs do not fit. It returns false if they do not fit.
+func (r *StorageClusterReconciler) checkCapacityFeasibility(sc *ocsv1.StorageCluster) (bool, error) {
+	nodes, err := r.getStorageClusterEligibleNodes(sc)
+	if err != nil {
+		return false, fmt.Errorf("failed to list storage nodes: %v", err)
+	}
+	problems := checkNodeCapacity(sc, nodes.Items)
+	volumeProblems, err := r.checkVolumeAvailability(sc)
+	if err != nil {
+		return false, err
+	}
+	problems = append(problems, volumeProblems...)
+
+	if len(problems) == 0 {
+		conditionsv1.RemoveStatusCondition(&sc.Status.Conditions, ocsv1.ConditionCapacityInsufficient)
+		return true, nil
+	}
+	r.Log.Info("StorageDeviceSets do not fit on the storage nodes.", "StorageCluster", klog.KRef(sc.Namespace, sc.Name), "Problems", problems)
+	conditionsv1.SetStatusCondition(&sc.Status.Conditions, conditionsv1.Condition{
+		Type:    ocsv1.ConditionCapacityInsufficient,
+		Status:  corev1.ConditionTrue,
+		Reason:  capacityInsufficientReason,
+		Message: strings.Join(problems, "; "),
+	})
+	return false, nil
+}
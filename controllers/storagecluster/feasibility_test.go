@@ -0,0 +1,319 @@
+package storagecluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	api "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/defaults"
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const feasibilityZoneLabel = "topology.kubernetes.io/zone"
+
+func newFeasibilityStorageCluster(count int, storageClassName string) *api.StorageCluster {
+	sc := &api.StorageCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocsinit", Namespace: "openshift-storage"},
+		Spec: api.StorageClusterSpec{
+			StorageDeviceSets: []api.StorageDeviceSet{
+				{
+					Name:  "ocs-deviceset",
+					Count: count,
+					DataPVCTemplate: corev1.PersistentVolumeClaim{
+						Spec: corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageClassName,
+						},
+					},
+				},
+			},
+		},
+		Status: api.StorageClusterStatus{
+			FailureDomain: "zone",
+			NodeTopologies: &api.NodeTopologyMap{
+				Labels: map[string]api.TopologyLabelValues{
+					feasibilityZoneLabel: {"a", "b", "c"},
+				},
+			},
+		},
+	}
+	return sc
+}
+
+func newFeasibilityNode(name, zone, cpu, memory string) corev1.Node {
+	node := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				defaults.NodeAffinityKey: "",
+				feasibilityZoneLabel:     zone,
+			},
+		},
+	}
+	if cpu != "" {
+		node.Status.Allocatable = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}
+	}
+	return node
+}
+
+func TestCheckNodeCapacity(t *testing.T) {
+	cases := []struct {
+		label            string
+		count            int
+		resources        *corev1.ResourceRequirements
+		nodes            []corev1.Node
+		expectedProblems []string
+	}{
+		{
+			label: "enough capacity",
+			count: 6,
+			nodes: []corev1.Node{
+				newFeasibilityNode("node-a", "a", "4", "10Gi"),
+				newFeasibilityNode("node-b", "b", "4", "10Gi"),
+				newFeasibilityNode("node-c", "c", "4", "10Gi"),
+			},
+		},
+		{
+			label: "zone without enough capacity",
+			count: 6,
+			nodes: []corev1.Node{
+				newFeasibilityNode("node-a", "a", "4", "10Gi"),
+				newFeasibilityNode("node-b", "b", "4", "10Gi"),
+				newFeasibilityNode("node-c1", "c", "2", "8Gi"),
+				newFeasibilityNode("node-c2", "c", "1", "8Gi"),
+			},
+			expectedProblems: []string{
+				"2 OSDs in topology.kubernetes.io/zone=c request cpu 4 and memory 10Gi but its nodes can allocate node-c1 (cpu 2, memory 8Gi), node-c2 (cpu 1, memory 8Gi)",
+			},
+		},
+		{
+			label:     "OSD larger than any node",
+			count:     3,
+			resources: &corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3"), corev1.ResourceMemory: resource.MustParse("5Gi")}},
+			nodes: []corev1.Node{
+				newFeasibilityNode("node-a", "a", "4", "10Gi"),
+				newFeasibilityNode("node-b", "b", "4", "10Gi"),
+				newFeasibilityNode("node-c1", "c", "2", "8Gi"),
+				newFeasibilityNode("node-c2", "c", "2", "8Gi"),
+			},
+			expectedProblems: []string{
+				"1 OSDs in topology.kubernetes.io/zone=c request cpu 3 and memory 5Gi but its nodes can allocate node-c1 (cpu 2, memory 8Gi), node-c2 (cpu 2, memory 8Gi)",
+			},
+		},
+		{
+			label: "nodes not reporting allocatable resources",
+			count: 6,
+			nodes: []corev1.Node{
+				newFeasibilityNode("node-a", "a", "", ""),
+				newFeasibilityNode("node-b", "b", "", ""),
+				newFeasibilityNode("node-c", "c", "", ""),
+			},
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := newFeasibilityStorageCluster(c.count, "gp2")
+		if c.resources != nil {
+			sc.Spec.StorageDeviceSets[0].Resources = *c.resources
+		}
+		problems := checkNodeCapacity(sc, c.nodes)
+		assert.Equal(t, c.expectedProblems, problems)
+	}
+}
+
+func TestGetOSDDemand(t *testing.T) {
+	// Without enough failure domains the OSDs may run on any storage node
+	sc := newFeasibilityStorageCluster(3, "gp2")
+	sc.Status.NodeTopologies.Labels[feasibilityZoneLabel] = api.TopologyLabelValues{"a"}
+	demands := getOSDDemand(sc)
+	assert.Len(t, demands, 1)
+	assert.Equal(t, 3, demands[failureDomain{}].osds)
+	assert.Equal(t, "6", demands[failureDomain{}].cpu.String())
+
+	// OSDs are shared evenly between the failure domains
+	sc = newFeasibilityStorageCluster(9, "gp2")
+	sc.Spec.StorageDeviceSets[0].Replica = 2
+	sc.Status.NodeTopologies.Labels[feasibilityZoneLabel] = api.TopologyLabelValues{"a", "b", "c", "d"}
+	demands = getOSDDemand(sc)
+	assert.Len(t, demands, 4)
+	for _, zone := range []string{"a", "b", "c", "d"} {
+		assert.Equal(t, 5, demands[failureDomain{key: feasibilityZoneLabel, value: zone}].osds)
+	}
+}
+
+func TestCheckVolumeAvailability(t *testing.T) {
+	localStorageClass := &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "localblock"},
+		Provisioner: noProvisioner,
+	}
+	gp2StorageClass := &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "gp2"},
+		Provisioner: "kubernetes.io/aws-ebs",
+	}
+
+	cases := []struct {
+		label            string
+		storageClassName string
+		count            int
+		availablePVs     int
+		boundPVCs        int
+		expectedProblems []string
+	}{
+		{
+			label:            "dynamically provisioned volumes",
+			storageClassName: "gp2",
+			count:            6,
+		},
+		{
+			label:            "unknown StorageClass",
+			storageClassName: "unknown",
+			count:            6,
+		},
+		{
+			label:            "enough local volumes",
+			storageClassName: "localblock",
+			count:            6,
+			availablePVs:     3,
+			boundPVCs:        3,
+		},
+		{
+			label:            "missing local volumes",
+			storageClassName: "localblock",
+			count:            6,
+			availablePVs:     1,
+			boundPVCs:        3,
+			expectedProblems: []string{
+				"StorageClass localblock has 3 bound and 1 available volumes for 6 OSD volumes",
+			},
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := newFeasibilityStorageCluster(c.count, c.storageClassName)
+		objs := []runtime.Object{localStorageClass, gp2StorageClass}
+		for j := 0; j < c.availablePVs; j++ {
+			objs = append(objs, &corev1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("local-pv-%d", j)},
+				Spec:       corev1.PersistentVolumeSpec{StorageClassName: c.storageClassName},
+				Status:     corev1.PersistentVolumeStatus{Phase: corev1.VolumeAvailable},
+			})
+		}
+		for j := 0; j < c.boundPVCs; j++ {
+			objs = append(objs, &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("ocs-deviceset-%d-data-0", j),
+					Namespace: sc.Namespace,
+					Labels:    map[string]string{osdDeviceSetLabel: fmt.Sprintf("ocs-deviceset-%d", j)},
+				},
+				Spec:   corev1.PersistentVolumeClaimSpec{StorageClassName: &c.storageClassName},
+				Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+			})
+		}
+		reconciler := createFakeStorageClusterReconciler(t, objs...)
+
+		problems, err := reconciler.checkVolumeAvailability(sc)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expectedProblems, problems)
+	}
+}
+
+func TestEnsureCephClusterCapacityInsufficient(t *testing.T) {
+	nodes := []corev1.Node{
+		newFeasibilityNode("node-a", "a", "4", "10Gi"),
+		newFeasibilityNode("node-b", "b", "4", "10Gi"),
+		newFeasibilityNode("node-c", "c", "4", "10Gi"),
+	}
+	objs := []runtime.Object{&storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "gp2"},
+		Provisioner: "kubernetes.io/aws-ebs",
+	}}
+	for i := range nodes {
+		objs = append(objs, &nodes[i])
+	}
+	sc := newFeasibilityStorageCluster(9, "gp2")
+	sc.Status.Images.Ceph = &api.ComponentImageStatus{}
+	reconciler := createFakeStorageClusterReconciler(t, objs...)
+	cephClusterKey := types.NamespacedName{Name: generateNameForCephCluster(sc), Namespace: sc.Namespace}
+	obj := &ocsCephCluster{}
+
+	// By default, the CephCluster is not created while the OSDs do not fit
+	err := obj.ensureCreated(&reconciler, sc)
+	assert.Error(t, err)
+	assert.True(t, conditionsv1.IsStatusConditionTrue(sc.Status.Conditions, api.ConditionCapacityInsufficient))
+	cephCluster := &rookCephv1.CephCluster{}
+	err = reconciler.Client.Get(context.TODO(), cephClusterKey, cephCluster)
+	assert.True(t, errors.IsNotFound(err))
+
+	// It is created once they fit
+	sc.Spec.StorageDeviceSets[0].Count = 6
+	err = obj.ensureCreated(&reconciler, sc)
+	assert.NoError(t, err)
+	assert.Nil(t, conditionsv1.FindStatusCondition(sc.Status.Conditions, api.ConditionCapacityInsufficient))
+	err = reconciler.Client.Get(context.TODO(), cephClusterKey, cephCluster)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cephCluster.Spec.Storage.StorageClassDeviceSets[0].Count)
+
+	// An expansion which does not fit is held back
+	sc.Spec.StorageDeviceSets[0].Count = 9
+	err = obj.ensureCreated(&reconciler, sc)
+	assert.NoError(t, err)
+	assert.True(t, conditionsv1.IsStatusConditionTrue(sc.Status.Conditions, api.ConditionCapacityInsufficient))
+	err = reconciler.Client.Get(context.TODO(), cephClusterKey, cephCluster)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cephCluster.Spec.Storage.StorageClassDeviceSets[0].Count)
+}
+
+func TestEnsureCephClusterCapacityInsufficientAdvisory(t *testing.T) {
+	nodes := []corev1.Node{
+		newFeasibilityNode("node-a", "a", "4", "10Gi"),
+		newFeasibilityNode("node-b", "b", "4", "10Gi"),
+		newFeasibilityNode("node-c", "c", "4", "10Gi"),
+	}
+	objs := []runtime.Object{&storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "gp2"},
+		Provisioner: "kubernetes.io/aws-ebs",
+	}}
+	for i := range nodes {
+		objs = append(objs, &nodes[i])
+	}
+	sc := newFeasibilityStorageCluster(9, "gp2")
+	enforceFeasibility := false
+	sc.Spec.Capacity = &api.CapacitySpec{EnforceFeasibility: &enforceFeasibility}
+	sc.Status.Images.Ceph = &api.ComponentImageStatus{}
+	reconciler := createFakeStorageClusterReconciler(t, objs...)
+	cephClusterKey := types.NamespacedName{Name: generateNameForCephCluster(sc), Namespace: sc.Namespace}
+	obj := &ocsCephCluster{}
+
+	// Without the feasibility enforced, the CephCluster is created and only
+	// the condition reports that the OSDs do not fit
+	err := obj.ensureCreated(&reconciler, sc)
+	assert.NoError(t, err)
+	assert.True(t, conditionsv1.IsStatusConditionTrue(sc.Status.Conditions, api.ConditionCapacityInsufficient))
+	cephCluster := &rookCephv1.CephCluster{}
+	err = reconciler.Client.Get(context.TODO(), cephClusterKey, cephCluster)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, cephCluster.Spec.Storage.StorageClassDeviceSets[0].Count)
+
+	// Expansions are applied as well
+	sc.Spec.StorageDeviceSets[0].Count = 12
+	err = obj.ensureCreated(&reconciler, sc)
+	assert.NoError(t, err)
+	cephCluster = &rookCephv1.CephCluster{}
+	err = reconciler.Client.Get(context.TODO(), cephClusterKey, cephCluster)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, cephCluster.Spec.Storage.StorageClassDeviceSets[0].Count)
+}
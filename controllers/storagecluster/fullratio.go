@@ -0,0 +1,325 @@
+package storagecluster
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	statusutil "github.com/openshift/ocs-operator/controllers/util"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+type ocsFullRatio struct{}
+
+const (
+	fullRatioJobName = "ocs-set-full-ratio"
+	fullRatioEnvVar  = "FULL_RATIO"
+
+	// maxFullRatio is the highest full ratio an override may set. Above
+	// it, the OSDs may fill up before Ceph stops them at the failsafe full
+	// ratio.
+	maxFullRatio = 0.9
+
+	// The reasons for which an override is reverted before being removed
+	// from the spec
+	fullRatioRevertExpired      = "Expired"
+	fullRatioRevertUsageDropped = "UsageDropped"
+
+	reasonFullRatioOverrideActive   = "FullRatioOverrideActive"
+	reasonFullRatioOverrideInactive = "FullRatioOverrideInactive"
+	reasonFullRatioOverrideApplying = "FullRatioOverrideApplying"
+)
+
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+
+// validateFullRatioOverride checks that the full ratio override is above the
+// configured full ratio and below the safe ceiling
+func validateFullRatioOverride(sc *ocsv1.StorageCluster) error {
+	if sc.Spec.Capacity == nil || sc.Spec.Capacity.FullRatioOverride == nil {
+		return nil
+	}
+	override := sc.Spec.Capacity.FullRatioOverride
+	ratio, err := strconv.ParseFloat(override.Ratio, 64)
+	if err != nil {
+		return fmt.Errorf("failed to validate fullRatioOverride: invalid ratio %q", override.Ratio)
+	}
+	if ratio > maxFullRatio {
+		return fmt.Errorf("failed to validate fullRatioOverride: ratio %s exceeds the maximum of %v", override.Ratio, maxFullRatio)
+	}
+	fullRatio, err := getCephConfigRatio(sc, "mon_osd_full_ratio")
+	if err != nil {
+		return err
+	}
+	if ratio <= fullRatio {
+		return fmt.Errorf("failed to validate fullRatioOverride: ratio %s must be above the full ratio %v", override.Ratio, fullRatio)
+	}
+	if override.Duration.Duration <= 0 {
+		return fmt.Errorf("failed to validate fullRatioOverride: duration must be positive")
+	}
+	return nil
+}
+
+// getCephConfigRatio returns the value of a ratio option of the global Ceph
+// config section, as set in spec.cephConfig or by default
+func getCephConfigRatio(sc *ocsv1.StorageCluster, option string) (float64, error) {
+	value, ok := sc.Spec.CephConfig["global"][option]
+	if !ok {
+		value = defaultRookConfig["global"][option]
+	}
+	ratio, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q of Ceph config option %s: %v", value, option, err)
+	}
+	return ratio, nil
+}
+
+func formatRatio(ratio float64) string {
+	return strconv.FormatFloat(ratio, 'f', -1, 64)
+}
+
+// ensureCreated applies the full ratio desired for the Ceph cluster, either
+// the override or the configured full ratio, through a toolbox Job
+func (obj *ocsFullRatio) ensureCreated(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) error {
+	fullRatio, err := getCephConfigRatio(sc, "mon_osd_full_ratio")
+	if err != nil {
+		return err
+	}
+	desired, err := r.getDesiredFullRatio(sc, time.Now())
+	if err != nil {
+		return err
+	}
+
+	status := sc.Status.FullRatio
+	job := &batchv1.Job{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: fullRatioJobName, Namespace: sc.Namespace}, job)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get Job %s: %v", fullRatioJobName, err)
+	} else if err == nil {
+		jobRatio := getFullRatioJobRatio(job)
+		if job.Status.Succeeded == 0 && job.Status.Failed == 0 {
+			// Another ratio is only applied once the running Job is done
+			r.Log.Info("Waiting for the full ratio Job to complete.", "Job", klog.KRef(job.Namespace, job.Name), "Ratio", jobRatio)
+			setFullRatioCondition(sc, fullRatio, reasonFullRatioOverrideApplying, fmt.Sprintf("applying full ratio %s", jobRatio))
+			r.setFullRatioProgressing(jobRatio)
+			return nil
+		}
+		if job.Status.Succeeded > 0 {
+			r.Log.Info("Applied full ratio to the Ceph cluster.", "Ratio", jobRatio)
+			status.AppliedRatio = jobRatio
+		}
+		r.Log.Info("Deleting completed full ratio Job.", "Job", klog.KRef(job.Namespace, job.Name))
+		if err := r.Client.Delete(context.TODO(), job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete Job %s: %v", job.Name, err)
+		}
+		if job.Status.Succeeded == 0 && jobRatio == desired {
+			return fmt.Errorf("failed to set the full ratio to %s, check the logs of Job %s", jobRatio, job.Name)
+		}
+		if getAppliedFullRatio(sc, fullRatio) != desired {
+			// The Job is recreated once the deleted one is gone
+			r.setFullRatioProgressing(desired)
+			return nil
+		}
+	}
+
+	if getAppliedFullRatio(sc, fullRatio) == desired {
+		if status.Override == nil {
+			// The condition is only reported while an override is requested
+			conditionsv1.RemoveStatusCondition(&sc.Status.Conditions, ocsv1.ConditionFullRatioOverridden)
+		} else if desired == formatRatio(fullRatio) {
+			setFullRatioCondition(sc, fullRatio, reasonFullRatioOverrideInactive,
+				fmt.Sprintf("the full ratio override was reverted: %s", status.RevertReason))
+		} else {
+			setFullRatioCondition(sc, fullRatio, reasonFullRatioOverrideActive,
+				fmt.Sprintf("the full ratio is raised to %s until %s", desired, status.ExpirationTime.UTC().Format(time.RFC3339)))
+		}
+		return nil
+	}
+
+	job = newFullRatioJob(sc, desired, r.images.Rook)
+	if err := controllerutil.SetControllerReference(sc, job, r.Scheme); err != nil {
+		return err
+	}
+	r.Log.Info("Creating Job to set the full ratio of the Ceph cluster.", "Job", klog.KRef(job.Namespace, job.Name), "Ratio", desired)
+	if err := r.Client.Create(context.TODO(), job); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Job %s: %v", job.Name, err)
+	}
+	setFullRatioCondition(sc, fullRatio, reasonFullRatioOverrideApplying, fmt.Sprintf("applying full ratio %s", desired))
+	r.setFullRatioProgressing(desired)
+	return nil
+}
+
+// ensureDeleted is dummy func for the ocsFullRatio, the Job is owned by the
+// StorageCluster
+func (obj *ocsFullRatio) ensureDeleted(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) error {
+	return nil
+}
+
+// getDesiredFullRatio tracks the state of the full ratio override in the
+// StorageCluster status and returns the full ratio to apply
+func (r *StorageClusterReconciler) getDesiredFullRatio(sc *ocsv1.StorageCluster, now time.Time) (string, error) {
+	fullRatio, err := getCephConfigRatio(sc, "mon_osd_full_ratio")
+	if err != nil {
+		return "", err
+	}
+	if sc.Status.FullRatio == nil {
+		sc.Status.FullRatio = &ocsv1.FullRatioStatus{}
+	}
+	status := sc.Status.FullRatio
+
+	var override *ocsv1.FullRatioOverrideSpec
+	if sc.Spec.Capacity != nil {
+		override = sc.Spec.Capacity.FullRatioOverride
+	}
+	if override == nil {
+		status.Override = nil
+		status.StartTime = nil
+		status.ExpirationTime = nil
+		status.RevertReason = ""
+		return formatRatio(fullRatio), nil
+	}
+
+	if status.Override == nil || *status.Override != *override {
+		r.Log.Info("Starting full ratio override.", "Ratio", override.Ratio, "Duration", override.Duration.Duration)
+		start := metav1.NewTime(now)
+		expiration := metav1.NewTime(now.Add(override.Duration.Duration))
+		status.Override = override.DeepCopy()
+		status.StartTime = &start
+		status.ExpirationTime = &expiration
+		status.RevertReason = ""
+	}
+	if status.RevertReason != "" {
+		return formatRatio(fullRatio), nil
+	}
+
+	if !now.Before(status.ExpirationTime.Time) {
+		r.revertFullRatioOverride(sc, fullRatioRevertExpired,
+			fmt.Sprintf("Full ratio override %s expired, restoring full ratio %v", override.Ratio, fullRatio))
+		return formatRatio(fullRatio), nil
+	}
+
+	backfillFullRatio, err := getCephConfigRatio(sc, "mon_osd_backfillfull_ratio")
+	if err != nil {
+		return "", err
+	}
+	usage, ok, err := r.getCephUsageRatio(sc)
+	if err != nil {
+		return "", err
+	}
+	if ok && usage < backfillFullRatio {
+		r.revertFullRatioOverride(sc, fullRatioRevertUsageDropped,
+			fmt.Sprintf("Usage of the Ceph cluster dropped to %.2f, restoring full ratio %v", usage, fullRatio))
+		return formatRatio(fullRatio), nil
+	}
+
+	ratio, err := strconv.ParseFloat(override.Ratio, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid full ratio override %q: %v", override.Ratio, err)
+	}
+	return formatRatio(ratio), nil
+}
+
+func (r *StorageClusterReconciler) revertFullRatioOverride(sc *ocsv1.StorageCluster, reason, message string) {
+	r.Log.Info("Reverting full ratio override.", "StorageCluster", klog.KRef(sc.Namespace, sc.Name), "Reason", reason)
+	sc.Status.FullRatio.RevertReason = reason
+	r.recorder.ReportIfNotPresent(sc, corev1.EventTypeNormal, statusutil.EventReasonFullRatioReverted, message)
+}
+
+// getCephUsageRatio returns the share of the raw capacity of the Ceph
+// cluster in use, if the CephCluster reports it
+func (r *StorageClusterReconciler) getCephUsageRatio(sc *ocsv1.StorageCluster) (float64, bool, error) {
+	cephCluster := &cephv1.CephCluster{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: generateNameForCephCluster(sc), Namespace: sc.Namespace}, cephCluster)
+	if errors.IsNotFound(err) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, fmt.Errorf("failed to get CephCluster: %v", err)
+	}
+	if cephCluster.Status.CephStatus == nil || cephCluster.Status.CephStatus.Capacity.TotalBytes == 0 {
+		return 0, false, nil
+	}
+	capacity := cephCluster.Status.CephStatus.Capacity
+	return float64(capacity.UsedBytes) / float64(capacity.TotalBytes), true, nil
+}
+
+// getFullRatioRequeueInterval returns the time left until the active full
+// ratio override expires, or 0 if there is none
+func getFullRatioRequeueInterval(sc *ocsv1.StorageCluster, now time.Time) time.Duration {
+	status := sc.Status.FullRatio
+	if status == nil || status.Override == nil || status.RevertReason != "" || status.ExpirationTime == nil {
+		return 0
+	}
+	if interval := status.ExpirationTime.Sub(now); interval > 0 {
+		return interval
+	}
+	// Already expired, the override is reverted on the next reconcile
+	return time.Second
+}
+
+func (r *StorageClusterReconciler) setFullRatioProgressing(ratio string) {
+	r.conditions = append(r.conditions, conditionsv1.Condition{
+		Type:    conditionsv1.ConditionProgressing,
+		Status:  corev1.ConditionTrue,
+		Reason:  reasonFullRatioOverrideApplying,
+		Message: fmt.Sprintf("Applying full ratio %s to the Ceph cluster", ratio),
+	})
+}
+
+// getAppliedFullRatio returns the full ratio applied to the Ceph cluster,
+// which is the configured one until the operator changed it
+func getAppliedFullRatio(sc *ocsv1.StorageCluster, fullRatio float64) string {
+	if sc.Status.FullRatio == nil || sc.Status.FullRatio.AppliedRatio == "" {
+		return formatRatio(fullRatio)
+	}
+	return sc.Status.FullRatio.AppliedRatio
+}
+
+func setFullRatioCondition(sc *ocsv1.StorageCluster, fullRatio float64, reason, message string) {
+	status := corev1.ConditionFalse
+	if getAppliedFullRatio(sc, fullRatio) != formatRatio(fullRatio) {
+		status = corev1.ConditionTrue
+	}
+	conditionsv1.SetStatusCondition(&sc.Status.Conditions, conditionsv1.Condition{
+		Type:    ocsv1.ConditionFullRatioOverridden,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+func getFullRatioJobRatio(job *batchv1.Job) string {
+	for _, container := range job.Spec.Template.Spec.Containers {
+		for _, env := range container.Env {
+			if env.Name == fullRatioEnvVar {
+				return env.Value
+			}
+		}
+	}
+	return ""
+}
+
+// newFullRatioJob returns the toolbox Job setting the full ratio of the Ceph
+// cluster. Setting the ratio is idempotent, unlike the script of the former
+// ocs-extend-cluster Template which toggled it.
+func newFullRatioJob(sc *ocsv1.StorageCluster, ratio string, rookImage string) *batchv1.Job {
+	return statusutil.NewToolboxJob(fullRatioJobName, sc.Namespace, rookImage, corev1.Container{
+		Name:    "script",
+		Command: []string{"bash", "-c", `ceph osd set-full-ratio "${FULL_RATIO}"`},
+		Env: []corev1.EnvVar{
+			{
+				Name:  fullRatioEnvVar,
+				Value: ratio,
+			},
+		},
+	})
+}
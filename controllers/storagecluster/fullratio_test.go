@@ -0,0 +1,231 @@
+package storagecluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	api "github.com/openshift/ocs-operator/api/v1"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newFullRatioStorageCluster(ratio string, duration time.Duration) *api.StorageCluster {
+	sc := &api.StorageCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocsinit", Namespace: "openshift-storage"},
+	}
+	if ratio != "" {
+		sc.Spec.Capacity = &api.CapacitySpec{
+			FullRatioOverride: &api.FullRatioOverrideSpec{
+				Ratio:    ratio,
+				Duration: metav1.Duration{Duration: duration},
+			},
+		}
+	}
+	return sc
+}
+
+func TestValidateFullRatioOverride(t *testing.T) {
+	cases := []struct {
+		label         string
+		ratio         string
+		duration      time.Duration
+		cephConfig    map[string]api.CephConfigSection
+		expectedError bool
+	}{
+		{
+			label: "no override",
+		},
+		{
+			label:    "valid override",
+			ratio:    "0.87",
+			duration: time.Hour,
+		},
+		{
+			label:         "above the safe ceiling",
+			ratio:         "0.95",
+			duration:      time.Hour,
+			expectedError: true,
+		},
+		{
+			label:         "not above the full ratio",
+			ratio:         ".85",
+			duration:      time.Hour,
+			expectedError: true,
+		},
+		{
+			label:         "not above the configured full ratio",
+			ratio:         "0.87",
+			duration:      time.Hour,
+			cephConfig:    map[string]api.CephConfigSection{"global": {"mon_osd_full_ratio": ".88"}},
+			expectedError: true,
+		},
+		{
+			label:         "no duration",
+			ratio:         "0.87",
+			expectedError: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := newFullRatioStorageCluster(c.ratio, c.duration)
+		sc.Spec.CephConfig = c.cephConfig
+		err := validateFullRatioOverride(sc)
+		if c.expectedError {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestGetDesiredFullRatio(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		label                string
+		ratio                string
+		status               *api.FullRatioStatus
+		usedBytes            uint64
+		expectedRatio        string
+		expectedRevertReason string
+	}{
+		{
+			label:         "no override",
+			expectedRatio: "0.85",
+		},
+		{
+			label:         "new override",
+			ratio:         "0.87",
+			usedBytes:     86,
+			expectedRatio: "0.87",
+		},
+		{
+			label: "expired override",
+			ratio: "0.87",
+			status: &api.FullRatioStatus{
+				AppliedRatio:   "0.87",
+				Override:       &api.FullRatioOverrideSpec{Ratio: "0.87", Duration: metav1.Duration{Duration: time.Hour}},
+				StartTime:      &metav1.Time{Time: now.Add(-2 * time.Hour)},
+				ExpirationTime: &metav1.Time{Time: now.Add(-time.Hour)},
+			},
+			usedBytes:            86,
+			expectedRatio:        "0.85",
+			expectedRevertReason: fullRatioRevertExpired,
+		},
+		{
+			label:                "usage dropped",
+			ratio:                "0.87",
+			usedBytes:            70,
+			expectedRatio:        "0.85",
+			expectedRevertReason: fullRatioRevertUsageDropped,
+		},
+		{
+			label: "reverted override stays reverted",
+			ratio: "0.87",
+			status: &api.FullRatioStatus{
+				Override:       &api.FullRatioOverrideSpec{Ratio: "0.87", Duration: metav1.Duration{Duration: time.Hour}},
+				StartTime:      &metav1.Time{Time: now},
+				ExpirationTime: &metav1.Time{Time: now.Add(time.Hour)},
+				RevertReason:   fullRatioRevertUsageDropped,
+			},
+			usedBytes:            86,
+			expectedRatio:        "0.85",
+			expectedRevertReason: fullRatioRevertUsageDropped,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := newFullRatioStorageCluster(c.ratio, time.Hour)
+		sc.Status.FullRatio = c.status
+		cephCluster := &cephv1.CephCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: generateNameForCephCluster(sc), Namespace: sc.Namespace},
+			Status: cephv1.ClusterStatus{
+				CephStatus: &cephv1.CephStatus{
+					Capacity: cephv1.Capacity{TotalBytes: 100, UsedBytes: c.usedBytes},
+				},
+			},
+		}
+		reconciler := createFakeStorageClusterReconciler(t, cephCluster)
+
+		ratio, err := reconciler.getDesiredFullRatio(sc, now)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expectedRatio, ratio)
+		assert.Equal(t, c.expectedRevertReason, sc.Status.FullRatio.RevertReason)
+		if c.ratio != "" {
+			assert.NotNil(t, sc.Status.FullRatio.ExpirationTime)
+		} else {
+			assert.Nil(t, sc.Status.FullRatio.Override)
+		}
+	}
+}
+
+func TestFullRatioEnsureCreated(t *testing.T) {
+	sc := newFullRatioStorageCluster("0.87", time.Hour)
+	reconciler := createFakeStorageClusterReconciler(t, sc)
+	obj := &ocsFullRatio{}
+	jobKey := types.NamespacedName{Name: fullRatioJobName, Namespace: sc.Namespace}
+
+	// The override is applied through a Job
+	err := obj.ensureCreated(&reconciler, sc)
+	assert.NoError(t, err)
+	job := &batchv1.Job{}
+	err = reconciler.Client.Get(context.TODO(), jobKey, job)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.87", getFullRatioJobRatio(job))
+	assert.Equal(t, reconciler.images.Rook, job.Spec.Template.Spec.Containers[0].Image)
+	assert.True(t, conditionsv1.IsStatusConditionFalse(sc.Status.Conditions, api.ConditionFullRatioOverridden))
+
+	// Nothing changes while the Job is running
+	err = obj.ensureCreated(&reconciler, sc)
+	assert.NoError(t, err)
+	assert.Empty(t, sc.Status.FullRatio.AppliedRatio)
+
+	job.Status.Succeeded = 1
+	err = reconciler.Client.Status().Update(context.TODO(), job)
+	assert.NoError(t, err)
+	err = obj.ensureCreated(&reconciler, sc)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.87", sc.Status.FullRatio.AppliedRatio)
+	assert.True(t, conditionsv1.IsStatusConditionTrue(sc.Status.Conditions, api.ConditionFullRatioOverridden))
+	err = reconciler.Client.Get(context.TODO(), jobKey, job)
+	assert.True(t, errors.IsNotFound(err))
+
+	// Removing the override restores the full ratio
+	sc.Spec.Capacity = nil
+	err = obj.ensureCreated(&reconciler, sc)
+	assert.NoError(t, err)
+	err = reconciler.Client.Get(context.TODO(), jobKey, job)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.85", getFullRatioJobRatio(job))
+	assert.Nil(t, sc.Status.FullRatio.Override)
+
+	job.Status.Succeeded = 1
+	err = reconciler.Client.Status().Update(context.TODO(), job)
+	assert.NoError(t, err)
+	err = obj.ensureCreated(&reconciler, sc)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.85", sc.Status.FullRatio.AppliedRatio)
+	assert.Nil(t, conditionsv1.FindStatusCondition(sc.Status.Conditions, api.ConditionFullRatioOverridden))
+}
+
+func TestGetFullRatioRequeueInterval(t *testing.T) {
+	now := time.Now()
+	sc := newFullRatioStorageCluster("0.87", time.Hour)
+	assert.Equal(t, time.Duration(0), getFullRatioRequeueInterval(sc, now))
+
+	sc.Status.FullRatio = &api.FullRatioStatus{
+		Override:       sc.Spec.Capacity.FullRatioOverride,
+		ExpirationTime: &metav1.Time{Time: now.Add(time.Hour)},
+	}
+	assert.Equal(t, time.Hour, getFullRatioRequeueInterval(sc, now))
+
+	sc.Status.FullRatio.RevertReason = fullRatioRevertExpired
+	assert.Equal(t, time.Duration(0), getFullRatioRequeueInterval(sc, now))
+}
@@ -2,11 +2,24 @@ package storagecluster
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	ocsv1 "github.com/openshift/ocs-operator/api/v1"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 )
 
+// invalidNameChars matches the characters which can not be part of the name
+// of a resource
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]`)
+
+// The labels of the cluster-scoped resources of a StorageCluster, which can
+// not be owned by it
+const (
+	storageClusterNameLabel      = "ocs.openshift.io/storagecluster-name"
+	storageClusterNamespaceLabel = "ocs.openshift.io/storagecluster-namespace"
+)
+
 func generateNameForCephCluster(initData *ocsv1.StorageCluster) string {
 	return generateNameForCephClusterFromString(initData.Name)
 }
@@ -19,6 +32,10 @@ func generateNameForCephFilesystem(initData *ocsv1.StorageCluster) string {
 	return fmt.Sprintf("%s-cephfilesystem", initData.Name)
 }
 
+func generateNameForAdditionalCephFilesystem(initData *ocsv1.StorageCluster, fsName string) string {
+	return fmt.Sprintf("%s-cephfilesystem-%s", initData.Name, fsName)
+}
+
 func generateNameForCephObjectStoreUser(initData *ocsv1.StorageCluster) string {
 	return fmt.Sprintf("%s-cephobjectstoreuser", initData.Name)
 }
@@ -27,36 +44,140 @@ func generateNameForCephBlockPool(initData *ocsv1.StorageCluster) string {
 	return fmt.Sprintf("%s-cephblockpool", initData.Name)
 }
 
+// generateNameForErasureCodedCephBlockPool returns the name of the pool
+// holding the data of RBD images when erasure coding is enabled for block
+func generateNameForErasureCodedCephBlockPool(initData *ocsv1.StorageCluster) string {
+	return fmt.Sprintf("%s-cephblockpool-%s", initData.Name, erasureCodedBlockPoolSuffix)
+}
+
+// generateNameForCephFilesystemDataPool returns the name Rook gives to the
+// data pool of the CephFilesystem at the given index
+func generateNameForCephFilesystemDataPool(initData *ocsv1.StorageCluster, index int) string {
+	return fmt.Sprintf("%s-data%d", generateNameForCephFilesystem(initData), index)
+}
+
+func generateNameForAdditionalCephBlockPool(initData *ocsv1.StorageCluster, poolName string) string {
+	return fmt.Sprintf("%s-cephblockpool-%s", initData.Name, poolName)
+}
+
+// generateNameForTopologyCephBlockPool returns the name of the CephBlockPool
+// restricted to the given failure domain
+func generateNameForTopologyCephBlockPool(initData *ocsv1.StorageCluster, failureDomainValue string) string {
+	return fmt.Sprintf("%s-cephblockpool-%s", initData.Name, generateTopologySuffix(initData, failureDomainValue))
+}
+
+// generateTopologySuffix returns the suffix naming the resources restricted
+// to the given failure domain, e.g. "zone-us-east-1a"
+func generateTopologySuffix(initData *ocsv1.StorageCluster, failureDomainValue string) string {
+	suffix := fmt.Sprintf("%s-%s", initData.Status.FailureDomain, strings.ToLower(failureDomainValue))
+	return invalidNameChars.ReplaceAllString(suffix, "-")
+}
+
 func generateNameForCephObjectStore(initData *ocsv1.StorageCluster) string {
 	return fmt.Sprintf("%s-%s", initData.Name, "cephobjectstore")
 }
 
+// generateNameForClusterScopedResource returns the name of a cluster-scoped
+// resource created for the StorageCluster. The resources of StorageClusters
+// outside of the operator namespace get their namespace as prefix, so that
+// StorageClusters of the same name in different namespaces do not share them.
+func generateNameForClusterScopedResource(initData *ocsv1.StorageCluster, name string) string {
+	if operatorNamespace == "" || initData.Namespace == operatorNamespace {
+		return name
+	}
+	return fmt.Sprintf("%s-%s", initData.Namespace, name)
+}
+
+// getStorageClusterLabels returns the labels identifying the cluster-scoped
+// resources of the StorageCluster
+func getStorageClusterLabels(sc *ocsv1.StorageCluster) map[string]string {
+	return map[string]string{
+		storageClusterNameLabel:      sc.Name,
+		storageClusterNamespaceLabel: sc.Namespace,
+	}
+}
+
+func generateNameForCephRBDMirror(initData *ocsv1.StorageCluster) string {
+	return fmt.Sprintf("%s-cephrbdmirror", initData.Name)
+}
+
+func generateNameForVolumeReplicationClass(initData *ocsv1.StorageCluster, interval string) string {
+	return generateNameForClusterScopedResource(initData, fmt.Sprintf("%s-rbd-volumereplicationclass-%s", initData.Name, interval))
+}
+
+func generateNameForCephNFS(initData *ocsv1.StorageCluster) string {
+	return fmt.Sprintf("%s-cephnfs", initData.Name)
+}
+
+func generateNameForCephNFSService(initData *ocsv1.StorageCluster) string {
+	return fmt.Sprintf("%s-service", generateNameForCephNFS(initData))
+}
+
+func generateNameForCephNFSSC(initData *ocsv1.StorageCluster) string {
+	return generateNameForClusterScopedResource(initData, fmt.Sprintf("%s-ceph-nfs", initData.Name))
+}
+
+func generateNameForTopologyCephBlockPoolSC(initData *ocsv1.StorageCluster, failureDomainValue string) string {
+	return generateNameForCephBlockPoolSC(initData, "-"+generateTopologySuffix(initData, failureDomainValue))
+}
+
 func generateNameForCephRgwSC(initData *ocsv1.StorageCluster) string {
-	return fmt.Sprintf("%s-ceph-rgw", initData.Name)
+	return generateNameForClusterScopedResource(initData, fmt.Sprintf("%s-ceph-rgw", initData.Name))
 }
 
 func generateNameForCephFilesystemSC(initData *ocsv1.StorageCluster) string {
-	return fmt.Sprintf("%s-cephfs", initData.Name)
+	return generateNameForClusterScopedResource(initData, fmt.Sprintf("%s-cephfs", initData.Name))
 }
 
 func generateNameForCephBlockPoolSC(initData *ocsv1.StorageCluster, suffix string) string {
-	return fmt.Sprintf("%s-ceph-rbd%s", initData.Name, suffix)
+	return generateNameForClusterScopedResource(initData, fmt.Sprintf("%s-ceph-rbd%s", initData.Name, suffix))
 }
 
 // generateNameForSnapshotClass function generates 'SnapshotClass' name.
 // 'snapshotType' can be: 'rbdSnapshotter' or 'cephfsSnapshotter'
 func generateNameForSnapshotClass(initData *ocsv1.StorageCluster, snapshotType SnapshotterType) string {
-	return fmt.Sprintf("%s-%splugin-snapclass", initData.Name, snapshotType)
+	return generateNameForClusterScopedResource(initData, fmt.Sprintf("%s-%splugin-snapclass", initData.Name, snapshotType))
+}
+
+// generateNameForAdditionalSnapshotClass function generates the 'SnapshotClass' name
+// for an additional CephBlockPool or CephFilesystem
+func generateNameForAdditionalSnapshotClass(initData *ocsv1.StorageCluster, snapshotType SnapshotterType, name string) string {
+	return fmt.Sprintf("%s-%s", generateNameForSnapshotClass(initData, snapshotType), name)
 }
 
 func generateNameForSnapshotClassDriver(initData *ocsv1.StorageCluster, snapshotType SnapshotterType) string {
-	return fmt.Sprintf("%s.%s.csi.ceph.com", initData.Namespace, snapshotType)
+	return generateNameForCSIDriver(initData, string(snapshotType))
+}
+
+// generateNameForCSIDriver returns the name of the Ceph-CSI driver of the
+// given type, e.g. "rbd"
+func generateNameForCSIDriver(initData *ocsv1.StorageCluster, driverType string) string {
+	return fmt.Sprintf("%s.%s.csi.ceph.com", getRookOperatorNamespace(initData), driverType)
+}
+
+// getRookOperatorNamespace returns the namespace Rook is deployed in. Rook
+// names its CSI drivers and bucket provisioner after it, so the
+// StorageClusters of all namespaces share them.
+func getRookOperatorNamespace(initData *ocsv1.StorageCluster) string {
+	if operatorNamespace == "" {
+		return initData.Namespace
+	}
+	return operatorNamespace
 }
 
 func generateNameForSnapshotClassSecret(snapshotType SnapshotterType) string {
 	return fmt.Sprintf("rook-csi-%s-provisioner", snapshotType)
 }
 
+// generateCephErasureCodedSpec returns the ErasureCodedSpec for a pool with
+// the given erasure coding profile
+func generateCephErasureCodedSpec(ec *ocsv1.ErasureCodedSpec) cephv1.ErasureCodedSpec {
+	return cephv1.ErasureCodedSpec{
+		DataChunks:   ec.DataChunks,
+		CodingChunks: ec.CodingChunks,
+	}
+}
+
 // generateCephReplicatedSpec returns the ReplicatedSpec for the cephCluster
 // based on the StorageCluster configuration
 func generateCephReplicatedSpec(initData *ocsv1.StorageCluster, poolType string) cephv1.ReplicatedSpec {
@@ -0,0 +1,82 @@
+package storagecluster
+
+import (
+	"fmt"
+
+	configv1 "github.com/openshift/api/config/v1"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+)
+
+// livenessProbeDaemons are the daemons whose liveness probe can be
+// overridden in spec.healthCheck.livenessProbe
+var livenessProbeDaemons = []string{"mon", "mgr", "osd"}
+
+// validateHealthCheckSpec checks the health checks of the StorageCluster
+func validateHealthCheckSpec(sc *ocsv1.StorageCluster) error {
+	healthCheck := sc.Spec.HealthCheck
+	if healthCheck == nil {
+		return nil
+	}
+	checks := map[string]*ocsv1.DaemonHealthCheckSpec{
+		"status": healthCheck.Status,
+		"mon":    healthCheck.Mon,
+		"osd":    healthCheck.OSD,
+	}
+	for name, check := range checks {
+		if check == nil {
+			continue
+		}
+		if check.Interval != nil && check.Interval.Duration <= 0 {
+			return fmt.Errorf("invalid %s health check interval %v: must be positive", name, check.Interval.Duration)
+		}
+		if check.Timeout != nil && check.Timeout.Duration <= 0 {
+			return fmt.Errorf("invalid %s health check timeout %v: must be positive", name, check.Timeout.Duration)
+		}
+	}
+	for daemon := range healthCheck.LivenessProbe {
+		if !contains(livenessProbeDaemons, string(daemon)) {
+			return fmt.Errorf("invalid liveness probe daemon %q: must be one of mon, mgr or osd", daemon)
+		}
+	}
+	return nil
+}
+
+// generateHealthCheckSpec returns the health checks of the CephCluster: the
+// defaults of the platform, overridden by spec.healthCheck
+func generateHealthCheckSpec(sc *ocsv1.StorageCluster, platform configv1.PlatformType) cephv1.CephClusterHealthCheckSpec {
+	spec := cephv1.CephClusterHealthCheckSpec{}
+	if platformSpec, ok := PlatformHealthChecks[platform]; ok {
+		platformSpec.DeepCopyInto(&spec)
+	}
+
+	healthCheck := sc.Spec.HealthCheck
+	if healthCheck == nil {
+		return spec
+	}
+	applyDaemonHealthCheck(&spec.DaemonHealth.Status, healthCheck.Status)
+	applyDaemonHealthCheck(&spec.DaemonHealth.Monitor, healthCheck.Mon)
+	applyDaemonHealthCheck(&spec.DaemonHealth.ObjectStorageDaemon, healthCheck.OSD)
+	for daemon, probe := range healthCheck.LivenessProbe {
+		if spec.LivenessProbe == nil {
+			spec.LivenessProbe = map[rookv1.KeyType]*rookv1.ProbeSpec{}
+		}
+		spec.LivenessProbe[daemon] = probe.DeepCopy()
+	}
+	return spec
+}
+
+func applyDaemonHealthCheck(spec *cephv1.HealthCheckSpec, check *ocsv1.DaemonHealthCheckSpec) {
+	if check == nil {
+		return
+	}
+	spec.Disabled = check.Disabled
+	if check.Interval != nil {
+		interval := *check.Interval
+		spec.Interval = &interval
+	}
+	if check.Timeout != nil {
+		spec.Timeout = check.Timeout.Duration.String()
+	}
+}
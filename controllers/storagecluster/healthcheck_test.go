@@ -0,0 +1,121 @@
+package storagecluster
+
+import (
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	api "github.com/openshift/ocs-operator/api/v1"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateHealthCheckSpec(t *testing.T) {
+	cases := []struct {
+		label         string
+		healthCheck   *api.HealthCheckSpec
+		expectedError bool
+	}{
+		{
+			label: "no health check",
+		},
+		{
+			label: "valid health check",
+			healthCheck: &api.HealthCheckSpec{
+				Mon:           &api.DaemonHealthCheckSpec{Interval: &metav1.Duration{Duration: time.Minute}, Timeout: &metav1.Duration{Duration: 10 * time.Minute}},
+				LivenessProbe: map[rookv1.KeyType]*rookv1.ProbeSpec{"osd": {Disabled: true}},
+			},
+		},
+		{
+			label: "negative interval",
+			healthCheck: &api.HealthCheckSpec{
+				OSD: &api.DaemonHealthCheckSpec{Interval: &metav1.Duration{Duration: -time.Minute}},
+			},
+			expectedError: true,
+		},
+		{
+			label: "zero timeout",
+			healthCheck: &api.HealthCheckSpec{
+				Mon: &api.DaemonHealthCheckSpec{Timeout: &metav1.Duration{}},
+			},
+			expectedError: true,
+		},
+		{
+			label: "unknown liveness probe daemon",
+			healthCheck: &api.HealthCheckSpec{
+				LivenessProbe: map[rookv1.KeyType]*rookv1.ProbeSpec{"rgw": {Disabled: true}},
+			},
+			expectedError: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := &api.StorageCluster{}
+		sc.Spec.HealthCheck = c.healthCheck
+		err := validateHealthCheckSpec(sc)
+		if c.expectedError {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestGenerateHealthCheckSpec(t *testing.T) {
+	probe := &rookv1.ProbeSpec{
+		Probe: &corev1.Probe{InitialDelaySeconds: 30},
+	}
+
+	cases := []struct {
+		label       string
+		platform    configv1.PlatformType
+		healthCheck *api.HealthCheckSpec
+		expected    cephv1.CephClusterHealthCheckSpec
+	}{
+		{
+			label:    "Rook defaults",
+			platform: configv1.AWSPlatformType,
+		},
+		{
+			label:    "platform defaults",
+			platform: configv1.IBMCloudPlatformType,
+			expected: cephv1.CephClusterHealthCheckSpec{
+				DaemonHealth: cephv1.DaemonHealthSpec{
+					Monitor: cephv1.HealthCheckSpec{Timeout: "15m"},
+				},
+			},
+		},
+		{
+			label:    "spec overrides the platform defaults",
+			platform: IBMCloudCosPlatformType,
+			healthCheck: &api.HealthCheckSpec{
+				Status:        &api.DaemonHealthCheckSpec{Interval: &metav1.Duration{Duration: 30 * time.Second}},
+				Mon:           &api.DaemonHealthCheckSpec{Timeout: &metav1.Duration{Duration: 20 * time.Minute}},
+				OSD:           &api.DaemonHealthCheckSpec{Disabled: true},
+				LivenessProbe: map[rookv1.KeyType]*rookv1.ProbeSpec{"mon": probe},
+			},
+			expected: cephv1.CephClusterHealthCheckSpec{
+				DaemonHealth: cephv1.DaemonHealthSpec{
+					Status:              cephv1.HealthCheckSpec{Interval: &metav1.Duration{Duration: 30 * time.Second}},
+					Monitor:             cephv1.HealthCheckSpec{Timeout: "20m0s"},
+					ObjectStorageDaemon: cephv1.HealthCheckSpec{Disabled: true},
+				},
+				LivenessProbe: map[rookv1.KeyType]*rookv1.ProbeSpec{"mon": probe},
+			},
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := &api.StorageCluster{}
+		sc.Spec.HealthCheck = c.healthCheck
+		assert.Equal(t, c.expected, generateHealthCheckSpec(sc, c.platform))
+	}
+
+	// The platform defaults are not modified by the overrides
+	assert.Equal(t, "15m", PlatformHealthChecks[IBMCloudCosPlatformType].DaemonHealth.Monitor.Timeout)
+}
@@ -12,18 +12,22 @@ import (
 	routev1 "github.com/openshift/api/route/v1"
 	openshiftv1 "github.com/openshift/api/template/v1"
 	api "github.com/openshift/ocs-operator/api/v1"
+	statusutil "github.com/openshift/ocs-operator/controllers/util"
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -177,6 +181,7 @@ func createFakeInitializationStorageClusterReconcilerWithPlatform(t *testing.T,
 		serverVersion: &version.Info{},
 		Log:           logf.Log.WithName("controller_storagecluster_test"),
 		platform:      platform,
+		recorder:      statusutil.NewEventReporter(&record.FakeRecorder{}),
 	}
 }
 
@@ -230,5 +235,14 @@ func createFakeInitializationScheme(t *testing.T, obj ...runtime.Object) *runtim
 		assert.Fail(t, "failed to add batchv1 scheme")
 	}
 
+	err = coordinationv1.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add coordinationv1 scheme")
+	}
+	// The VolumeReplicationClass API is not vendored, its objects are
+	// handled as unstructured
+	scheme.AddKnownTypeWithName(volumeReplicationClassGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(volumeReplicationClassGVK.GroupVersion().WithKind(volumeReplicationClassGVK.Kind+"List"), &unstructured.UnstructuredList{})
+
 	return scheme
 }
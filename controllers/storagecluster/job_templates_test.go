@@ -5,67 +5,39 @@ import (
 	"testing"
 
 	openshiftv1 "github.com/openshift/api/template/v1"
-	api "github.com/openshift/ocs-operator/api/v1"
 	"github.com/stretchr/testify/assert"
-	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 func TestJobTemplates(t *testing.T) {
-
-	var cases = []struct {
-		jobTemplateName    string
-		templateParameters []string
-		jobCmds            []string
-		jobFunc            func(*api.StorageCluster, string, []string) *batchv1.Job
-	}{
-		{
-			jobTemplateName:    "ocs-osd-removal",
-			templateParameters: []string{"FAILED_OSD_IDS"},
-			jobCmds:            osdCleanupArgs,
-			jobFunc:            newosdCleanUpJob,
-		},
-		{
-			jobTemplateName:    "ocs-extend-cluster",
-			templateParameters: []string{"RECONFIGURE"},
-			jobCmds:            extendClusterCommand,
-			jobFunc:            newExtendClusterJob,
-		},
-	}
-
-	t, reconciler, cr, request := initStorageClusterResourceCreateUpdateTestWithPlatform(
+	t, reconciler, cr, _ := initStorageClusterResourceCreateUpdateTestWithPlatform(
 		t, nil, nil)
 
-	for _, c := range cases {
-		template := &openshiftv1.Template{
+	// No Template is created anymore
+	actualTemplateList := &openshiftv1.TemplateList{}
+	err := reconciler.Client.List(context.TODO(), actualTemplateList)
+	assert.NoError(t, err)
+	assert.Empty(t, actualTemplateList.Items)
+
+	for _, name := range staleJobTemplateNames {
+		staleTemplate := &openshiftv1.Template{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      c.jobTemplateName,
+				Name:      name,
 				Namespace: cr.Namespace,
 			},
 		}
-
-		request.Name = c.jobTemplateName
-		err := reconciler.Client.Get(context.TODO(), request.NamespacedName, template)
-
-		assert.NoError(t, err)
-		for i, param := range c.templateParameters {
-			assert.Equal(t, param, template.Parameters[i].Name)
-		}
-
-		expectedJob := c.jobFunc(cr, c.jobTemplateName, c.jobCmds)
-		actualJob := &batchv1.Job{}
-		actualRaw := template.Objects[0].Raw
-
-		err = runtime.DecodeInto(unstructured.UnstructuredJSONScheme, actualRaw, actualJob)
+		err := reconciler.Client.Create(context.TODO(), staleTemplate)
 		assert.NoError(t, err)
-
-		assert.Equal(t, expectedJob, actualJob)
 	}
 
-	actualTemplateList := &openshiftv1.TemplateList{}
-	err := reconciler.Client.List(context.TODO(), actualTemplateList)
+	obj := &ocsJobTemplates{}
+	err = obj.ensureCreated(&reconciler, cr)
 	assert.NoError(t, err)
-	assert.Equal(t, len(cases), len(actualTemplateList.Items))
+
+	for _, name := range staleJobTemplateNames {
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: cr.Namespace}, &openshiftv1.Template{})
+		assert.True(t, errors.IsNotFound(err), name)
+	}
 }
@@ -0,0 +1,491 @@
+package storagecluster
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// poolMirroringModeImage enables mirroring per image, as required by
+	// the snapshot-based mirroring of VolumeReplications
+	poolMirroringModeImage = "image"
+	// defaultSchedulingInterval is the scheduling interval of the
+	// VolumeReplicationClass created when none is set
+	defaultSchedulingInterval = "5m"
+	// rbdMirrorBootstrapPeerSecretInfoKey is the key of the CephBlockPool
+	// status info holding the name of the bootstrap peer Secret of the pool
+	rbdMirrorBootstrapPeerSecretInfoKey = "rbdMirrorBootstrapPeerSecretName"
+
+	// poolMirroringHealthError is the mirroring health of a pool whose
+	// images or rbd-mirror daemons are failing
+	poolMirroringHealthError = "ERROR"
+
+	reasonPoolMirroringFailed = "PoolMirroringFailed"
+	reasonPeerSecretInvalid   = "MirroringPeerSecretInvalid"
+	reasonPeerMissing         = "MirroringPeerMissing"
+)
+
+// volumeReplicationClassGVK is the kind of the VolumeReplicationClasses,
+// whose API is provided by the volume-replication-operator
+var volumeReplicationClassGVK = schema.GroupVersionKind{
+	Group:   "replication.storage.openshift.io",
+	Version: "v1alpha1",
+	Kind:    "VolumeReplicationClass",
+}
+
+// schedulingIntervalRegexp matches the intervals supported by the RBD
+// mirror snapshot schedules
+var schedulingIntervalRegexp = regexp.MustCompile(`^[1-9][0-9]*[mhd]$`)
+
+type ocsMirroring struct{}
+
+// mirroringEnabled returns true if the StorageCluster mirrors its
+// CephBlockPools
+func mirroringEnabled(sc *ocsv1.StorageCluster) bool {
+	return sc.Spec.Mirroring != nil && sc.Spec.Mirroring.Enabled
+}
+
+// validateMirroringSpec checks that mirroring can be enabled and that its
+// scheduling intervals are valid
+func validateMirroringSpec(sc *ocsv1.StorageCluster) error {
+	if !mirroringEnabled(sc) {
+		return nil
+	}
+	if sc.Spec.ExternalStorage.Enable {
+		return fmt.Errorf("mirroring is not supported with external storage")
+	}
+	seen := map[string]bool{}
+	for _, interval := range sc.Spec.Mirroring.SchedulingIntervals {
+		if !schedulingIntervalRegexp.MatchString(interval) {
+			return fmt.Errorf("invalid mirroring scheduling interval %q: must be a number of minutes, hours or days, e.g. 5m, 1h or 1d", interval)
+		}
+		if seen[interval] {
+			return fmt.Errorf("mirroring scheduling interval %q is set more than once", interval)
+		}
+		seen[interval] = true
+	}
+	return nil
+}
+
+// getSchedulingIntervals returns the scheduling intervals of the
+// VolumeReplicationClasses of the StorageCluster
+func getSchedulingIntervals(sc *ocsv1.StorageCluster) []string {
+	if len(sc.Spec.Mirroring.SchedulingIntervals) == 0 {
+		return []string{defaultSchedulingInterval}
+	}
+	return sc.Spec.Mirroring.SchedulingIntervals
+}
+
+// setCephBlockPoolMirroring enables image mirroring on a replicated
+// CephBlockPool of a StorageCluster with mirroring enabled, and disables it
+// otherwise so that Rook stops mirroring the pools once mirroring is
+// disabled. The erasure-coded pools only hold the data of images whose
+// metadata, which is what gets mirrored, lives in a replicated pool.
+func setCephBlockPoolMirroring(initData *ocsv1.StorageCluster, pool *cephv1.CephBlockPool) {
+	if !mirroringEnabled(initData) || pool.Spec.ErasureCoded.CodingChunks > 0 {
+		pool.Spec.Mirroring = cephv1.MirroringSpec{Enabled: false}
+		pool.Spec.StatusCheck.Mirror = cephv1.HealthCheckSpec{}
+		return
+	}
+	pool.Spec.Mirroring = cephv1.MirroringSpec{
+		Enabled: true,
+		Mode:    poolMirroringModeImage,
+	}
+	pool.Spec.StatusCheck.Mirror = cephv1.HealthCheckSpec{
+		Interval: &metav1.Duration{Duration: time.Minute},
+	}
+}
+
+// newCephRBDMirrorInstance returns the CephRBDMirror running the rbd-mirror
+// daemon of the StorageCluster
+func (r *StorageClusterReconciler) newCephRBDMirrorInstance(initData *ocsv1.StorageCluster) (*cephv1.CephRBDMirror, error) {
+	obj := &cephv1.CephRBDMirror{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateNameForCephRBDMirror(initData),
+			Namespace: initData.Namespace,
+		},
+		Spec: cephv1.RBDMirroringSpec{
+			Count: 1,
+			Peers: cephv1.RBDMirroringPeerSpec{
+				SecretNames: initData.Spec.Mirroring.PeerSecretNames,
+			},
+			Placement:         getPlacement(initData, "rbd-mirror"),
+			Resources:         getDaemonResources(initData, "rbd-mirror"),
+			PriorityClassName: openshiftUserCritical,
+		},
+	}
+	err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
+	if err != nil {
+		r.Log.Error(err, "Unable to set Controller Reference for CephRBDMirror.", "CephRBDMirror", klog.KRef(obj.Namespace, obj.Name))
+		return nil, err
+	}
+	return obj, nil
+}
+
+// newVolumeReplicationClasses returns a VolumeReplicationClass of the RBD
+// driver of the StorageCluster for each scheduling interval
+func newVolumeReplicationClasses(initData *ocsv1.StorageCluster) []*unstructured.Unstructured {
+	var ret []*unstructured.Unstructured
+	for _, interval := range getSchedulingIntervals(initData) {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(volumeReplicationClassGVK)
+		obj.SetName(generateNameForVolumeReplicationClass(initData, interval))
+		obj.SetLabels(getStorageClusterLabels(initData))
+		obj.Object["spec"] = map[string]interface{}{
+			"provisioner": generateNameForCSIDriver(initData, "rbd"),
+			"parameters": map[string]interface{}{
+				"mirroringMode":      "snapshot",
+				"schedulingInterval": interval,
+				"replication.storage.openshift.io/replication-secret-name":      "rook-csi-rbd-provisioner",
+				"replication.storage.openshift.io/replication-secret-namespace": initData.Namespace,
+			},
+		}
+		ret = append(ret, obj)
+	}
+	return ret
+}
+
+// getPoolMirroringHealth returns the mirroring health summary of a
+// CephBlockPool, without the counts of images in each state which change
+// as the images are replayed. It is empty until Rook reports it.
+func getPoolMirroringHealth(pool *cephv1.CephBlockPool) cephv1.PoolMirroringStatusSummarySpec {
+	if pool.Status == nil || pool.Status.MirroringStatus == nil || pool.Status.MirroringStatus.Summary == nil {
+		return cephv1.PoolMirroringStatusSummarySpec{}
+	}
+	summary := *pool.Status.MirroringStatus.Summary
+	summary.States = cephv1.StatesSpec{}
+	return summary
+}
+
+// ensureCreated ensures that the CephRBDMirror and the
+// VolumeReplicationClasses exist in the desired state when mirroring is
+// enabled, and reports the mirroring health of the pools. They are deleted
+// when mirroring is disabled. The CephBlockPools themselves are mirrored by
+// the blockPools component.
+func (obj *ocsMirroring) ensureCreated(r *StorageClusterReconciler, instance *ocsv1.StorageCluster) error {
+	reconcileStrategy := ReconcileStrategyUnknown
+	if instance.Spec.Mirroring != nil {
+		reconcileStrategy = ReconcileStrategy(instance.Spec.Mirroring.ReconcileStrategy)
+	}
+	if reconcileStrategy == ReconcileStrategyIgnore {
+		return nil
+	}
+	if !mirroringEnabled(instance) {
+		instance.Status.Mirroring = nil
+		return r.deleteMirroringResources(instance)
+	}
+
+	if err := r.ensureCephRBDMirror(instance, reconcileStrategy); err != nil {
+		return err
+	}
+	if err := r.ensureVolumeReplicationClasses(instance, reconcileStrategy); err != nil {
+		return err
+	}
+	if err := r.checkMirroringPeerSecrets(instance); err != nil {
+		return err
+	}
+	return r.updateMirroringStatus(instance)
+}
+
+func (r *StorageClusterReconciler) ensureCephRBDMirror(instance *ocsv1.StorageCluster, reconcileStrategy ReconcileStrategy) error {
+	cephRBDMirror, err := r.newCephRBDMirrorInstance(instance)
+	if err != nil {
+		return err
+	}
+
+	existing := cephv1.CephRBDMirror{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: cephRBDMirror.Name, Namespace: cephRBDMirror.Namespace}, &existing)
+	switch {
+	case err == nil:
+		if reconcileStrategy == ReconcileStrategyInit {
+			return nil
+		}
+		if existing.DeletionTimestamp != nil {
+			r.Log.Info("Unable to restore CephRBDMirror because it is marked for deletion.", "CephRBDMirror", klog.KRef(existing.Namespace, existing.Name))
+			return fmt.Errorf("failed to restore initialization object %s because it is marked for deletion", existing.Name)
+		}
+
+		drifted, err := getDriftedFields(cephRBDMirror, &existing, "spec", "metadata.ownerReferences")
+		if err != nil {
+			return err
+		}
+		if len(drifted) == 0 {
+			return nil
+		}
+		r.reportDrift(instance, componentMirroring, "CephRBDMirror", cephRBDMirror.Namespace, cephRBDMirror.Name, drifted)
+
+		r.Log.Info("Restoring original CephRBDMirror.", "CephRBDMirror", klog.KRef(cephRBDMirror.Namespace, cephRBDMirror.Name), "Fields", drifted)
+		existing.ObjectMeta.OwnerReferences = cephRBDMirror.ObjectMeta.OwnerReferences
+		cephRBDMirror.ObjectMeta = existing.ObjectMeta
+		err = r.Client.Update(context.TODO(), cephRBDMirror)
+		if err != nil {
+			r.Log.Error(err, "Unable to update CephRBDMirror.", "CephRBDMirror", klog.KRef(cephRBDMirror.Namespace, cephRBDMirror.Name))
+			return err
+		}
+	case errors.IsNotFound(err):
+		r.Log.Info("Creating CephRBDMirror.", "CephRBDMirror", klog.KRef(cephRBDMirror.Namespace, cephRBDMirror.Name))
+		err = r.Client.Create(context.TODO(), cephRBDMirror)
+		if err != nil {
+			r.Log.Error(err, "Unable to create CephRBDMirror.", "CephRBDMirror", klog.KRef(cephRBDMirror.Namespace, cephRBDMirror.Name))
+			return err
+		}
+	default:
+		r.Log.Error(err, "Unable to get CephRBDMirror.", "CephRBDMirror", klog.KRef(cephRBDMirror.Namespace, cephRBDMirror.Name))
+		return err
+	}
+	return nil
+}
+
+// ensureVolumeReplicationClasses creates the VolumeReplicationClasses of the
+// scheduling intervals, and deletes those of the intervals which were
+// removed from the spec
+func (r *StorageClusterReconciler) ensureVolumeReplicationClasses(instance *ocsv1.StorageCluster, reconcileStrategy ReconcileStrategy) error {
+	desired := map[string]bool{}
+	for _, vrc := range newVolumeReplicationClasses(instance) {
+		desired[vrc.GetName()] = true
+
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(volumeReplicationClassGVK)
+		err := r.Client.Get(context.TODO(), types.NamespacedName{Name: vrc.GetName()}, existing)
+		switch {
+		case err == nil:
+			if reconcileStrategy == ReconcileStrategyInit {
+				continue
+			}
+			drifted, err := getDriftedFields(vrc, existing, "spec")
+			if err != nil {
+				return err
+			}
+			driftedLabels, err := getDriftedSetFields(vrc, existing, "metadata.labels")
+			if err != nil {
+				return err
+			}
+			drifted = append(drifted, driftedLabels...)
+			if len(drifted) == 0 {
+				continue
+			}
+			r.reportDrift(instance, componentMirroring, "VolumeReplicationClass", "", vrc.GetName(), drifted)
+
+			r.Log.Info("Restoring original VolumeReplicationClass.", "VolumeReplicationClass", klog.KRef("", vrc.GetName()), "Fields", drifted)
+			labels := existing.GetLabels()
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			for key, value := range vrc.GetLabels() {
+				labels[key] = value
+			}
+			existing.SetLabels(labels)
+			existing.Object["spec"] = vrc.Object["spec"]
+			err = r.Client.Update(context.TODO(), existing)
+			if err != nil {
+				r.Log.Error(err, "Unable to update VolumeReplicationClass.", "VolumeReplicationClass", klog.KRef("", vrc.GetName()))
+				return err
+			}
+		case errors.IsNotFound(err):
+			r.Log.Info("Creating VolumeReplicationClass.", "VolumeReplicationClass", klog.KRef("", vrc.GetName()))
+			err = r.Client.Create(context.TODO(), vrc)
+			if err != nil {
+				r.Log.Error(err, "Unable to create VolumeReplicationClass.", "VolumeReplicationClass", klog.KRef("", vrc.GetName()))
+				return err
+			}
+		case meta.IsNoMatchError(err):
+			r.Log.Info("VolumeReplicationClass API not available, skipping the VolumeReplicationClasses.")
+			return nil
+		default:
+			r.Log.Error(err, "Unable to get VolumeReplicationClass.", "VolumeReplicationClass", klog.KRef("", vrc.GetName()))
+			return err
+		}
+	}
+
+	existingList, err := r.listVolumeReplicationClasses(instance)
+	if err != nil {
+		return fmt.Errorf("failed to list VolumeReplicationClasses: %v", err)
+	}
+	for i := range existingList.Items {
+		existing := &existingList.Items[i]
+		if desired[existing.GetName()] {
+			continue
+		}
+		r.Log.Info("Deleting VolumeReplicationClass of a removed scheduling interval.", "VolumeReplicationClass", klog.KRef("", existing.GetName()))
+		err = r.Client.Delete(context.TODO(), existing)
+		if err != nil && !errors.IsNotFound(err) {
+			r.Log.Error(err, "Unable to delete VolumeReplicationClass.", "VolumeReplicationClass", klog.KRef("", existing.GetName()))
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *StorageClusterReconciler) listVolumeReplicationClasses(sc *ocsv1.StorageCluster) (*unstructured.UnstructuredList, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(volumeReplicationClassGVK.GroupVersion().WithKind(volumeReplicationClassGVK.Kind + "List"))
+	err := r.Client.List(context.TODO(), list, client.MatchingLabels(getStorageClusterLabels(sc)))
+	return list, err
+}
+
+// checkMirroringPeerSecrets reports the peer Secrets which are missing or
+// do not hold a bootstrap peer token as a Degraded condition
+func (r *StorageClusterReconciler) checkMirroringPeerSecrets(instance *ocsv1.StorageCluster) error {
+	var invalid []string
+	for _, name := range instance.Spec.Mirroring.PeerSecretNames {
+		secret := &corev1.Secret{}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: instance.Namespace}, secret)
+		switch {
+		case errors.IsNotFound(err):
+			invalid = append(invalid, fmt.Sprintf("%s not found", name))
+		case err != nil:
+			return fmt.Errorf("failed to get mirroring peer Secret %s: %v", name, err)
+		case len(secret.Data["token"]) == 0 || len(secret.Data["pool"]) == 0:
+			invalid = append(invalid, fmt.Sprintf("%s has no token or pool", name))
+		}
+	}
+	if len(invalid) > 0 {
+		r.conditions = append(r.conditions, conditionsv1.Condition{
+			Type:    conditionsv1.ConditionDegraded,
+			Status:  corev1.ConditionTrue,
+			Reason:  reasonPeerSecretInvalid,
+			Message: fmt.Sprintf("Invalid mirroring peer Secrets: %s", strings.Join(invalid, ", ")),
+		})
+	}
+	return nil
+}
+
+// updateMirroringStatus records the mirroring health and the peers of the
+// mirrored CephBlockPools, as reported by Rook, and reports the failing pools
+// and those without any peer as Degraded conditions
+func (r *StorageClusterReconciler) updateMirroringStatus(instance *ocsv1.StorageCluster) error {
+	cephBlockPools, err := r.newCephBlockPoolInstances(instance)
+	if err != nil {
+		return err
+	}
+
+	status := &ocsv1.MirroringStatus{}
+	var failed, unpeered []string
+	for _, cephBlockPool := range cephBlockPools {
+		if !cephBlockPool.Spec.Mirroring.Enabled {
+			continue
+		}
+		poolStatus := ocsv1.PoolMirroringStatus{Name: cephBlockPool.Name}
+
+		existing := &cephv1.CephBlockPool{}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{Name: cephBlockPool.Name, Namespace: cephBlockPool.Namespace}, existing)
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get CephBlockPool %s: %v", cephBlockPool.Name, err)
+		}
+		if existing.Status != nil {
+			poolStatus.BootstrapPeerSecretName = existing.Status.Info[rbdMirrorBootstrapPeerSecretInfoKey]
+			if existing.Status.MirroringStatus != nil {
+				poolStatus.LastChecked = existing.Status.MirroringStatus.LastChecked
+			}
+			// The peers are only known once Rook reported the mirroring
+			// info of the pool
+			if info := existing.Status.MirroringInfo; info != nil && info.PoolMirroringInfo != nil {
+				for _, peer := range info.Peers {
+					poolStatus.PeerSites = append(poolStatus.PeerSites, peer.SiteName)
+				}
+				if len(info.Peers) == 0 {
+					unpeered = append(unpeered, cephBlockPool.Name)
+				}
+			}
+		}
+		health := getPoolMirroringHealth(existing)
+		poolStatus.Health = health.Health
+		poolStatus.DaemonHealth = health.DaemonHealth
+		poolStatus.ImageHealth = health.ImageHealth
+		if poolStatus.Health == poolMirroringHealthError {
+			failed = append(failed, cephBlockPool.Name)
+		}
+		status.Pools = append(status.Pools, poolStatus)
+	}
+	instance.Status.Mirroring = status
+
+	if len(failed) > 0 {
+		r.conditions = append(r.conditions, conditionsv1.Condition{
+			Type:    conditionsv1.ConditionDegraded,
+			Status:  corev1.ConditionTrue,
+			Reason:  reasonPoolMirroringFailed,
+			Message: fmt.Sprintf("Mirroring of CephBlockPools %s is failing", strings.Join(failed, ", ")),
+		})
+	}
+	if len(unpeered) > 0 {
+		r.conditions = append(r.conditions, conditionsv1.Condition{
+			Type:    conditionsv1.ConditionDegraded,
+			Status:  corev1.ConditionTrue,
+			Reason:  reasonPeerMissing,
+			Message: fmt.Sprintf("Mirrored CephBlockPools %s have no peer, add a peer Secret or import a bootstrap peer token", strings.Join(unpeered, ", ")),
+		})
+	}
+	return nil
+}
+
+// deleteMirroringResources deletes the VolumeReplicationClasses and the
+// CephRBDMirror of the StorageCluster without waiting for them to be gone
+func (r *StorageClusterReconciler) deleteMirroringResources(sc *ocsv1.StorageCluster) error {
+	vrcList, err := r.listVolumeReplicationClasses(sc)
+	if err != nil && !meta.IsNoMatchError(err) {
+		r.Log.Error(err, "Unable to list VolumeReplicationClasses.")
+		return fmt.Errorf("unable to list VolumeReplicationClasses: %v", err)
+	} else if err == nil {
+		for i := range vrcList.Items {
+			vrc := &vrcList.Items[i]
+			r.Log.Info("Deleting VolumeReplicationClass.", "VolumeReplicationClass", klog.KRef("", vrc.GetName()))
+			err = r.Client.Delete(context.TODO(), vrc)
+			if err != nil && !errors.IsNotFound(err) {
+				r.Log.Error(err, "Failed to delete VolumeReplicationClass.", "VolumeReplicationClass", klog.KRef("", vrc.GetName()))
+				return fmt.Errorf("failed to delete VolumeReplicationClass %v: %v", vrc.GetName(), err)
+			}
+		}
+	}
+
+	cephRBDMirror := &cephv1.CephRBDMirror{}
+	cephRBDMirrorName := generateNameForCephRBDMirror(sc)
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: cephRBDMirrorName, Namespace: sc.Namespace}, cephRBDMirror)
+	if err == nil && cephRBDMirror.DeletionTimestamp == nil {
+		r.Log.Info("Deleting CephRBDMirror.", "CephRBDMirror", klog.KRef(sc.Namespace, cephRBDMirrorName))
+		err = r.Client.Delete(context.TODO(), cephRBDMirror)
+		if err != nil && !errors.IsNotFound(err) {
+			r.Log.Error(err, "Failed to delete CephRBDMirror.", "CephRBDMirror", klog.KRef(sc.Namespace, cephRBDMirrorName))
+			return fmt.Errorf("failed to delete CephRBDMirror %v: %v", cephRBDMirrorName, err)
+		}
+	} else if err != nil && !errors.IsNotFound(err) {
+		r.Log.Error(err, "Unable to retrieve CephRBDMirror.", "CephRBDMirror", klog.KRef(sc.Namespace, cephRBDMirrorName))
+		return fmt.Errorf("unable to retrieve CephRBDMirror %v: %v", cephRBDMirrorName, err)
+	}
+	return nil
+}
+
+// ensureDeleted deletes the VolumeReplicationClasses and the CephRBDMirror
+// of the StorageCluster, and waits for the CephRBDMirror to be gone so that
+// the CephBlockPools can be deleted next
+func (obj *ocsMirroring) ensureDeleted(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) error {
+	if err := r.deleteMirroringResources(sc); err != nil {
+		return fmt.Errorf("uninstall: %v", err)
+	}
+
+	foundCephRBDMirror := &cephv1.CephRBDMirror{}
+	cephRBDMirrorName := generateNameForCephRBDMirror(sc)
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: cephRBDMirrorName, Namespace: sc.Namespace}, foundCephRBDMirror)
+	if err != nil && errors.IsNotFound(err) {
+		r.Log.Info("Uninstall: CephRBDMirror is deleted.", "CephRBDMirror", klog.KRef(sc.Namespace, cephRBDMirrorName))
+		return nil
+	}
+	r.Log.Error(err, "Uninstall: Waiting for CephRBDMirror to be deleted.", "CephRBDMirror", klog.KRef(sc.Namespace, cephRBDMirrorName))
+	return fmt.Errorf("uninstall: Waiting for CephRBDMirror %v to be deleted", cephRBDMirrorName)
+}
@@ -0,0 +1,407 @@
+package storagecluster
+
+import (
+	"context"
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	api "github.com/openshift/ocs-operator/api/v1"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestValidateMirroringSpec(t *testing.T) {
+	cases := []struct {
+		label         string
+		mirroring     *api.MirroringSpec
+		external      bool
+		expectedError bool
+	}{
+		{
+			label: "no mirroring",
+		},
+		{
+			label:     "mirroring enabled",
+			mirroring: &api.MirroringSpec{Enabled: true},
+		},
+		{
+			label:     "mirroring enabled with scheduling intervals",
+			mirroring: &api.MirroringSpec{Enabled: true, SchedulingIntervals: []string{"5m", "1h", "1d"}},
+		},
+		{
+			label:         "invalid scheduling interval",
+			mirroring:     &api.MirroringSpec{Enabled: true, SchedulingIntervals: []string{"5s"}},
+			expectedError: true,
+		},
+		{
+			label:         "zero scheduling interval",
+			mirroring:     &api.MirroringSpec{Enabled: true, SchedulingIntervals: []string{"0m"}},
+			expectedError: true,
+		},
+		{
+			label:         "duplicate scheduling interval",
+			mirroring:     &api.MirroringSpec{Enabled: true, SchedulingIntervals: []string{"1h", "1h"}},
+			expectedError: true,
+		},
+		{
+			label:     "mirroring disabled with external storage",
+			mirroring: &api.MirroringSpec{},
+			external:  true,
+		},
+		{
+			label:         "mirroring enabled with external storage",
+			mirroring:     &api.MirroringSpec{Enabled: true},
+			external:      true,
+			expectedError: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := &api.StorageCluster{}
+		sc.Spec.Mirroring = c.mirroring
+		sc.Spec.ExternalStorage.Enable = c.external
+		err := validateMirroringSpec(sc)
+		if c.expectedError {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestCephBlockPoolsMirroring(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	sc := createDefaultStorageCluster()
+	sc.Spec.ManagedResources.CephBlockPools.ErasureCoded = &api.ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}
+
+	pools, err := reconciler.newCephBlockPoolInstances(sc)
+	assert.NoError(t, err)
+	for _, pool := range pools {
+		assert.False(t, pool.Spec.Mirroring.Enabled)
+	}
+
+	sc.Spec.Mirroring = &api.MirroringSpec{Enabled: true}
+	pools, err = reconciler.newCephBlockPoolInstances(sc)
+	assert.NoError(t, err)
+	for _, pool := range pools {
+		if pool.Name == "ocsinit-cephblockpool-ecdata" {
+			assert.False(t, pool.Spec.Mirroring.Enabled)
+			continue
+		}
+		assert.True(t, pool.Spec.Mirroring.Enabled)
+		assert.Equal(t, poolMirroringModeImage, pool.Spec.Mirroring.Mode)
+		assert.NotNil(t, pool.Spec.StatusCheck.Mirror.Interval)
+	}
+}
+
+func getVolumeReplicationClass(reconciler StorageClusterReconciler, name string) (*unstructured.Unstructured, error) {
+	vrc := &unstructured.Unstructured{}
+	vrc.SetGroupVersionKind(volumeReplicationClassGVK)
+	err := reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name}, vrc)
+	return vrc, err
+}
+
+func TestMirroringEnsureCreated(t *testing.T) {
+	cases := []struct {
+		label           string
+		mirroring       *api.MirroringSpec
+		expectedCreated bool
+		expectedVRCs    []string
+	}{
+		{
+			label: "mirroring not configured",
+		},
+		{
+			label:     "mirroring disabled",
+			mirroring: &api.MirroringSpec{},
+		},
+		{
+			label:           "mirroring enabled",
+			mirroring:       &api.MirroringSpec{Enabled: true},
+			expectedCreated: true,
+			expectedVRCs:    []string{"ocsinit-rbd-volumereplicationclass-5m"},
+		},
+		{
+			label:           "mirroring enabled with scheduling intervals",
+			mirroring:       &api.MirroringSpec{Enabled: true, SchedulingIntervals: []string{"1h", "1d"}},
+			expectedCreated: true,
+			expectedVRCs: []string{
+				"ocsinit-rbd-volumereplicationclass-1h",
+				"ocsinit-rbd-volumereplicationclass-1d",
+			},
+		},
+		{
+			label:     "mirroring ignored",
+			mirroring: &api.MirroringSpec{Enabled: true, ReconcileStrategy: string(ReconcileStrategyIgnore)},
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		reconciler := createFakeStorageClusterReconciler(t)
+		sc := createDefaultStorageCluster()
+		sc.Spec.Mirroring = c.mirroring
+
+		var obj ocsMirroring
+		assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+
+		cephRBDMirror := &cephv1.CephRBDMirror{}
+		err := reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephrbdmirror", Namespace: sc.Namespace}, cephRBDMirror)
+		vrcList, listErr := reconciler.listVolumeReplicationClasses(sc)
+		assert.NoError(t, listErr)
+		if !c.expectedCreated {
+			assert.True(t, errors.IsNotFound(err))
+			assert.Empty(t, vrcList.Items)
+			assert.Nil(t, sc.Status.Mirroring)
+			continue
+		}
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, cephRBDMirror.Spec.Count)
+		assert.Len(t, cephRBDMirror.OwnerReferences, 1)
+		assert.Len(t, vrcList.Items, len(c.expectedVRCs))
+		for j, name := range c.expectedVRCs {
+			vrc, err := getVolumeReplicationClass(reconciler, name)
+			assert.NoError(t, err)
+			provisioner, _, _ := unstructured.NestedString(vrc.Object, "spec", "provisioner")
+			assert.Equal(t, sc.Namespace+".rbd.csi.ceph.com", provisioner)
+			interval, _, _ := unstructured.NestedString(vrc.Object, "spec", "parameters", "schedulingInterval")
+			assert.Equal(t, getSchedulingIntervals(sc)[j], interval)
+		}
+		assert.NotNil(t, sc.Status.Mirroring)
+		assert.Len(t, sc.Status.Mirroring.Pools, 1)
+		assert.Equal(t, "ocsinit-cephblockpool", sc.Status.Mirroring.Pools[0].Name)
+	}
+}
+
+func TestMirroringSchedulingIntervalsChange(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	sc := createDefaultStorageCluster()
+	sc.Spec.Mirroring = &api.MirroringSpec{Enabled: true, SchedulingIntervals: []string{"5m", "1h"}}
+
+	var obj ocsMirroring
+	assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+
+	vrc, err := getVolumeReplicationClass(reconciler, "ocsinit-rbd-volumereplicationclass-1h")
+	assert.NoError(t, err)
+	assert.NoError(t, unstructured.SetNestedField(vrc.Object, "2h", "spec", "parameters", "schedulingInterval"))
+	assert.NoError(t, reconciler.Client.Update(context.TODO(), vrc))
+
+	// The drifted VolumeReplicationClass is restored
+	assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+	vrc, err = getVolumeReplicationClass(reconciler, "ocsinit-rbd-volumereplicationclass-1h")
+	assert.NoError(t, err)
+	interval, _, _ := unstructured.NestedString(vrc.Object, "spec", "parameters", "schedulingInterval")
+	assert.Equal(t, "1h", interval)
+
+	// The VolumeReplicationClass of a removed interval is deleted
+	sc.Spec.Mirroring.SchedulingIntervals = []string{"1h"}
+	assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+	_, err = getVolumeReplicationClass(reconciler, "ocsinit-rbd-volumereplicationclass-5m")
+	assert.True(t, errors.IsNotFound(err))
+	_, err = getVolumeReplicationClass(reconciler, "ocsinit-rbd-volumereplicationclass-1h")
+	assert.NoError(t, err)
+}
+
+func TestMirroringDisable(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	sc := createDefaultStorageCluster()
+	sc.Spec.Mirroring = &api.MirroringSpec{Enabled: true}
+
+	var pools ocsCephBlockPools
+	var obj ocsMirroring
+	assert.NoError(t, pools.ensureCreated(&reconciler, sc))
+	assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+
+	// The CephRBDMirror and the VolumeReplicationClasses are deleted, and the
+	// mirroring of the CephBlockPools is disabled
+	sc.Spec.Mirroring.Enabled = false
+	assert.NoError(t, pools.ensureCreated(&reconciler, sc))
+	assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+
+	err := reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephrbdmirror", Namespace: sc.Namespace}, &cephv1.CephRBDMirror{})
+	assert.True(t, errors.IsNotFound(err))
+	vrcList, err := reconciler.listVolumeReplicationClasses(sc)
+	assert.NoError(t, err)
+	assert.Empty(t, vrcList.Items)
+	assert.Nil(t, sc.Status.Mirroring)
+
+	pool := &cephv1.CephBlockPool{}
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephblockpool", Namespace: sc.Namespace}, pool)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.MirroringSpec{Enabled: false}, pool.Spec.Mirroring)
+	assert.Nil(t, pool.Spec.StatusCheck.Mirror.Interval)
+}
+
+func TestMirroringPeerSecrets(t *testing.T) {
+	cases := []struct {
+		label            string
+		secret           *corev1.Secret
+		expectedDegraded bool
+	}{
+		{
+			label: "valid peer Secret",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "peer-site"},
+				Data:       map[string][]byte{"token": []byte("dG9rZW4="), "pool": []byte("ocsinit-cephblockpool")},
+			},
+		},
+		{
+			label: "peer Secret without a token",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "peer-site"},
+				Data:       map[string][]byte{"pool": []byte("ocsinit-cephblockpool")},
+			},
+			expectedDegraded: true,
+		},
+		{
+			label:            "peer Secret not found",
+			expectedDegraded: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		reconciler := createFakeStorageClusterReconciler(t)
+		if c.secret != nil {
+			assert.NoError(t, reconciler.Client.Create(context.TODO(), c.secret))
+		}
+		sc := createDefaultStorageCluster()
+		sc.Spec.Mirroring = &api.MirroringSpec{Enabled: true, PeerSecretNames: []string{"peer-site"}}
+
+		var obj ocsMirroring
+		assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+
+		cephRBDMirror := &cephv1.CephRBDMirror{}
+		assert.NoError(t, reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephrbdmirror", Namespace: sc.Namespace}, cephRBDMirror))
+		assert.Equal(t, []string{"peer-site"}, cephRBDMirror.Spec.Peers.SecretNames)
+
+		degraded := false
+		for _, condition := range reconciler.conditions {
+			if condition.Type == conditionsv1.ConditionDegraded && condition.Reason == reasonPeerSecretInvalid {
+				degraded = true
+			}
+		}
+		assert.Equal(t, c.expectedDegraded, degraded)
+	}
+}
+
+func TestMirroringStatus(t *testing.T) {
+	lastChecked := "2021-03-15T10:30:00Z"
+	pool := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocsinit-cephblockpool"},
+		Status: &cephv1.CephBlockPoolStatus{
+			MirroringStatus: &cephv1.MirroringStatusSpec{
+				PoolMirroringStatus: cephv1.PoolMirroringStatus{
+					Summary: &cephv1.PoolMirroringStatusSummarySpec{
+						Health:       poolMirroringHealthError,
+						DaemonHealth: "OK",
+						ImageHealth:  poolMirroringHealthError,
+					},
+				},
+				LastChecked: lastChecked,
+			},
+			Info: map[string]string{rbdMirrorBootstrapPeerSecretInfoKey: "pool-peer-token-ocsinit-cephblockpool"},
+		},
+	}
+	reconciler := createFakeStorageClusterReconciler(t, pool)
+	sc := createDefaultStorageCluster()
+	sc.Spec.Mirroring = &api.MirroringSpec{Enabled: true}
+
+	var obj ocsMirroring
+	assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+
+	assert.Equal(t, &api.MirroringStatus{
+		Pools: []api.PoolMirroringStatus{
+			{
+				Name:                    "ocsinit-cephblockpool",
+				Health:                  poolMirroringHealthError,
+				DaemonHealth:            "OK",
+				ImageHealth:             poolMirroringHealthError,
+				BootstrapPeerSecretName: "pool-peer-token-ocsinit-cephblockpool",
+				LastChecked:             lastChecked,
+			},
+		},
+	}, sc.Status.Mirroring)
+
+	degraded := false
+	for _, condition := range reconciler.conditions {
+		if condition.Type == conditionsv1.ConditionDegraded && condition.Reason == reasonPoolMirroringFailed {
+			degraded = true
+		}
+	}
+	assert.True(t, degraded)
+}
+
+func TestMirroringPeerMissing(t *testing.T) {
+	cases := []struct {
+		label             string
+		mirroringInfo     *cephv1.MirroringInfoSpec
+		expectedPeerSites []string
+		expectedDegraded  bool
+	}{
+		{
+			label: "mirroring info not reported yet",
+		},
+		{
+			label:            "no peer",
+			mirroringInfo:    &cephv1.MirroringInfoSpec{PoolMirroringInfo: &cephv1.PoolMirroringInfo{Mode: poolMirroringModeImage}},
+			expectedDegraded: true,
+		},
+		{
+			label: "peered",
+			mirroringInfo: &cephv1.MirroringInfoSpec{PoolMirroringInfo: &cephv1.PoolMirroringInfo{
+				Mode:  poolMirroringModeImage,
+				Peers: []cephv1.PeersSpec{{UUID: "1", SiteName: "site-b"}},
+			}},
+			expectedPeerSites: []string{"site-b"},
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		pool := &cephv1.CephBlockPool{
+			ObjectMeta: metav1.ObjectMeta{Name: "ocsinit-cephblockpool"},
+			Status:     &cephv1.CephBlockPoolStatus{MirroringInfo: c.mirroringInfo},
+		}
+		reconciler := createFakeStorageClusterReconciler(t, pool)
+		sc := createDefaultStorageCluster()
+		sc.Spec.Mirroring = &api.MirroringSpec{Enabled: true}
+
+		var obj ocsMirroring
+		assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+		assert.Equal(t, c.expectedPeerSites, sc.Status.Mirroring.Pools[0].PeerSites)
+
+		degraded := false
+		for _, condition := range reconciler.conditions {
+			if condition.Type == conditionsv1.ConditionDegraded && condition.Reason == reasonPeerMissing {
+				degraded = true
+			}
+		}
+		assert.Equal(t, c.expectedDegraded, degraded)
+	}
+}
+
+func TestMirroringEnsureDeleted(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	sc := createDefaultStorageCluster()
+	sc.Spec.Mirroring = &api.MirroringSpec{Enabled: true}
+
+	var obj ocsMirroring
+	// Nothing to delete
+	assert.NoError(t, obj.ensureDeleted(&reconciler, sc))
+
+	assert.NoError(t, obj.ensureCreated(&reconciler, sc))
+	assert.NoError(t, obj.ensureDeleted(&reconciler, sc))
+
+	err := reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-cephrbdmirror", Namespace: sc.Namespace}, &cephv1.CephRBDMirror{})
+	assert.True(t, errors.IsNotFound(err))
+	_, err = getVolumeReplicationClass(reconciler, "ocsinit-rbd-volumereplicationclass-5m")
+	assert.True(t, errors.IsNotFound(err))
+}
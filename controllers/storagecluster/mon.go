@@ -0,0 +1,150 @@
+package storagecluster
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/defaults"
+	statusutil "github.com/openshift/ocs-operator/controllers/util"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// monEndpointsConfigMapName is the ConfigMap in which Rook records the
+	// mons of the Ceph cluster
+	monEndpointsConfigMapName = "rook-ceph-mon-endpoints"
+	// monDownHealthCheck is the Ceph health check raised while mons are out
+	// of quorum
+	monDownHealthCheck = "MON_DOWN"
+)
+
+// monDownQuorumRegexp matches the message of the MON_DOWN health check, e.g.
+// "1/3 mons down, quorum a,c"
+var monDownQuorumRegexp = regexp.MustCompile(`quorum ([^ ]+)`)
+
+// validateMonSpec checks the mon count of the StorageCluster
+func validateMonSpec(sc *ocsv1.StorageCluster) error {
+	count := sc.Spec.Mon.Count
+	if count == 0 {
+		return nil
+	}
+	if count != defaults.DefaultMonCount && count != defaults.ArbiterModeMonCount {
+		return fmt.Errorf("invalid mon count %d: must be %d or %d", count, defaults.DefaultMonCount, defaults.ArbiterModeMonCount)
+	}
+	if arbiterEnabled(sc) && count != defaults.ArbiterModeMonCount {
+		return fmt.Errorf("invalid mon count %d: arbiter mode requires %d mons", count, defaults.ArbiterModeMonCount)
+	}
+	return nil
+}
+
+// validateMonPlacement checks that the mons of the StorageCluster can each
+// run on a different storage node, and in a different failure domain as
+// required by their default anti-affinity. It needs the node topology of the
+// StorageCluster, so it cannot run in the admission webhook.
+func validateMonPlacement(sc *ocsv1.StorageCluster, nodeCount int) error {
+	// The stretch cluster places one of the mons on the arbiter node, which
+	// is not a storage node
+	if sc.Spec.ExternalStorage.Enable || sc.Spec.Mon.AllowMultiplePerNode || arbiterEnabled(sc) {
+		return nil
+	}
+	count := getMonCount(sc)
+	if nodeCount < count {
+		return fmt.Errorf("%d mons require at least %d storage nodes, found %d: set spec.mon.allowMultiplePerNode to run several mons on a node",
+			count, count, nodeCount)
+	}
+	// Custom placements spread the mons themselves
+	if _, ok := sc.Spec.Placement[rookv1.KeyType("mon")]; ok {
+		return nil
+	}
+	if len(sc.Status.FailureDomainValues) < count {
+		return fmt.Errorf("%d mons require at least %d failure domains of type %q, found %d",
+			count, count, sc.Status.FailureDomain, len(sc.Status.FailureDomainValues))
+	}
+	return nil
+}
+
+// relaxMonAntiAffinity turns the required anti-affinity of the mons into a
+// preferred one, so that several mons can run on the same node while still
+// being spread when possible
+func relaxMonAntiAffinity(placement *rookv1.Placement) {
+	if placement.PodAntiAffinity == nil {
+		return
+	}
+	for _, term := range placement.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+		placement.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+			placement.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			corev1.WeightedPodAffinityTerm{Weight: 100, PodAffinityTerm: term})
+	}
+	placement.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = nil
+}
+
+// reconcileMonStatus reports the mons of the Ceph cluster and those in
+// quorum. The StorageCluster is Progressing while the quorum does not have
+// the desired number of mons, e.g. after the count is changed.
+func (r *StorageClusterReconciler) reconcileMonStatus(sc *ocsv1.StorageCluster, cephCluster *cephv1.CephCluster) error {
+	endpoints := &corev1.ConfigMap{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: monEndpointsConfigMapName, Namespace: sc.Namespace}, endpoints)
+	if err != nil && !errors.IsNotFound(err) {
+		r.Log.Error(err, "Failed to get the mon endpoints.", "ConfigMap", klog.KRef(sc.Namespace, monEndpointsConfigMapName))
+		return err
+	}
+	mons := getMons(endpoints)
+	quorum, known := getMonQuorum(cephCluster, mons)
+
+	count := getMonCount(sc)
+	sc.Status.Mon = &ocsv1.MonStatus{
+		Count:      count,
+		Mons:       mons,
+		QuorumMons: quorum,
+	}
+	// Until Ceph reports its health, the CephCluster reports the progress
+	// itself
+	if known && len(quorum) != count {
+		reason := "MonQuorumIncomplete"
+		message := fmt.Sprintf("%d of %d mons are in quorum", len(quorum), count)
+		statusutil.SetProgressingCondition(&r.conditions, reason, message)
+	}
+	return nil
+}
+
+// getMons returns the sorted IDs of the mons Rook recorded in its mon
+// endpoints ConfigMap, whose data is e.g. "a=10.0.0.1:6789,b=10.0.0.2:6789"
+func getMons(endpoints *corev1.ConfigMap) []string {
+	mons := []string{}
+	for _, endpoint := range strings.Split(endpoints.Data["data"], ",") {
+		if id := strings.SplitN(endpoint, "=", 2)[0]; id != "" {
+			mons = append(mons, id)
+		}
+	}
+	sort.Strings(mons)
+	return mons
+}
+
+// getMonQuorum returns the sorted IDs of the mons in quorum, as reported by
+// Ceph in the status of the CephCluster, and whether Ceph reported it. All
+// the mons are in quorum unless Ceph raises the MON_DOWN health check, whose
+// message lists the quorum.
+func getMonQuorum(cephCluster *cephv1.CephCluster, mons []string) ([]string, bool) {
+	if cephCluster.Status.CephStatus == nil || cephCluster.Status.CephStatus.Health == "" {
+		return nil, false
+	}
+	monDown, ok := cephCluster.Status.CephStatus.Details[monDownHealthCheck]
+	if !ok {
+		return mons, true
+	}
+	quorum := []string{}
+	if match := monDownQuorumRegexp.FindStringSubmatch(monDown.Message); match != nil {
+		quorum = strings.Split(match[1], ",")
+	}
+	sort.Strings(quorum)
+	return quorum, true
+}
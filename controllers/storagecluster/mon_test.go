@@ -0,0 +1,190 @@
+package storagecluster
+
+import (
+	"testing"
+
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	api "github.com/openshift/ocs-operator/api/v1"
+	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestValidateMonSpec(t *testing.T) {
+	cases := []struct {
+		label         string
+		count         int
+		arbiter       bool
+		expectedError bool
+	}{
+		{
+			label: "default count",
+		},
+		{
+			label: "five mons",
+			count: 5,
+		},
+		{
+			label:         "unsupported count",
+			count:         4,
+			expectedError: true,
+		},
+		{
+			label:         "three mons in arbiter mode",
+			count:         3,
+			arbiter:       true,
+			expectedError: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := &api.StorageCluster{}
+		sc.Spec.Mon.Count = c.count
+		sc.Spec.Arbiter.Enable = c.arbiter
+		err := validateMonSpec(sc)
+		if c.expectedError {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestValidateMonPlacement(t *testing.T) {
+	cases := []struct {
+		label               string
+		mon                 api.MonSpec
+		nodeCount           int
+		failureDomainValues []string
+		expectedError       bool
+	}{
+		{
+			label:               "three mons",
+			nodeCount:           3,
+			failureDomainValues: []string{"rack0", "rack1", "rack2"},
+		},
+		{
+			label:               "not enough nodes",
+			mon:                 api.MonSpec{Count: 5},
+			nodeCount:           4,
+			failureDomainValues: []string{"rack0", "rack1", "rack2"},
+			expectedError:       true,
+		},
+		{
+			label:               "not enough failure domains",
+			mon:                 api.MonSpec{Count: 5},
+			nodeCount:           6,
+			failureDomainValues: []string{"rack0", "rack1", "rack2"},
+			expectedError:       true,
+		},
+		{
+			label:               "several mons per node",
+			mon:                 api.MonSpec{Count: 5, AllowMultiplePerNode: true},
+			nodeCount:           1,
+			failureDomainValues: []string{"rack0"},
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := &api.StorageCluster{}
+		sc.Spec.Mon = c.mon
+		sc.Status.FailureDomain = "rack"
+		sc.Status.FailureDomainValues = c.failureDomainValues
+		err := validateMonPlacement(sc, c.nodeCount)
+		if c.expectedError {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestMonPlacementAllowMultiplePerNode(t *testing.T) {
+	sc := &api.StorageCluster{}
+	placement := getPlacement(sc, "mon")
+	assert.Len(t, placement.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, 1)
+	assert.Empty(t, placement.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
+
+	sc.Spec.Mon.AllowMultiplePerNode = true
+	placement = getPlacement(sc, "mon")
+	assert.Empty(t, placement.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+	assert.Len(t, placement.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, 1)
+	assert.True(t, generateMonSpec(sc).AllowMultiplePerNode)
+}
+
+func TestReconcileMonStatus(t *testing.T) {
+	monEndpoints := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: monEndpointsConfigMapName, Namespace: "openshift-storage"},
+		Data:       map[string]string{"data": "b=10.0.0.2:6789,a=10.0.0.1:6789,c=10.0.0.3:6789"},
+	}
+	cases := []struct {
+		label               string
+		count               int
+		cephStatus          *rookCephv1.CephStatus
+		objs                []runtime.Object
+		expectedMons        []string
+		expectedQuorumMons  []string
+		expectedProgressing bool
+	}{
+		{
+			label:        "no mons yet",
+			expectedMons: []string{},
+		},
+		{
+			label:        "health not reported yet",
+			objs:         []runtime.Object{monEndpoints},
+			expectedMons: []string{"a", "b", "c"},
+		},
+		{
+			label:              "all mons in quorum",
+			cephStatus:         &rookCephv1.CephStatus{Health: "HEALTH_OK"},
+			objs:               []runtime.Object{monEndpoints},
+			expectedMons:       []string{"a", "b", "c"},
+			expectedQuorumMons: []string{"a", "b", "c"},
+		},
+		{
+			label: "mon out of quorum",
+			cephStatus: &rookCephv1.CephStatus{
+				Health: "HEALTH_WARN",
+				Details: map[string]rookCephv1.CephHealthMessage{
+					monDownHealthCheck: {Severity: "HEALTH_WARN", Message: "1/3 mons down, quorum c,a"},
+				},
+			},
+			objs:                []runtime.Object{monEndpoints},
+			expectedMons:        []string{"a", "b", "c"},
+			expectedQuorumMons:  []string{"a", "c"},
+			expectedProgressing: true,
+		},
+		{
+			label:               "mon count increased",
+			count:               5,
+			cephStatus:          &rookCephv1.CephStatus{Health: "HEALTH_OK"},
+			objs:                []runtime.Object{monEndpoints},
+			expectedMons:        []string{"a", "b", "c"},
+			expectedQuorumMons:  []string{"a", "b", "c"},
+			expectedProgressing: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := &api.StorageCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "ocsinit", Namespace: "openshift-storage"},
+		}
+		sc.Spec.Mon.Count = c.count
+		cephCluster := &rookCephv1.CephCluster{}
+		cephCluster.Status.CephStatus = c.cephStatus
+		reconciler := createFakeStorageClusterReconciler(t, c.objs...)
+
+		err := reconciler.reconcileMonStatus(sc, cephCluster)
+		assert.NoError(t, err)
+		assert.Equal(t, getMonCount(sc), sc.Status.Mon.Count)
+		assert.Equal(t, c.expectedMons, sc.Status.Mon.Mons)
+		assert.Equal(t, c.expectedQuorumMons, sc.Status.Mon.QuorumMons)
+		assert.Equal(t, c.expectedProgressing, conditionsv1.IsStatusConditionTrue(reconciler.conditions, conditionsv1.ConditionProgressing))
+	}
+}
@@ -72,13 +72,24 @@ func (obj *ocsNoobaaSystem) ensureCreated(r *StorageClusterReconciler, sc *ocsv1
 	}
 
 	// Reconcile the noobaa state, creating or updating if needed
-	_, err = controllerutil.CreateOrUpdate(context.TODO(), r.Client, nb, func() error {
-		return r.setNooBaaDesiredState(nb, sc)
+	var drifted []string
+	result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, nb, func() error {
+		live := nb.DeepCopy()
+		if err := r.setNooBaaDesiredState(nb, sc); err != nil {
+			return err
+		}
+		fields, err := getDriftedFields(nb, live, "spec")
+		drifted = fields
+		return err
 	})
 	if err != nil {
 		r.Log.Error(err, "Failed to create or update NooBaa system.", "Noobaa", klog.KRef(nb.Namespace, nb.Name))
 		return err
 	}
+	if result == controllerutil.OperationResultUpdated && len(drifted) > 0 {
+		r.Log.Info("Updated NooBaa system.", "Noobaa", klog.KRef(nb.Namespace, nb.Name), "Fields", drifted)
+		r.reportDrift(sc, componentNoobaa, "NooBaa", nb.Namespace, nb.Name, drifted)
+	}
 	// Need to happen after the noobaa CR update was confirmed
 	sc.Status.Images.NooBaaCore.ActualImage = *nb.Spec.Image
 	sc.Status.Images.NooBaaDB.ActualImage = *nb.Spec.DBImage
@@ -98,10 +109,10 @@ func (obj *ocsNoobaaSystem) ensureCreated(r *StorageClusterReconciler, sc *ocsv1
 
 func (r *StorageClusterReconciler) setNooBaaDesiredState(nb *nbv1.NooBaa, sc *ocsv1.StorageCluster) error {
 	storageClassName := generateNameForCephBlockPoolSC(sc, "")
-	coreResources := defaults.GetDaemonResources("noobaa-core", sc.Spec.Resources)
-	dbResources := defaults.GetDaemonResources("noobaa-db", sc.Spec.Resources)
+	coreResources := getDaemonResources(sc, "noobaa-core")
+	dbResources := getDaemonResources(sc, "noobaa-db")
 	dBVolumeResources := defaults.GetDaemonResources("noobaa-db-vol", sc.Spec.Resources)
-	endpointResources := defaults.GetDaemonResources("noobaa-endpoint", sc.Spec.Resources)
+	endpointResources := getDaemonResources(sc, "noobaa-endpoint")
 
 	nb.Labels = map[string]string{
 		"app": "noobaa",
@@ -126,7 +137,7 @@ func (r *StorageClusterReconciler) setNooBaaDesiredState(nb *nbv1.NooBaa, sc *oc
 
 		// TODO: After spec.resources["noobaa-endpoint"] is decleared obesolete this
 		// definition should hold a constant value. and should not be read from
-		// GetDaemonResources()
+		// getDaemonResources()
 		Resources: &endpointResources,
 	}
 
@@ -0,0 +1,140 @@
+package storagecluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	statusutil "github.com/openshift/ocs-operator/controllers/util"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// A namespace holds at most one active StorageCluster, as the Ceph and NooBaa
+// resources are managed per namespace. The active StorageCluster is the one
+// holding the owner Lease of its namespace: the first StorageCluster to create
+// the Lease holds it until it is deleted. The other StorageClusters of the
+// namespace are ignored and take over once the Lease is released.
+// StorageClusters in different namespaces do not affect each other.
+const (
+	namespaceOwnerLeaseName = "ocs-storagecluster-owner"
+
+	// ignoredRequeueInterval is how often an ignored StorageCluster checks
+	// whether the owner of its namespace is gone
+	ignoredRequeueInterval = time.Minute
+)
+
+// ensureNamespaceOwner returns the name of the StorageCluster owning the
+// namespace of the given StorageCluster, claiming the namespace for it if it
+// has no owner yet
+func (r *StorageClusterReconciler) ensureNamespaceOwner(sc *ocsv1.StorageCluster) (string, error) {
+	lease := &coordinationv1.Lease{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: namespaceOwnerLeaseName, Namespace: sc.Namespace}, lease)
+	if errors.IsNotFound(err) {
+		// StorageClusters which were active before the owner was recorded
+		// keep owning the namespace
+		owner, err := r.getActiveStorageCluster(sc.Namespace)
+		if err != nil {
+			return "", err
+		}
+		if owner != "" && owner != sc.Name {
+			return owner, nil
+		}
+
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      namespaceOwnerLeaseName,
+				Namespace: sc.Namespace,
+			},
+		}
+		if err := r.setNamespaceOwner(lease, sc); err != nil {
+			return "", err
+		}
+		r.Log.Info("Claiming the namespace for StorageCluster.", "Lease", klog.KRef(lease.Namespace, lease.Name))
+		// Creating the Lease fails if another StorageCluster claimed the
+		// namespace in the meantime, in which case it is checked again
+		if err := r.Client.Create(context.TODO(), lease); err != nil {
+			return "", fmt.Errorf("failed to create Lease %s: %v", lease.Name, err)
+		}
+		return sc.Name, nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get Lease %s: %v", namespaceOwnerLeaseName, err)
+	}
+
+	if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != "" {
+		owner := *lease.Spec.HolderIdentity
+		if owner == sc.Name {
+			return owner, nil
+		}
+		err = r.Client.Get(context.TODO(), types.NamespacedName{Name: owner, Namespace: sc.Namespace}, &ocsv1.StorageCluster{})
+		if err == nil {
+			return owner, nil
+		} else if !errors.IsNotFound(err) {
+			return "", fmt.Errorf("failed to get StorageCluster %s: %v", owner, err)
+		}
+	}
+
+	// The owner is gone without releasing the namespace
+	if err := r.setNamespaceOwner(lease, sc); err != nil {
+		return "", err
+	}
+	r.Log.Info("Taking over the namespace for StorageCluster.", "Lease", klog.KRef(lease.Namespace, lease.Name))
+	if err := r.Client.Update(context.TODO(), lease); err != nil {
+		return "", fmt.Errorf("failed to update Lease %s: %v", lease.Name, err)
+	}
+	return sc.Name, nil
+}
+
+// releaseNamespaceOwner releases the namespace if it is owned by the given
+// StorageCluster, so that another StorageCluster can take it over
+func (r *StorageClusterReconciler) releaseNamespaceOwner(sc *ocsv1.StorageCluster) error {
+	lease := &coordinationv1.Lease{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: namespaceOwnerLeaseName, Namespace: sc.Namespace}, lease)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get Lease %s: %v", namespaceOwnerLeaseName, err)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != sc.Name {
+		return nil
+	}
+	r.Log.Info("Releasing the namespace owned by StorageCluster.", "Lease", klog.KRef(lease.Namespace, lease.Name))
+	if err := r.Client.Delete(context.TODO(), lease); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete Lease %s: %v", lease.Name, err)
+	}
+	return nil
+}
+
+// setNamespaceOwner makes the StorageCluster the holder and the owner of
+// the Lease, so that the Lease is garbage collected along with it
+func (r *StorageClusterReconciler) setNamespaceOwner(lease *coordinationv1.Lease, sc *ocsv1.StorageCluster) error {
+	holder := sc.Name
+	now := metav1.NewMicroTime(time.Now())
+	lease.Spec.HolderIdentity = &holder
+	lease.Spec.AcquireTime = &now
+	lease.OwnerReferences = nil
+	return controllerutil.SetControllerReference(sc, lease, r.Scheme)
+}
+
+// getActiveStorageCluster returns the name of a StorageCluster of the
+// namespace which is already being reconciled, or an empty string if there
+// is none
+func (r *StorageClusterReconciler) getActiveStorageCluster(namespace string) (string, error) {
+	storageClusterList := &ocsv1.StorageClusterList{}
+	err := r.Client.List(context.TODO(), storageClusterList, client.InNamespace(namespace))
+	if err != nil {
+		return "", fmt.Errorf("failed to list StorageClusters: %v", err)
+	}
+	for _, storageCluster := range storageClusterList.Items {
+		if storageCluster.Status.Phase != "" && storageCluster.Status.Phase != statusutil.PhaseIgnored {
+			return storageCluster.Name, nil
+		}
+	}
+	return "", nil
+}
@@ -24,6 +24,8 @@ func getPlacement(sc *ocsv1.StorageCluster, component string) rookv1.Placement {
 	// ignore default PodAntiAffinity mon placement when arbiter is enabled
 	if component == "mon" && arbiterEnabled(sc) {
 		placement.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	} else if component == "mon" && sc.Spec.Mon.AllowMultiplePerNode {
+		relaxMonAntiAffinity(&placement)
 	}
 
 	if component == "arbiter" {
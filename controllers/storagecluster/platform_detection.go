@@ -7,6 +7,7 @@ import (
 
 	configv1 "github.com/openshift/api/config/v1"
 	"github.com/openshift/ocs-operator/controllers/util"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -37,6 +38,22 @@ var TuneFastPlatforms = []configv1.PlatformType{
 	configv1.AzurePlatformType,
 }
 
+// PlatformHealthChecks maps the PlatformTypes which need different health
+// checks than the Rook defaults to their health checks
+var PlatformHealthChecks = map[configv1.PlatformType]cephv1.CephClusterHealthCheckSpec{
+	// Nodes take longer to come back on IBM Cloud, so mons are failed over later
+	configv1.IBMCloudPlatformType: {
+		DaemonHealth: cephv1.DaemonHealthSpec{
+			Monitor: cephv1.HealthCheckSpec{Timeout: "15m"},
+		},
+	},
+	IBMCloudCosPlatformType: {
+		DaemonHealth: cephv1.DaemonHealthSpec{
+			Monitor: cephv1.HealthCheckSpec{Timeout: "15m"},
+		},
+	},
+}
+
 // Platform is used to get the CloudPlatformType of the running cluster in a thread-safe manner
 type Platform struct {
 	platform configv1.PlatformType
@@ -104,7 +121,7 @@ func getActualIBMPlatformType(c client.Client) (configv1.PlatformType, error) {
 // in the target namespace
 func IsCosSecretPresent(c client.Client) (bool, error) {
 	// TODO: better way to get target namespace
-	ns, nsErr := util.GetWatchNamespace()
+	ns, nsErr := util.GetOperatorNamespace()
 	if nsErr != nil {
 		return false, nsErr
 	}
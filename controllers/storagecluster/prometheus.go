@@ -21,12 +21,11 @@ const (
 	internalPrometheusRuleFilepath = "/ocs-prometheus-rules/prometheus-ocs-rules.yaml"
 	externalPrometheusRuleFilepath = "/ocs-prometheus-rules/prometheus-ocs-rules-external.yaml"
 	ruleName                       = "ocs-prometheus-rules"
-	ruleNamespace                  = "openshift-storage"
 )
 
 // enablePrometheusRules is a wrapper around CreateOrUpdatePrometheusRule()
 func (r *StorageClusterReconciler) enablePrometheusRules(instance *ocsv1.StorageCluster) error {
-	rule, err := getPrometheusRules(instance.Spec.ExternalStorage.Enable)
+	rule, err := getPrometheusRules(instance)
 	if err != nil {
 		r.Log.Error(err, "Prometheus rules file not found.")
 		return err
@@ -43,7 +42,9 @@ func (r *StorageClusterReconciler) enablePrometheusRules(instance *ocsv1.Storage
 	return nil
 }
 
-func getPrometheusRules(isExternal bool) (*monitoringv1.PrometheusRule, error) {
+// getPrometheusRules returns the rules for the StorageCluster, deployed in
+// its own namespace so that each StorageCluster gets its own rules
+func getPrometheusRules(sc *ocsv1.StorageCluster) (*monitoringv1.PrometheusRule, error) {
 	rule := &monitoringv1.PrometheusRule{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       monitoringv1.PrometheusRuleKind,
@@ -51,12 +52,12 @@ func getPrometheusRules(isExternal bool) (*monitoringv1.PrometheusRule, error) {
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      ruleName,
-			Namespace: ruleNamespace,
+			Namespace: sc.Namespace,
 		},
 	}
 	var err error
 	var ruleSpec *monitoringv1.PrometheusRuleSpec
-	if isExternal {
+	if sc.Spec.ExternalStorage.Enable {
 		ruleSpec, err = getPrometheusRuleSpecFrom(externalPrometheusRuleFilepath)
 		if err != nil {
 			return nil, err
@@ -3,6 +3,7 @@ package storagecluster
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,10 +15,8 @@ import (
 	"github.com/openshift/ocs-operator/version"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog/v2"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -33,21 +32,9 @@ type resourceManager interface {
 	ensureDeleted(*StorageClusterReconciler, *ocsv1.StorageCluster) error
 }
 
-type ocsCephConfig struct{}
 type ocsJobTemplates struct{}
 
 const (
-	rookConfigMapName     = "rook-config-override"
-	defaultRookConfigData = `
-[global]
-bdev_flock_retry = 20
-mon_osd_full_ratio = .85
-mon_osd_backfillfull_ratio = .8
-mon_osd_nearfull_ratio = .75
-mon_max_pg_per_osd = 600
-[osd]
-osd_memory_target_cgroup_limit_ratio = 0.5
-`
 	monCountOverrideEnvVar = "MON_COUNT_OVERRIDE"
 
 	// Name of MetadataPVCTemplate
@@ -117,20 +104,22 @@ var validTopologyLabelKeys = []string{
 }
 
 // +kubebuilder:rbac:groups=ocs.openshift.io,resources=*,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=ceph.rook.io,resources=cephclusters;cephblockpools;cephfilesystems;cephobjectstores;cephobjectstoreusers,verbs=*
+// +kubebuilder:rbac:groups=ceph.rook.io,resources=cephclusters;cephblockpools;cephfilesystems;cephnfses;cephobjectstores;cephobjectstoreusers;cephrbdmirrors,verbs=*
 // +kubebuilder:rbac:groups=noobaa.io,resources=noobaas,verbs=*
 // +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=*
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=csidrivers,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=pods;services;endpoints;persistentvolumeclaims;events;configmaps;secrets;nodes,verbs=*
 // +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
 // +kubebuilder:rbac:groups=apps,resources=deployments;daemonsets;replicasets;statefulsets,verbs=*
 // +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;prometheusrules,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots;volumesnapshotclasses,verbs=*
+// +kubebuilder:rbac:groups=replication.storage.openshift.io,resources=volumereplicationclasses,verbs=*
 // +kubebuilder:rbac:groups=template.openshift.io,resources=templates,verbs=*
 // +kubebuilder:rbac:groups=config.openshift.io,resources=infrastructures,verbs=get;list;watch
 // +kubebuilder:rbac:groups=console.openshift.io,resources=consolequickstarts,verbs=*
 // +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=*
-// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;create;update
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;create;update;delete
 
 // Reconcile reads that state of the cluster for a StorageCluster object and makes changes based on the state read
 // and what is in the StorageCluster.Spec
@@ -201,8 +190,8 @@ func (r *StorageClusterReconciler) initializeImagesStatus(sc *ocsv1.StorageClust
 
 // validateStorageClusterSpec must be called before reconciling. Any syntactic and sematic errors in the CR must be caught here.
 func (r *StorageClusterReconciler) validateStorageClusterSpec(instance *ocsv1.StorageCluster, request reconcile.Request) error {
-	if err := versionCheck(instance, r.Log); err != nil {
-		r.Log.Error(err, "Failed to validate StorageCluster version.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
+	if err := validateStorageCluster(instance, r.Log); err != nil {
+		r.Log.Error(err, "Failed to validate StorageCluster.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
 		r.recorder.ReportIfNotPresent(instance, corev1.EventTypeWarning, statusutil.EventReasonValidationFailed, err.Error())
 		instance.Status.Phase = statusutil.PhaseError
 		if updateErr := r.Client.Status().Update(context.TODO(), instance); updateErr != nil {
@@ -211,30 +200,76 @@ func (r *StorageClusterReconciler) validateStorageClusterSpec(instance *ocsv1.St
 		}
 		return err
 	}
+	return nil
+}
 
-	if !instance.Spec.ExternalStorage.Enable {
-		if err := r.validateStorageDeviceSets(instance); err != nil {
-			r.Log.Error(err, "Failed to validate StorageDeviceSets.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
-			r.recorder.ReportIfNotPresent(instance, corev1.EventTypeWarning, statusutil.EventReasonValidationFailed, err.Error())
-			instance.Status.Phase = statusutil.PhaseError
-			if updateErr := r.Client.Status().Update(context.TODO(), instance); updateErr != nil {
-				r.Log.Error(updateErr, "Failed to update StorageCluster.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
-				return updateErr
-			}
+// validateStorageCluster runs all the checks that do not need to look at the
+// state of the cluster. It is shared by the reconciler and the admission
+// webhook, so it must not rely on anything but the StorageCluster itself.
+func validateStorageCluster(sc *ocsv1.StorageCluster, reqLogger logr.Logger) error {
+	if err := versionCheck(sc, reqLogger); err != nil {
+		return err
+	}
+
+	if !sc.Spec.ExternalStorage.Enable {
+		if err := validateStorageDeviceSets(sc); err != nil {
 			return err
 		}
 	}
 
-	if err := validateArbiterSpec(instance, r.Log); err != nil {
-		r.Log.Error(err, "Failed to validate ArbiterSpec.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
-		r.recorder.ReportIfNotPresent(instance, corev1.EventTypeWarning, statusutil.EventReasonValidationFailed, err.Error())
-		instance.Status.Phase = statusutil.PhaseError
-		if updateErr := r.Client.Status().Update(context.TODO(), instance); updateErr != nil {
-			r.Log.Error(updateErr, "Could not update StorageCluster.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
-			return updateErr
-		}
+	if err := validateArbiterSpec(sc, reqLogger); err != nil {
+		return err
+	}
+
+	if err := validateAdditionalBlockPools(sc); err != nil {
+		return err
+	}
+
+	if err := validateAdditionalFilesystems(sc); err != nil {
+		return err
+	}
+
+	if err := validateCephConfig(sc); err != nil {
+		return err
+	}
+
+	if err := validateFullRatioOverride(sc); err != nil {
+		return err
+	}
+
+	if err := validateResourceProfile(sc); err != nil {
+		return err
+	}
+
+	if err := validateMonSpec(sc); err != nil {
 		return err
 	}
+
+	if err := validateMgrSpec(sc); err != nil {
+		return err
+	}
+
+	if err := validateHealthCheckSpec(sc); err != nil {
+		return err
+	}
+
+	if err := validateNFSSpec(sc); err != nil {
+		return err
+	}
+
+	if err := validateMirroringSpec(sc); err != nil {
+		return err
+	}
+
+	if err := validateStorageClassOverrides(sc); err != nil {
+		return err
+	}
+
+	if isMultus(sc.Spec.Network) {
+		if err := validateMultusSelectors(sc.Spec.Network.Selectors); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -251,21 +286,23 @@ func (r *StorageClusterReconciler) reconcilePhases(
 	// Initialize the StatusImages section of the storageclsuter CR
 	r.initializeImagesStatus(instance)
 
-	// Check for active StorageCluster only if Create request is made
-	// and ignore it if there's another active StorageCluster
-	// If Update request is made and StorageCluster is PhaseIgnored, no need to
-	// proceed further
-	if instance.Status.Phase == "" {
-		isActive, err := r.isActiveStorageCluster(instance)
+	// Only the StorageCluster owning the namespace is reconciled, the other
+	// ones are ignored until the owner is deleted
+	if instance.GetDeletionTimestamp().IsZero() {
+		owner, err := r.ensureNamespaceOwner(instance)
 		if err != nil {
 			r.Log.Error(err, "StorageCluster could not be reconciled. Retrying.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
 			return reconcile.Result{}, err
 		}
-		if !isActive {
+		if owner != instance.Name {
+			r.Log.Info("Ignoring StorageCluster as another one owns the namespace.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name), "Owner", owner)
+			r.recorder.ReportIfNotPresent(instance, corev1.EventTypeWarning, statusutil.EventReasonStorageClusterIgnored,
+				fmt.Sprintf("StorageCluster %s already owns namespace %s", owner, instance.Namespace))
 			instance.Status.Phase = statusutil.PhaseIgnored
-			return reconcile.Result{}, nil
+			return reconcile.Result{RequeueAfter: ignoredRequeueInterval}, nil
 		}
-	} else if instance.Status.Phase == statusutil.PhaseIgnored {
+	} else if instance.Status.Phase == "" || instance.Status.Phase == statusutil.PhaseIgnored {
+		instance.Status.Phase = statusutil.PhaseIgnored
 		return reconcile.Result{}, nil
 	}
 
@@ -308,6 +345,10 @@ func (r *StorageClusterReconciler) reconcilePhases(
 				r.recorder.ReportIfNotPresent(instance, corev1.EventTypeWarning, statusutil.EventReasonUninstallPending, err.Error())
 				return reconcile.Result{RequeueAfter: time.Second * time.Duration(1)}, nil
 			}
+			if err := r.releaseNamespaceOwner(instance); err != nil {
+				r.Log.Error(err, "Failed to release the namespace owned by StorageCluster.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
+				return reconcile.Result{}, err
+			}
 			r.Log.Info("Removing finalizer from StorageCluster.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
 			// Once all finalizers have been removed, the object will be deleted
 			instance.ObjectMeta.Finalizers = remove(instance.ObjectMeta.Finalizers, storageClusterFinalizer)
@@ -327,7 +368,32 @@ func (r *StorageClusterReconciler) reconcilePhases(
 			r.Log.Error(err, "Failed to set node Topology Map for StorageCluster.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
 			return reconcile.Result{}, err
 		}
+
+		if err := validateMonPlacement(instance, r.nodeCount); err != nil {
+			r.Log.Error(err, "Failed to validate the mons of StorageCluster.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
+			r.recorder.ReportIfNotPresent(instance, corev1.EventTypeWarning, statusutil.EventReasonValidationFailed, err.Error())
+			instance.Status.Phase = statusutil.PhaseError
+			return reconcile.Result{}, err
+		}
+	}
+
+	if isDryRun(instance) {
+		// Only record what would change, the managed resources are left as is
+		instance.Status.Plan = r.planStorageCluster(instance)
+		r.Log.Info("Dry-run mode enabled, recorded the planned changes without applying them.",
+			"StorageCluster", klog.KRef(instance.Namespace, instance.Name), "Changes", len(instance.Status.Plan.Changes))
+		return reconcile.Result{}, nil
 	}
+	instance.Status.Plan = nil
+
+	if !instance.Spec.ExternalStorage.Enable {
+		if err := r.reconcileAutoExpansion(instance, time.Now()); err != nil {
+			r.Log.Error(err, "Failed to expand StorageDeviceSets automatically.", "StorageCluster", klog.KRef(instance.Namespace, instance.Name))
+			return reconcile.Result{}, err
+		}
+	}
+
+	r.reconcileResourceProfile(instance)
 
 	// in-memory conditions should start off empty. It will only ever hold
 	// negative conditions (!Available, Degraded, Progressing)
@@ -344,11 +410,14 @@ func (r *StorageClusterReconciler) reconcilePhases(
 			&ocsCephObjectStoreUsers{},
 			&ocsCephRGWRoutes{},
 			&ocsCephBlockPools{},
+			&ocsMirroring{},
 			&ocsCephFilesystems{},
+			&ocsCephNFS{},
 			&ocsCephConfig{},
 			&ocsCephCluster{},
 			&ocsNoobaaSystem{},
 			&ocsJobTemplates{},
+			&ocsFullRatio{},
 			&ocsQuickStarts{},
 		}
 
@@ -363,23 +432,16 @@ func (r *StorageClusterReconciler) reconcilePhases(
 		}
 	}
 
-	for _, obj := range objs {
-		err := obj.ensureCreated(r, instance)
-		if r.phase == statusutil.PhaseClusterExpanding {
-			instance.Status.Phase = statusutil.PhaseClusterExpanding
-		} else if instance.Status.Phase != statusutil.PhaseReady &&
-			instance.Status.Phase != statusutil.PhaseConnecting {
-			instance.Status.Phase = statusutil.PhaseProgressing
-		}
-		if err != nil {
-			reason := ocsv1.ReconcileFailed
-			message := fmt.Sprintf("Error while reconciling: %v", err)
-			statusutil.SetErrorCondition(&instance.Status.Conditions, reason, message)
-			instance.Status.Phase = statusutil.PhaseError
-			// don't want to overwrite the actual reconcile failure
-			return reconcile.Result{}, err
-		}
+	if err := r.ensureComponentsCreated(instance, objs); err != nil {
+		reason := ocsv1.ReconcileFailed
+		message := fmt.Sprintf("Error while reconciling: %v", err)
+		statusutil.SetErrorCondition(&instance.Status.Conditions, reason, message)
+		instance.Status.Phase = statusutil.PhaseError
+		// don't want to overwrite the actual reconcile failure
+		return reconcile.Result{}, err
 	}
+
+	r.conditions = aggregateComponentConditions(instance.Status.Components)
 	// All component operators are in a happy state.
 	if r.conditions == nil {
 		r.Log.Info("No component operator reported negatively.")
@@ -399,11 +461,11 @@ func (r *StorageClusterReconciler) reconcilePhases(
 		// the instance while preserving it's lastTransitionTime.
 		// For example, consider the resource has the Available condition
 		// type with type "False". When reconciling the resource we would
-		// add it to the in-memory representation of OCS's conditions (r.conditions)
-		// and here we are simply writing it back to the server.
-		// One shortcoming is that only one failure of a particular condition can be
-		// captured at one time (ie. if resource1 and resource2 are both reporting !Available,
-		// you will only see resource2q as it updates last).
+		// record it in the status of the component that reported it, and
+		// here we are simply writing the conditions of all the components,
+		// combined per condition type, back to the server. That way, if
+		// resource1 and resource2 are both reporting !Available, the
+		// message names both of them.
 		for _, condition := range r.conditions {
 			conditionsv1.SetStatusCondition(&instance.Status.Conditions, condition)
 		}
@@ -452,9 +514,55 @@ func (r *StorageClusterReconciler) reconcilePhases(
 		}
 	}
 
+	// Come back once the full ratio override expires
+	if interval := getFullRatioRequeueInterval(instance, time.Now()); interval > 0 {
+		return reconcile.Result{RequeueAfter: interval}, nil
+	}
+
 	return reconcile.Result{}, nil
 }
 
+// ensureComponentsCreated reconciles all the components of the StorageCluster
+// and records their status. A failing component does not stop the others
+// from being reconciled, only the components depending on it are skipped.
+// The errors of all failed components are returned as one aggregate error.
+func (r *StorageClusterReconciler) ensureComponentsCreated(instance *ocsv1.StorageCluster, objs []resourceManager) error {
+	var reconcileErrors []error
+	failedComponents := map[string]bool{}
+	reconciledComponents := map[string]bool{}
+	for _, obj := range objs {
+		name := getComponentName(obj)
+		reconciledComponents[name] = true
+		if dependency := getFailedDependency(name, failedComponents); dependency != "" {
+			r.Log.Info("Skipping component as a dependency failed to reconcile.", "Component", name, "Dependency", dependency)
+			failedComponents[name] = true
+			setComponentStatus(instance, name, nil, fmt.Errorf("skipped as dependency %q failed to reconcile", dependency))
+			continue
+		}
+
+		// Each component reports its conditions separately, they are
+		// aggregated once all of them have been reconciled
+		r.conditions = nil
+		err := obj.ensureCreated(r, instance)
+		setComponentStatus(instance, name, r.conditions, err)
+		if r.phase == statusutil.PhaseClusterExpanding {
+			instance.Status.Phase = statusutil.PhaseClusterExpanding
+		} else if instance.Status.Phase != statusutil.PhaseReady &&
+			instance.Status.Phase != statusutil.PhaseConnecting {
+			instance.Status.Phase = statusutil.PhaseProgressing
+		}
+		if err != nil {
+			r.Log.Error(err, "Failed to reconcile component.", "Component", name)
+			r.recorder.ReportIfNotPresent(instance, corev1.EventTypeWarning, statusutil.EventReasonReconcileFailed,
+				fmt.Sprintf("Failed to reconcile %s: %v", name, err))
+			failedComponents[name] = true
+			reconcileErrors = append(reconcileErrors, fmt.Errorf("%s: %v", name, err))
+		}
+	}
+	pruneComponentStatus(instance, reconciledComponents)
+	return utilerrors.NewAggregate(reconcileErrors)
+}
+
 // versionCheck populates the `.Spec.Version` field
 func versionCheck(sc *ocsv1.StorageCluster, reqLogger logr.Logger) error {
 	if sc.Spec.Version == "" {
@@ -487,7 +595,7 @@ func versionCheck(sc *ocsv1.StorageCluster, reqLogger logr.Logger) error {
 
 // validateStorageDeviceSets checks the StorageDeviceSets of the given
 // StorageCluster for completeness and correctness
-func (r *StorageClusterReconciler) validateStorageDeviceSets(sc *ocsv1.StorageCluster) error {
+func validateStorageDeviceSets(sc *ocsv1.StorageCluster) error {
 	for i, ds := range sc.Spec.StorageDeviceSets {
 		if ds.DataPVCTemplate.Spec.StorageClassName == nil || *ds.DataPVCTemplate.Spec.StorageClassName == "" {
 			return fmt.Errorf("failed to validate StorageDeviceSet %d: no StorageClass specified", i)
@@ -507,114 +615,45 @@ func (r *StorageClusterReconciler) validateStorageDeviceSets(sc *ocsv1.StorageCl
 				return fmt.Errorf("failed to validate DeviceType %q: no Device of this type", ds.DeviceType)
 			}
 		}
+		if err := validateStorageDeviceSetConfig(ds); err != nil {
+			return fmt.Errorf("failed to validate StorageDeviceSet %d: %v", i, err)
+		}
+		if err := validateAutoExpandPolicy(ds); err != nil {
+			return fmt.Errorf("failed to validate StorageDeviceSet %d: %v", i, err)
+		}
 	}
 
 	return nil
 }
 
-// ensureCreated ensures that a ConfigMap resource exists with its Spec in
-// the desired state.
-func (obj *ocsCephConfig) ensureCreated(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) error {
-	reconcileStrategy := ReconcileStrategy(sc.Spec.ManagedResources.CephConfig.ReconcileStrategy)
-	if reconcileStrategy == ReconcileStrategyIgnore {
-		return nil
-	}
-
-	found := &corev1.ConfigMap{}
-	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: rookConfigMapName, Namespace: sc.Namespace}, found)
-
-	if err == nil && reconcileStrategy == ReconcileStrategyInit {
-		return nil
-	}
-
-	ownerRef := metav1.OwnerReference{
-		UID:        sc.UID,
-		APIVersion: sc.APIVersion,
-		Kind:       sc.Kind,
-		Name:       sc.Name,
-	}
-	cm := &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            rookConfigMapName,
-			Namespace:       sc.Namespace,
-			OwnerReferences: []metav1.OwnerReference{ownerRef},
-		},
-		Data: map[string]string{
-			"config": defaultRookConfigData,
-		},
+// validateStorageDeviceSetConfig checks that the Ceph OSD config options of
+// the StorageDeviceSet can be understood by Rook
+func validateStorageDeviceSetConfig(ds ocsv1.StorageDeviceSet) error {
+	config := ds.Config
+	if config.OsdsPerDevice < 0 {
+		return fmt.Errorf("invalid config %s %d: must be at least 1", ocsv1.OsdsPerDeviceKey, config.OsdsPerDevice)
 	}
-
-	if err != nil {
-		if errors.IsNotFound(err) {
-			r.Log.Info("Creating Ceph ConfigMap.", "ConfigMap", klog.KRef(sc.Namespace, rookConfigMapName))
-			err = r.Client.Create(context.TODO(), cm)
-			if err != nil {
-				r.Log.Error(err, "Failed to create Ceph ConfigMap.", "ConfigMap", klog.KRef(sc.Namespace, rookConfigMapName))
-				return err
-			}
+	if config.MetadataDevice != "" {
+		if ds.MetadataPVCTemplate != nil {
+			return fmt.Errorf("config %s can not be combined with a metadataPVCTemplate", ocsv1.MetadataDeviceKey)
 		}
-		return err
-	}
-
-	ownerRefFound := false
-	for _, ownerRef := range found.OwnerReferences {
-		if ownerRef.UID == sc.UID {
-			ownerRefFound = true
+		if strings.ContainsAny(config.MetadataDevice, " \t\n") {
+			return fmt.Errorf("invalid config %s %q: must not contain whitespace", ocsv1.MetadataDeviceKey, config.MetadataDevice)
 		}
 	}
-	val, ok := found.Data["config"]
-	if !ok || val != defaultRookConfigData || !ownerRefFound {
-		r.Log.Info("Updating Ceph ConfigMap.", "ConfigMap", klog.KRef(sc.Namespace, cm.Name))
-		return r.Client.Update(context.TODO(), cm)
-	}
-	return nil
-}
-
-// ensureDeleted is dummy func for the ocsCephConfig
-func (obj *ocsCephConfig) ensureDeleted(r *StorageClusterReconciler, instance *ocsv1.StorageCluster) error {
-	return nil
-}
-
-func (r *StorageClusterReconciler) isActiveStorageCluster(instance *ocsv1.StorageCluster) (bool, error) {
-	storageClusterList := ocsv1.StorageClusterList{}
-
-	// instance is already marked for deletion
-	// do not mark it as active
-	if !instance.GetDeletionTimestamp().IsZero() {
-		return false, nil
-	}
-
-	err := r.Client.List(context.TODO(), &storageClusterList, client.InNamespace(instance.Namespace))
-	if err != nil {
-		return false, fmt.Errorf("Error fetching StorageClusterList. %+v", err)
-	}
-
-	// There is only one StorageCluster i.e. instance
-	if len(storageClusterList.Items) == 1 {
-		return true, nil
-	}
-
-	// There are many StorageClusters. Check if this is Active
-	for n, storageCluster := range storageClusterList.Items {
-		if storageCluster.Status.Phase != statusutil.PhaseIgnored &&
-			storageCluster.ObjectMeta.Name != instance.ObjectMeta.Name {
-			// Both StorageClusters are in creation phase
-			// Tiebreak using CreationTimestamp and Alphanumeric ordering
-			if storageCluster.Status.Phase == "" {
-				if storageCluster.CreationTimestamp.Before(&instance.CreationTimestamp) {
-					return false, nil
-				} else if storageCluster.CreationTimestamp.Equal(&instance.CreationTimestamp) && storageCluster.Name < instance.Name {
-					return false, nil
-				}
-				if n == len(storageClusterList.Items)-1 {
-					return true, nil
-				}
-				continue
+	for key, value := range config.OsdConfig {
+		switch key {
+		case ocsv1.OsdsPerDeviceKey, ocsv1.EncryptedDeviceKey, ocsv1.MetadataDeviceKey:
+			return fmt.Errorf("invalid osdConfig key %q: use the config.%s field instead", key, key)
+		case ocsv1.DatabaseSizeMBKey, ocsv1.WalSizeMBKey, ocsv1.JournalSizeMBKey:
+			if size, err := strconv.Atoi(value); err != nil || size <= 0 {
+				return fmt.Errorf("invalid osdConfig %s %q: must be a positive integer", key, value)
 			}
-			return false, nil
+		default:
+			return fmt.Errorf("invalid osdConfig key %q: must be one of %v", key, ocsv1.OsdConfigKeys)
 		}
 	}
-	return true, nil
+	return nil
 }
 
 // Checks whether a string is contained within a slice
@@ -643,7 +682,7 @@ func validateArbiterSpec(sc *ocsv1.StorageCluster, reqLogger logr.Logger) error
 	if sc.Spec.Arbiter.Enable && sc.Spec.FlexibleScaling {
 		return fmt.Errorf("arbiter and flexibleScaling both can't be enabled")
 	}
-	if sc.Spec.Arbiter.Enable && sc.Spec.NodeTopologies.ArbiterLocation == "" {
+	if sc.Spec.Arbiter.Enable && (sc.Spec.NodeTopologies == nil || sc.Spec.NodeTopologies.ArbiterLocation == "") {
 		return fmt.Errorf("arbiter is set to enable but no arbiterLocation has been provided in the Spec.NodeTopologies.ArbiterLocation")
 	}
 	return nil
@@ -0,0 +1,92 @@
+package storagecluster
+
+import (
+	"fmt"
+
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/defaults"
+	statusutil "github.com/openshift/ocs-operator/controllers/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// getResourceProfile returns the resource profile of the StorageCluster,
+// which defaults to balanced
+func getResourceProfile(sc *ocsv1.StorageCluster) string {
+	if sc.Spec.ResourceProfile == "" {
+		return defaults.ResourceProfileBalanced
+	}
+	return sc.Spec.ResourceProfile
+}
+
+// validateResourceProfile checks that the resource profile of the
+// StorageCluster is known
+func validateResourceProfile(sc *ocsv1.StorageCluster) error {
+	if _, ok := defaults.ResourceProfiles[getResourceProfile(sc)]; !ok {
+		return fmt.Errorf("invalid resourceProfile %q: must be one of %s, %s or %s", sc.Spec.ResourceProfile,
+			defaults.ResourceProfileLean, defaults.ResourceProfileBalanced, defaults.ResourceProfilePerformance)
+	}
+	return nil
+}
+
+// getDaemonResources returns the resources of the named daemon from
+// spec.resources, or from the resource profile of the StorageCluster
+func getDaemonResources(sc *ocsv1.StorageCluster, name string) corev1.ResourceRequirements {
+	return defaults.GetProfileDaemonResources(getResourceProfile(sc), name, sc.Spec.Resources)
+}
+
+// getProfileResources returns the resources of the named daemon from the
+// resource profile of the StorageCluster. The resources of the OSDs are not
+// taken from spec.resources, StorageDeviceSets set their own.
+func getProfileResources(sc *ocsv1.StorageCluster, name string) corev1.ResourceRequirements {
+	return defaults.ResourceProfiles[getResourceProfile(sc)][name]
+}
+
+// getDeviceSetResources returns the resources of the OSDs of a
+// StorageDeviceSet, which default to the ones of the resource profile
+func getDeviceSetResources(sc *ocsv1.StorageCluster, ds *ocsv1.StorageDeviceSet) corev1.ResourceRequirements {
+	if ds.Resources.Requests == nil && ds.Resources.Limits == nil {
+		return getProfileResources(sc, "osd")
+	}
+	return ds.Resources
+}
+
+// getEffectiveResources returns the resources applied to each daemon of the
+// StorageCluster. The OSDs of each StorageDeviceSet are reported separately
+// as "osd-<name>", as StorageDeviceSets may set their own resources.
+func getEffectiveResources(sc *ocsv1.StorageCluster) map[string]corev1.ResourceRequirements {
+	resources := map[string]corev1.ResourceRequirements{}
+	if !sc.Spec.ExternalStorage.Enable {
+		for name, res := range newCephDaemonResources(sc) {
+			resources[name] = res
+		}
+		resources["osd"] = getProfileResources(sc, "osd")
+		for i := range sc.Spec.StorageDeviceSets {
+			ds := &sc.Spec.StorageDeviceSets[i]
+			resources["osd-"+ds.Name] = getDeviceSetResources(sc, ds)
+		}
+	}
+	for _, name := range []string{"noobaa-core", "noobaa-db", "noobaa-endpoint"} {
+		resources[name] = getDaemonResources(sc, name)
+	}
+	if mcg := sc.Spec.MultiCloudGateway; mcg != nil && mcg.Endpoints != nil && mcg.Endpoints.Resources != nil {
+		resources["noobaa-endpoint"] = *mcg.Endpoints.Resources
+	}
+	return resources
+}
+
+// reconcileResourceProfile reports the resource profile and the resources
+// applied to the daemons in the status. The daemons are not restarted all at
+// once on a change of profile: Rook updates the Ceph daemons one at a time and
+// waits for the Ceph cluster to be healthy in between.
+func (r *StorageClusterReconciler) reconcileResourceProfile(sc *ocsv1.StorageCluster) {
+	profile := getResourceProfile(sc)
+	if sc.Status.ResourceProfile != "" && sc.Status.ResourceProfile != profile {
+		r.Log.Info("Resource profile changed, rolling out the new resources.", "StorageCluster", klog.KRef(sc.Namespace, sc.Name),
+			"Previous", sc.Status.ResourceProfile, "ResourceProfile", profile)
+		r.recorder.ReportIfNotPresent(sc, corev1.EventTypeNormal, statusutil.EventReasonResourceProfileChanged,
+			fmt.Sprintf("Resource profile changed from %s to %s, the daemons are restarted with the new resources", sc.Status.ResourceProfile, profile))
+	}
+	sc.Status.ResourceProfile = profile
+	sc.Status.EffectiveResources = getEffectiveResources(sc)
+}
@@ -0,0 +1,133 @@
+package storagecluster
+
+import (
+	"testing"
+
+	api "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/defaults"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateResourceProfile(t *testing.T) {
+	cases := []struct {
+		label         string
+		profile       string
+		expectedError bool
+	}{
+		{
+			label: "default profile",
+		},
+		{
+			label:   "lean profile",
+			profile: defaults.ResourceProfileLean,
+		},
+		{
+			label:         "unknown profile",
+			profile:       "tiny",
+			expectedError: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := &api.StorageCluster{Spec: api.StorageClusterSpec{ResourceProfile: c.profile}}
+		err := validateResourceProfile(sc)
+		if c.expectedError {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestGetDaemonResourcesWithProfile(t *testing.T) {
+	custom := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("6"),
+			corev1.ResourceMemory: resource.MustParse("16Gi"),
+		},
+	}
+
+	cases := []struct {
+		label     string
+		profile   string
+		resources map[string]corev1.ResourceRequirements
+		daemon    string
+		expected  corev1.ResourceRequirements
+	}{
+		{
+			label:    "balanced profile by default",
+			daemon:   "mds",
+			expected: defaults.DaemonResources["mds"],
+		},
+		{
+			label:    "lean profile",
+			profile:  defaults.ResourceProfileLean,
+			daemon:   "noobaa-core",
+			expected: defaults.LeanDaemonResources["noobaa-core"],
+		},
+		{
+			label:     "spec.resources overrides the profile",
+			profile:   defaults.ResourceProfilePerformance,
+			resources: map[string]corev1.ResourceRequirements{"rgw": custom},
+			daemon:    "rgw",
+			expected:  custom,
+		},
+		{
+			label:    "daemon missing from the profile",
+			profile:  defaults.ResourceProfileLean,
+			daemon:   "noobaa-db-vol",
+			expected: defaults.DaemonResources["noobaa-db-vol"],
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := &api.StorageCluster{Spec: api.StorageClusterSpec{ResourceProfile: c.profile, Resources: c.resources}}
+		assert.Equal(t, c.expected, getDaemonResources(sc, c.daemon))
+	}
+}
+
+func TestReconcileResourceProfile(t *testing.T) {
+	sc := &api.StorageCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocsinit", Namespace: "openshift-storage"},
+	}
+	reconciler := createFakeStorageClusterReconciler(t)
+
+	reconciler.reconcileResourceProfile(sc)
+	assert.Equal(t, defaults.ResourceProfileBalanced, sc.Status.ResourceProfile)
+	assert.Equal(t, defaults.DaemonResources["osd"], sc.Status.EffectiveResources["osd"])
+	assert.Equal(t, defaults.DaemonResources["noobaa-endpoint"], sc.Status.EffectiveResources["noobaa-endpoint"])
+
+	// Switching the profile changes the resources of all the daemons, the
+	// OSDs included
+	sc.Spec.ResourceProfile = defaults.ResourceProfileLean
+	reconciler.reconcileResourceProfile(sc)
+	assert.Equal(t, defaults.ResourceProfileLean, sc.Status.ResourceProfile)
+	for _, daemon := range []string{"mon", "mgr", "mds", "rgw", "osd", "noobaa-core", "noobaa-db", "noobaa-endpoint"} {
+		assert.Equal(t, defaults.LeanDaemonResources[daemon], sc.Status.EffectiveResources[daemon], daemon)
+	}
+
+	// StorageDeviceSets setting their own resources are reported with them
+	customResources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("4"),
+			corev1.ResourceMemory: resource.MustParse("16Gi"),
+		},
+	}
+	sc.Spec.StorageDeviceSets = []api.StorageDeviceSet{
+		{Name: "default"},
+		{Name: "fast", Resources: customResources},
+	}
+	reconciler.reconcileResourceProfile(sc)
+	assert.Equal(t, defaults.LeanDaemonResources["osd"], sc.Status.EffectiveResources["osd-default"])
+	assert.Equal(t, customResources, sc.Status.EffectiveResources["osd-fast"])
+
+	// Only the noobaa daemons run with an external Ceph cluster
+	sc.Spec.ExternalStorage.Enable = true
+	reconciler.reconcileResourceProfile(sc)
+	assert.Len(t, sc.Status.EffectiveResources, 3)
+}
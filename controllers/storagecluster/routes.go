@@ -105,7 +105,17 @@ func (r *StorageClusterReconciler) createCephRGWRoutes(routes []*routev1.Route,
 				return err
 			}
 
-			r.Log.Info("Restoring original Ceph RGW Route.", "CephRGWRoute", klog.KRef(route.Namespace, route.Name))
+			// The router generates the host of the Route when it is not set
+			drifted, err := getDriftedSetFields(route, &existing, "spec", "metadata.ownerReferences")
+			if err != nil {
+				return err
+			}
+			if len(drifted) == 0 {
+				continue
+			}
+			r.reportDrift(instance, componentRGWRoutes, "Route", route.Namespace, route.Name, drifted)
+
+			r.Log.Info("Restoring original Ceph RGW Route.", "CephRGWRoute", klog.KRef(route.Namespace, route.Name), "Fields", drifted)
 			existing.ObjectMeta.OwnerReferences = route.ObjectMeta.OwnerReferences
 			route.ObjectMeta = existing.ObjectMeta
 			err = r.Client.Update(context.TODO(), route)
@@ -3,7 +3,8 @@ package storagecluster
 import (
 	"context"
 	"fmt"
-	"reflect"
+	"strconv"
+	"strings"
 
 	ocsv1 "github.com/openshift/ocs-operator/api/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -12,6 +13,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // StorageClassConfiguration provides configuration options for a StorageClass.
@@ -21,6 +23,27 @@ type StorageClassConfiguration struct {
 	disable           bool
 }
 
+const (
+	// defaultStorageClassAnnotation marks the default StorageClass of the
+	// cluster
+	defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+	// defaultStorageClassOverrideAnnotation marks the StorageClasses whose
+	// defaultStorageClassAnnotation is set by the Default override, so that
+	// it is removed together with the override
+	defaultStorageClassOverrideAnnotation = "ocs.openshift.io/default-class-override"
+	// rbdLayeringFeature is the RBD image feature enabled on all images
+	rbdLayeringFeature = "layering"
+)
+
+// rbdImageFeatureDependencies maps the RBD image features which can be
+// enabled in addition to layering to the feature they require, if any
+var rbdImageFeatureDependencies = map[string]string{
+	"exclusive-lock": "",
+	"object-map":     "exclusive-lock",
+	"fast-diff":      "object-map",
+	"deep-flatten":   "",
+}
+
 type ocsStorageClass struct{}
 
 // ensureCreated ensures that StorageClass resources exist in the desired
@@ -31,11 +54,51 @@ func (obj *ocsStorageClass) ensureCreated(r *StorageClusterReconciler, instance
 		return err
 	}
 
-	err = r.createStorageClasses(scs)
+	err = r.createStorageClasses(scs, instance, componentStorageClasses)
 	if err != nil {
 		return err
 	}
 
+	return r.deleteRemovedStorageClasses(scs, instance)
+}
+
+// getRemovedClassReconcileStrategy returns the reconcile strategy of the
+// pools or filesystems whose removed StorageClasses and SnapshotClasses of
+// the CSI driver are deleted
+func getRemovedClassReconcileStrategy(instance *ocsv1.StorageCluster, driver string) ReconcileStrategy {
+	if driver == generateNameForCSIDriver(instance, "cephfs") {
+		return ReconcileStrategy(instance.Spec.ManagedResources.CephFilesystems.ReconcileStrategy)
+	}
+	return ReconcileStrategy(instance.Spec.ManagedResources.CephBlockPools.ReconcileStrategy)
+}
+
+// deleteRemovedStorageClasses deletes the StorageClasses labeled as
+// belonging to the StorageCluster which are no longer desired, i.e. those
+// of the additional CephBlockPools and CephFilesystems removed from the spec
+func (r *StorageClusterReconciler) deleteRemovedStorageClasses(sccs []StorageClassConfiguration, instance *ocsv1.StorageCluster) error {
+	desired := map[string]bool{}
+	for _, scc := range sccs {
+		desired[scc.storageClass.Name] = true
+	}
+
+	storageClasses := &storagev1.StorageClassList{}
+	err := r.Client.List(context.TODO(), storageClasses, client.MatchingLabels(getStorageClusterLabels(instance)))
+	if err != nil {
+		return fmt.Errorf("failed to list StorageClasses: %v", err)
+	}
+	for i := range storageClasses.Items {
+		existing := &storageClasses.Items[i]
+		if desired[existing.Name] || existing.DeletionTimestamp != nil ||
+			getRemovedClassReconcileStrategy(instance, existing.Provisioner) == ReconcileStrategyIgnore {
+			continue
+		}
+		r.Log.Info("Deleting StorageClass removed from the StorageCluster.", "StorageClass", klog.KRef("", existing.Name))
+		err = r.Client.Delete(context.TODO(), existing)
+		if err != nil && !errors.IsNotFound(err) {
+			r.Log.Error(err, "Failed to delete StorageClass.", "StorageClass", klog.KRef("", existing.Name))
+			return err
+		}
+	}
 	return nil
 }
 
@@ -76,7 +139,7 @@ func (obj *ocsStorageClass) ensureDeleted(r *StorageClusterReconciler, instance
 	return nil
 }
 
-func (r *StorageClusterReconciler) createStorageClasses(sccs []StorageClassConfiguration) error {
+func (r *StorageClusterReconciler) createStorageClasses(sccs []StorageClassConfiguration, instance *ocsv1.StorageCluster, component string) error {
 	for _, scc := range sccs {
 		if scc.reconcileStrategy == ReconcileStrategyIgnore || scc.disable {
 			continue
@@ -101,10 +164,15 @@ func (r *StorageClusterReconciler) createStorageClasses(sccs []StorageClassConfi
 			if existing.DeletionTimestamp != nil {
 				return fmt.Errorf("failed to restore StorageClass  %s because it is marked for deletion", existing.Name)
 			}
-			if !reflect.DeepEqual(sc.Parameters, existing.Parameters) {
+			drifted, recreate, err := getStorageClassDrift(sc, existing)
+			if err != nil {
+				return err
+			}
+			if len(drifted) > 0 && recreate {
 				// Since we have to update the existing StorageClass
 				// So, we will delete the existing storageclass and create a new one
-				r.Log.Info("StorageClass needs to be updated, deleting it.", "StorageClass", klog.KRef(sc.Namespace, existing.Name))
+				r.Log.Info("StorageClass needs to be updated, deleting it.", "StorageClass", klog.KRef(sc.Namespace, existing.Name), "Fields", drifted)
+				r.reportDrift(instance, component, "StorageClass", sc.Namespace, sc.Name, drifted)
 				err = r.Client.Delete(context.TODO(), existing)
 				if err != nil {
 					r.Log.Error(err, "Failed to delete StorageClass.", "StorageClass", klog.KRef(sc.Namespace, existing.Name))
@@ -116,18 +184,167 @@ func (r *StorageClusterReconciler) createStorageClasses(sccs []StorageClassConfi
 					r.Log.Info("Failed to craete StorageClass.", "StorageClass", klog.KRef(sc.Namespace, sc.Name))
 					return err
 				}
+				continue
+			}
+
+			if len(drifted) > 0 {
+				r.Log.Info("Updating StorageClass.", "StorageClass", klog.KRef(sc.Namespace, existing.Name), "Fields", drifted)
+				r.reportDrift(instance, component, "StorageClass", sc.Namespace, sc.Name, drifted)
+				if existing.Labels == nil {
+					existing.Labels = map[string]string{}
+				}
+				for key, value := range sc.Labels {
+					existing.Labels[key] = value
+				}
+				if existing.Annotations == nil {
+					existing.Annotations = map[string]string{}
+				}
+				if _, ok := sc.Annotations[defaultStorageClassOverrideAnnotation]; !ok {
+					if _, ok := existing.Annotations[defaultStorageClassOverrideAnnotation]; ok {
+						delete(existing.Annotations, defaultStorageClassOverrideAnnotation)
+						delete(existing.Annotations, defaultStorageClassAnnotation)
+					}
+				}
+				for key, value := range sc.Annotations {
+					existing.Annotations[key] = value
+				}
+				existing.AllowVolumeExpansion = sc.AllowVolumeExpansion
+				existing.MountOptions = sc.MountOptions
+				err = r.Client.Update(context.TODO(), existing)
+				if err != nil {
+					r.Log.Error(err, "Failed to update StorageClass.", "StorageClass", klog.KRef(sc.Namespace, existing.Name))
+					return err
+				}
 			}
 		}
 	}
 	return nil
 }
 
+// getStorageClassDrift returns the fields of the existing StorageClass which
+// differ from the desired ones, and whether the StorageClass has to be
+// recreated because they can not be updated
+func getStorageClassDrift(sc, existing *storagev1.StorageClass) ([]string, bool, error) {
+	// The provisioner, the parameters, the reclaim policy, the volume binding
+	// mode and the allowed topologies of a StorageClass can not be updated
+	drifted, err := getDriftedFields(sc, existing, "provisioner", "parameters", "reclaimPolicy", "volumeBindingMode", "allowedTopologies")
+	if err != nil {
+		return nil, false, err
+	}
+	if len(drifted) > 0 {
+		return drifted, true, nil
+	}
+
+	drifted, err = getDriftedSetFields(sc, existing, "metadata.labels", "metadata.annotations", "allowVolumeExpansion")
+	if err != nil {
+		return nil, false, err
+	}
+	// The default annotation set by a removed Default override is removed
+	_, desiredOverride := sc.Annotations[defaultStorageClassOverrideAnnotation]
+	_, existingOverride := existing.Annotations[defaultStorageClassOverrideAnnotation]
+	if !desiredOverride && existingOverride {
+		drifted = append(drifted, "metadata.annotations."+defaultStorageClassAnnotation)
+	}
+	if !equalStringSlices(sc.MountOptions, existing.MountOptions) {
+		drifted = append(drifted, "mountOptions")
+	}
+	return drifted, false, nil
+}
+
+// validateStorageClassOverrides checks the overrides of the RBD and CephFS
+// StorageClasses
+func validateStorageClassOverrides(sc *ocsv1.StorageCluster) error {
+	rbdOverrides := sc.Spec.ManagedResources.CephBlockPools.StorageClassOverrides
+	cephfsOverrides := sc.Spec.ManagedResources.CephFilesystems.StorageClassOverrides
+
+	if rbdOverrides != nil {
+		features := map[string]bool{}
+		for _, feature := range rbdOverrides.ImageFeatures {
+			if _, ok := rbdImageFeatureDependencies[feature]; !ok && feature != rbdLayeringFeature {
+				return fmt.Errorf("invalid RBD image feature %q", feature)
+			}
+			if features[feature] {
+				return fmt.Errorf("RBD image feature %q is listed more than once", feature)
+			}
+			features[feature] = true
+		}
+		for feature := range features {
+			if dependency := rbdImageFeatureDependencies[feature]; dependency != "" && !features[dependency] {
+				return fmt.Errorf("RBD image feature %q requires %q", feature, dependency)
+			}
+		}
+	}
+
+	if cephfsOverrides != nil {
+		if cephfsOverrides.FSType != "" || len(cephfsOverrides.ImageFeatures) > 0 {
+			return fmt.Errorf("fsType and imageFeatures can not be overridden for CephFS StorageClasses")
+		}
+	}
+
+	if rbdOverrides != nil && rbdOverrides.Default != nil && *rbdOverrides.Default &&
+		cephfsOverrides != nil && cephfsOverrides.Default != nil && *cephfsOverrides.Default {
+		return fmt.Errorf("the RBD and CephFS StorageClasses can not both be the default StorageClass")
+	}
+	return nil
+}
+
+// applyStorageClassOverrides merges the overrides into the StorageClass.
+// isDefault tells whether the StorageClass is the one marked as the default
+// StorageClass of the cluster by the overrides.
+func applyStorageClassOverrides(storageClass *storagev1.StorageClass, overrides *ocsv1.StorageClassOverrides, isDefault bool) {
+	// The volume binding mode is set explicitly, so that removing its
+	// override recreates the StorageClass with the default one
+	volumeBindingMode := storagev1.VolumeBindingImmediate
+	storageClass.VolumeBindingMode = &volumeBindingMode
+	if overrides == nil {
+		return
+	}
+	if overrides.FSType != "" {
+		storageClass.Parameters["csi.storage.k8s.io/fstype"] = overrides.FSType
+	}
+	if len(overrides.ImageFeatures) > 0 {
+		features := []string{rbdLayeringFeature}
+		for _, feature := range overrides.ImageFeatures {
+			if feature != rbdLayeringFeature {
+				features = append(features, feature)
+			}
+		}
+		storageClass.Parameters["imageFeatures"] = strings.Join(features, ",")
+	}
+	if overrides.ReclaimPolicy != nil {
+		reclaimPolicy := *overrides.ReclaimPolicy
+		storageClass.ReclaimPolicy = &reclaimPolicy
+	}
+	if overrides.VolumeBindingMode != nil {
+		volumeBindingMode = *overrides.VolumeBindingMode
+	}
+	if len(overrides.MountOptions) > 0 {
+		storageClass.MountOptions = append([]string{}, overrides.MountOptions...)
+	}
+	if isDefault && overrides.Default != nil {
+		storageClass.Annotations[defaultStorageClassAnnotation] = strconv.FormatBool(*overrides.Default)
+		storageClass.Annotations[defaultStorageClassOverrideAnnotation] = "true"
+	}
+}
+
+func equalStringSlices(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // newCephFilesystemStorageClassConfiguration generates configuration options for a Ceph Filesystem StorageClass.
 func newCephFilesystemStorageClassConfiguration(initData *ocsv1.StorageCluster) StorageClassConfiguration {
 	persistentVolumeReclaimDelete := corev1.PersistentVolumeReclaimDelete
 	allowVolumeExpansion := true
 	managementSpec := initData.Spec.ManagedResources.CephFilesystems
-	return StorageClassConfiguration{
+	scc := StorageClassConfiguration{
 		storageClass: &storagev1.StorageClass{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: generateNameForCephFilesystemSC(initData),
@@ -135,7 +352,7 @@ func newCephFilesystemStorageClassConfiguration(initData *ocsv1.StorageCluster)
 					"description": "Provides RWO and RWX Filesystem volumes",
 				},
 			},
-			Provisioner:   fmt.Sprintf("%s.cephfs.csi.ceph.com", initData.Namespace),
+			Provisioner:   generateNameForCSIDriver(initData, "cephfs"),
 			ReclaimPolicy: &persistentVolumeReclaimDelete,
 			// AllowVolumeExpansion is set to true to enable expansion of OCS backed Volumes
 			AllowVolumeExpansion: &allowVolumeExpansion,
@@ -153,6 +370,27 @@ func newCephFilesystemStorageClassConfiguration(initData *ocsv1.StorageCluster)
 		reconcileStrategy: ReconcileStrategy(managementSpec.ReconcileStrategy),
 		disable:           managementSpec.DisableStorageClass,
 	}
+	if managementSpec.ErasureCoded != nil {
+		// The erasure-coded pool is the second data pool of the filesystem
+		scc.storageClass.Parameters["pool"] = generateNameForCephFilesystemDataPool(initData, 1)
+	}
+	applyStorageClassOverrides(scc.storageClass, managementSpec.StorageClassOverrides, true)
+	return scc
+}
+
+// newAdditionalCephFilesystemStorageClassConfiguration generates configuration options for the StorageClass of an additional CephFilesystem.
+func newAdditionalCephFilesystemStorageClassConfiguration(initData *ocsv1.StorageCluster, fs ocsv1.AdditionalFilesystem) StorageClassConfiguration {
+	scc := newCephFilesystemStorageClassConfiguration(initData)
+	scc.storageClass.Name = generateNameForCephFilesystemSC(initData) + "-" + fs.Name
+	scc.storageClass.Parameters["fsName"] = generateNameForAdditionalCephFilesystem(initData, fs.Name)
+	// volumes go to the first data pool of the filesystem
+	delete(scc.storageClass.Parameters, "pool")
+	delete(scc.storageClass.Annotations, defaultStorageClassAnnotation)
+	// The StorageClass is deleted along with the filesystem when the
+	// filesystem is removed from the spec
+	scc.storageClass.Labels = getStorageClusterLabels(initData)
+	scc.disable = fs.DisableStorageClass
+	return scc
 }
 
 // newCephBlockPoolStorageClassConfiguration generates configuration options for a Ceph Block Pool StorageClass.
@@ -167,7 +405,7 @@ func newCephBlockPoolStorageClassConfiguration(initData *ocsv1.StorageCluster, t
 	persistentVolumeReclaimDelete := corev1.PersistentVolumeReclaimDelete
 	allowVolumeExpansion := true
 	managementSpec := initData.Spec.ManagedResources.CephBlockPools
-	return StorageClassConfiguration{
+	scc := StorageClassConfiguration{
 		storageClass: &storagev1.StorageClass{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: generateNameForCephBlockPoolSC(initData, storageClassSuffix),
@@ -175,7 +413,7 @@ func newCephBlockPoolStorageClassConfiguration(initData *ocsv1.StorageCluster, t
 					"description": "Provides RWO Filesystem volumes, and RWO and RWX Block volumes",
 				},
 			},
-			Provisioner:   fmt.Sprintf("%s.rbd.csi.ceph.com", initData.Namespace),
+			Provisioner:   generateNameForCSIDriver(initData, "rbd"),
 			ReclaimPolicy: &persistentVolumeReclaimDelete,
 			// AllowVolumeExpansion is set to true to enable expansion of OCS backed Volumes
 			AllowVolumeExpansion: &allowVolumeExpansion,
@@ -197,6 +435,30 @@ func newCephBlockPoolStorageClassConfiguration(initData *ocsv1.StorageCluster, t
 		reconcileStrategy: ReconcileStrategy(managementSpec.ReconcileStrategy),
 		disable:           managementSpec.DisableStorageClass,
 	}
+	if managementSpec.ErasureCoded != nil {
+		scc.storageClass.Parameters["dataPool"] = generateNameForErasureCodedCephBlockPool(initData)
+	}
+	applyStorageClassOverrides(scc.storageClass, managementSpec.StorageClassOverrides, !thickProvision)
+	return scc
+}
+
+// newAdditionalCephBlockPoolStorageClassConfiguration generates configuration options for the StorageClass of an additional CephBlockPool.
+func newAdditionalCephBlockPoolStorageClassConfiguration(initData *ocsv1.StorageCluster, pool ocsv1.AdditionalBlockPool) StorageClassConfiguration {
+	scc := newCephBlockPoolStorageClassConfiguration(initData, false)
+	scc.storageClass.Name = generateNameForCephBlockPoolSC(initData, "-"+pool.Name)
+	delete(scc.storageClass.Annotations, defaultStorageClassAnnotation)
+	if pool.ErasureCoded != nil {
+		// RBD can not keep image metadata in an erasure-coded pool, so only
+		// the data goes there and the metadata stays in the default pool
+		scc.storageClass.Parameters["dataPool"] = generateNameForAdditionalCephBlockPool(initData, pool.Name)
+	} else {
+		scc.storageClass.Parameters["pool"] = generateNameForAdditionalCephBlockPool(initData, pool.Name)
+	}
+	// The StorageClass is deleted along with the pool when the pool is
+	// removed from the spec
+	scc.storageClass.Labels = getStorageClusterLabels(initData)
+	scc.disable = pool.DisableStorageClass
+	return scc
 }
 
 // newCephOBCStorageClassConfiguration generates configuration options for a Ceph Object Store StorageClass.
@@ -211,7 +473,7 @@ func newCephOBCStorageClassConfiguration(initData *ocsv1.StorageCluster) Storage
 					"description": "Provides Object Bucket Claims (OBCs)",
 				},
 			},
-			Provisioner:   fmt.Sprintf("%s.ceph.rook.io/bucket", initData.Namespace),
+			Provisioner:   fmt.Sprintf("%s.ceph.rook.io/bucket", getRookOperatorNamespace(initData)),
 			ReclaimPolicy: &reclaimPolicy,
 			Parameters: map[string]string{
 				"objectStoreNamespace": initData.Namespace,
@@ -232,6 +494,19 @@ func (r *StorageClusterReconciler) newStorageClassConfigurations(initData *ocsv1
 		newCephBlockPoolStorageClassConfiguration(initData, false),
 		newCephBlockPoolStorageClassConfiguration(initData, true),
 	}
+	if !initData.Spec.ExternalStorage.Enable {
+		for _, pool := range initData.Spec.ManagedResources.CephBlockPools.AdditionalPools {
+			ret = append(ret, newAdditionalCephBlockPoolStorageClassConfiguration(initData, pool))
+		}
+		for _, fs := range initData.Spec.ManagedResources.CephFilesystems.AdditionalFilesystems {
+			ret = append(ret, newAdditionalCephFilesystemStorageClassConfiguration(initData, fs))
+		}
+		topologySCCs, err := newTopologyCephBlockPoolStorageClassConfigurations(initData)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, topologySCCs...)
+	}
 	// OBC storageclass will be returned only in TWO conditions,
 	// a. either 'externalStorage' is enabled
 	// OR
@@ -7,7 +7,9 @@ import (
 	configv1 "github.com/openshift/api/config/v1"
 	api "github.com/openshift/ocs-operator/api/v1"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -83,3 +85,197 @@ func assertStorageClasses(t *testing.T, reconciler StorageClusterReconciler, cr
 		}
 	}
 }
+
+func TestValidateStorageClassOverrides(t *testing.T) {
+	isDefault := true
+	cases := []struct {
+		label         string
+		rbd           *api.StorageClassOverrides
+		cephfs        *api.StorageClassOverrides
+		expectedError bool
+	}{
+		{
+			label: "no overrides",
+		},
+		{
+			label: "all RBD image features",
+			rbd:   &api.StorageClassOverrides{ImageFeatures: []string{"layering", "exclusive-lock", "object-map", "fast-diff", "deep-flatten"}},
+		},
+		{
+			label:         "unknown RBD image feature",
+			rbd:           &api.StorageClassOverrides{ImageFeatures: []string{"journaling"}},
+			expectedError: true,
+		},
+		{
+			label:         "duplicate RBD image feature",
+			rbd:           &api.StorageClassOverrides{ImageFeatures: []string{"exclusive-lock", "exclusive-lock"}},
+			expectedError: true,
+		},
+		{
+			label:         "missing RBD image feature dependency",
+			rbd:           &api.StorageClassOverrides{ImageFeatures: []string{"exclusive-lock", "fast-diff"}},
+			expectedError: true,
+		},
+		{
+			label:         "fsType for CephFS",
+			cephfs:        &api.StorageClassOverrides{FSType: "xfs"},
+			expectedError: true,
+		},
+		{
+			label:         "two default StorageClasses",
+			rbd:           &api.StorageClassOverrides{Default: &isDefault},
+			cephfs:        &api.StorageClassOverrides{Default: &isDefault},
+			expectedError: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := &api.StorageCluster{}
+		sc.Spec.ManagedResources.CephBlockPools.StorageClassOverrides = c.rbd
+		sc.Spec.ManagedResources.CephFilesystems.StorageClassOverrides = c.cephfs
+		err := validateStorageClassOverrides(sc)
+		if c.expectedError {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestStorageClassOverrides(t *testing.T) {
+	isDefault := true
+	retain := corev1.PersistentVolumeReclaimRetain
+	waitForFirstConsumer := storagev1.VolumeBindingWaitForFirstConsumer
+	cr := createDefaultStorageCluster()
+	cr.Spec.ManagedResources.CephBlockPools.StorageClassOverrides = &api.StorageClassOverrides{
+		FSType:            "xfs",
+		ImageFeatures:     []string{"exclusive-lock", "object-map", "fast-diff", "deep-flatten"},
+		ReclaimPolicy:     &retain,
+		VolumeBindingMode: &waitForFirstConsumer,
+		MountOptions:      []string{"discard"},
+		Default:           &isDefault,
+	}
+	cr.Spec.ManagedResources.CephBlockPools.AdditionalPools = []api.AdditionalBlockPool{{Name: "fast"}}
+	cr.Spec.ManagedResources.CephFilesystems.StorageClassOverrides = &api.StorageClassOverrides{ReclaimPolicy: &retain}
+
+	rbd := newCephBlockPoolStorageClassConfiguration(cr, false).storageClass
+	assert.Equal(t, "xfs", rbd.Parameters["csi.storage.k8s.io/fstype"])
+	assert.Equal(t, "layering,exclusive-lock,object-map,fast-diff,deep-flatten", rbd.Parameters["imageFeatures"])
+	assert.Equal(t, retain, *rbd.ReclaimPolicy)
+	assert.Equal(t, waitForFirstConsumer, *rbd.VolumeBindingMode)
+	assert.Equal(t, []string{"discard"}, rbd.MountOptions)
+	assert.Equal(t, "true", rbd.Annotations[defaultStorageClassAnnotation])
+
+	// Only the default RBD StorageClass is marked as the default one
+	thick := newCephBlockPoolStorageClassConfiguration(cr, true).storageClass
+	assert.Equal(t, "xfs", thick.Parameters["csi.storage.k8s.io/fstype"])
+	assert.NotContains(t, thick.Annotations, defaultStorageClassAnnotation)
+	additional := newAdditionalCephBlockPoolStorageClassConfiguration(cr, cr.Spec.ManagedResources.CephBlockPools.AdditionalPools[0]).storageClass
+	assert.Equal(t, retain, *additional.ReclaimPolicy)
+	assert.NotContains(t, additional.Annotations, defaultStorageClassAnnotation)
+
+	cephfs := newCephFilesystemStorageClassConfiguration(cr).storageClass
+	assert.Equal(t, retain, *cephfs.ReclaimPolicy)
+	assert.Equal(t, storagev1.VolumeBindingImmediate, *cephfs.VolumeBindingMode)
+	assert.NotContains(t, cephfs.Annotations, defaultStorageClassAnnotation)
+}
+
+func TestStorageClassOverridesUpdate(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	cr := createDefaultStorageCluster()
+	var obj ocsStorageClass
+	assert.NoError(t, obj.ensureCreated(&reconciler, cr))
+
+	name := generateNameForCephBlockPoolSC(cr, "")
+	existing := &storagev1.StorageClass{}
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name}, existing))
+	uid := types.UID("original")
+	existing.UID = uid
+	assert.NoError(t, reconciler.Client.Update(context.TODO(), existing))
+
+	// Mount options and annotations are updated in place
+	isDefault := true
+	cr.Spec.ManagedResources.CephBlockPools.StorageClassOverrides = &api.StorageClassOverrides{
+		MountOptions: []string{"discard"},
+		Default:      &isDefault,
+	}
+	assert.NoError(t, obj.ensureCreated(&reconciler, cr))
+	actual := &storagev1.StorageClass{}
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name}, actual))
+	assert.Equal(t, uid, actual.UID)
+	assert.Equal(t, []string{"discard"}, actual.MountOptions)
+	assert.Equal(t, "true", actual.Annotations[defaultStorageClassAnnotation])
+
+	// The reclaim policy can not be updated, so the StorageClass is recreated
+	retain := corev1.PersistentVolumeReclaimRetain
+	cr.Spec.ManagedResources.CephBlockPools.StorageClassOverrides.ReclaimPolicy = &retain
+	assert.NoError(t, obj.ensureCreated(&reconciler, cr))
+	actual = &storagev1.StorageClass{}
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name}, actual))
+	assert.NotEqual(t, uid, actual.UID)
+	assert.Equal(t, retain, *actual.ReclaimPolicy)
+	assert.Equal(t, []string{"discard"}, actual.MountOptions)
+}
+
+func TestStorageClassOverridesRevert(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	cr := createDefaultStorageCluster()
+	isDefault := true
+	waitForFirstConsumer := storagev1.VolumeBindingWaitForFirstConsumer
+	cr.Spec.ManagedResources.CephBlockPools.StorageClassOverrides = &api.StorageClassOverrides{
+		VolumeBindingMode: &waitForFirstConsumer,
+		Default:           &isDefault,
+	}
+	var obj ocsStorageClass
+	assert.NoError(t, obj.ensureCreated(&reconciler, cr))
+
+	name := generateNameForCephBlockPoolSC(cr, "")
+	actual := &storagev1.StorageClass{}
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name}, actual))
+	assert.Equal(t, waitForFirstConsumer, *actual.VolumeBindingMode)
+	assert.Equal(t, "true", actual.Annotations[defaultStorageClassAnnotation])
+
+	// A default annotation set by hand is left alone
+	cephfsName := generateNameForCephFilesystemSC(cr)
+	cephfs := &storagev1.StorageClass{}
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: cephfsName}, cephfs))
+	cephfs.Annotations[defaultStorageClassAnnotation] = "true"
+	assert.NoError(t, reconciler.Client.Update(context.TODO(), cephfs))
+
+	// Removing the overrides recreates the StorageClass with the default
+	// binding mode and removes the default annotation
+	cr.Spec.ManagedResources.CephBlockPools.StorageClassOverrides = nil
+	change := findPlannedChange(reconciler.planStorageCluster(cr), "StorageClass", name)
+	assert.NotNil(t, change)
+	assert.Equal(t, api.PlannedActionRecreate, change.Action)
+	assert.Equal(t, []string{"volumeBindingMode"}, change.Fields)
+
+	assert.NoError(t, obj.ensureCreated(&reconciler, cr))
+	actual = &storagev1.StorageClass{}
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name}, actual))
+	assert.Equal(t, storagev1.VolumeBindingImmediate, *actual.VolumeBindingMode)
+	assert.NotContains(t, actual.Annotations, defaultStorageClassAnnotation)
+	assert.NotContains(t, actual.Annotations, defaultStorageClassOverrideAnnotation)
+	assert.Nil(t, findPlannedChange(reconciler.planStorageCluster(cr), "StorageClass", name))
+
+	cephfs = &storagev1.StorageClass{}
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: cephfsName}, cephfs))
+	assert.Equal(t, "true", cephfs.Annotations[defaultStorageClassAnnotation])
+
+	// Removing only the Default override updates the StorageClass in place
+	cr.Spec.ManagedResources.CephBlockPools.StorageClassOverrides = &api.StorageClassOverrides{Default: &isDefault}
+	assert.NoError(t, obj.ensureCreated(&reconciler, cr))
+	actual = &storagev1.StorageClass{}
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name}, actual))
+	uid := actual.UID
+	cr.Spec.ManagedResources.CephBlockPools.StorageClassOverrides = nil
+	change = findPlannedChange(reconciler.planStorageCluster(cr), "StorageClass", name)
+	assert.NotNil(t, change)
+	assert.Equal(t, api.PlannedActionUpdate, change.Action)
+	assert.NoError(t, obj.ensureCreated(&reconciler, cr))
+	actual = &storagev1.StorageClass{}
+	assert.NoError(t, reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: name}, actual))
+	assert.Equal(t, uid, actual.UID)
+	assert.NotContains(t, actual.Annotations, defaultStorageClassAnnotation)
+}
@@ -1,6 +1,7 @@
 package storagecluster
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -10,6 +11,7 @@ import (
 	ocsv1 "github.com/openshift/ocs-operator/api/v1"
 	"github.com/openshift/ocs-operator/controllers/util"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/version"
@@ -24,12 +26,16 @@ import (
 
 var (
 	log = ctrl.Log.WithName("controllers").WithName("StorageCluster")
+
+	// operatorNamespace is the namespace the operator is deployed in
+	operatorNamespace string
 )
 
 func (r *StorageClusterReconciler) initializeImageVars() error {
 	r.images.Ceph = os.Getenv("CEPH_IMAGE")
 	r.images.NooBaaCore = os.Getenv("NOOBAA_CORE_IMAGE")
 	r.images.NooBaaDB = os.Getenv("NOOBAA_DB_IMAGE")
+	r.images.Rook = os.Getenv("ROOK_CEPH_IMAGE")
 
 	if r.images.Ceph == "" {
 		err := fmt.Errorf("CEPH_IMAGE environment variable not found")
@@ -43,6 +49,10 @@ func (r *StorageClusterReconciler) initializeImageVars() error {
 		err := fmt.Errorf("NOOBAA_DB_IMAGE environment variable not found")
 		r.Log.Error(err, "Missing NOOBAA_DB_IMAGE environment variable for ocs initialization.")
 		return err
+	} else if r.images.Rook == "" {
+		err := fmt.Errorf("ROOK_CEPH_IMAGE environment variable not found")
+		r.Log.Error(err, "Missing ROOK_CEPH_IMAGE environment variable for ocs initialization.")
+		return err
 	}
 	return nil
 }
@@ -66,6 +76,7 @@ type ImageMap struct {
 	Ceph       string
 	NooBaaCore string
 	NooBaaDB   string
+	Rook       string
 }
 
 // StorageClusterReconciler reconciles a StorageCluster object
@@ -95,9 +106,20 @@ func (r *StorageClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
+	ns, err := util.GetOperatorNamespace()
+	if err != nil {
+		return err
+	}
+	operatorNamespace = ns
+
 	r.platform = &Platform{}
 	r.recorder = util.NewEventReporter(mgr.GetEventRecorderFor("controller_storagecluster"))
 
+	err = mgr.GetFieldIndexer().IndexField(context.TODO(), &corev1.PersistentVolume{}, pvStorageClassNameField, indexPVStorageClassName)
+	if err != nil {
+		return err
+	}
+
 	// Compose a predicate that is an OR of the specified predicates
 	scPredicate := util.ComposePredicates(
 		predicate.GenerationChangedPredicate{},
@@ -111,10 +133,28 @@ func (r *StorageClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		},
 	}
 
+	// The CephBlockPools are only watched to report their mirroring health,
+	// which Rook refreshes periodically
+	cephBlockPoolPredicate := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldPool, oldOK := e.ObjectOld.(*cephv1.CephBlockPool)
+			newPool, newOK := e.ObjectNew.(*cephv1.CephBlockPool)
+			if !oldOK || !newOK {
+				return false
+			}
+			return getPoolMirroringHealth(oldPool) != getPoolMirroringHealth(newPool)
+		},
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&ocsv1.StorageCluster{}, builder.WithPredicates(scPredicate)).
 		Owns(&cephv1.CephCluster{}).
 		Owns(&nbv1.NooBaa{}).
 		Owns(&corev1.PersistentVolumeClaim{}, builder.WithPredicates(pvcPredicate)).
+		Owns(&batchv1.Job{}).
+		Owns(&cephv1.CephBlockPool{}, builder.WithPredicates(cephBlockPoolPredicate)).
 		Complete(r)
 }
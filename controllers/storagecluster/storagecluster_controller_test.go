@@ -3,6 +3,8 @@ package storagecluster
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/blang/semver"
@@ -18,14 +20,18 @@ import (
 	rookCephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	v1 "github.com/rook/rook/pkg/apis/rook.io/v1"
 	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	k8sVersion "k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -516,15 +522,16 @@ func TestThrottleStorageDevices(t *testing.T) {
 	}
 }
 
-func TestIsActiveStorageCluster(t *testing.T) {
+func TestEnsureNamespaceOwner(t *testing.T) {
 	testcases := []struct {
 		label           string
 		storageCluster1 *api.StorageCluster
 		storageCluster2 *api.StorageCluster
-		isActive        bool
+		lease           *coordinationv1.Lease
+		expectedOwner   string
 	}{
 		{
-			label: "Case 1", // storageCluster1 has phase ignored. So storageCluster2 should be active
+			label: "Case 1", // storageCluster1 has phase ignored. So storageCluster2 should own the namespace
 			storageCluster1: &api.StorageCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "storage-test-a",
@@ -540,17 +547,20 @@ func TestIsActiveStorageCluster(t *testing.T) {
 					Namespace: "storage-test-ns",
 				},
 			},
-			isActive: true,
+			expectedOwner: "storage-test-b",
 		},
 		{
-			// storageCluster1 and storageCluster2 have same creationTimeStamp. So storageCluster2 is not active
-			// based on the alphabetic order of the object name (storage-test-a < storage-test-b)
+			// storageCluster1 was reconciled before the owner was recorded, so
+			// it keeps owning the namespace
 			label: "Case 2",
 			storageCluster1: &api.StorageCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "storage-test-a",
 					Namespace: "storage-test-ns",
 				},
+				Status: api.StorageClusterStatus{
+					Phase: statusutil.PhaseReady,
+				},
 			},
 			storageCluster2: &api.StorageCluster{
 				ObjectMeta: metav1.ObjectMeta{
@@ -558,12 +568,10 @@ func TestIsActiveStorageCluster(t *testing.T) {
 					Namespace: "storage-test-ns",
 				},
 			},
-			isActive: false,
+			expectedOwner: "storage-test-a",
 		},
 		{
-			// storageCluster1 and storageCluster2 have same creationTimeStamp. So storageCluster2 is active
-			// based on the alphabetic order of the object name. (storage-test-z > storage-test-b)
-			label: "Case 3",
+			label: "Case 3", // storageCluster1 holds the Lease, no matter the names or creation times
 			storageCluster1: &api.StorageCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "storage-test-z",
@@ -576,29 +584,117 @@ func TestIsActiveStorageCluster(t *testing.T) {
 					Namespace: "storage-test-ns",
 				},
 			},
-			isActive: true,
+			lease:         newNamespaceOwnerLease("storage-test-ns", "storage-test-z"),
+			expectedOwner: "storage-test-z",
 		},
 		{
-			label:           "Case 4", // storageCluster2 should be active as there are no other storageClusters available
-			storageCluster1: &api.StorageCluster{},
+			label: "Case 4", // the holder of the Lease is gone, so storageCluster2 takes over
+			storageCluster1: &api.StorageCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "storage-test-a",
+					Namespace: "other-ns",
+				},
+			},
 			storageCluster2: &api.StorageCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "storage-test-b",
 					Namespace: "storage-test-ns",
 				},
 			},
-			isActive: true,
+			lease:         newNamespaceOwnerLease("storage-test-ns", "storage-test-a"),
+			expectedOwner: "storage-test-b",
+		},
+		{
+			label: "Case 5", // StorageClusters in different namespaces own their own namespace
+			storageCluster1: &api.StorageCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "storage-test-a",
+					Namespace: "other-ns",
+				},
+				Status: api.StorageClusterStatus{
+					Phase: statusutil.PhaseReady,
+				},
+			},
+			storageCluster2: &api.StorageCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "storage-test-a",
+					Namespace: "storage-test-ns",
+				},
+			},
+			lease:         newNamespaceOwnerLease("other-ns", "storage-test-a"),
+			expectedOwner: "storage-test-a",
 		},
 	}
 
 	for _, tc := range testcases {
-		reconciler := createFakeStorageClusterReconciler(t, tc.storageCluster1, tc.storageCluster2)
-		actual, err := reconciler.isActiveStorageCluster(tc.storageCluster2)
+		objs := []runtime.Object{tc.storageCluster1, tc.storageCluster2}
+		if tc.lease != nil {
+			objs = append(objs, tc.lease)
+		}
+		reconciler := createFakeStorageClusterReconciler(t, objs...)
+		owner, err := reconciler.ensureNamespaceOwner(tc.storageCluster2)
 		assert.NoError(t, err)
-		assert.Equalf(t, tc.isActive, actual, "[%q] failed to assert if current storagecluster is active or not", tc.label)
+		assert.Equalf(t, tc.expectedOwner, owner, "[%q] failed to assert the owner of the namespace", tc.label)
+
+		lease := &coordinationv1.Lease{}
+		err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: namespaceOwnerLeaseName, Namespace: tc.storageCluster2.Namespace}, lease)
+		if tc.expectedOwner == tc.storageCluster2.Name {
+			assert.NoErrorf(t, err, "[%q] the owner must hold the Lease", tc.label)
+			assert.Equalf(t, tc.storageCluster2.Name, *lease.Spec.HolderIdentity, "[%q] unexpected Lease holder", tc.label)
+
+			// The namespace is released once the owner is deleted
+			err = reconciler.releaseNamespaceOwner(tc.storageCluster2)
+			assert.NoError(t, err)
+			err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: namespaceOwnerLeaseName, Namespace: tc.storageCluster2.Namespace}, lease)
+			assert.Truef(t, errors.IsNotFound(err), "[%q] the Lease must be deleted", tc.label)
+		}
+	}
+}
+
+func newNamespaceOwnerLease(namespace, holder string) *coordinationv1.Lease {
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      namespaceOwnerLeaseName,
+			Namespace: namespace,
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity: &holder,
+		},
 	}
+}
 
+func TestGenerateNameForClusterScopedResource(t *testing.T) {
+	defer func(ns string) { operatorNamespace = ns }(operatorNamespace)
+	operatorNamespace = "openshift-storage"
+
+	sc := &api.StorageCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ocs-storagecluster",
+			Namespace: "openshift-storage",
+		},
+	}
+	assert.Equal(t, "ocs-storagecluster-ceph-rbd", generateNameForCephBlockPoolSC(sc, ""))
+	assert.Equal(t, "ocs-storagecluster-rbdplugin-snapclass", generateNameForSnapshotClass(sc, rbdSnapshotter))
+
+	sc.Namespace = "openshift-storage-extended"
+	assert.Equal(t, "openshift-storage-extended-ocs-storagecluster-ceph-rbd", generateNameForCephBlockPoolSC(sc, ""))
+	assert.Equal(t, "openshift-storage-extended-ocs-storagecluster-cephfs", generateNameForCephFilesystemSC(sc))
+	assert.Equal(t, "openshift-storage-extended-ocs-storagecluster-rbdplugin-snapclass", generateNameForSnapshotClass(sc, rbdSnapshotter))
+
+	// The CSI drivers are deployed once, in the operator namespace
+	assert.Equal(t, "openshift-storage.rbd.csi.ceph.com", generateNameForCSIDriver(sc, "rbd"))
+	assert.Equal(t, "openshift-storage.cephfs.csi.ceph.com", generateNameForSnapshotClassDriver(sc, cephfsSnapshotter))
+	reconciler := createFakeStorageClusterReconciler(t)
+	sccs, err := reconciler.newStorageClassConfigurations(sc)
+	assert.NoError(t, err)
+	for _, scc := range sccs {
+		assert.True(t, strings.HasPrefix(scc.storageClass.Provisioner, "openshift-storage."), scc.storageClass.Name)
+		if clusterID, ok := scc.storageClass.Parameters["clusterID"]; ok {
+			assert.Equal(t, "openshift-storage-extended", clusterID, scc.storageClass.Name)
+		}
+	}
 }
+
 func TestReconcileWithNonWatchedResource(t *testing.T) {
 	testcases := []struct {
 		label     string
@@ -683,17 +779,6 @@ func TestStorageDeviceSets(t *testing.T) {
 	scName := ""
 	metadataScName := ""
 	walScName := ""
-	storageClassEBS := &storagev1.StorageClass{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: "gp2",
-		},
-		Provisioner: string(EBS),
-		Parameters: map[string]string{
-			"type": "gp2",
-		},
-	}
-
-	reconciler := createFakeStorageClusterReconciler(t, storageClassEBS)
 
 	testcases := []struct {
 		label          string
@@ -804,11 +889,108 @@ func TestStorageDeviceSets(t *testing.T) {
 			},
 			expectedError: fmt.Errorf("no StorageClass specified for walPVCTemplate"),
 		},
+		{
+			label:          "Case 8",
+			storageCluster: &api.StorageCluster{},
+			deviceSets: []api.StorageDeviceSet{
+				{
+					Name:            "mock-sds",
+					Count:           3,
+					DataPVCTemplate: mockDataPVCTemplate,
+					Config: api.StorageDeviceSetConfig{
+						OsdsPerDevice:  2,
+						MetadataDevice: "nvme0n1",
+						OsdConfig: map[string]string{
+							api.DatabaseSizeMBKey: "1024",
+							api.WalSizeMBKey:      "512",
+							api.JournalSizeMBKey:  "256",
+						},
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			label:          "Case 9",
+			storageCluster: &api.StorageCluster{},
+			deviceSets: []api.StorageDeviceSet{
+				{
+					Name:                "mock-sds",
+					Count:               3,
+					DataPVCTemplate:     mockDataPVCTemplate,
+					MetadataPVCTemplate: mockMetaDataPVCTemplate,
+					Config: api.StorageDeviceSetConfig{
+						MetadataDevice: "nvme0n1",
+					},
+				},
+			},
+			expectedError: fmt.Errorf("can not be combined with a metadataPVCTemplate"),
+		},
+		{
+			label:          "Case 10",
+			storageCluster: &api.StorageCluster{},
+			deviceSets: []api.StorageDeviceSet{
+				{
+					Name:            "mock-sds",
+					Count:           3,
+					DataPVCTemplate: mockDataPVCTemplate,
+					Config: api.StorageDeviceSetConfig{
+						OsdsPerDevice: -1,
+					},
+				},
+			},
+			expectedError: fmt.Errorf("invalid config osdsPerDevice"),
+		},
+		{
+			label:          "Case 11",
+			storageCluster: &api.StorageCluster{},
+			deviceSets: []api.StorageDeviceSet{
+				{
+					Name:            "mock-sds",
+					Count:           3,
+					DataPVCTemplate: mockDataPVCTemplate,
+					Config: api.StorageDeviceSetConfig{
+						OsdConfig: map[string]string{api.DatabaseSizeMBKey: "big"},
+					},
+				},
+			},
+			expectedError: fmt.Errorf("invalid osdConfig databaseSizeMB"),
+		},
+		{
+			label:          "Case 12",
+			storageCluster: &api.StorageCluster{},
+			deviceSets: []api.StorageDeviceSet{
+				{
+					Name:            "mock-sds",
+					Count:           3,
+					DataPVCTemplate: mockDataPVCTemplate,
+					Config: api.StorageDeviceSetConfig{
+						OsdConfig: map[string]string{api.EncryptedDeviceKey: "true"},
+					},
+				},
+			},
+			expectedError: fmt.Errorf("use the config.encryptedDevice field instead"),
+		},
+		{
+			label:          "Case 13",
+			storageCluster: &api.StorageCluster{},
+			deviceSets: []api.StorageDeviceSet{
+				{
+					Name:            "mock-sds",
+					Count:           3,
+					DataPVCTemplate: mockDataPVCTemplate,
+					Config: api.StorageDeviceSetConfig{
+						OsdConfig: map[string]string{"osd_memory_target": "4294967296"},
+					},
+				},
+			},
+			expectedError: fmt.Errorf("invalid osdConfig key \"osd_memory_target\""),
+		},
 	}
 
 	for _, tc := range testcases {
 		tc.storageCluster.Spec.StorageDeviceSets = tc.deviceSets
-		err := reconciler.validateStorageDeviceSets(tc.storageCluster)
+		err := validateStorageDeviceSets(tc.storageCluster)
 		if tc.expectedError == nil {
 			assert.NoError(t, err)
 			continue
@@ -938,6 +1120,8 @@ func createFakeStorageClusterReconciler(t *testing.T, obj ...runtime.Object) Sto
 		serverVersion: &k8sVersion.Info{},
 		Log:           logf.Log.WithName("controller_storagecluster_test"),
 		platform:      &Platform{platform: configv1.NonePlatformType},
+		images:        ImageMap{Rook: "rook/ceph:test"},
+		recorder:      statusutil.NewEventReporter(&record.FakeRecorder{}),
 	}
 }
 
@@ -986,17 +1170,71 @@ func createFakeScheme(t *testing.T) *runtime.Scheme {
 	if err != nil {
 		assert.Fail(t, "failed to add routev1 scheme")
 	}
+	err = coordinationv1.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add coordinationv1 scheme")
+	}
+	err = batchv1.AddToScheme(scheme)
+	if err != nil {
+		assert.Fail(t, "failed to add batchv1 scheme")
+	}
+	// The VolumeReplicationClass API is not vendored, its objects are
+	// handled as unstructured
+	scheme.AddKnownTypeWithName(volumeReplicationClassGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(volumeReplicationClassGVK.GroupVersion().WithKind(volumeReplicationClassGVK.Kind+"List"), &unstructured.UnstructuredList{})
 
 	return scheme
 }
 
-//nolint //ignoring err checks as causing failures
 func TestMonCountChange(t *testing.T) {
-	for nodeCount := 0; nodeCount <= 10; nodeCount++ {
-		monCountExpected := defaults.DefaultMonCount
-		monCountActual := getMonCount(nodeCount, false)
-		assert.Equal(t, monCountExpected, monCountActual)
+	cases := []struct {
+		label    string
+		mon      api.MonSpec
+		arbiter  bool
+		override string
+		expected int
+	}{
+		{
+			label:    "default count",
+			expected: defaults.DefaultMonCount,
+		},
+		{
+			label:    "default count in arbiter mode",
+			arbiter:  true,
+			expected: defaults.ArbiterModeMonCount,
+		},
+		{
+			label:    "explicit count",
+			mon:      api.MonSpec{Count: 5},
+			expected: 5,
+		},
+		{
+			label:    "env override",
+			override: "1",
+			expected: 1,
+		},
+		{
+			label:    "explicit count takes precedence over env override",
+			mon:      api.MonSpec{Count: 5},
+			override: "1",
+			expected: 5,
+		},
+		{
+			label:    "invalid env override",
+			override: "one",
+			expected: defaults.DefaultMonCount,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		os.Setenv(monCountOverrideEnvVar, c.override)
+		sc := &api.StorageCluster{}
+		sc.Spec.Mon = c.mon
+		sc.Spec.Arbiter.Enable = c.arbiter
+		assert.Equal(t, c.expected, getMonCount(sc))
 	}
+	os.Unsetenv(monCountOverrideEnvVar)
 }
 
 // TestStorageClusterOnMultus tests if multus configurations in StorageCluster are successfully applied to CephClusterCR
@@ -1074,7 +1312,7 @@ func TestStorageClusterOnMultus(t *testing.T) {
 func assertCephClusterNetwork(t assert.TestingT, reconciler StorageClusterReconciler, cr *api.StorageCluster, request reconcile.Request) {
 	serverVersion := &k8sVersion.Info{}
 	request.Name = "ocsinit-cephcluster"
-	cephCluster := newCephCluster(cr, "", 3, serverVersion, nil, log)
+	cephCluster := newCephCluster(cr, "", serverVersion, nil, log)
 	err := reconciler.Client.Get(context.TODO(), request.NamespacedName, cephCluster)
 	assert.NoError(t, err)
 	if cr.Spec.Network == nil {
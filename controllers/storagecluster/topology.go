@@ -48,6 +48,20 @@ func getFailureDomainKey(sc *ocsv1.StorageCluster) string {
 	return sc.Status.FailureDomainKey
 }
 
+// validateErasureCodedSpec checks that the StorageCluster has enough failure
+// domains to place every chunk of an erasure-coded pool in a different one
+func validateErasureCodedSpec(sc *ocsv1.StorageCluster, ec *ocsv1.ErasureCodedSpec) error {
+	if ec == nil {
+		return nil
+	}
+	chunks := int(ec.DataChunks + ec.CodingChunks)
+	if len(sc.Status.FailureDomainValues) < chunks {
+		return fmt.Errorf("erasure coding with %d data and %d coding chunks requires at least %d failure domains of type %q, found %d",
+			ec.DataChunks, ec.CodingChunks, chunks, sc.Status.FailureDomain, len(sc.Status.FailureDomainValues))
+	}
+	return nil
+}
+
 // setFailureDomain determines the appropriate Ceph failure domain based
 // on the storage cluster's topology map
 func setFailureDomain(sc *ocsv1.StorageCluster) {
@@ -0,0 +1,143 @@
+package storagecluster
+
+import (
+	"fmt"
+	"strings"
+
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// topologyConstrainedPoolsEnabled returns true if a CephBlockPool and a
+// StorageClass are created for each failure domain of the StorageCluster
+func topologyConstrainedPoolsEnabled(sc *ocsv1.StorageCluster) bool {
+	return sc.Spec.ManagedResources.CephBlockPools.TopologyConstrainedPools && !sc.Spec.ExternalStorage.Enable
+}
+
+// validateTopologyConstrainedPools checks that the CRUSH subtree of each
+// failure domain can be found. Portable OSDs are not placed under the CRUSH
+// host of their node, so there is no subtree per host.
+func validateTopologyConstrainedPools(sc *ocsv1.StorageCluster) error {
+	if !topologyConstrainedPoolsEnabled(sc) || getFailureDomain(sc) != "host" {
+		return nil
+	}
+	for _, ds := range sc.Spec.StorageDeviceSets {
+		if isDeviceSetPortable(sc, &ds) {
+			return fmt.Errorf("topology constrained pools with the host failure domain require non-portable OSDs, StorageDeviceSet %q is portable", ds.Name)
+		}
+	}
+	return nil
+}
+
+// isDeviceSetPortable returns true if the OSDs of the StorageDeviceSet can
+// move between nodes, see newStorageClassDeviceSets
+func isDeviceSetPortable(sc *ocsv1.StorageCluster, ds *ocsv1.StorageDeviceSet) bool {
+	if !ds.Portable {
+		return false
+	}
+	noPlacement := ds.Placement.NodeAffinity == nil && ds.Placement.PodAffinity == nil &&
+		ds.Placement.PodAntiAffinity == nil && ds.Placement.TopologySpreadConstraints == nil
+	if !noPlacement {
+		return true
+	}
+	topologyKey := ds.TopologyKey
+	if topologyKey == "" {
+		topologyKey = getFailureDomain(sc)
+	}
+	return topologyKey != "host"
+}
+
+// getTopologyCrushRoot returns the CRUSH bucket of a failure domain. Rook
+// replaces the dots of the node labels in the CRUSH bucket names.
+func getTopologyCrushRoot(value string) string {
+	return strings.ReplaceAll(value, ".", "-")
+}
+
+// getTopologySubFailureDomain returns the failure domain of the replicas
+// within a failure domain of the StorageCluster
+func getTopologySubFailureDomain(sc *ocsv1.StorageCluster) string {
+	if getFailureDomain(sc) == "host" {
+		return "osd"
+	}
+	return "host"
+}
+
+// newTopologyCephBlockPools returns the CephBlockPools restricted to the
+// CRUSH subtree of each failure domain of the StorageCluster
+func newTopologyCephBlockPools(initData *ocsv1.StorageCluster) []*cephv1.CephBlockPool {
+	if !topologyConstrainedPoolsEnabled(initData) {
+		return nil
+	}
+	// Each failure domain of a stretch cluster only holds its share of the
+	// replicas
+	size := getCephPoolReplicatedSize(initData)
+	if arbiterEnabled(initData) {
+		size = uint(getReplicasPerFailureDomain(initData))
+	}
+
+	var ret []*cephv1.CephBlockPool
+	for _, value := range initData.Status.FailureDomainValues {
+		ret = append(ret, &cephv1.CephBlockPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      generateNameForTopologyCephBlockPool(initData, value),
+				Namespace: initData.Namespace,
+			},
+			Spec: cephv1.PoolSpec{
+				CrushRoot:      getTopologyCrushRoot(value),
+				FailureDomain:  getTopologySubFailureDomain(initData),
+				Replicated:     cephv1.ReplicatedSpec{Size: size},
+				EnableRBDStats: true,
+			},
+		})
+	}
+	return ret
+}
+
+// newTopologyCephBlockPoolStorageClassConfigurations generates configuration
+// options for the StorageClasses pinning their volumes to a failure domain
+// of the StorageCluster.
+func newTopologyCephBlockPoolStorageClassConfigurations(initData *ocsv1.StorageCluster) ([]StorageClassConfiguration, error) {
+	if !topologyConstrainedPoolsEnabled(initData) {
+		return nil, nil
+	}
+	if err := validateTopologyConstrainedPools(initData); err != nil {
+		return nil, err
+	}
+	// The scheduler matches the allowed topologies with the node labels.
+	// ceph-csi does not need to report the topology of the nodes, as each
+	// StorageClass only provisions volumes from the pool of its failure
+	// domain.
+	topologyKey := getFailureDomainKey(initData)
+	// The volume is only provisioned once its consumer is scheduled, so
+	// that the consumer is not bound to a failure domain it can not run in
+	waitForFirstConsumer := storagev1.VolumeBindingWaitForFirstConsumer
+
+	var ret []StorageClassConfiguration
+	for _, value := range initData.Status.FailureDomainValues {
+		poolName := generateNameForTopologyCephBlockPool(initData, value)
+
+		scc := newCephBlockPoolStorageClassConfiguration(initData, false)
+		scc.storageClass.Name = generateNameForTopologyCephBlockPoolSC(initData, value)
+		// The StorageClasses of removed failure domains are pruned by label
+		scc.storageClass.Labels = getStorageClusterLabels(initData)
+		scc.storageClass.Annotations["description"] = fmt.Sprintf("Provides RWO Filesystem volumes, and RWO and RWX Block volumes in %s %s", getFailureDomain(initData), value)
+		delete(scc.storageClass.Annotations, defaultStorageClassAnnotation)
+		delete(scc.storageClass.Annotations, defaultStorageClassOverrideAnnotation)
+		scc.storageClass.Parameters["pool"] = poolName
+		// The erasure-coded data pool spans all the failure domains
+		delete(scc.storageClass.Parameters, "dataPool")
+		scc.storageClass.VolumeBindingMode = &waitForFirstConsumer
+		scc.storageClass.AllowedTopologies = []corev1.TopologySelectorTerm{
+			{
+				MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{
+					{Key: topologyKey, Values: []string{value}},
+				},
+			},
+		}
+		ret = append(ret, scc)
+	}
+	return ret, nil
+}
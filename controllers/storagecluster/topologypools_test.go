@@ -0,0 +1,208 @@
+package storagecluster
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/openshift/ocs-operator/api/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newTopologyStorageCluster() *api.StorageCluster {
+	sc := createDefaultStorageCluster()
+	sc.Namespace = "openshift-storage"
+	sc.Spec.ManagedResources.CephBlockPools.TopologyConstrainedPools = true
+	sc.Status.FailureDomain = "zone"
+	sc.Status.FailureDomainKey = corev1.LabelZoneFailureDomainStable
+	sc.Status.FailureDomainValues = []string{"us-east-1a", "us-east-1b", "us-east-1c"}
+	sc.Spec.StorageDeviceSets = []api.StorageDeviceSet{{Name: "mock-sds", Count: 3, Replica: 1}}
+	return sc
+}
+
+func TestNewTopologyCephBlockPools(t *testing.T) {
+	cases := []struct {
+		label               string
+		failureDomain       string
+		arbiter             bool
+		external            bool
+		expectedCount       int
+		values              []string
+		expectedSubDomain   string
+		expectedReplicaSize uint
+		expectedCrushRoots  []string
+	}{
+		{
+			label:               "zone failure domain",
+			failureDomain:       "zone",
+			expectedCount:       3,
+			expectedSubDomain:   "host",
+			expectedReplicaSize: 3,
+			expectedCrushRoots:  []string{"us-east-1a", "us-east-1b", "us-east-1c"},
+		},
+		{
+			label:               "host failure domain",
+			failureDomain:       "host",
+			values:              []string{"node-a.example.com", "node-b.example.com", "node-c.example.com"},
+			expectedCount:       3,
+			expectedSubDomain:   "osd",
+			expectedReplicaSize: 3,
+			expectedCrushRoots:  []string{"node-a-example-com", "node-b-example-com", "node-c-example-com"},
+		},
+		{
+			label:               "stretch cluster",
+			failureDomain:       "zone",
+			arbiter:             true,
+			expectedCount:       3,
+			expectedSubDomain:   "host",
+			expectedReplicaSize: 2,
+			expectedCrushRoots:  []string{"us-east-1a", "us-east-1b", "us-east-1c"},
+		},
+		{
+			label:    "external cluster",
+			external: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := newTopologyStorageCluster()
+		sc.Status.FailureDomain = c.failureDomain
+		if c.values != nil {
+			sc.Status.FailureDomainValues = c.values
+		}
+		sc.Spec.Arbiter.Enable = c.arbiter
+		sc.Spec.ExternalStorage.Enable = c.external
+
+		pools := newTopologyCephBlockPools(sc)
+		assert.Len(t, pools, c.expectedCount)
+		for j, pool := range pools {
+			value := sc.Status.FailureDomainValues[j]
+			assert.Equal(t, generateNameForTopologyCephBlockPool(sc, value), pool.Name)
+			assert.Equal(t, c.expectedCrushRoots[j], pool.Spec.CrushRoot)
+			assert.Equal(t, c.expectedSubDomain, pool.Spec.FailureDomain)
+			assert.Equal(t, c.expectedReplicaSize, pool.Spec.Replicated.Size)
+		}
+	}
+}
+
+func TestNewTopologyCephBlockPoolStorageClassConfigurations(t *testing.T) {
+	sc := newTopologyStorageCluster()
+	isDefault := true
+	sc.Spec.ManagedResources.CephBlockPools.StorageClassOverrides = &api.StorageClassOverrides{FSType: "xfs", Default: &isDefault}
+
+	sccs, err := newTopologyCephBlockPoolStorageClassConfigurations(sc)
+	assert.NoError(t, err)
+	assert.Len(t, sccs, 3)
+
+	storageClass := sccs[0].storageClass
+	assert.Equal(t, "ocsinit-ceph-rbd-zone-us-east-1a", storageClass.Name)
+	assert.Equal(t, "ocsinit-cephblockpool-zone-us-east-1a", storageClass.Parameters["pool"])
+	assert.NotContains(t, storageClass.Parameters, "topologyConstrainedPools")
+	assert.Equal(t, getStorageClusterLabels(sc), storageClass.Labels)
+	assert.Equal(t, "xfs", storageClass.Parameters["csi.storage.k8s.io/fstype"])
+	assert.Equal(t, storagev1.VolumeBindingWaitForFirstConsumer, *storageClass.VolumeBindingMode)
+	assert.Equal(t, []corev1.TopologySelectorTerm{
+		{
+			MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{
+				{Key: corev1.LabelZoneFailureDomainStable, Values: []string{"us-east-1a"}},
+			},
+		},
+	}, storageClass.AllowedTopologies)
+	assert.NotContains(t, storageClass.Annotations, defaultStorageClassAnnotation)
+
+	// The pools and StorageClasses are part of the managed resources
+	reconciler := createFakeStorageClusterReconciler(t)
+	pools, err := reconciler.newCephBlockPoolInstances(sc)
+	assert.NoError(t, err)
+	assert.Len(t, pools, 4)
+	allSCCs, err := reconciler.newStorageClassConfigurations(sc)
+	assert.NoError(t, err)
+	names := []string{}
+	for _, scc := range allSCCs {
+		names = append(names, scc.storageClass.Name)
+	}
+	assert.Contains(t, names, "ocsinit-ceph-rbd-zone-us-east-1c")
+
+	sc.Spec.ManagedResources.CephBlockPools.TopologyConstrainedPools = false
+	sccs, err = newTopologyCephBlockPoolStorageClassConfigurations(sc)
+	assert.NoError(t, err)
+	assert.Empty(t, sccs)
+}
+
+func TestValidateTopologyConstrainedPools(t *testing.T) {
+	cases := []struct {
+		label         string
+		failureDomain string
+		deviceSet     api.StorageDeviceSet
+		expectedError bool
+	}{
+		{
+			label:         "portable OSDs with the zone failure domain",
+			failureDomain: "zone",
+			deviceSet:     api.StorageDeviceSet{Name: "mock-sds", Portable: true},
+		},
+		{
+			label:         "portable OSDs placed by host",
+			failureDomain: "host",
+			deviceSet:     api.StorageDeviceSet{Name: "mock-sds", Portable: true},
+		},
+		{
+			label:         "portable OSDs placed by zone with the host failure domain",
+			failureDomain: "host",
+			deviceSet:     api.StorageDeviceSet{Name: "mock-sds", Portable: true, TopologyKey: "zone"},
+			expectedError: true,
+		},
+		{
+			label:         "portable OSDs with their own placement with the host failure domain",
+			failureDomain: "host",
+			deviceSet: api.StorageDeviceSet{Name: "mock-sds", Portable: true, Placement: rookv1.Placement{
+				NodeAffinity: &corev1.NodeAffinity{},
+			}},
+			expectedError: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Logf("Case %d: %s\n", i+1, c.label)
+		sc := newTopologyStorageCluster()
+		sc.Status.FailureDomain = c.failureDomain
+		sc.Spec.StorageDeviceSets = []api.StorageDeviceSet{c.deviceSet}
+
+		err := validateTopologyConstrainedPools(sc)
+		_, sccErr := newTopologyCephBlockPoolStorageClassConfigurations(sc)
+		if c.expectedError {
+			assert.Error(t, err)
+			assert.Error(t, sccErr)
+		} else {
+			assert.NoError(t, err)
+			assert.NoError(t, sccErr)
+		}
+	}
+}
+
+func TestTopologyConstrainedPoolsPruning(t *testing.T) {
+	reconciler := createFakeStorageClusterReconciler(t)
+	sc := newTopologyStorageCluster()
+	var storageClasses ocsStorageClass
+	assert.NoError(t, storageClasses.ensureCreated(&reconciler, sc))
+
+	// The StorageClass of a removed failure domain is deleted
+	sc.Status.FailureDomainValues = []string{"us-east-1a", "us-east-1b"}
+	assert.NoError(t, storageClasses.ensureCreated(&reconciler, sc))
+	err := reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-ceph-rbd-zone-us-east-1c"}, &storagev1.StorageClass{})
+	assert.True(t, errors.IsNotFound(err))
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: "ocsinit-ceph-rbd-zone-us-east-1a"}, &storagev1.StorageClass{})
+	assert.NoError(t, err)
+}
+
+func TestGenerateTopologySuffix(t *testing.T) {
+	sc := &api.StorageCluster{}
+	sc.Status.FailureDomain = "rack"
+	assert.Equal(t, "rack-rack0", generateTopologySuffix(sc, "rack0"))
+	assert.Equal(t, "rack-my-rack-1", generateTopologySuffix(sc, "My_Rack 1"))
+}
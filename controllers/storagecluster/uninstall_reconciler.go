@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/go-logr/logr"
 	nbv1 "github.com/noobaa/noobaa-operator/v2/pkg/apis/noobaa/v1alpha1"
 	ocsv1 "github.com/openshift/ocs-operator/api/v1"
 	"github.com/openshift/ocs-operator/controllers/defaults"
@@ -225,47 +226,56 @@ func (r *StorageClusterReconciler) setNoobaaUninstallMode(sc *ocsv1.StorageClust
 
 // reconcileUninstallAnnotations looks at the current uninstall annotations on the StorageCluster and sets defaults if none or unrecognized ones are set.
 func (r *StorageClusterReconciler) reconcileUninstallAnnotations(sc *ocsv1.StorageCluster) error {
+	updateRequired := setDefaultUninstallAnnotations(sc, r.Log)
+
+	if updateRequired {
+		oldSc := ocsv1.StorageCluster{}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{Name: sc.Name, Namespace: sc.Namespace}, &oldSc)
+		if err != nil {
+			r.Log.Error(err, "Uninstall: Failed to get StorageCluster.", "StorageCluster", klog.KRef(sc.Namespace, sc.Name))
+			return err
+		}
+		sc.ObjectMeta.ResourceVersion = oldSc.ObjectMeta.ResourceVersion
+		if err := r.Client.Update(context.TODO(), sc); err != nil {
+			r.Log.Error(err, "Uninstall: Failed to update the StorageCluster with uninstall defaults.", "StorageCluster", klog.KRef(sc.Namespace, sc.Name))
+			return err
+		}
+		r.Log.Info("Uninstall: Default uninstall annotations has been set on StorageCluster", "StorageCluster", klog.KRef(sc.Namespace, sc.Name))
+	}
+	return nil
+}
+
+// setDefaultUninstallAnnotations sets the uninstall mode and cleanup policy
+// annotations to their defaults when they are missing or unrecognized. It
+// returns true if the annotations of the StorageCluster were changed.
+func setDefaultUninstallAnnotations(sc *ocsv1.StorageCluster, reqLogger logr.Logger) bool {
 	var updateRequired bool
 
 	if v, found := sc.ObjectMeta.Annotations[UninstallModeAnnotation]; !found {
 		metav1.SetMetaDataAnnotation(&sc.ObjectMeta, string(UninstallModeAnnotation), string(UninstallModeGraceful))
-		r.Log.Info("Uninstall: Setting uninstall mode annotation to default.", "UninstallMode", UninstallModeGraceful)
+		reqLogger.Info("Uninstall: Setting uninstall mode annotation to default.", "UninstallMode", UninstallModeGraceful)
 		updateRequired = true
 	} else if found && v != string(UninstallModeGraceful) && v != string(UninstallModeForced) {
 		// if wrong value found
 		metav1.SetMetaDataAnnotation(&sc.ObjectMeta, string(UninstallModeAnnotation), string(UninstallModeGraceful))
-		r.Log.Info("Uninstall: Found unrecognized uninstall mode annotation. Changing it to default.",
+		reqLogger.Info("Uninstall: Found unrecognized uninstall mode annotation. Changing it to default.",
 			"CurrentUninstallMode", v, "DefaultUninstallMode", UninstallModeGraceful)
 		updateRequired = true
 	}
 
 	if v, found := sc.ObjectMeta.Annotations[CleanupPolicyAnnotation]; !found {
 		metav1.SetMetaDataAnnotation(&sc.ObjectMeta, string(CleanupPolicyAnnotation), string(CleanupPolicyDelete))
-		r.Log.Info("Uninstall: Setting uninstall cleanup policy annotation to default.", "CleanupPolicy", CleanupPolicyDelete)
+		reqLogger.Info("Uninstall: Setting uninstall cleanup policy annotation to default.", "CleanupPolicy", CleanupPolicyDelete)
 		updateRequired = true
 	} else if found && v != string(CleanupPolicyDelete) && v != string(CleanupPolicyRetain) {
 		// if wrong value found
 		metav1.SetMetaDataAnnotation(&sc.ObjectMeta, string(CleanupPolicyAnnotation), string(CleanupPolicyDelete))
-		r.Log.Info("Uninstall: Found unrecognized uninstall cleanup policy annotation.Changing it to default.",
+		reqLogger.Info("Uninstall: Found unrecognized uninstall cleanup policy annotation.Changing it to default.",
 			"CurrentCleanupPolicy", v, "DefaultCleanupPolicy", CleanupPolicyDelete)
 		updateRequired = true
 	}
 
-	if updateRequired {
-		oldSc := ocsv1.StorageCluster{}
-		err := r.Client.Get(context.TODO(), types.NamespacedName{Name: sc.Name, Namespace: sc.Namespace}, &oldSc)
-		if err != nil {
-			r.Log.Error(err, "Uninstall: Failed to get StorageCluster.", "StorageCluster", klog.KRef(sc.Namespace, sc.Name))
-			return err
-		}
-		sc.ObjectMeta.ResourceVersion = oldSc.ObjectMeta.ResourceVersion
-		if err := r.Client.Update(context.TODO(), sc); err != nil {
-			r.Log.Error(err, "Uninstall: Failed to update the StorageCluster with uninstall defaults.", "StorageCluster", klog.KRef(sc.Namespace, sc.Name))
-			return err
-		}
-		r.Log.Info("Uninstall: Default uninstall annotations has been set on StorageCluster", "StorageCluster", klog.KRef(sc.Namespace, sc.Name))
-	}
-	return nil
+	return updateRequired
 }
 
 // deleteResources is the function where the storageClusterFinalizer is handled
@@ -294,7 +304,9 @@ func (r *StorageClusterReconciler) deleteResources(sc *ocsv1.StorageCluster) err
 		&ocsCephRGWRoutes{},
 		&ocsCephObjectStoreUsers{},
 		&ocsCephObjectStores{},
+		&ocsCephNFS{},
 		&ocsCephFilesystems{},
+		&ocsMirroring{},
 		&ocsCephBlockPools{},
 		&ocsSnapshotClass{},
 		&ocsStorageClass{},
@@ -3,7 +3,6 @@ package storagecluster
 import (
 	"context"
 	"fmt"
-	"reflect"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
@@ -12,6 +11,7 @@ import (
 	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 	ocsv1 "github.com/openshift/ocs-operator/api/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // SnapshotterType represents a snapshotter type
@@ -71,16 +71,50 @@ func newCephBlockPoolSnapshotClassConfiguration(instance *ocsv1.StorageCluster)
 	}
 }
 
+func newAdditionalCephBlockPoolSnapshotClassConfiguration(instance *ocsv1.StorageCluster, pool ocsv1.AdditionalBlockPool) SnapshotClassConfiguration {
+	vsc := newVolumeSnapshotClass(instance, rbdSnapshotter)
+	vsc.Name = generateNameForAdditionalSnapshotClass(instance, rbdSnapshotter, pool.Name)
+	// The SnapshotClass is deleted along with the pool when the pool is
+	// removed from the spec
+	vsc.Labels = getStorageClusterLabels(instance)
+	return SnapshotClassConfiguration{
+		snapshotClass:     vsc,
+		reconcileStrategy: ReconcileStrategy(instance.Spec.ManagedResources.CephBlockPools.ReconcileStrategy),
+		disable:           pool.DisableSnapshotClass,
+	}
+}
+
+func newAdditionalCephFilesystemSnapshotClassConfiguration(instance *ocsv1.StorageCluster, fs ocsv1.AdditionalFilesystem) SnapshotClassConfiguration {
+	vsc := newVolumeSnapshotClass(instance, cephfsSnapshotter)
+	vsc.Name = generateNameForAdditionalSnapshotClass(instance, cephfsSnapshotter, fs.Name)
+	// The SnapshotClass is deleted along with the filesystem when the
+	// filesystem is removed from the spec
+	vsc.Labels = getStorageClusterLabels(instance)
+	return SnapshotClassConfiguration{
+		snapshotClass:     vsc,
+		reconcileStrategy: ReconcileStrategy(instance.Spec.ManagedResources.CephFilesystems.ReconcileStrategy),
+		disable:           fs.DisableSnapshotClass,
+	}
+}
+
 // newSnapshotClassConfigurations generates configuration options for Ceph SnapshotClasses.
 func newSnapshotClassConfigurations(instance *ocsv1.StorageCluster) []SnapshotClassConfiguration {
 	vsccs := []SnapshotClassConfiguration{
 		newCephFilesystemSnapshotClassConfiguration(instance),
 		newCephBlockPoolSnapshotClassConfiguration(instance),
 	}
+	if !instance.Spec.ExternalStorage.Enable {
+		for _, pool := range instance.Spec.ManagedResources.CephBlockPools.AdditionalPools {
+			vsccs = append(vsccs, newAdditionalCephBlockPoolSnapshotClassConfiguration(instance, pool))
+		}
+		for _, fs := range instance.Spec.ManagedResources.CephFilesystems.AdditionalFilesystems {
+			vsccs = append(vsccs, newAdditionalCephFilesystemSnapshotClassConfiguration(instance, fs))
+		}
+	}
 	return vsccs
 }
 
-func (r *StorageClusterReconciler) createSnapshotClasses(vsccs []SnapshotClassConfiguration) error {
+func (r *StorageClusterReconciler) createSnapshotClasses(vsccs []SnapshotClassConfiguration, instance *ocsv1.StorageCluster) error {
 
 	for _, vscc := range vsccs {
 		if vscc.reconcileStrategy == ReconcileStrategyIgnore || vscc.disable {
@@ -107,17 +141,35 @@ func (r *StorageClusterReconciler) createSnapshotClasses(vsccs []SnapshotClassCo
 			}
 		}
 		if vscc.reconcileStrategy == ReconcileStrategyInit {
-			return nil
+			continue
 		}
 		if existing.DeletionTimestamp != nil {
 			return fmt.Errorf("failed to restore SnapshotClass %q because it is marked for deletion", existing.Name)
 		}
 		// if there is a mis-match in the parameters of existing vs created resources,
-		if !reflect.DeepEqual(vsc.Parameters, existing.Parameters) {
+		drifted, err := getDriftedFields(vsc, existing, "parameters")
+		if err != nil {
+			return err
+		}
+		driftedLabels, err := getDriftedSetFields(vsc, existing, "metadata.labels")
+		if err != nil {
+			return err
+		}
+		drifted = append(drifted, driftedLabels...)
+		if len(drifted) > 0 {
 			// we have to update the existing SnapshotClass
-			r.Log.Info("SnapshotClass needs to be updated", "SnapshotClass", klog.KRef(existing.Namespace, existing.Name))
+			r.Log.Info("SnapshotClass needs to be updated", "SnapshotClass", klog.KRef(existing.Namespace, existing.Name), "Fields", drifted)
+			r.reportDrift(instance, componentSnapshotClasses, "VolumeSnapshotClass", existing.Namespace, existing.Name, drifted)
 			existing.ObjectMeta.OwnerReferences = vsc.ObjectMeta.OwnerReferences
+			labels := existing.ObjectMeta.Labels
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			for key, value := range vsc.Labels {
+				labels[key] = value
+			}
 			vsc.ObjectMeta = existing.ObjectMeta
+			vsc.ObjectMeta.Labels = labels
 			if err := r.Client.Update(context.TODO(), vsc); err != nil {
 				r.Log.Error(err, "SnapshotClass updation failed.", "SnapshotClass", klog.KRef(existing.Namespace, existing.Name))
 				return err
@@ -131,11 +183,41 @@ func (r *StorageClusterReconciler) createSnapshotClasses(vsccs []SnapshotClassCo
 func (obj *ocsSnapshotClass) ensureCreated(r *StorageClusterReconciler, instance *ocsv1.StorageCluster) error {
 	vsccs := newSnapshotClassConfigurations(instance)
 
-	err := r.createSnapshotClasses(vsccs)
+	err := r.createSnapshotClasses(vsccs, instance)
 	if err != nil {
 		return nil
 	}
 
+	return r.deleteRemovedSnapshotClasses(vsccs, instance)
+}
+
+// deleteRemovedSnapshotClasses deletes the SnapshotClasses labeled as
+// belonging to the StorageCluster which are no longer desired, i.e. those
+// of the additional CephBlockPools and CephFilesystems removed from the spec
+func (r *StorageClusterReconciler) deleteRemovedSnapshotClasses(vsccs []SnapshotClassConfiguration, instance *ocsv1.StorageCluster) error {
+	desired := map[string]bool{}
+	for _, vscc := range vsccs {
+		desired[vscc.snapshotClass.Name] = true
+	}
+
+	snapshotClasses := &snapapi.VolumeSnapshotClassList{}
+	err := r.Client.List(context.TODO(), snapshotClasses, client.MatchingLabels(getStorageClusterLabels(instance)))
+	if err != nil {
+		return fmt.Errorf("failed to list SnapshotClasses: %v", err)
+	}
+	for i := range snapshotClasses.Items {
+		existing := &snapshotClasses.Items[i]
+		if desired[existing.Name] || existing.DeletionTimestamp != nil ||
+			getRemovedClassReconcileStrategy(instance, existing.Driver) == ReconcileStrategyIgnore {
+			continue
+		}
+		r.Log.Info("Deleting SnapshotClass removed from the StorageCluster.", "SnapshotClass", klog.KRef("", existing.Name))
+		err = r.Client.Delete(context.TODO(), existing)
+		if err != nil && !errors.IsNotFound(err) {
+			r.Log.Error(err, "Failed to delete SnapshotClass.", "SnapshotClass", klog.KRef("", existing.Name))
+			return err
+		}
+	}
 	return nil
 }
 
@@ -15,6 +15,45 @@ const (
 
 	// EventReasonUninstallPending is used when the StorageCluster uninstall is Pending
 	EventReasonUninstallPending = "UninstallPending"
+
+	// EventReasonReconcileFailed is used when a component of the StorageCluster fails to reconcile
+	EventReasonReconcileFailed = "ReconcileFailed"
+
+	// EventReasonResourceDrift is used when a resource managed by the StorageCluster was modified and is restored
+	EventReasonResourceDrift = "ResourceDrift"
+
+	// EventReasonStorageClusterIgnored is used when the StorageCluster is ignored as another one owns its namespace
+	EventReasonStorageClusterIgnored = "StorageClusterIgnored"
+
+	// EventReasonOSDRemovalFailed is used when some of the OSDs of an OSDRemovalRequest could not be removed
+	EventReasonOSDRemovalFailed = "OSDRemovalFailed"
+
+	// EventReasonConsistentSnapshotFailed is used when a hook or a snapshot of a ConsistentSnapshot failed
+	EventReasonConsistentSnapshotFailed = "ConsistentSnapshotFailed"
+
+	// EventReasonConsistentSnapshotRestoreFailed is used when the PVCs of a ConsistentSnapshotRestore could not be restored
+	EventReasonConsistentSnapshotRestoreFailed = "ConsistentSnapshotRestoreFailed"
+
+	// EventReasonScheduledSnapshotFailed is used when the snapshots taken by a SnapshotSchedule failed
+	EventReasonScheduledSnapshotFailed = "ScheduledSnapshotFailed"
+
+	// EventReasonFullRatioReverted is used when the full ratio override of the StorageCluster is reverted
+	EventReasonFullRatioReverted = "FullRatioReverted"
+
+	// EventReasonDeviceSetExpanded is used when the Count of a StorageDeviceSet is increased by its autoExpand policy
+	EventReasonDeviceSetExpanded = "DeviceSetExpanded"
+
+	// EventReasonDeviceSetExpansionSkipped is used when the autoExpand policy of a StorageDeviceSet is triggered but does not allow an expansion
+	EventReasonDeviceSetExpansionSkipped = "DeviceSetExpansionSkipped"
+
+	// EventReasonCapacityInsufficient is used when the OSDs of the StorageDeviceSets cannot be placed on the storage nodes
+	EventReasonCapacityInsufficient = "CapacityInsufficient"
+
+	// EventReasonResourceProfileChanged is used when the resource profile of the StorageCluster changes
+	EventReasonResourceProfileChanged = "ResourceProfileChanged"
+
+	// EventReasonNFSRemovalBlocked is used when NFS is disabled but volumes of the NFS StorageClass remain
+	EventReasonNFSRemovalBlocked = "NFSRemovalBlocked"
 )
 
 // EventReporter is custom events reporter type which allows user to limit the events
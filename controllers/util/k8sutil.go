@@ -3,6 +3,7 @@ package util
 import (
 	"fmt"
 	"os"
+	"strings"
 )
 
 // WatchNamespaceEnvVar is the constant for env variable WATCH_NAMESPACE
@@ -18,3 +19,24 @@ func GetWatchNamespace() (string, error) {
 	}
 	return ns, nil
 }
+
+// OperatorNamespaceEnvVar is the constant for env variable OPERATOR_NAMESPACE
+// which is the namespace the operator is deployed in.
+const OperatorNamespaceEnvVar = "OPERATOR_NAMESPACE"
+
+// GetOperatorNamespace returns the namespace the operator is deployed in. It
+// falls back to the watch namespace for deployments which don't set
+// OPERATOR_NAMESPACE and watch only their own namespace.
+func GetOperatorNamespace() (string, error) {
+	if ns, found := os.LookupEnv(OperatorNamespaceEnvVar); found && ns != "" {
+		return ns, nil
+	}
+	ns, err := GetWatchNamespace()
+	if err != nil {
+		return "", fmt.Errorf("%s must be set", OperatorNamespaceEnvVar)
+	}
+	if ns == "" || strings.Contains(ns, ",") {
+		return "", fmt.Errorf("%s must be set when watching several namespaces", OperatorNamespaceEnvVar)
+	}
+	return ns, nil
+}
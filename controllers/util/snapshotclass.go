@@ -0,0 +1,73 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The suffixes of the names of the OCS CSI drivers
+const (
+	rbdDriverSuffix    = ".rbd.csi.ceph.com"
+	cephfsDriverSuffix = ".cephfs.csi.ceph.com"
+)
+
+// ListVolumeSnapshotClasses returns the VolumeSnapshotClasses sorted by name,
+// for GetVolumeSnapshotClassName to pick from
+func ListVolumeSnapshotClasses(c client.Client) ([]snapapi.VolumeSnapshotClass, error) {
+	classList := &snapapi.VolumeSnapshotClassList{}
+	if err := c.List(context.TODO(), classList); err != nil {
+		return nil, fmt.Errorf("failed to list VolumeSnapshotClasses: %v", err)
+	}
+	sort.Slice(classList.Items, func(i, j int) bool { return classList.Items[i].Name < classList.Items[j].Name })
+	return classList.Items, nil
+}
+
+// GetVolumeSnapshotClassName returns className if it is one of the classes,
+// or else, if className is empty, the first OCS VolumeSnapshotClass of the
+// driver and cluster of the StorageClass of the PVC. The returned message
+// explains why the PVC can not be snapshotted.
+func GetVolumeSnapshotClassName(c client.Client, className string, pvc *corev1.PersistentVolumeClaim, classes []snapapi.VolumeSnapshotClass) (string, string, error) {
+	if className != "" {
+		for _, class := range classes {
+			if class.Name == className {
+				return class.Name, "", nil
+			}
+		}
+		return "", fmt.Sprintf("VolumeSnapshotClass %s not found", className), nil
+	}
+
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return "", fmt.Sprintf("PVC %s has no StorageClass", pvc.Name), nil
+	}
+	storageClass := &storagev1.StorageClass{}
+	err := c.Get(context.TODO(), types.NamespacedName{Name: *pvc.Spec.StorageClassName}, storageClass)
+	if errors.IsNotFound(err) {
+		return "", fmt.Sprintf("StorageClass %s of PVC %s not found", *pvc.Spec.StorageClassName, pvc.Name), nil
+	} else if err != nil {
+		return "", "", fmt.Errorf("failed to get StorageClass %s: %v", *pvc.Spec.StorageClassName, err)
+	}
+	if !IsOCSDriver(storageClass.Provisioner) {
+		return "", fmt.Sprintf("PVC %s is not provisioned by OCS", pvc.Name), nil
+	}
+	for _, class := range classes {
+		if class.Driver == storageClass.Provisioner && class.Parameters["clusterID"] == storageClass.Parameters["clusterID"] {
+			return class.Name, "", nil
+		}
+	}
+	return "", fmt.Sprintf("no VolumeSnapshotClass found for driver %s of PVC %s", storageClass.Provisioner, pvc.Name), nil
+}
+
+// IsOCSDriver returns whether the CSI driver is one of the OCS RBD or CephFS
+// drivers
+func IsOCSDriver(driver string) bool {
+	return strings.HasSuffix(driver, rbdDriverSuffix) || strings.HasSuffix(driver, cephfsDriverSuffix)
+}
@@ -0,0 +1,30 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNewToolboxJob(t *testing.T) {
+	job := NewToolboxJob("ocs-test-job", "openshift-storage", "rook/ceph:test", corev1.Container{
+		Name:    "script",
+		Command: []string{"bash", "-c", "ceph status"},
+	})
+	assert.Equal(t, "ocs-test-job", job.Name)
+	assert.Equal(t, "openshift-storage", job.Namespace)
+
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, corev1.RestartPolicyNever, podSpec.RestartPolicy)
+	assert.Len(t, podSpec.InitContainers, 1)
+	assert.Equal(t, "rook/ceph:test", podSpec.InitContainers[0].Image)
+	assert.Contains(t, podSpec.InitContainers[0].Env, corev1.EnvVar{Name: "POD_NAMESPACE", Value: "openshift-storage"})
+
+	// The container gets the image and the Ceph config generated by the
+	// init container
+	assert.Len(t, podSpec.Containers, 1)
+	assert.Equal(t, "script", podSpec.Containers[0].Name)
+	assert.Equal(t, "rook/ceph:test", podSpec.Containers[0].Image)
+	assert.Contains(t, podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{Name: "ceph-config", MountPath: "/etc/ceph", ReadOnly: true})
+}
@@ -31,8 +31,11 @@ import (
 	openshiftv1 "github.com/openshift/api/template/v1"
 	secv1client "github.com/openshift/client-go/security/clientset/versioned/typed/security/v1"
 	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/consistentsnapshot"
 	"github.com/openshift/ocs-operator/controllers/ocsinitialization"
+	"github.com/openshift/ocs-operator/controllers/osdremovalrequest"
 	"github.com/openshift/ocs-operator/controllers/persistentvolume"
+	"github.com/openshift/ocs-operator/controllers/snapshotschedule"
 	"github.com/openshift/ocs-operator/controllers/storagecluster"
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
@@ -140,6 +143,38 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "PersistentVolume")
 		os.Exit(1)
 	}
+	if err = (&osdremovalrequest.OSDRemovalRequestReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("OSDRemovalRequest"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "OSDRemovalRequest")
+		os.Exit(1)
+	}
+	if err = (&consistentsnapshot.ConsistentSnapshotReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("ConsistentSnapshot"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ConsistentSnapshot")
+		os.Exit(1)
+	}
+	if err = (&consistentsnapshot.ConsistentSnapshotRestoreReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("ConsistentSnapshotRestore"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ConsistentSnapshotRestore")
+		os.Exit(1)
+	}
+	if err = (&snapshotschedule.SnapshotScheduleReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("SnapshotSchedule"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SnapshotSchedule")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	// Create OCSInitialization CR if it's not present
@@ -0,0 +1,141 @@
+package collectors
+
+import (
+	"github.com/openshift/ocs-operator/metrics/internal/options"
+	"github.com/prometheus/client_golang/prometheus"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned"
+	cephv1listers "github.com/rook/rook/pkg/client/listers/ceph.rook.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+const (
+	// component within the project/exporter
+	poolSubsystem = "pool"
+)
+
+var _ prometheus.Collector = &CephBlockPoolCollector{}
+
+// CephBlockPoolCollector is a custom collector for CephBlockPool Custom Resource
+type CephBlockPoolCollector struct {
+	MirroringStatus      *prometheus.Desc
+	MirroringImageHealth *prometheus.Desc
+	Informer             cache.SharedIndexInformer
+	AllowedNamespaces    []string
+}
+
+// NewCephBlockPoolCollector constructs a collector
+func NewCephBlockPoolCollector(opts *options.Options) *CephBlockPoolCollector {
+	client, err := rookclient.NewForConfig(opts.Kubeconfig)
+	if err != nil {
+		klog.Error(err)
+	}
+
+	lw := cache.NewListWatchFromClient(client.CephV1().RESTClient(), "cephblockpools", metav1.NamespaceAll, fields.Everything())
+	sharedIndexInformer := cache.NewSharedIndexInformer(lw, &cephv1.CephBlockPool{}, 0, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+
+	return &CephBlockPoolCollector{
+		MirroringStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, poolSubsystem, "mirroring_status"),
+			`Mirroring Status of the CephBlockPool. 0=OK, 1=Warning, 2=Error & 3=Unknown`,
+			[]string{"name", "namespace"},
+			nil,
+		),
+		MirroringImageHealth: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, poolSubsystem, "mirroring_image_health"),
+			`Mirroring Image Health of the CephBlockPool. 0=OK, 1=Warning, 2=Error & 3=Unknown`,
+			[]string{"name", "namespace"},
+			nil,
+		),
+		Informer:          sharedIndexInformer,
+		AllowedNamespaces: opts.AllowedNamespaces,
+	}
+}
+
+// Run starts CephBlockPool informer
+func (c *CephBlockPoolCollector) Run(stopCh <-chan struct{}) {
+	go c.Informer.Run(stopCh)
+}
+
+// Describe implements prometheus.Collector interface
+func (c *CephBlockPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ds := []*prometheus.Desc{
+		c.MirroringStatus,
+		c.MirroringImageHealth,
+	}
+
+	for _, d := range ds {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector interface
+func (c *CephBlockPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	cephBlockPoolLister := cephv1listers.NewCephBlockPoolLister(c.Informer.GetIndexer())
+	cephBlockPools := getAllBlockPools(cephBlockPoolLister, c.AllowedNamespaces)
+
+	if len(cephBlockPools) > 0 {
+		c.collectMirroringHealth(cephBlockPools, ch)
+	}
+}
+
+func getAllBlockPools(lister cephv1listers.CephBlockPoolLister, namespaces []string) (cephBlockPools []*cephv1.CephBlockPool) {
+	var tempCephBlockPools []*cephv1.CephBlockPool
+	var err error
+	if len(namespaces) == 0 {
+		cephBlockPools, err = lister.List(labels.Everything())
+		if err != nil {
+			klog.Errorf("couldn't list CephBlockPools. %v", err)
+		}
+		return
+	}
+	for _, namespace := range namespaces {
+		tempCephBlockPools, err = lister.CephBlockPools(namespace).List(labels.Everything())
+		if err != nil {
+			klog.Errorf("couldn't list CephBlockPools in namespace %s. %v", namespace, err)
+			continue
+		}
+		cephBlockPools = append(cephBlockPools, tempCephBlockPools...)
+	}
+	return
+}
+
+// mirroringHealthValue returns the metric value of a mirroring health
+// reported by Ceph
+func mirroringHealthValue(health string) float64 {
+	switch health {
+	case "OK":
+		return 0
+	case "WARNING":
+		return 1
+	case "ERROR":
+		return 2
+	default:
+		return 3
+	}
+}
+
+func (c *CephBlockPoolCollector) collectMirroringHealth(cephBlockPools []*cephv1.CephBlockPool, ch chan<- prometheus.Metric) {
+	for _, cephBlockPool := range cephBlockPools {
+		// Only the pools with mirroring enabled report a mirroring health
+		if !cephBlockPool.Spec.Mirroring.Enabled {
+			continue
+		}
+		var summary cephv1.PoolMirroringStatusSummarySpec
+		if cephBlockPool.Status != nil && cephBlockPool.Status.MirroringStatus != nil && cephBlockPool.Status.MirroringStatus.Summary != nil {
+			summary = *cephBlockPool.Status.MirroringStatus.Summary
+		}
+		ch <- prometheus.MustNewConstMetric(c.MirroringStatus,
+			prometheus.GaugeValue, mirroringHealthValue(summary.Health),
+			cephBlockPool.Name,
+			cephBlockPool.Namespace)
+		ch <- prometheus.MustNewConstMetric(c.MirroringImageHealth,
+			prometheus.GaugeValue, mirroringHealthValue(summary.ImageHealth),
+			cephBlockPool.Name,
+			cephBlockPool.Namespace)
+	}
+}
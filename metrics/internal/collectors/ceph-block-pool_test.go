@@ -0,0 +1,175 @@
+package collectors
+
+import (
+	"testing"
+
+	"github.com/openshift/ocs-operator/metrics/internal/options"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephv1listers "github.com/rook/rook/pkg/client/listers/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	mockCephBlockPool1 = cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mockCephBlockPool-1",
+			Namespace: "openshift-storage",
+		},
+		Spec: cephv1.PoolSpec{
+			Mirroring: cephv1.MirroringSpec{Enabled: true},
+		},
+	}
+	mockCephBlockPool2 = cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mockCephBlockPool-2",
+			Namespace: "openshift-storage",
+		},
+	}
+	mockCephBlockPool3 = cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mockCephBlockPool-3",
+			Namespace: "default",
+		},
+	}
+)
+
+func getMockCephBlockPoolCollector(t *testing.T, mockOpts *options.Options) (mockCephBlockPoolCollector *CephBlockPoolCollector) {
+	setKubeConfig(t)
+	mockCephBlockPoolCollector = NewCephBlockPoolCollector(mockOpts)
+	assert.NotNil(t, mockCephBlockPoolCollector)
+	return
+}
+
+func TestNewCephBlockPoolCollector(t *testing.T) {
+	got := getMockCephBlockPoolCollector(t, mockOpts)
+	assert.NotNil(t, got.AllowedNamespaces)
+	assert.NotNil(t, got.Informer)
+}
+
+func TestGetAllBlockPools(t *testing.T) {
+	mockOpts.StopCh = make(chan struct{})
+	defer close(mockOpts.StopCh)
+
+	cephBlockPoolCollector := getMockCephBlockPoolCollector(t, mockOpts)
+	lister := cephv1listers.NewCephBlockPoolLister(cephBlockPoolCollector.Informer.GetIndexer())
+
+	tests := []struct {
+		name                string
+		namespaces          []string
+		inputCephBlockPools []*cephv1.CephBlockPool
+		wantCephBlockPools  []*cephv1.CephBlockPool
+	}{
+		{
+			name:                "CephBlockPool doesn't exist",
+			namespaces:          cephBlockPoolCollector.AllowedNamespaces,
+			inputCephBlockPools: []*cephv1.CephBlockPool{},
+			wantCephBlockPools:  []*cephv1.CephBlockPool(nil),
+		},
+		{
+			name:       "Two CephBlockPools exist",
+			namespaces: cephBlockPoolCollector.AllowedNamespaces,
+			inputCephBlockPools: []*cephv1.CephBlockPool{
+				&mockCephBlockPool1,
+				&mockCephBlockPool2,
+			},
+			wantCephBlockPools: []*cephv1.CephBlockPool{
+				&mockCephBlockPool1,
+				&mockCephBlockPool2,
+			},
+		},
+		{
+			name:       "One CephBlockPool exists in disallowed namespace",
+			namespaces: cephBlockPoolCollector.AllowedNamespaces,
+			inputCephBlockPools: []*cephv1.CephBlockPool{
+				&mockCephBlockPool1,
+				&mockCephBlockPool2,
+				&mockCephBlockPool3,
+			},
+			wantCephBlockPools: []*cephv1.CephBlockPool{
+				&mockCephBlockPool1,
+				&mockCephBlockPool2,
+			},
+		},
+	}
+	for _, tt := range tests {
+		store := cephBlockPoolCollector.Informer.GetStore()
+		for _, obj := range tt.inputCephBlockPools {
+			assert.Nil(t, store.Add(obj))
+		}
+		gotCephBlockPools := getAllBlockPools(lister, tt.namespaces)
+		assert.Len(t, gotCephBlockPools, len(tt.wantCephBlockPools))
+		for _, obj := range gotCephBlockPools {
+			assert.Contains(t, tt.wantCephBlockPools, obj)
+		}
+		for _, obj := range tt.inputCephBlockPools {
+			assert.Nil(t, store.Delete(obj))
+		}
+	}
+}
+
+func TestCollectMirroringHealth(t *testing.T) {
+	mockOpts.StopCh = make(chan struct{})
+	defer close(mockOpts.StopCh)
+
+	cephBlockPoolCollector := getMockCephBlockPoolCollector(t, mockOpts)
+
+	objOK := mockCephBlockPool1.DeepCopy()
+	objOK.Name = objOK.Name + "-ok"
+	objOK.Status = &cephv1.CephBlockPoolStatus{
+		MirroringStatus: &cephv1.MirroringStatusSpec{
+			PoolMirroringStatus: cephv1.PoolMirroringStatus{
+				Summary: &cephv1.PoolMirroringStatusSummarySpec{Health: "OK", ImageHealth: "OK"},
+			},
+		},
+	}
+	objError := mockCephBlockPool1.DeepCopy()
+	objError.Name = objError.Name + "-error"
+	objError.Status = &cephv1.CephBlockPoolStatus{
+		MirroringStatus: &cephv1.MirroringStatusSpec{
+			PoolMirroringStatus: cephv1.PoolMirroringStatus{
+				Summary: &cephv1.PoolMirroringStatusSummarySpec{Health: "ERROR", ImageHealth: "WARNING"},
+			},
+		},
+	}
+	// The health of a mirrored pool is unknown until Rook reports it
+	objUnknown := mockCephBlockPool1.DeepCopy()
+	objUnknown.Name = objUnknown.Name + "-unknown"
+	// Pools without mirroring are not reported
+	objNotMirrored := mockCephBlockPool2.DeepCopy()
+
+	ch := make(chan prometheus.Metric)
+	metric := dto.Metric{}
+	go func() {
+		cephBlockPoolCollector.collectMirroringHealth([]*cephv1.CephBlockPool{objOK, objError, objUnknown, objNotMirrored}, ch)
+		close(ch)
+	}()
+
+	values := map[string]map[string]float64{}
+	for m := range ch {
+		metric.Reset()
+		assert.Nil(t, m.Write(&metric))
+		name := ""
+		for _, label := range metric.GetLabel() {
+			if *label.Name == "name" {
+				name = *label.Value
+			} else if *label.Name == "namespace" {
+				assert.Contains(t, cephBlockPoolCollector.AllowedNamespaces, *label.Value)
+			}
+		}
+		if values[name] == nil {
+			values[name] = map[string]float64{}
+		}
+		values[name][m.Desc().String()] = *metric.Gauge.Value
+	}
+
+	status := cephBlockPoolCollector.MirroringStatus.String()
+	imageHealth := cephBlockPoolCollector.MirroringImageHealth.String()
+	assert.Equal(t, map[string]map[string]float64{
+		objOK.Name:      {status: 0, imageHealth: 0},
+		objError.Name:   {status: 2, imageHealth: 1},
+		objUnknown.Name: {status: 3, imageHealth: 3},
+	}, values)
+}
@@ -11,7 +11,13 @@ import (
 func RegisterCustomResourceCollectors(registry *prometheus.Registry, opts *options.Options) {
 	cephObjectStoreCollector := NewCephObjectStoreCollector(opts)
 	cephObjectStoreCollector.Run(opts.StopCh)
+	snapshotScheduleCollector := NewSnapshotScheduleCollector(opts)
+	snapshotScheduleCollector.Run(opts.StopCh)
+	cephBlockPoolCollector := NewCephBlockPoolCollector(opts)
+	cephBlockPoolCollector.Run(opts.StopCh)
 	registry.MustRegister(
 		cephObjectStoreCollector,
+		cephBlockPoolCollector,
+		snapshotScheduleCollector,
 	)
 }
@@ -0,0 +1,164 @@
+package collectors
+
+import (
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/metrics/internal/options"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+const (
+	// component within the project/exporter
+	snapshotScheduleSubsystem = "snapshot_schedule"
+)
+
+var _ prometheus.Collector = &SnapshotScheduleCollector{}
+
+// SnapshotScheduleCollector is a custom collector for SnapshotSchedule Custom Resource
+type SnapshotScheduleCollector struct {
+	LastSuccessTime   *prometheus.Desc
+	LastFailureTime   *prometheus.Desc
+	NextScheduleTime  *prometheus.Desc
+	Snapshots         *prometheus.Desc
+	Informer          cache.SharedIndexInformer
+	AllowedNamespaces []string
+}
+
+// NewSnapshotScheduleCollector constructs a collector
+func NewSnapshotScheduleCollector(opts *options.Options) *SnapshotScheduleCollector {
+	client, err := newOCSRESTClient(opts.Kubeconfig)
+	if err != nil {
+		klog.Error(err)
+	}
+
+	lw := cache.NewListWatchFromClient(client, "snapshotschedules", metav1.NamespaceAll, fields.Everything())
+	sharedIndexInformer := cache.NewSharedIndexInformer(lw, &ocsv1.SnapshotSchedule{}, 0, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+
+	labelNames := []string{"name", "namespace"}
+	return &SnapshotScheduleCollector{
+		LastSuccessTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, snapshotScheduleSubsystem, "last_success_timestamp_seconds"),
+			`Schedule time of the latest snapshots of the SnapshotSchedule which all became ready`,
+			labelNames,
+			nil,
+		),
+		LastFailureTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, snapshotScheduleSubsystem, "last_failure_timestamp_seconds"),
+			`Schedule time of the latest snapshots of the SnapshotSchedule which failed`,
+			labelNames,
+			nil,
+		),
+		NextScheduleTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, snapshotScheduleSubsystem, "next_schedule_timestamp_seconds"),
+			`Time the next snapshots of the SnapshotSchedule are taken`,
+			labelNames,
+			nil,
+		),
+		Snapshots: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, snapshotScheduleSubsystem, "snapshots"),
+			`Number of VolumeSnapshots retained by the SnapshotSchedule`,
+			labelNames,
+			nil,
+		),
+		Informer:          sharedIndexInformer,
+		AllowedNamespaces: opts.AllowedNamespaces,
+	}
+}
+
+// newOCSRESTClient returns a REST client for the ocs.openshift.io API group
+func newOCSRESTClient(config *rest.Config) (*rest.RESTClient, error) {
+	scheme := runtime.NewScheme()
+	if err := ocsv1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	config = rest.CopyConfig(config)
+	config.GroupVersion = &ocsv1.GroupVersion
+	config.APIPath = "/apis"
+	config.NegotiatedSerializer = serializer.NewCodecFactory(scheme).WithoutConversion()
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+	return rest.RESTClientFor(config)
+}
+
+// Run starts SnapshotSchedule informer
+func (c *SnapshotScheduleCollector) Run(stopCh <-chan struct{}) {
+	go c.Informer.Run(stopCh)
+}
+
+// Describe implements prometheus.Collector interface
+func (c *SnapshotScheduleCollector) Describe(ch chan<- *prometheus.Desc) {
+	ds := []*prometheus.Desc{
+		c.LastSuccessTime,
+		c.LastFailureTime,
+		c.NextScheduleTime,
+		c.Snapshots,
+	}
+
+	for _, d := range ds {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector interface
+func (c *SnapshotScheduleCollector) Collect(ch chan<- prometheus.Metric) {
+	snapshotSchedules := getAllSnapshotSchedules(c.Informer.GetIndexer(), c.AllowedNamespaces)
+
+	if len(snapshotSchedules) > 0 {
+		c.collectSnapshotScheduleStatus(snapshotSchedules, ch)
+	}
+}
+
+func getAllSnapshotSchedules(indexer cache.Indexer, namespaces []string) (snapshotSchedules []*ocsv1.SnapshotSchedule) {
+	appendSnapshotSchedule := func(obj interface{}) {
+		if snapshotSchedule, ok := obj.(*ocsv1.SnapshotSchedule); ok {
+			snapshotSchedules = append(snapshotSchedules, snapshotSchedule)
+		}
+	}
+	if len(namespaces) == 0 {
+		err := cache.ListAll(indexer, labels.Everything(), appendSnapshotSchedule)
+		if err != nil {
+			klog.Errorf("couldn't list SnapshotSchedules. %v", err)
+		}
+		return
+	}
+	for _, namespace := range namespaces {
+		err := cache.ListAllByNamespace(indexer, namespace, labels.Everything(), appendSnapshotSchedule)
+		if err != nil {
+			klog.Errorf("couldn't list SnapshotSchedules in namespace %s. %v", namespace, err)
+			continue
+		}
+	}
+	return
+}
+
+func (c *SnapshotScheduleCollector) collectSnapshotScheduleStatus(snapshotSchedules []*ocsv1.SnapshotSchedule, ch chan<- prometheus.Metric) {
+	for _, snapshotSchedule := range snapshotSchedules {
+		status := snapshotSchedule.Status
+		for desc, t := range map[*prometheus.Desc]*metav1.Time{
+			c.LastSuccessTime:  status.LastSuccessTime,
+			c.LastFailureTime:  status.LastFailureTime,
+			c.NextScheduleTime: status.NextScheduleTime,
+		} {
+			if t == nil {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(desc,
+				prometheus.GaugeValue, float64(t.Unix()),
+				snapshotSchedule.Name,
+				snapshotSchedule.Namespace)
+		}
+		ch <- prometheus.MustNewConstMetric(c.Snapshots,
+			prometheus.GaugeValue, float64(status.Snapshots),
+			snapshotSchedule.Name,
+			snapshotSchedule.Namespace)
+	}
+}
@@ -0,0 +1,170 @@
+package collectors
+
+import (
+	"testing"
+	"time"
+
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/metrics/internal/options"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	mockSnapshotSchedule1 = ocsv1.SnapshotSchedule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mockSnapshotSchedule-1",
+			Namespace: "openshift-storage",
+		},
+	}
+	mockSnapshotSchedule2 = ocsv1.SnapshotSchedule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mockSnapshotSchedule-2",
+			Namespace: "openshift-storage",
+		},
+	}
+	mockSnapshotSchedule3 = ocsv1.SnapshotSchedule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mockSnapshotSchedule-3",
+			Namespace: "default",
+		},
+	}
+)
+
+func getMockSnapshotScheduleCollector(t *testing.T, mockOpts *options.Options) (mockSnapshotScheduleCollector *SnapshotScheduleCollector) {
+	setKubeConfig(t)
+	mockSnapshotScheduleCollector = NewSnapshotScheduleCollector(mockOpts)
+	assert.NotNil(t, mockSnapshotScheduleCollector)
+	return
+}
+
+func TestNewSnapshotScheduleCollector(t *testing.T) {
+	got := getMockSnapshotScheduleCollector(t, mockOpts)
+	assert.NotNil(t, got.AllowedNamespaces)
+	assert.NotNil(t, got.Informer)
+}
+
+func TestGetAllSnapshotSchedules(t *testing.T) {
+	mockOpts.StopCh = make(chan struct{})
+	defer close(mockOpts.StopCh)
+
+	snapshotScheduleCollector := getMockSnapshotScheduleCollector(t, mockOpts)
+
+	tests := []struct {
+		name                   string
+		namespaces             []string
+		inputSnapshotSchedules []*ocsv1.SnapshotSchedule
+		wantSnapshotSchedules  []*ocsv1.SnapshotSchedule
+	}{
+		{
+			name:                   "SnapshotSchedule doesn't exist",
+			namespaces:             snapshotScheduleCollector.AllowedNamespaces,
+			inputSnapshotSchedules: []*ocsv1.SnapshotSchedule{},
+			wantSnapshotSchedules:  []*ocsv1.SnapshotSchedule(nil),
+		},
+		{
+			name:       "Two SnapshotSchedules exist",
+			namespaces: snapshotScheduleCollector.AllowedNamespaces,
+			inputSnapshotSchedules: []*ocsv1.SnapshotSchedule{
+				&mockSnapshotSchedule1,
+				&mockSnapshotSchedule2,
+			},
+			wantSnapshotSchedules: []*ocsv1.SnapshotSchedule{
+				&mockSnapshotSchedule1,
+				&mockSnapshotSchedule2,
+			},
+		},
+		{
+			name:       "One SnapshotSchedule exists in disallowed namespace",
+			namespaces: snapshotScheduleCollector.AllowedNamespaces,
+			inputSnapshotSchedules: []*ocsv1.SnapshotSchedule{
+				&mockSnapshotSchedule1,
+				&mockSnapshotSchedule2,
+				&mockSnapshotSchedule3,
+			},
+			wantSnapshotSchedules: []*ocsv1.SnapshotSchedule{
+				&mockSnapshotSchedule1,
+				&mockSnapshotSchedule2,
+			},
+		},
+		{
+			name: "All namespaces allowed",
+			inputSnapshotSchedules: []*ocsv1.SnapshotSchedule{
+				&mockSnapshotSchedule1,
+				&mockSnapshotSchedule3,
+			},
+			wantSnapshotSchedules: []*ocsv1.SnapshotSchedule{
+				&mockSnapshotSchedule1,
+				&mockSnapshotSchedule3,
+			},
+		},
+	}
+	for _, tt := range tests {
+		store := snapshotScheduleCollector.Informer.GetStore()
+		for _, obj := range tt.inputSnapshotSchedules {
+			assert.Nil(t, store.Add(obj))
+		}
+		gotSnapshotSchedules := getAllSnapshotSchedules(snapshotScheduleCollector.Informer.GetIndexer(), tt.namespaces)
+		assert.Len(t, gotSnapshotSchedules, len(tt.wantSnapshotSchedules))
+		for _, obj := range gotSnapshotSchedules {
+			assert.Contains(t, tt.wantSnapshotSchedules, obj)
+		}
+		for _, obj := range tt.inputSnapshotSchedules {
+			assert.Nil(t, store.Delete(obj))
+		}
+	}
+}
+
+func TestCollectSnapshotScheduleStatus(t *testing.T) {
+	mockOpts.StopCh = make(chan struct{})
+	defer close(mockOpts.StopCh)
+
+	snapshotScheduleCollector := getMockSnapshotScheduleCollector(t, mockOpts)
+	lastSuccess := metav1.NewTime(time.Date(2021, time.March, 15, 2, 0, 0, 0, time.UTC))
+	nextSchedule := metav1.NewTime(time.Date(2021, time.March, 16, 2, 0, 0, 0, time.UTC))
+
+	objScheduled := mockSnapshotSchedule1.DeepCopy()
+	objScheduled.Status = ocsv1.SnapshotScheduleStatus{
+		LastSuccessTime:  &lastSuccess,
+		NextScheduleTime: &nextSchedule,
+		Snapshots:        7,
+	}
+	objNew := mockSnapshotSchedule2.DeepCopy()
+
+	ch := make(chan prometheus.Metric)
+	metric := dto.Metric{}
+	go func() {
+		snapshotScheduleCollector.collectSnapshotScheduleStatus([]*ocsv1.SnapshotSchedule{objScheduled, objNew}, ch)
+		close(ch)
+	}()
+
+	values := map[string]map[string]float64{}
+	for m := range ch {
+		metric.Reset()
+		assert.Nil(t, m.Write(&metric))
+		name := ""
+		for _, label := range metric.GetLabel() {
+			if *label.Name == "name" {
+				name = *label.Value
+			} else if *label.Name == "namespace" {
+				assert.Contains(t, snapshotScheduleCollector.AllowedNamespaces, *label.Value)
+			}
+		}
+		if values[name] == nil {
+			values[name] = map[string]float64{}
+		}
+		values[name][m.Desc().String()] = *metric.Gauge.Value
+	}
+
+	assert.Equal(t, map[string]float64{
+		snapshotScheduleCollector.LastSuccessTime.String():  float64(lastSuccess.Unix()),
+		snapshotScheduleCollector.NextScheduleTime.String(): float64(nextSchedule.Unix()),
+		snapshotScheduleCollector.Snapshots.String():        7,
+	}, values[objScheduled.Name])
+	// The times of a schedule which never ran are not reported
+	assert.Equal(t, map[string]float64{
+		snapshotScheduleCollector.Snapshots.String(): 0,
+	}, values[objNew.Name])
+}
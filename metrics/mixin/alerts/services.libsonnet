@@ -20,6 +20,22 @@
               severity_level: 'error',
             },
           },
+          {
+            alert: 'PoolMirroringState',
+            expr: |||
+              ocs_pool_mirroring_status{%(ocsExporterSelector)s} == 2
+            ||| % $._config,
+            'for': $._config.poolMirroringStateAlertTime,
+            labels: {
+              severity: 'critical',
+            },
+            annotations: {
+              message: 'Mirroring of a Ceph Block Pool is in error state. Please check the rbd-mirror daemon and the peer cluster.',
+              description: 'Mirroring of the Ceph Block Pool {{ $labels.name }} is in error state for more than %s. Please check the rbd-mirror daemon and the peer cluster.' % $._config.poolMirroringStateAlertTime,
+              storage_type: $._config.blockStorageType,
+              severity_level: 'error',
+            },
+          },
         ],
       },
     ],
@@ -5,9 +5,11 @@
 
     // Duration to raise various Alerts
     clusterObjectStoreStateAlertTime: '15s',
+    poolMirroringStateAlertTime: '1m',
 
     // Constants
     objectStorageType: 'RGW',
+    blockStorageType: 'RBD',
 
     // We build alerts for the presence of all these jobs.
     jobs: {
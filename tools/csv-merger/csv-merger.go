@@ -18,12 +18,14 @@ import (
 	ocsversion "github.com/openshift/ocs-operator/version"
 	"github.com/operator-framework/api/pkg/lib/version"
 	csvv1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbac "k8s.io/api/rbac/v1"
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 var (
@@ -526,6 +528,14 @@ func generateUnifiedCSV() *csvv1.ClusterServiceVersion {
 	}
 	templateStrategySpec.DeploymentSpecs = append(templateStrategySpec.DeploymentSpecs, metricExporterStrategySpec)
 
+	// Add StorageCluster admission webhook server deployment to CSV
+	webhookServerStrategySpec := csvv1.StrategyDeploymentSpec{
+		Name: "ocs-webhook-server",
+		Spec: getWebhookServerDeployment(),
+	}
+	templateStrategySpec.DeploymentSpecs = append(templateStrategySpec.DeploymentSpecs, webhookServerStrategySpec)
+	ocsCSV.Spec.WebhookDefinitions = getStorageClusterWebhookDefinitions("ocs-webhook-server")
+
 	// Add tolerations to deployments
 	for i := range templateStrategySpec.DeploymentSpecs {
 		d := &templateStrategySpec.DeploymentSpecs[i]
@@ -1028,6 +1038,146 @@ func getMetricsExporterDeployment() appsv1.DeploymentSpec {
 	return deployment
 }
 
+func getWebhookServerDeployment() appsv1.DeploymentSpec {
+	replica := int32(1)
+	runAsNonRoot := true
+	deployment := appsv1.DeploymentSpec{
+		Replicas: &replica,
+		Selector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"app.kubernetes.io/component": "ocs-webhook-server",
+				"app.kubernetes.io/name":      "ocs-webhook-server",
+			},
+		},
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					"app.kubernetes.io/component": "ocs-webhook-server",
+					"app.kubernetes.io/name":      "ocs-webhook-server",
+				},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name:    "ocs-webhook-server",
+						Image:   *ocsContainerImage,
+						Command: []string{"/usr/local/bin/ocs-webhook"},
+						Ports: []corev1.ContainerPort{
+							{
+								ContainerPort: 9443,
+							},
+						},
+						SecurityContext: &corev1.SecurityContext{
+							RunAsNonRoot: &runAsNonRoot,
+						},
+					},
+				},
+				ServiceAccountName: "ocs-operator",
+			},
+		},
+	}
+	return deployment
+}
+
+func getStorageClusterWebhookDefinitions(deploymentName string) []csvv1.WebhookDescription {
+	failurePolicy := admissionregistrationv1.Fail
+	sideEffects := admissionregistrationv1.SideEffectClassNone
+	targetPort := intstr.FromInt(9443)
+	validatePath := "/validate"
+	mutatePath := "/mutate"
+	rules := []admissionregistrationv1.RuleWithOperations{
+		{
+			Operations: []admissionregistrationv1.OperationType{
+				admissionregistrationv1.Create,
+				admissionregistrationv1.Update,
+			},
+			Rule: admissionregistrationv1.Rule{
+				APIGroups:   []string{"ocs.openshift.io"},
+				APIVersions: []string{"v1"},
+				Resources:   []string{"storageclusters"},
+			},
+		},
+	}
+	validateConsistentSnapshotPath := "/validate-consistentsnapshot"
+	consistentSnapshotRules := []admissionregistrationv1.RuleWithOperations{
+		{
+			Operations: []admissionregistrationv1.OperationType{
+				admissionregistrationv1.Create,
+				admissionregistrationv1.Update,
+			},
+			Rule: admissionregistrationv1.Rule{
+				APIGroups:   []string{"ocs.openshift.io"},
+				APIVersions: []string{"v1"},
+				Resources:   []string{"consistentsnapshots"},
+			},
+		},
+	}
+	validateConsistentSnapshotRestorePath := "/validate-consistentsnapshotrestore"
+	consistentSnapshotRestoreRules := []admissionregistrationv1.RuleWithOperations{
+		{
+			Operations: []admissionregistrationv1.OperationType{
+				admissionregistrationv1.Create,
+				admissionregistrationv1.Update,
+			},
+			Rule: admissionregistrationv1.Rule{
+				APIGroups:   []string{"ocs.openshift.io"},
+				APIVersions: []string{"v1"},
+				Resources:   []string{"consistentsnapshotrestores"},
+			},
+		},
+	}
+	return []csvv1.WebhookDescription{
+		{
+			GenerateName:            "vstoragecluster.ocs.openshift.io",
+			Type:                    csvv1.ValidatingAdmissionWebhook,
+			DeploymentName:          deploymentName,
+			ContainerPort:           443,
+			TargetPort:              &targetPort,
+			Rules:                   rules,
+			FailurePolicy:           &failurePolicy,
+			SideEffects:             &sideEffects,
+			AdmissionReviewVersions: []string{"v1"},
+			WebhookPath:             &validatePath,
+		},
+		{
+			GenerateName:            "mstoragecluster.ocs.openshift.io",
+			Type:                    csvv1.MutatingAdmissionWebhook,
+			DeploymentName:          deploymentName,
+			ContainerPort:           443,
+			TargetPort:              &targetPort,
+			Rules:                   rules,
+			FailurePolicy:           &failurePolicy,
+			SideEffects:             &sideEffects,
+			AdmissionReviewVersions: []string{"v1"},
+			WebhookPath:             &mutatePath,
+		},
+		{
+			GenerateName:            "vconsistentsnapshot.ocs.openshift.io",
+			Type:                    csvv1.ValidatingAdmissionWebhook,
+			DeploymentName:          deploymentName,
+			ContainerPort:           443,
+			TargetPort:              &targetPort,
+			Rules:                   consistentSnapshotRules,
+			FailurePolicy:           &failurePolicy,
+			SideEffects:             &sideEffects,
+			AdmissionReviewVersions: []string{"v1"},
+			WebhookPath:             &validateConsistentSnapshotPath,
+		},
+		{
+			GenerateName:            "vconsistentsnapshotrestore.ocs.openshift.io",
+			Type:                    csvv1.ValidatingAdmissionWebhook,
+			DeploymentName:          deploymentName,
+			ContainerPort:           443,
+			TargetPort:              &targetPort,
+			Rules:                   consistentSnapshotRestoreRules,
+			FailurePolicy:           &failurePolicy,
+			SideEffects:             &sideEffects,
+			AdmissionReviewVersions: []string{"v1"},
+			WebhookPath:             &validateConsistentSnapshotRestorePath,
+		},
+	}
+}
+
 func main() {
 	flag.Parse()
 
@@ -0,0 +1,104 @@
+/*
+Copyright 2021 Red Hat OpenShift Container Storage.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"os"
+
+	ocsv1 "github.com/openshift/ocs-operator/api/v1"
+	"github.com/openshift/ocs-operator/controllers/consistentsnapshot"
+	"github.com/openshift/ocs-operator/controllers/storagecluster"
+	apiruntime "k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var (
+	scheme   = apiruntime.NewScheme()
+	setupLog = ctrl.Log.WithName("webhook")
+)
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(ocsv1.AddToScheme(scheme))
+}
+
+func main() {
+	var port int
+	var certDir string
+	var probeAddr string
+	flag.IntVar(&port, "port", webhook.DefaultPort, "The port the webhook server listens on.")
+	// OLM mounts the serving certificate and key of webhooks it manages here
+	flag.StringVar(&certDir, "cert-dir", "/apiserver.local.config/certificates", "The directory containing the serving certificate and key.")
+	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+
+	loggerOpts := zap.Options{}
+	loggerOpts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&loggerOpts)))
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+		Scheme:                 scheme,
+		MetricsBindAddress:     "0",
+		HealthProbeBindAddress: probeAddr,
+		Port:                   port,
+		CertDir:                certDir,
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to create manager")
+		os.Exit(1)
+	}
+
+	server := mgr.GetWebhookServer()
+	server.CertName = "apiserver.crt"
+	server.KeyName = "apiserver.key"
+	server.Register(storagecluster.ValidatingWebhookPath, &webhook.Admission{
+		Handler: &storagecluster.StorageClusterValidator{Log: ctrl.Log.WithName("webhook").WithName("validate")},
+	})
+	server.Register(storagecluster.MutatingWebhookPath, &webhook.Admission{
+		Handler: &storagecluster.StorageClusterMutator{Log: ctrl.Log.WithName("webhook").WithName("mutate")},
+	})
+	server.Register(consistentsnapshot.ValidatingWebhookPath, &webhook.Admission{
+		Handler: &consistentsnapshot.ConsistentSnapshotValidator{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("webhook").WithName("validate-consistentsnapshot"),
+		},
+	})
+	server.Register(consistentsnapshot.RestoreValidatingWebhookPath, &webhook.Admission{
+		Handler: &consistentsnapshot.ConsistentSnapshotRestoreValidator{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("webhook").WithName("validate-consistentsnapshotrestore"),
+		},
+	})
+
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable add a readiness check")
+		os.Exit(1)
+	}
+
+	setupLog.Info("starting webhook server")
+	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+		setupLog.Error(err, "problem running webhook server")
+		os.Exit(1)
+	}
+}